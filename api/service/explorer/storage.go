@@ -6,14 +6,12 @@ import (
 	"path"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/harmony/core/types"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/harmony-one/harmony/internal/utils"
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/filter"
-	"github.com/syndtr/goleveldb/leveldb/opt"
-	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // Constants for storage.
@@ -36,9 +34,17 @@ func GetCheckpointKey(blockNum *big.Int) string {
 var storage *Storage
 var once sync.Once
 
-// Storage dump the block info into leveldb.
+// StorageDir returns the directory of the storage of the database backend
+func StorageDir(dbDir, backend, ip, port string) string {
+	if backend == shardchain.BadgerDBBackend {
+		return path.Join(dbDir, "explorer_badgerdb_"+ip+"_"+port)
+	}
+	return path.Join(dbDir, "explorer_storage_"+ip+"_"+port)
+}
+
+// Storage dump the block info into the database.
 type Storage struct {
-	db   *leveldb.DB
+	db   ethdb.KeyValueStore
 	lock sync.Mutex
 }
 
@@ -53,25 +59,20 @@ func GetStorageInstance(ip, port string) *Storage {
 
 // Init initializes the block update.
 func (storage *Storage) Init(ip, port string) {
-	dbFileName := path.Join(nodeconfig.GetDefaultConfig().DBDir, "explorer_storage_"+ip+"_"+port)
+	backend := nodeconfig.GetDefaultConfig().DBBackend
+	if backend == "" {
+		backend = shardchain.LDBBackend
+	}
+	dbFileName := StorageDir(nodeconfig.GetDefaultConfig().DBDir, backend, ip, port)
 	utils.Logger().Info().Msg("explorer storage folder: " + dbFileName)
 	var err error
-
-	// https://github.com/ethereum/go-ethereum/blob/master/ethdb/leveldb/leveldb.go#L98 options.
-	// We had 0 for handles and cache params before, so set 0s for all of them. Filter opt is the same.
-	options := &opt.Options{
-		OpenFilesCacheCapacity: 0,
-		BlockCacheCapacity:     0,
-		WriteBuffer:            0,
-		Filter:                 filter.NewBloomFilter(10),
-	}
-	if storage.db, err = leveldb.OpenFile(dbFileName, options); err != nil {
+	if storage.db, err = shardchain.NewKeyValueStore(backend, dbFileName); err != nil {
 		utils.Logger().Error().Err(err).Msg("Failed to create new database")
 	}
 }
 
-// GetDB returns the LDBDatabase of the storage.
-func (storage *Storage) GetDB() *leveldb.DB {
+// GetDB returns the database of the storage.
+func (storage *Storage) GetDB() ethdb.KeyValueStore {
 	return storage.db
 }
 
@@ -79,7 +80,7 @@ func (storage *Storage) GetDB() *leveldb.DB {
 func (storage *Storage) Dump(block *types.Block, height uint64) {
 	// Skip dump for redundant blocks with lower block number than the checkpoint block number
 	blockCheckpoint := GetCheckpointKey(block.Header().Number())
-	if _, err := storage.GetDB().Get([]byte(blockCheckpoint), nil); err == nil {
+	if _, err := storage.GetDB().Get([]byte(blockCheckpoint)); err == nil {
 		return
 	}
 
@@ -99,14 +100,14 @@ func (storage *Storage) Dump(block *types.Block, height uint64) {
 	}
 
 	// save checkpoint of block dumped
-	storage.GetDB().Put([]byte(blockCheckpoint), []byte{}, nil)
+	storage.GetDB().Put([]byte(blockCheckpoint), []byte{})
 }
 
 // UpdateTxAddressStorage updates specific addr tx Address.
 func (storage *Storage) UpdateTxAddressStorage(addr string, txRecords TxRecords, isStaking bool) {
 	var address Address
 	key := GetAddressKey(addr)
-	if data, err := storage.GetDB().Get([]byte(key), nil); err == nil {
+	if data, err := storage.GetDB().Get([]byte(key)); err == nil {
 		if err = rlp.DecodeBytes(data, &address); err != nil {
 			utils.Logger().Error().
 				Bool("isStaking", isStaking).Err(err).Msg("Failed due to error")
@@ -121,7 +122,7 @@ func (storage *Storage) UpdateTxAddressStorage(addr string, txRecords TxRecords,
 	}
 	encoded, err := rlp.EncodeToBytes(address)
 	if err == nil {
-		storage.GetDB().Put([]byte(key), encoded, nil)
+		storage.GetDB().Put([]byte(key), encoded)
 	} else {
 		utils.Logger().Error().
 			Bool("isStaking", isStaking).Err(err).Msg("cannot encode address")
@@ -132,7 +133,7 @@ func (storage *Storage) UpdateTxAddressStorage(addr string, txRecords TxRecords,
 func (storage *Storage) GetAddresses(size int, prefix string) ([]string, error) {
 	db := storage.GetDB()
 	key := GetAddressKey(prefix)
-	iterator := db.NewIterator(&util.Range{Start: []byte(key)}, nil)
+	iterator := db.NewIteratorWithStart([]byte(key))
 	addresses := make([]string, 0)
 	read := 0
 	for iterator.Next() && read < size {
@@ -17,10 +17,10 @@ func TestGetAddressKey(t *testing.T) {
 func TestInit(t *testing.T) {
 	nodeconfig.GetDefaultConfig().DBDir = "/tmp"
 	ins := GetStorageInstance("1.1.1.1", "3333")
-	if err := ins.GetDB().Put([]byte{1}, []byte{2}, nil); err != nil {
+	if err := ins.GetDB().Put([]byte{1}, []byte{2}); err != nil {
 		t.Fatal("(*LDBDatabase).Put failed:", err)
 	}
-	value, err := ins.GetDB().Get([]byte{1}, nil)
+	value, err := ins.GetDB().Get([]byte{1})
 	assert.Equal(t, bytes.Compare(value, []byte{2}), 0, "value should be []byte{2}")
 	assert.Nil(t, err, "error should be nil")
 }
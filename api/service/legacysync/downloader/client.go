@@ -2,12 +2,13 @@ package downloader
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"time"
 
 	pb "github.com/harmony-one/harmony/api/service/legacysync/downloader/proto"
 	"github.com/harmony-one/harmony/internal/utils"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Client is the client model for downloader package.
@@ -20,12 +21,16 @@ type Client struct {
 // ClientSetup setups a Client given ip and port.
 func ClientSetup(ip, port string) *Client {
 	client := Client{}
-	client.opts = append(client.opts, grpc.WithInsecure())
+	if clientTLS != nil {
+		client.opts = append(client.opts, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+	} else {
+		client.opts = append(client.opts, grpc.WithInsecure())
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	var err error
-	client.conn, err = grpc.DialContext(ctx, fmt.Sprintf(ip+":"+port), client.opts...)
+	client.conn, err = grpc.DialContext(ctx, net.JoinHostPort(ip, port), client.opts...)
 	if err != nil {
 		utils.Logger().Error().Err(err).Str("ip", ip).Msg("[SYNC] client.go:ClientSetup fail to dial")
 		return nil
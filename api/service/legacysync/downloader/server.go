@@ -4,23 +4,43 @@ import (
 	"context"
 	"log"
 	"net"
+	"sync"
 
 	pb "github.com/harmony-one/harmony/api/service/legacysync/downloader/proto"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
+	p2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 // Constants for downloader server.
 const (
 	DefaultDownloadPort = "6666"
+
+	// DefaultMaxRequestSize is the default maximum number of block hashes in a request
+	DefaultMaxRequestSize = 100
+	// DefaultMaxConcurrency is the default maximum number of concurrent requests of a client
+	DefaultMaxConcurrency = 8
 )
 
 // Server is the Server struct for downloader package.
 type Server struct {
 	downloadInterface DownloadInterface
 	GrpcServer        *grpc.Server
+
+	config    nodeconfig.LegacySyncConfig
+	key       p2p_crypto.PrivKey
+	allowIPs  map[string]struct{}
+	allowIDs  map[libp2p_peer.ID]struct{}
+	lock      sync.Mutex
+	inflights map[string]int // Number of requests being served by client
 }
 
 // Query returns the feature at the given point.
@@ -33,6 +53,9 @@ func (s *Server) Query(ctx context.Context, request *pb.DownloaderRequest) (*pb.
 	} else {
 		pinfo = p.Addr.String()
 	}
+	if len(request.Hashes) > s.config.MaxRequestSize {
+		return nil, status.Errorf(codes.InvalidArgument, "number of hashes exceeds cap of %v", s.config.MaxRequestSize)
+	}
 	response, err := s.downloadInterface.CalculateResponse(request, pinfo)
 	if err != nil {
 		return nil, err
@@ -42,12 +65,25 @@ func (s *Server) Query(ctx context.Context, request *pb.DownloaderRequest) (*pb.
 
 // Start starts the Server on given ip and port.
 func (s *Server) Start(ip, port string) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.limitClient),
+	}
+	if s.config.TLS {
+		conf, err := newTLSConfig(s.key)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf)))
+	}
+	if err := s.parseAllowlist(); err != nil {
+		return nil, err
+	}
+
 	addr := net.JoinHostPort("", port)
-	lis, err := net.Listen("tcp4", addr)
+	lis, err := net.Listen("tcp", addr)
 	if err != nil {
 		log.Fatalf("[SYNC] failed to listen: %v", err)
 	}
-	var opts []grpc.ServerOption
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterDownloaderServer(grpcServer, s)
 	go func() {
@@ -61,8 +97,117 @@ func (s *Server) Start(ip, port string) (*grpc.Server, error) {
 	return grpcServer, nil
 }
 
+func (s *Server) parseAllowlist() error {
+	ips, ids, err := parseAllowlist(s.config.Allowlist, s.config.TLS)
+	if err != nil {
+		return err
+	}
+	s.allowIPs, s.allowIDs = ips, ids
+	return nil
+}
+
+// ValidateAllowlist checks the entries of the allowlist of the server, IPs or
+// peer IDs which require TLS
+func ValidateAllowlist(allowlist []string, tls bool) error {
+	_, _, err := parseAllowlist(allowlist, tls)
+	return err
+}
+
+// parseAllowlist returns the IPs and the peer IDs of the allowlist, nil for an
+// empty allowlist allowing any client
+func parseAllowlist(
+	allowlist []string, tls bool,
+) (map[string]struct{}, map[libp2p_peer.ID]struct{}, error) {
+	if len(allowlist) == 0 {
+		return nil, nil, nil
+	}
+	ips := make(map[string]struct{})
+	ids := make(map[libp2p_peer.ID]struct{})
+	for _, entry := range allowlist {
+		if ip := net.ParseIP(entry); ip != nil {
+			ips[ip.String()] = struct{}{}
+			continue
+		}
+		id, err := libp2p_peer.Decode(entry)
+		if err != nil {
+			return nil, nil, errors.Errorf("invalid allowlist entry %v: not an ip or a peer ID", entry)
+		}
+		if !tls {
+			return nil, nil, errors.Errorf("allowlist peer ID %v requires TLS", entry)
+		}
+		ids[id] = struct{}{}
+	}
+	return ips, ids, nil
+}
+
+// limitClient rejects the requests of the clients not in the allowlist, and
+// the requests above the concurrency limit of the client
+func (s *Server) limitClient(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	client, err := s.authClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	if s.inflights[client] >= s.config.MaxConcurrency {
+		s.lock.Unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "too many concurrent requests")
+	}
+	s.inflights[client]++
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		if s.inflights[client]--; s.inflights[client] == 0 {
+			delete(s.inflights, client)
+		}
+		s.lock.Unlock()
+	}()
+	return handler(ctx, req)
+}
+
+// authClient checks the client against the allowlist, and returns its peer ID
+// with tls, or its ip without.
+func (s *Server) authClient(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.Unauthenticated, "unknown client")
+	}
+	ip, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		ip = p.Addr.String()
+	}
+	id, err := peerIDFromAuthInfo(p.AuthInfo)
+	if err != nil {
+		return "", status.Errorf(codes.Unauthenticated, "invalid client certificate: %v", err)
+	}
+
+	if s.allowIPs != nil || s.allowIDs != nil {
+		_, ipAllowed := s.allowIPs[ip]
+		_, idAllowed := s.allowIDs[id]
+		if !ipAllowed && (id == "" || !idAllowed) {
+			return "", status.Errorf(codes.PermissionDenied, "client not in allowlist")
+		}
+	}
+	if id != "" {
+		return string(id), nil
+	}
+	return ip, nil
+}
+
 // NewServer creates new Server which implements DownloadInterface.
-func NewServer(dlInterface DownloadInterface) *Server {
-	s := &Server{downloadInterface: dlInterface}
+func NewServer(dlInterface DownloadInterface, config nodeconfig.LegacySyncConfig, key p2p_crypto.PrivKey) *Server {
+	if config.MaxRequestSize <= 0 {
+		config.MaxRequestSize = DefaultMaxRequestSize
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = DefaultMaxConcurrency
+	}
+	s := &Server{
+		downloadInterface: dlInterface,
+		config:            config,
+		key:               key,
+		inflights:         make(map[string]int),
+	}
 	return s
 }
@@ -0,0 +1,134 @@
+package downloader
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"net"
+	"testing"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	p2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Allowlist(t *testing.T) {
+	s := NewServer(nil, nodeconfig.LegacySyncConfig{
+		Allowlist: []string{"127.0.0.1"},
+	}, nil)
+	if err := s.parseAllowlist(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.authClient(makeTestPeerContext("127.0.0.1:6000", nil)); err != nil {
+		t.Errorf("allowed client rejected: %v", err)
+	}
+	_, err := s.authClient(makeTestPeerContext("10.0.0.1:6000", nil))
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("unexpected error %v / %v", status.Code(err), codes.PermissionDenied)
+	}
+}
+
+func TestServer_AllowlistRequiresTLS(t *testing.T) {
+	_, pub, _ := p2p_crypto.GenerateECDSAKeyPair(rand.Reader)
+	id, _ := libp2p_peer.IDFromPublicKey(pub)
+
+	s := NewServer(nil, nodeconfig.LegacySyncConfig{
+		Allowlist: []string{id.Pretty()},
+	}, nil)
+	if err := s.parseAllowlist(); err == nil {
+		t.Errorf("peer ID allowlist accepted without TLS")
+	}
+}
+
+func TestValidateAllowlist(t *testing.T) {
+	if err := ValidateAllowlist([]string{"127.0.0.1", "::1"}, false); err != nil {
+		t.Errorf("valid allowlist rejected: %v", err)
+	}
+	if err := ValidateAllowlist([]string{"127.0.0.1", "localhost"}, true); err == nil {
+		t.Errorf("invalid allowlist entry accepted")
+	}
+}
+
+func TestServer_MaxConcurrency(t *testing.T) {
+	s := NewServer(nil, nodeconfig.LegacySyncConfig{
+		MaxConcurrency: 1,
+	}, nil)
+	ctx := makeTestPeerContext("127.0.0.1:6000", nil)
+
+	var innerErr error
+	_, err := s.limitClient(ctx, nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		_, innerErr = s.limitClient(ctx, nil, nil, func(context.Context, interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Code(innerErr) != codes.ResourceExhausted {
+		t.Errorf("unexpected error %v / %v", status.Code(innerErr), codes.ResourceExhausted)
+	}
+	if len(s.inflights) != 0 {
+		t.Errorf("inflight requests not released")
+	}
+}
+
+func TestTLS_PeerID(t *testing.T) {
+	serverKey, _, _ := p2p_crypto.GenerateECDSAKeyPair(rand.Reader)
+	clientKey, clientPub, _ := p2p_crypto.GenerateECDSAKeyPair(rand.Reader)
+	clientID, _ := libp2p_peer.IDFromPublicKey(clientPub)
+
+	serverConf, err := newTLSConfig(serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConf, err := newTLSConfig(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverRaw, clientRaw := net.Pipe()
+	server, client := tls.Server(serverRaw, serverConf), tls.Client(clientRaw, clientConf)
+
+	errC := make(chan error, 1)
+	go func() { errC <- client.Handshake() }()
+	if err := server.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+
+	info := credentials.TLSInfo{State: server.ConnectionState()}
+	id, err := peerIDFromAuthInfo(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != clientID {
+		t.Errorf("unexpected peer ID %v / %v", id, clientID)
+	}
+
+	s := NewServer(nil, nodeconfig.LegacySyncConfig{
+		TLS:       true,
+		Allowlist: []string{clientID.Pretty()},
+	}, serverKey)
+	if err := s.parseAllowlist(); err != nil {
+		t.Fatal(err)
+	}
+	client2, err := s.authClient(makeTestPeerContext("10.0.0.1:6000", info))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client2 != string(clientID) {
+		t.Errorf("unexpected client %v / %v", client2, clientID)
+	}
+}
+
+func makeTestPeerContext(addr string, info credentials.AuthInfo) context.Context {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr, AuthInfo: info})
+}
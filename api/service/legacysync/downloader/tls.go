@@ -0,0 +1,69 @@
+package downloader
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	p2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	libp2ptls "github.com/libp2p/go-libp2p-tls"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// clientTLS is the tls config of the clients, nil for plaintext
+var clientTLS *tls.Config
+
+// EnableClientTLS makes the clients connect with mutual TLS, with the
+// certificate tied to the p2p key of the node
+func EnableClientTLS(key p2p_crypto.PrivKey) error {
+	conf, err := newTLSConfig(key)
+	if err != nil {
+		return err
+	}
+	clientTLS = conf
+	return nil
+}
+
+// newTLSConfig returns the tls config presenting a certificate signed by the
+// p2p key, and accepting the certificates signed by any p2p key
+func newTLSConfig(key p2p_crypto.PrivKey) (*tls.Config, error) {
+	identity, err := libp2ptls.NewIdentity(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create tls identity")
+	}
+	conf, _ := identity.ConfigForAny()
+	// the single use key channel of the config is not used, so that the config
+	// is reused over the connections
+	conf.VerifyPeerCertificate = verifyPeerCertificate
+	// negotiated by grpc
+	conf.NextProtos = nil
+	return conf, nil
+}
+
+func verifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	chain := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		chain = append(chain, cert)
+	}
+	_, err := libp2ptls.PubKeyFromCertChain(chain)
+	return err
+}
+
+// peerIDFromAuthInfo returns the p2p peer ID of the tls certificate of the
+// remote end, or an empty ID without tls
+func peerIDFromAuthInfo(info credentials.AuthInfo) (libp2p_peer.ID, error) {
+	tlsInfo, ok := info.(credentials.TLSInfo)
+	if !ok {
+		return "", nil
+	}
+	pubKey, err := libp2ptls.PubKeyFromCertChain(tlsInfo.State.PeerCertificates)
+	if err != nil {
+		return "", err
+	}
+	return libp2p_peer.IDFromPublicKey(pubKey)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/node/worker"
 	"github.com/harmony-one/harmony/p2p"
+	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pkg/errors"
 )
 
@@ -42,6 +43,10 @@ const (
 	numPeersHighBound = 5
 
 	downloadTaskBatch = 30
+
+	// syncStatusCacheTime is how long the max peer height queried for the
+	// sync status is reused
+	syncStatusCacheTime = 10 * time.Second
 )
 
 // SyncPeerConfig is peer config to sync.
@@ -176,6 +181,14 @@ type StateSync struct {
 	stateSyncTaskQueue *queue.Queue
 	syncMux            sync.Mutex
 	lastMileMux        sync.Mutex
+	statusCache        syncStatusCache
+}
+
+// syncStatusCache is the max peer height last queried for the sync status
+type syncStatusCache struct {
+	peerHeight uint64
+	updated    time.Time
+	lock       sync.Mutex
 }
 
 func (ss *StateSync) purgeAllBlocksFromCache() {
@@ -1013,6 +1026,34 @@ func (ss *StateSync) IsOutOfSync(bc *core.BlockChain, doubleCheck bool) bool {
 	return wasOutOfSync && isOutOfSync && lastHeight == currentHeight
 }
 
+// SyncStatus returns whether the node is out of sync from other peers, and
+// the block the node is syncing to. Unlike IsOutOfSync, the max peer height is
+// queried at most once per syncStatusCacheTime, so that frequent status checks
+// such as the readiness probes of the node do not flood the peers. Without any
+// sync peer the node cannot tell its status and is reported out of sync.
+func (ss *StateSync) SyncStatus(bc *core.BlockChain) (bool, uint64) {
+	if ss.GetActivePeerNumber() == 0 {
+		return true, 0
+	}
+	otherHeight := ss.cachedMaxPeerHeight()
+	currentHeight := bc.CurrentBlock().NumberU64()
+	if currentHeight+inSyncThreshold < otherHeight {
+		return true, otherHeight
+	}
+	return false, currentHeight
+}
+
+func (ss *StateSync) cachedMaxPeerHeight() uint64 {
+	ss.statusCache.lock.Lock()
+	defer ss.statusCache.lock.Unlock()
+
+	if time.Since(ss.statusCache.updated) > syncStatusCacheTime {
+		ss.statusCache.peerHeight = ss.getMaxPeerHeight(false)
+		ss.statusCache.updated = time.Now()
+	}
+	return ss.statusCache.peerHeight
+}
+
 // SyncLoop will keep syncing with peers until catches up
 func (ss *StateSync) SyncLoop(bc *core.BlockChain, worker *worker.Worker, isBeacon bool, consensus *consensus.Consensus) {
 	if !isBeacon {
@@ -1076,10 +1117,16 @@ func (ss *StateSync) addConsensusLastMile(bc *core.BlockChain, consensus *consen
 	return nil
 }
 
-// GetSyncingPort returns the syncing port.
+// GetSyncingPort returns the syncing port from the p2p port of the node, or
+// from its p2p TCP multiaddr.
 func GetSyncingPort(nodePort string) string {
 	if port, err := strconv.Atoi(nodePort); err == nil {
 		return fmt.Sprintf("%d", port-SyncingPortDifference)
 	}
+	if maddr, err := ma.NewMultiaddr(nodePort); err == nil {
+		if port, err := maddr.ValueForProtocol(ma.P_TCP); err == nil {
+			return GetSyncingPort(port)
+		}
+	}
 	return ""
 }
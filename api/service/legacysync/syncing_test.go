@@ -6,8 +6,15 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/harmony-one/harmony/api/service/legacysync/downloader"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/chain"
+	"github.com/harmony-one/harmony/internal/params"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +109,33 @@ func TestCreateStateSync(t *testing.T) {
 	}
 }
 
+func TestStateSync_SyncStatus(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	gspec := core.Genesis{
+		Config:  params.TestChainConfig,
+		Factory: blockfactory.ForTest,
+	}
+	gspec.MustCommit(db)
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, chain.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := CreateStateSync("127.0.0.1", "8000", [20]byte{}, false)
+	if syncing, target := ss.SyncStatus(bc); !syncing || target != 0 {
+		t.Errorf("unexpected status without sync peers: %v %v", syncing, target)
+	}
+
+	ss.syncConfig.AddPeer(CreateTestSyncPeerConfig(nil, nil))
+	ss.statusCache.peerHeight, ss.statusCache.updated = 100, time.Now()
+	if syncing, target := ss.SyncStatus(bc); !syncing || target != 100 {
+		t.Errorf("unexpected status behind the peers: %v %v", syncing, target)
+	}
+	ss.statusCache.peerHeight = 0
+	if syncing, target := ss.SyncStatus(bc); syncing || target != 0 {
+		t.Errorf("unexpected status at the peer height: %v %v", syncing, target)
+	}
+}
+
 func TestCheckPeersDuplicity(t *testing.T) {
 	tests := []struct {
 		peers  []p2p.Peer
@@ -199,3 +233,21 @@ func assertTestError(got, expect error) error {
 	}
 	return nil
 }
+
+func TestGetSyncingPort(t *testing.T) {
+	tests := []struct {
+		nodePort string
+		exp      string
+	}{
+		{"9000", "6000"},
+		{"/ip4/0.0.0.0/tcp/9000", "6000"},
+		{"/ip6/::/tcp/9100", "6100"},
+		{"/ip4/0.0.0.0/udp/9000/quic", ""},
+		{"invalid", ""},
+	}
+	for i, test := range tests {
+		if got := GetSyncingPort(test.nodePort); got != test.exp {
+			t.Errorf("Test %v: unexpected syncing port %v / %v", i, got, test.exp)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/pelletier/go-toml"
+)
+
+const bootnodeConfigVersion = "1.0.0"
+
+// bootnodeConfig contains all the configs user can set for running bootnode binary.
+// The config can be persisted to a toml file, and the flags set override it.
+type bootnodeConfig struct {
+	Version      string
+	IP           string
+	Port         int
+	KeyFile      string
+	DHTDataStore string // file to persist the dht routing table, .dht-<ip>-<port> if empty
+	Network      string // network type, used for the shard topics
+	Shards       int    // number of shards, used for the shard topics
+	Log          logConfig
+	HTTP         httpConfig
+	Prometheus   prometheusConfig
+}
+
+type logConfig struct {
+	Folder    string
+	MaxSize   int
+	Verbosity int
+	LogConn   bool
+}
+
+// httpConfig is the config of the http status server
+type httpConfig struct {
+	Enabled bool
+	IP      string
+	Port    int
+}
+
+type prometheusConfig struct {
+	Enabled bool
+	IP      string
+	Port    int
+}
+
+var defaultConfig = bootnodeConfig{
+	Version: bootnodeConfigVersion,
+	IP:      "127.0.0.1",
+	Port:    9876,
+	KeyFile: "./.bnkey",
+	Network: nodeconfig.Mainnet,
+	Shards:  4,
+	Log: logConfig{
+		Folder:    "latest",
+		MaxSize:   100,
+		Verbosity: 5,
+		LogConn:   false,
+	},
+	HTTP: httpConfig{
+		Enabled: false,
+		IP:      "127.0.0.1",
+		Port:    9099,
+	},
+	Prometheus: prometheusConfig{
+		Enabled: false,
+		IP:      "0.0.0.0",
+		Port:    9900,
+	},
+}
+
+func (config bootnodeConfig) dataStorePath() string {
+	if config.DHTDataStore != "" {
+		return config.DHTDataStore
+	}
+	return fmt.Sprintf(".dht-%s-%d", config.IP, config.Port)
+}
+
+func validateBootnodeConfig(config bootnodeConfig) error {
+	switch nodeconfig.NetworkType(config.Network) {
+	case nodeconfig.Mainnet, nodeconfig.Testnet, nodeconfig.Pangaea, nodeconfig.Partner,
+		nodeconfig.Stressnet, nodeconfig.Devnet, nodeconfig.Localnet:
+	default:
+		return fmt.Errorf("unknown network type: %v", config.Network)
+	}
+	if config.Shards <= 0 {
+		return fmt.Errorf("invalid number of shards: %v", config.Shards)
+	}
+	return nil
+}
+
+func loadBootnodeConfig(file string) (bootnodeConfig, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return bootnodeConfig{}, err
+	}
+	var config bootnodeConfig
+	if err := toml.Unmarshal(b, &config); err != nil {
+		return bootnodeConfig{}, err
+	}
+	return config, nil
+}
+
+func writeBootnodeConfigToFile(config bootnodeConfig, file string) error {
+	b, err := toml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// applyFlags overrides the config with the flags explicitly set
+func applyFlags(fs *flag.FlagSet, config *bootnodeConfig) {
+	fs.Visit(func(f *flag.Flag) {
+		getter := f.Value.(flag.Getter)
+		switch f.Name {
+		case "ip":
+			config.IP = getter.Get().(string)
+		case "port":
+			config.Port = getter.Get().(int)
+		case "key":
+			config.KeyFile = getter.Get().(string)
+		case "dht_datastore":
+			config.DHTDataStore = getter.Get().(string)
+		case "network":
+			config.Network = getter.Get().(string)
+		case "shards":
+			config.Shards = getter.Get().(int)
+		case "log_folder":
+			config.Log.Folder = getter.Get().(string)
+		case "log_max_size":
+			config.Log.MaxSize = getter.Get().(int)
+		case "verbosity":
+			config.Log.Verbosity = getter.Get().(int)
+		case "log_conn":
+			config.Log.LogConn = getter.Get().(bool)
+		case "http":
+			config.HTTP.Enabled = getter.Get().(bool)
+		case "http_ip":
+			config.HTTP.IP = getter.Get().(string)
+		case "http_port":
+			config.HTTP.Port = getter.Get().(int)
+		case "metrics":
+			config.Prometheus.Enabled = getter.Get().(bool)
+		case "metrics_ip":
+			config.Prometheus.IP = getter.Get().(string)
+		case "metrics_port":
+			config.Prometheus.Port = getter.Get().(int)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPersistConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bootnode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "bootnode.conf")
+
+	config := defaultConfig
+	config.DHTDataStore = "./.dht"
+	config.HTTP.Enabled = true
+	if err := writeBootnodeConfigToFile(config, file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadBootnodeConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, config) {
+		t.Errorf("unexpected config: \n\t%+v\n\t%+v", loaded, config)
+	}
+}
+
+func TestApplyFlags(t *testing.T) {
+	fs := flag.NewFlagSet("bootnode", flag.ContinueOnError)
+	fs.String("ip", defaultConfig.IP, "")
+	fs.Int("port", defaultConfig.Port, "")
+	fs.Bool("metrics", defaultConfig.Prometheus.Enabled, "")
+	fs.String("dht_datastore", defaultConfig.DHTDataStore, "")
+	if err := fs.Parse([]string{"-port", "9000", "-metrics", "-dht_datastore", "./.dht"}); err != nil {
+		t.Fatal(err)
+	}
+
+	config := defaultConfig
+	config.IP = "1.2.3.4"
+	applyFlags(fs, &config)
+
+	exp := defaultConfig
+	exp.IP = "1.2.3.4"
+	exp.Port = 9000
+	exp.Prometheus.Enabled = true
+	exp.DHTDataStore = "./.dht"
+	if !reflect.DeepEqual(config, exp) {
+		t.Errorf("unexpected config: \n\t%+v\n\t%+v", config, exp)
+	}
+	if config.dataStorePath() != "./.dht" {
+		t.Errorf("unexpected datastore path %v", config.dataStorePath())
+	}
+}
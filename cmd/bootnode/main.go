@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/harmony-one/harmony/api/service/prometheus"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
 	net "github.com/libp2p/go-libp2p-core/network"
@@ -89,34 +92,70 @@ func printVersion(me string) {
 }
 
 func main() {
-	ip := flag.String("ip", "127.0.0.1", "IP of the node")
-	port := flag.String("port", "9876", "port of the node.")
-	logFolder := flag.String("log_folder", "latest", "the folder collecting the logs of this execution")
-	logMaxSize := flag.Int("log_max_size", 100, "the max size in megabytes of the log file before it gets rotated")
-	keyFile := flag.String("key", "./.bnkey", "the private key file of the bootnode")
-	versionFlag := flag.Bool("version", false, "Output version info")
-	verbosity := flag.Int("verbosity", 5, "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail (default: 5)")
-	logConn := flag.Bool("log_conn", false, "log incoming/outgoing connections")
+	fs := flag.CommandLine
+	configFile := fs.String("config", "", "load the bootnode config from the toml file, the flags set override it")
+	dumpConfigFile := fs.String("dumpconfig", "", "dump the default config to the toml file and exit")
+	fs.String("ip", defaultConfig.IP, "IP of the node")
+	fs.Int("port", defaultConfig.Port, "port of the node.")
+	fs.String("log_folder", defaultConfig.Log.Folder, "the folder collecting the logs of this execution")
+	fs.Int("log_max_size", defaultConfig.Log.MaxSize, "the max size in megabytes of the log file before it gets rotated")
+	fs.String("key", defaultConfig.KeyFile, "the private key file of the bootnode")
+	versionFlag := fs.Bool("version", false, "Output version info")
+	fs.Int("verbosity", defaultConfig.Log.Verbosity, "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail (default: 5)")
+	fs.Bool("log_conn", defaultConfig.Log.LogConn, "log incoming/outgoing connections")
+	fs.String("dht_datastore", defaultConfig.DHTDataStore, "the datastore file to persist the dht routing table (default: .dht-<ip>-<port>)")
+	fs.String("network", defaultConfig.Network, "network type of the shard topics listed by the status server")
+	fs.Int("shards", defaultConfig.Shards, "number of shards of the shard topics listed by the status server")
+	fs.Bool("http", defaultConfig.HTTP.Enabled, "enable the http status server")
+	fs.String("http_ip", defaultConfig.HTTP.IP, "ip of the http status server")
+	fs.Int("http_port", defaultConfig.HTTP.Port, "port of the http status server")
+	fs.Bool("metrics", defaultConfig.Prometheus.Enabled, "enable the prometheus metrics server")
+	fs.String("metrics_ip", defaultConfig.Prometheus.IP, "ip of the prometheus metrics server")
+	fs.Int("metrics_port", defaultConfig.Prometheus.Port, "port of the prometheus metrics server")
 
 	flag.Parse()
 
 	if *versionFlag {
 		printVersion(os.Args[0])
 	}
+	if *dumpConfigFile != "" {
+		if err := writeBootnodeConfigToFile(defaultConfig, *dumpConfigFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(128)
+		}
+		os.Exit(0)
+	}
+
+	config := defaultConfig
+	if *configFile != "" {
+		var err error
+		if config, err = loadBootnodeConfig(*configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot load config from %s: %v\n", *configFile, err)
+			os.Exit(128)
+		}
+	}
+	applyFlags(fs, &config)
+	if err := validateBootnodeConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(128)
+	}
+	nodeconfig.SetNetworkType(nodeconfig.NetworkType(config.Network))
+
+	ip, port := config.IP, strconv.Itoa(config.Port)
 
 	// Logging setup
-	utils.SetLogContext(*port, *ip)
-	utils.SetLogVerbosity(log.Lvl(*verbosity))
-	utils.AddLogFile(fmt.Sprintf("%v/bootnode-%v-%v.log", *logFolder, *ip, *port), *logMaxSize)
+	utils.SetLogContext(port, ip)
+	utils.SetLogVerbosity(log.Lvl(config.Log.Verbosity))
+	utils.AddLogFile(fmt.Sprintf("%v/bootnode-%v-%v.log", config.Log.Folder, ip, port), config.Log.MaxSize)
 
-	privKey, _, err := utils.LoadKeyFromFile(*keyFile)
+	privKey, _, err := utils.LoadKeyFromFile(config.KeyFile)
 	if err != nil {
-		utils.FatalErrMsg(err, "cannot load key from %s", *keyFile)
+		utils.FatalErrMsg(err, "cannot load key from %s", config.KeyFile)
 	}
 
 	// For bootstrap nodes, we shall keep .dht file.
-	dataStorePath := fmt.Sprintf(".dht-%s-%s", *ip, *port)
-	selfPeer := p2p.Peer{IP: *ip, Port: *port}
+	dataStorePath := config.dataStorePath()
+	selfPeer := p2p.Peer{IP: ip, Port: port}
 	host, err := p2p.NewHost(p2p.HostConfig{
 		Self:          &selfPeer,
 		BLSKey:        privKey,
@@ -128,14 +167,31 @@ func main() {
 	}
 
 	fmt.Printf("bootnode BN_MA=%s",
-		fmt.Sprintf("/ip4/%s/tcp/%s/p2p/%s", *ip, *port, host.GetID().Pretty()),
+		fmt.Sprintf("/ip4/%s/tcp/%s/p2p/%s", ip, port, host.GetID().Pretty()),
 	)
 
 	host.Start()
 
-	if *logConn {
+	if config.Log.LogConn {
 		host.GetP2PHost().Network().Notify(NewConnLogger(utils.GetLogInstance()))
 	}
 
+	if config.Prometheus.Enabled {
+		p := prometheus.NewService(prometheus.Config{
+			Enabled:  true,
+			IP:       config.Prometheus.IP,
+			Port:     config.Prometheus.Port,
+			Network:  config.Network,
+			NodeType: "bootnode",
+			Instance: host.GetID().Pretty(),
+		})
+		if err := p.Start(); err != nil {
+			utils.FatalErrMsg(err, "cannot start prometheus service")
+		}
+	}
+	if config.HTTP.Enabled {
+		newStatusServer(host, config).start()
+	}
+
 	select {}
 }
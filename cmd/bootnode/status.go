@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+const (
+	// findPeersTimeout is the timeout to find the peers of a topic in the dht
+	findPeersTimeout = 10 * time.Second
+	// peersRefreshInterval is the interval of the dht lookups of the peers
+	// served, which are not looked up per request
+	peersRefreshInterval = time.Minute
+	// pubsubDiscoveryPrefix prefixes the topics advertised by the pubsub of
+	// the nodes in the dht
+	pubsubDiscoveryPrefix = "floodsub:"
+)
+
+type peerInfo struct {
+	ID    string   `json:"id"`
+	Addrs []string `json:"addrs"`
+}
+
+type statusResponse struct {
+	ID        string                `json:"id"`
+	Addrs     []string              `json:"addrs"`
+	Connected int                   `json:"connected"`
+	Topics    map[string][]peerInfo `json:"topics"`
+}
+
+// statusServer serves the status of the bootnode, including the peers known
+// for each shard topic
+type statusServer struct {
+	host   p2p.Host
+	topics []string
+	server *http.Server
+
+	lock  sync.RWMutex
+	peers map[string][]peerInfo // Peers of the topics found by the last lookup
+}
+
+func newStatusServer(host p2p.Host, config bootnodeConfig) *statusServer {
+	var topics []string
+	for i := 0; i != config.Shards; i++ {
+		topics = append(topics, nodeconfig.NewGroupIDByShardID(nodeconfig.ShardID(i)).String())
+	}
+	s := &statusServer{
+		host:   host,
+		topics: topics,
+		peers:  make(map[string][]peerInfo),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/peers", s.peersHandler)
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", config.HTTP.IP, config.HTTP.Port),
+		Handler: mux,
+	}
+	return s
+}
+
+func (s *statusServer) start() {
+	go s.refreshLoop()
+	go func() {
+		utils.Logger().Info().Str("address", s.server.Addr).Msg("Starting status server")
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			utils.Logger().Error().Err(err).Str("address", s.server.Addr).Msg("status server failed")
+		}
+	}()
+}
+
+// refreshLoop looks up the peers of the topics in the dht periodically
+func (s *statusServer) refreshLoop() {
+	ticker := time.NewTicker(peersRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		s.refreshPeers()
+		<-ticker.C
+	}
+}
+
+// refreshPeers looks up the peers of each topic, keeping the last peers found
+// for the topics failing the lookup
+func (s *statusServer) refreshPeers() {
+	for _, topic := range s.topics {
+		ctx, cancel := context.WithTimeout(context.Background(), findPeersTimeout)
+		peers, err := s.findPeers(ctx, topic)
+		cancel()
+		if err != nil {
+			utils.Logger().Warn().Err(err).Str("topic", topic).Msg("failed to find peers")
+			continue
+		}
+		s.lock.Lock()
+		s.peers[topic] = peers
+		s.lock.Unlock()
+	}
+}
+
+func (s *statusServer) peersHandler(w http.ResponseWriter, r *http.Request) {
+	resp := statusResponse{
+		ID:        s.host.GetID().Pretty(),
+		Connected: len(s.host.GetP2PHost().Network().Peers()),
+		Topics:    make(map[string][]peerInfo),
+	}
+	for _, addr := range s.host.GetP2PHost().Addrs() {
+		resp.Addrs = append(resp.Addrs, addr.String())
+	}
+	s.lock.RLock()
+	for _, topic := range s.topics {
+		peers, ok := s.peers[topic]
+		if !ok {
+			peers = make([]peerInfo, 0)
+		}
+		resp.Topics[topic] = peers
+	}
+	s.lock.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		utils.Logger().Warn().Err(err).Msg("failed to write status response")
+	}
+}
+
+// findPeers returns the peers advertising the topic in the dht
+func (s *statusServer) findPeers(ctx context.Context, topic string) ([]peerInfo, error) {
+	ch, err := s.host.GetDiscovery().FindPeers(ctx, pubsubDiscoveryPrefix+topic, 0)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]peerInfo, 0)
+	for ai := range ch {
+		info := peerInfo{ID: ai.ID.Pretty()}
+		for _, addr := range ai.Addrs {
+			info.Addrs = append(info.Addrs, addr.String())
+		}
+		peers = append(peers, info)
+	}
+	return peers, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/p2p"
+)
+
+func newTestHost(t *testing.T, bootNodes []string) p2p.Host {
+	key, _, err := utils.GenKeyP2PRand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := p2p.NewHost(p2p.HostConfig{
+		Self:        &p2p.Peer{IP: "127.0.0.1", Port: "0"},
+		BLSKey:      key,
+		BootNodes:   bootNodes,
+		ListenAddrs: []string{"/ip4/127.0.0.1/tcp/0"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := host.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return host
+}
+
+func TestStatusServerFindPeers(t *testing.T) {
+	bootnode := newTestHost(t, nil)
+	defer bootnode.Close()
+	bootAddr := fmt.Sprintf("%s/p2p/%s", bootnode.GetP2PHost().Addrs()[0], bootnode.GetID().Pretty())
+	node := newTestHost(t, []string{bootAddr})
+	defer node.Close()
+
+	// the node advertises the topic once subscribed, joined to the dht
+	deadline := time.Now().Add(20 * time.Second)
+	for len(node.GetP2PHost().Network().Peers()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("node not connected to the bootnode")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	topic := nodeconfig.NewGroupIDByShardID(0).String()
+	joined, err := node.GetOrJoin(topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := joined.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Cancel()
+
+	s := &statusServer{host: bootnode, topics: []string{topic}}
+	deadline = time.Now().Add(20 * time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		peers, err := s.findPeers(ctx, topic)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(peers) != 0 {
+			if peers[0].ID != node.GetID().Pretty() {
+				t.Errorf("unexpected peer %v", peers[0].ID)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("node joined to %v not found", topic)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
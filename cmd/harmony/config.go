@@ -7,8 +7,12 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	legdownloader "github.com/harmony-one/harmony/api/service/legacysync/downloader"
 	"github.com/harmony-one/harmony/internal/cli"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/pelletier/go-toml"
 	"github.com/spf13/cobra"
 )
@@ -32,9 +36,11 @@ type harmonyConfig struct {
 	Sys        *sysConfig        `toml:",omitempty"`
 	Consensus  *consensusConfig  `toml:",omitempty"`
 	Devnet     *devnetConfig     `toml:",omitempty"`
-	Revert     *revertConfig     `toml:",omitempty"`
 	Legacy     *legacyConfig     `toml:",omitempty"`
 	Prometheus *prometheusConfig `toml:",omitempty"`
+	GPO        *gpoConfig        `toml:",omitempty"`
+	Health     *healthConfig     `toml:",omitempty"`
+	DB         *dbConfig         `toml:",omitempty"`
 }
 
 type networkConfig struct {
@@ -47,10 +53,22 @@ type networkConfig struct {
 }
 
 type p2pConfig struct {
-	Port         int
-	IP           string
-	KeyFile      string
-	DHTDataStore *string `toml:",omitempty"`
+	Port          int
+	IP            string
+	KeyFile       string
+	DHTDataStore  *string  `toml:",omitempty"`
+	ListenAddrs   []string `toml:",omitempty"`
+	AnnounceAddrs []string `toml:",omitempty"`
+	StaticPeers   []string `toml:",omitempty"`
+	TrustedPeers  []string `toml:",omitempty"`
+	NoDiscovery   bool
+	DNSTrees      []string `toml:",omitempty"`
+	PeersFile     string   `toml:",omitempty"`
+	// Connection manager watermarks, and grace and silence periods in seconds
+	ConnLowWater      int
+	ConnHighWater     int
+	ConnGracePeriod   int
+	ConnSilencePeriod int
 }
 
 type generalConfig struct {
@@ -98,6 +116,10 @@ type logConfig struct {
 	RotateSize int
 	Verbosity  int
 	Context    *logContext `toml:",omitempty"`
+	// Recording of the consensus and node p2p messages for offline replay
+	MsgRecordFile       string `toml:",omitempty"` // disabled if empty
+	MsgRecordRotateSize int    `toml:",omitempty"` // rotation size in megabytes
+	MsgRecordMaxBackups int    `toml:",omitempty"` // rotated files kept, all if 0
 }
 
 type logContext struct {
@@ -124,7 +146,33 @@ type wsConfig struct {
 }
 
 type rpcOptConfig struct {
-	DebugEnabled bool // Enables PrivateDebugService APIs, including the EVM tracer
+	DebugEnabled      bool // Enables PrivateDebugService APIs, including the EVM tracer
+	TraceIndexEnabled bool // Indexes the flat traces of the trace namespace, archival nodes only
+
+	// Request limits, zero values for no limit
+	BatchLimit       int                  // Maximum number of requests in a batch
+	ResponseLimit    int                  // Maximum size of a http response in bytes
+	RateLimit        int                  // Requests per second of each client ip
+	RateBurst        int                  // Requests above the rate accepted in a burst
+	MethodRateLimits []rpcRateLimitConfig `toml:",omitempty"`
+
+	APIKeys        []rpcAPIKeyConfig `toml:",omitempty"`
+	APIKeyRequired bool              // Rejects the requests without a known api key
+}
+
+// rpcRateLimitConfig is the request rate of each client for a method (eth_call)
+// or a namespace (debug)
+type rpcRateLimitConfig struct {
+	Method string
+	Rate   float64
+	Burst  int
+}
+
+// rpcAPIKeyConfig is an api key and the request rate of its client
+type rpcAPIKeyConfig struct {
+	Key   string
+	Rate  float64
+	Burst int
 }
 
 type devnetConfig struct {
@@ -133,13 +181,6 @@ type devnetConfig struct {
 	HmyNodeSize int
 }
 
-// TODO: make `revert` to a separate command
-type revertConfig struct {
-	RevertBeacon bool
-	RevertTo     int
-	RevertBefore int
-}
-
 type legacyConfig struct {
 	WebHookConfig         *string `toml:",omitempty"`
 	TPBroadcastInvalidTxn *bool   `toml:",omitempty"`
@@ -153,6 +194,27 @@ type prometheusConfig struct {
 	Gateway    string
 }
 
+type gpoConfig struct {
+	Blocks      int   // Number of recent blocks sampled for the gas price suggestion
+	Percentile  int   // Percentile of the sampled gas prices suggested
+	IgnorePrice int64 // Gas price in wei below which transactions are not sampled
+	MaxPrice    int64 // Highest gas price in wei suggested
+}
+
+type dbConfig struct {
+	Backend          string // key value store of the chain and explorer databases, leveldb or badgerdb
+	Freezer          bool   // move the old blocks from the key value store to the append-only freezer
+	FreezerThreshold int    // number of recent blocks kept in the key value store with the freezer
+}
+
+type healthConfig struct {
+	RPC           bool // serve the health endpoints on the http rpc port
+	Metrics       bool // serve the health endpoints on the prometheus port
+	MinPeers      int  // minimum number of connected peers to be ready
+	MaxBlockAge   int  // maximum age in seconds of the head block to be ready, unchecked if 0
+	MaxRPCBacklog int  // maximum number of rpc requests in flight to be ready, unchecked if 0
+}
+
 type syncConfig struct {
 	// TODO: Remove this bool after stream sync is fully up.
 	Downloader     bool // start the sync downloader client
@@ -165,6 +227,36 @@ type syncConfig struct {
 	DiscHardLowCap int  // when removing stream, num is below this value, spin discovery immediately
 	DiscHighCap    int  // upper limit of streams in one sync protocol
 	DiscBatch      int  // size of each discovery
+
+	// Rate limits of the stream sync protocol in request cost or bytes per
+	// second, and the cost of each request type. Zero for the defaults.
+	RateLimit          int `toml:",omitempty"` // request cost served to all streams
+	StreamRateLimit    int `toml:",omitempty"` // request cost served to a single stream
+	ClientRateLimit    int `toml:",omitempty"` // request cost sent to the remote streams
+	ResponseBytesLimit int `toml:",omitempty"` // response bytes served to a single stream
+	CostBlockNumber    int `toml:",omitempty"` // cost of a block number request
+	CostBlockHashes    int `toml:",omitempty"` // cost per block hash requested
+	CostBlocksByNumber int `toml:",omitempty"` // cost per block requested by number
+	CostBlocksByHashes int `toml:",omitempty"` // cost per block requested by hash
+
+	// Hardening of the gRPC sync server. TLS certificates are tied to the p2p
+	// keys, and the allowlist takes peer IDs (with TLS) or IPs.
+	LegacyTLS            bool     `toml:",omitempty"` // mutual TLS of the gRPC sync server and clients
+	LegacyAllowlist      []string `toml:",omitempty"` // clients allowed by the gRPC sync server, empty for any
+	LegacyMaxRequestSize int      `toml:",omitempty"` // maximum block hashes in a gRPC sync request
+	LegacyMaxConcurrency int      `toml:",omitempty"` // maximum concurrent gRPC sync requests of a client
+
+	LightServer bool `toml:",omitempty"` // serve the light stream protocol to light clients
+
+	// Checkpoint sync of the node shard. The checkpoint is the hash of a trusted
+	// last block of an epoch, and its state is imported from the snapshot file or
+	// URL if not in the database. The snapshot of a beacon checkpoint of the
+	// staking era also carries its off-chain staking data.
+	Checkpoint      string `toml:",omitempty"`
+	CheckpointState string `toml:",omitempty"`
+	// Checkpoint sync of the beacon chain of the nodes of other shards
+	BeaconCheckpoint      string `toml:",omitempty"`
+	BeaconCheckpointState string `toml:",omitempty"`
 }
 
 // TODO: use specific type wise validation instead of general string types assertion.
@@ -199,15 +291,57 @@ func validateHarmonyConfig(config harmonyConfig) error {
 		return errors.New("flag --run.shard must be specified for explorer node")
 	}
 
+	if config.DB != nil {
+		accepts = []string{shardchain.LDBBackend, shardchain.BadgerDBBackend}
+		if err := checkStringAccepted("--db.backend", config.DB.Backend, accepts); err != nil {
+			return err
+		}
+		if config.DB.Freezer && config.DB.FreezerThreshold <= 0 {
+			return fmt.Errorf("invalid freezer threshold: %v", config.DB.FreezerThreshold)
+		}
+	}
+
 	if config.General.IsOffline && config.P2P.IP != nodeconfig.DefaultLocalListenIP {
 		return fmt.Errorf("flag --run.offline must have p2p IP be %v", nodeconfig.DefaultLocalListenIP)
 	}
 
+	if config.P2P.ConnLowWater > config.P2P.ConnHighWater {
+		return fmt.Errorf("flag --p2p.connmgr.low %v must not exceed --p2p.connmgr.high %v",
+			config.P2P.ConnLowWater, config.P2P.ConnHighWater)
+	}
+
 	if !config.Sync.Downloader && !config.Sync.LegacyClient {
 		// There is no module up for sync
 		return errors.New("either --sync.downloader or --sync.legacy.client shall be enabled")
 	}
 
+	if err := legdownloader.ValidateAllowlist(config.Sync.LegacyAllowlist, config.Sync.LegacyTLS); err != nil {
+		return fmt.Errorf("invalid --sync.legacy.allowlist: %v", err)
+	}
+	if len(config.Sync.LegacyAllowlist) != 0 && !config.Sync.LegacyServer {
+		return errors.New("flag --sync.legacy.allowlist requires --sync.legacy.server")
+	}
+	if config.Sync.LegacyTLS && !config.Sync.LegacyServer && !config.Sync.LegacyClient {
+		return errors.New("flag --sync.legacy.tls requires --sync.legacy.server or --sync.legacy.client")
+	}
+
+	if config.Sync.Checkpoint != "" {
+		if !config.Sync.Downloader {
+			return errors.New("flag --sync.checkpoint requires --sync.downloader")
+		}
+		if b, err := hexutil.Decode(config.Sync.Checkpoint); err != nil || len(b) != common.HashLength {
+			return fmt.Errorf("invalid checkpoint hash: %v", config.Sync.Checkpoint)
+		}
+	}
+	if config.Sync.BeaconCheckpoint != "" {
+		if !config.Sync.Downloader {
+			return errors.New("flag --sync.checkpoint.beacon requires --sync.downloader")
+		}
+		if b, err := hexutil.Decode(config.Sync.BeaconCheckpoint); err != nil || len(b) != common.HashLength {
+			return fmt.Errorf("invalid beacon checkpoint hash: %v", config.Sync.BeaconCheckpoint)
+		}
+	}
+
 	return nil
 }
 
@@ -304,9 +438,18 @@ func loadHarmonyConfig(file string) (harmonyConfig, error) {
 	if config.P2P.IP == "" {
 		config.P2P.IP = defaultConfig.P2P.IP
 	}
+	if config.P2P.ConnHighWater == 0 {
+		config.P2P.ConnLowWater = defaultConfig.P2P.ConnLowWater
+		config.P2P.ConnHighWater = defaultConfig.P2P.ConnHighWater
+		config.P2P.ConnGracePeriod = defaultConfig.P2P.ConnGracePeriod
+		config.P2P.ConnSilencePeriod = defaultConfig.P2P.ConnSilencePeriod
+	}
 	if config.Prometheus == nil {
 		config.Prometheus = defaultConfig.Prometheus
 	}
+	if config.DB != nil && config.DB.Backend == "" {
+		config.DB.Backend = defaultDBConfig.Backend
+	}
 	return config, nil
 }
 
@@ -149,9 +149,6 @@ func TestPersistConfig(t *testing.T) {
 				devnet := getDefaultDevnetConfigCopy()
 				cfg.Devnet = &devnet
 
-				revert := getDefaultRevertConfigCopy()
-				cfg.Revert = &revert
-
 				webHook := "web hook"
 				cfg.Legacy = &legacyConfig{
 					WebHookConfig:         &webHook,
@@ -0,0 +1,584 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/api/service/explorer"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state/pruner"
+	"github.com/harmony-one/harmony/core/state/snapshot"
+	"github.com/harmony-one/harmony/internal/cli"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/shardchain"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "maintain the chain databases of the node",
+	Long:  "maintain the chain databases of the node, offline. Stop the node before running the commands.",
+}
+
+var dbFreezeCmd = &cobra.Command{
+	Use:   "freeze [shard_id...]",
+	Short: "move the old blocks of the chain databases to the freezer",
+	Long: `move at once the blocks behind --db.freezer.threshold from the LevelDB of the shard chain
+databases in the data directory to their freezer, and compact the LevelDB. All the shard databases
+found in the data directory are migrated if no shard is given. Start the node with --db.freezer
+afterwards to keep moving the blocks in the background.`,
+	Run: runDBFreeze,
+}
+
+var dbPruneStateCmd = &cobra.Command{
+	Use:   "prune-state [shard_id...]",
+	Short: "delete the state no longer needed from the chain databases of a non-archival node",
+	Long: `delete the trie nodes and contract codes of the shard chain databases in the data directory
+which are not reachable from the state of the head block, or from the states of the last blocks of
+the last --prune.keep-epochs epochs. The reachable nodes are marked in a bloom filter of
+--prune.bloom-size megabytes before anything is deleted. All the shard databases found in the data
+directory are pruned if no shard is given. With --prune.dry-run, only report the space reclaimable.`,
+	Run: runDBPruneState,
+}
+
+var dbInspectCmd = &cobra.Command{
+	Use:   "inspect [shard_id...]",
+	Short: "show the number and size of the entries of the chain databases per kind of key",
+	Long: `iterate over the shard chain databases in the data directory, and show the number and
+size of the entries of every kind of key of the database schema, and of the freezer tables if any.
+All the shard databases found in the data directory are inspected if no shard is given.`,
+	Run: runDBInspect,
+}
+
+var dbCheckChainCmd = &cobra.Command{
+	Use:   "check-chain [shard_id...]",
+	Short: "check the consistency of the canonical blocks of the chain databases",
+	Long: `check the canonical blocks from --check.from to --check.to of the shard chain databases
+in the data directory: the canonical hash and number mappings, the header and parent hashes, the
+transactions root of the bodies and the receipts. All the shard databases found in the data
+directory are checked if no shard is given. Exit with 1 if any inconsistency is found.`,
+	Run: runDBCheckChain,
+}
+
+var dbGetCmd = &cobra.Command{
+	Use:   "get shard_id key",
+	Short: "read the raw value of a key of a chain database",
+	Long: `read the raw value of the key of the shard chain database in the data directory. The key
+is given in hex with the 0x prefix, or as a string otherwise. The values of the headers, shard
+states, validator snapshots and crosslinks are also shown decoded.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runDBGet,
+}
+
+var dbPutCmd = &cobra.Command{
+	Use:   "put shard_id key value",
+	Short: "write the raw value of a key of a chain database",
+	Long: `write the raw value of the key of the shard chain database in the data directory. The key
+is given in hex with the 0x prefix, or as a string otherwise, and the value in hex with the 0x prefix.`,
+	Args: cobra.ExactArgs(3),
+	Run:  runDBPut,
+}
+
+var dbRepairHeadCmd = &cobra.Command{
+	Use:   "repair-head [shard_id...]",
+	Short: "reset the head of the chain databases to the highest block with complete state",
+	Long: `reset the head of the shard chain databases in the data directory to the highest canonical
+block at or below --repair.to whose header, body and complete state are in the database, and delete
+the canonical hashes above it. The state of a block is complete when all the nodes of its state trie,
+of the storage tries and the codes of the contracts are found, which walks the whole state of the
+candidate blocks and takes a while on a large state. The blocks above are synced again when the node
+restarts. All the shard databases found in the data directory are repaired if no shard is given.`,
+	Run: runDBRepairHead,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate [shard_id...]",
+	Short: "copy the chain and explorer databases to another backend",
+	Long: `copy the shard chain databases in the data directory with their freezer, and the explorer
+storage of the node, from the backend of --db.backend into new databases of the backend of
+--migrate.to. All the shard databases found in the data directory are migrated if no shard is
+given. The source databases are left untouched. Start the node with --db.backend set to the new
+backend afterwards.`,
+	Run: runDBMigrate,
+}
+
+var dbExportStateCmd = &cobra.Command{
+	Use:   "export-state shard_id block_number output",
+	Short: "export the state snapshot of a checkpoint block of a chain database",
+	Long: `export the trie nodes and contract codes of the state of the canonical block of the shard
+chain database in the data directory to the output file, gzipped if it ends with .gz. The block must
+be the last block of an epoch with its state in the database. The snapshot of a beacon block of the
+staking era also carries its off-chain staking data. A node can then sync the shard from this block
+with --sync.checkpoint set to the block hash printed and --sync.checkpoint.state set to the file, or
+its beacon chain with --sync.checkpoint.beacon and --sync.checkpoint.beacon.state on the nodes of
+other shards.`,
+	Args: cobra.ExactArgs(3),
+	Run:  runDBExportState,
+}
+
+var (
+	migrateToFlag = cli.StringFlag{
+		Name:     "migrate.to",
+		Usage:    "backend the databases are copied to (leveldb, badgerdb)",
+		DefValue: shardchain.BadgerDBBackend,
+	}
+	checkFromFlag = cli.IntFlag{
+		Name:     "check.from",
+		Usage:    "number of the first block checked",
+		DefValue: 0,
+	}
+	checkToFlag = cli.IntFlag{
+		Name:     "check.to",
+		Usage:    "number of the last block checked, the head block if -1",
+		DefValue: -1,
+	}
+	repairToFlag = cli.IntFlag{
+		Name:     "repair.to",
+		Usage:    "number of the highest block the head can be reset to, the head block if -1",
+		DefValue: -1,
+	}
+	pruneKeepEpochsFlag = cli.IntFlag{
+		Name:     "prune.keep-epochs",
+		Usage:    "number of the last epochs whose last block state is kept",
+		DefValue: 2,
+	}
+	pruneBloomSizeFlag = cli.IntFlag{
+		Name:     "prune.bloom-size",
+		Usage:    "size in megabytes of the bloom filter of the state nodes kept",
+		DefValue: 1024,
+	}
+	pruneDryRunFlag = cli.BoolFlag{
+		Name:     "prune.dry-run",
+		Usage:    "only report the number and size of the state entries which would be deleted",
+		DefValue: false,
+	}
+)
+
+func registerDBFlags() error {
+	dbCmd.AddCommand(dbFreezeCmd)
+	dbCmd.AddCommand(dbPruneStateCmd)
+	dbCmd.AddCommand(dbInspectCmd)
+	dbCmd.AddCommand(dbCheckChainCmd)
+	dbCmd.AddCommand(dbGetCmd)
+	dbCmd.AddCommand(dbPutCmd)
+	dbCmd.AddCommand(dbRepairHeadCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbExportStateCmd)
+	for _, cmd := range []*cobra.Command{dbFreezeCmd, dbInspectCmd, dbGetCmd, dbPutCmd, dbExportStateCmd} {
+		if err := cli.RegisterFlags(cmd, getRootFlags()); err != nil {
+			return err
+		}
+	}
+	if err := cli.RegisterFlags(dbCheckChainCmd, append(getRootFlags(), checkFromFlag, checkToFlag)); err != nil {
+		return err
+	}
+	if err := cli.RegisterFlags(dbRepairHeadCmd, append(getRootFlags(), repairToFlag)); err != nil {
+		return err
+	}
+	if err := cli.RegisterFlags(dbMigrateCmd, append(getRootFlags(), migrateToFlag)); err != nil {
+		return err
+	}
+	return cli.RegisterFlags(dbPruneStateCmd, append(getRootFlags(),
+		pruneKeepEpochsFlag, pruneBloomSizeFlag, pruneDryRunFlag))
+}
+
+// newChainDBFactory returns the factory of the chain databases in the directory
+func newChainDBFactory(hc harmonyConfig, dir string) shardchain.DiskDBFactory {
+	backend, threshold := shardchain.LDBBackend, uint64(0)
+	if hc.DB != nil {
+		backend = hc.DB.Backend
+		if hc.DB.Freezer {
+			threshold = uint64(hc.DB.FreezerThreshold)
+		}
+	}
+	return newDiskDBFactory(backend, dir, threshold)
+}
+
+func newDiskDBFactory(backend, dir string, threshold uint64) shardchain.DiskDBFactory {
+	factory, err := shardchain.NewDiskDBFactory(backend, dir, threshold)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(128)
+	}
+	return factory
+}
+
+func runDBFreeze(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+
+	// the freezer is enabled by the command regardless of the config
+	factory := newDiskDBFactory(hc.DB.Backend, hc.General.DataDir, uint64(hc.DB.FreezerThreshold))
+	for _, shardID := range shardIDs {
+		db := openChainDB(factory, shardID)
+		moved, err := rawdb.MigrateToFreezer(db)
+		frozen, _ := db.Ancients()
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to freeze blocks of shard %v: %v\n", shardID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("shard %v: moved %d blocks to the freezer, %d blocks frozen\n", shardID, moved, frozen)
+	}
+}
+
+func runDBPruneState(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+	config := pruner.Config{
+		KeepEpochs: cli.GetIntFlagValue(cmd, pruneKeepEpochsFlag),
+		BloomSize:  uint64(cli.GetIntFlagValue(cmd, pruneBloomSizeFlag)),
+		DryRun:     cli.GetBoolFlagValue(cmd, pruneDryRunFlag),
+	}
+	if config.KeepEpochs < 0 || config.BloomSize == 0 {
+		fmt.Fprintln(os.Stderr, "invalid --prune.keep-epochs or --prune.bloom-size")
+		os.Exit(128)
+	}
+
+	factory := newChainDBFactory(hc, hc.General.DataDir)
+	for _, shardID := range shardIDs {
+		if hc.General.IsArchival || (shardID == shard.BeaconChainShardID && hc.General.IsBeaconArchival) {
+			fmt.Printf("shard %v: skipped, the archival node keeps all the states\n", shardID)
+			continue
+		}
+		db := openChainDB(factory, shardID)
+		result, err := pruner.Prune(db, config)
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to prune state of shard %v: %v\n", shardID, err)
+			os.Exit(1)
+		}
+		verb := "deleted"
+		if config.DryRun {
+			verb = "would delete"
+		}
+		fmt.Printf("shard %v: kept the states of blocks %v (%d nodes), %s %d entries of %v\n",
+			shardID, result.Blocks, result.Marked, verb, result.Deleted, result.Size)
+	}
+}
+
+func runDBInspect(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+	factory := newChainDBFactory(hc, hc.General.DataDir)
+	for _, shardID := range shardIDs {
+		db := openChainDB(factory, shardID)
+		stats, err := rawdb.InspectDatabase(db)
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to inspect database of shard %v: %v\n", shardID, err)
+			os.Exit(1)
+		}
+		var (
+			count uint64
+			size  common.StorageSize
+		)
+		fmt.Printf("shard %v:\n", shardID)
+		for _, stat := range stats {
+			fmt.Printf("  %-28s %12d %12v\n", stat.Name, stat.Count, stat.Size)
+			count += stat.Count
+			size += stat.Size
+		}
+		fmt.Printf("  %-28s %12d %12v\n", "total", count, size)
+	}
+}
+
+func runDBCheckChain(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+	from, to := cli.GetIntFlagValue(cmd, checkFromFlag), cli.GetIntFlagValue(cmd, checkToFlag)
+	if from < 0 || to < -1 {
+		fmt.Fprintln(os.Stderr, "invalid --check.from or --check.to")
+		os.Exit(128)
+	}
+
+	factory := newChainDBFactory(hc, hc.General.DataDir)
+	var failed bool
+	for _, shardID := range shardIDs {
+		db := openChainDB(factory, shardID)
+		last := uint64(to)
+		if to == -1 {
+			last = readHeadNumber(db, shardID)
+		}
+		issues := rawdb.CheckChain(db, uint64(from), last)
+		db.Close()
+		for _, issue := range issues {
+			fmt.Printf("shard %v: %v\n", shardID, issue)
+		}
+		fmt.Printf("shard %v: checked blocks %d to %d, %d issues found\n", shardID, from, last, len(issues))
+		failed = failed || len(issues) != 0
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func runDBGet(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args[:1])
+	key := parseDBKey(args[1])
+
+	db := openChainDB(newChainDBFactory(hc, hc.General.DataDir), shardIDs[0])
+	defer db.Close()
+	value, err := db.Get(key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read key %x: %v\n", key, err)
+		os.Exit(1)
+	}
+	fmt.Printf("kind: %v\nvalue: %v\n", rawdb.KeyKind(key), hexutil.Encode(value))
+	decoded, err := rawdb.DecodeEntry(key, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot decode value: %v\n", err)
+		os.Exit(1)
+	}
+	if decoded != nil {
+		b, err := json.MarshalIndent(decoded, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot encode decoded value: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("decoded: %s\n", b)
+	}
+}
+
+func runDBPut(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args[:1])
+	key := parseDBKey(args[1])
+	value, err := hexutil.Decode(args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid value %v: %v\n", args[2], err)
+		os.Exit(128)
+	}
+	if _, err := rawdb.DecodeEntry(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid value of %v: %v\n", rawdb.KeyKind(key), err)
+		os.Exit(128)
+	}
+
+	db := openChainDB(newChainDBFactory(hc, hc.General.DataDir), shardIDs[0])
+	defer db.Close()
+	if err := db.Put(key, value); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot write key %x: %v\n", key, err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %d bytes of %v\n", len(value), rawdb.KeyKind(key))
+}
+
+func runDBRepairHead(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+	to := cli.GetIntFlagValue(cmd, repairToFlag)
+	if to < -1 {
+		fmt.Fprintln(os.Stderr, "invalid --repair.to")
+		os.Exit(128)
+	}
+
+	factory := newChainDBFactory(hc, hc.General.DataDir)
+	for _, shardID := range shardIDs {
+		db := openChainDB(factory, shardID)
+		target := uint64(to)
+		if to == -1 {
+			target = readHeadNumber(db, shardID)
+		}
+		head, err := rawdb.RepairHead(db, target)
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to repair head of shard %v: %v\n", shardID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("shard %v: head reset to block %v (%v)\n", shardID, head.Number(), head.Hash().Hex())
+	}
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args)
+	to := cli.GetStringFlagValue(cmd, migrateToFlag)
+	if to == hc.DB.Backend {
+		fmt.Fprintf(os.Stderr, "the databases are already in %v\n", to)
+		os.Exit(128)
+	}
+
+	src := newDiskDBFactory(hc.DB.Backend, hc.General.DataDir, 0)
+	dst := newDiskDBFactory(to, hc.General.DataDir, 0)
+	for _, shardID := range shardIDs {
+		copied, err := shardchain.MigrateDB(src, dst, shardID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to migrate database of shard %v: %v\n", shardID, err)
+			os.Exit(1)
+		}
+		fmt.Printf("shard %v: copied %d entries to %v\n", shardID, copied, dst.ShardDBDir(shardID))
+	}
+
+	port := strconv.Itoa(hc.P2P.Port)
+	srcDir := explorer.StorageDir(hc.General.DataDir, hc.DB.Backend, hc.P2P.IP, port)
+	if _, err := os.Stat(srcDir); err != nil {
+		return
+	}
+	dstDir := explorer.StorageDir(hc.General.DataDir, to, hc.P2P.IP, port)
+	if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "explorer storage %v already exists\n", dstDir)
+		os.Exit(1)
+	}
+	copied, err := shardchain.MigrateKeyValueStore(hc.DB.Backend, srcDir, to, dstDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to migrate explorer storage: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("explorer: copied %d entries to %v\n", copied, dstDir)
+}
+
+func runDBExportState(cmd *cobra.Command, args []string) {
+	hc, shardIDs := getDBCmdConfig(cmd, args[:1])
+	number, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid block number %v: %v\n", args[1], err)
+		os.Exit(128)
+	}
+	output := args[2]
+
+	db := openChainDB(newChainDBFactory(hc, hc.General.DataDir), shardIDs[0])
+	defer db.Close()
+	hash := rawdb.ReadCanonicalHash(db, number)
+	header := rawdb.ReadHeader(db, hash, number)
+	if header == nil {
+		fmt.Fprintf(os.Stderr, "canonical block %v not found\n", number)
+		os.Exit(1)
+	}
+	if !header.IsLastBlockInEpoch() {
+		fmt.Fprintf(os.Stderr, "block %v is not the last block of an epoch\n", number)
+		os.Exit(1)
+	}
+	chainConfig := core.NewGenesisSpec(nodeconfig.NetworkType(hc.Network.NetworkType), shardIDs[0]).Config
+	offChain, err := core.ReadCheckpointOffChain(db, chainConfig, header)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read off-chain data of block %v: %v\n", number, err)
+		os.Exit(1)
+	}
+	var offChainData []byte
+	if offChain != nil {
+		if offChainData, err = rlp.EncodeToBytes(offChain); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode off-chain data of block %v: %v\n", number, err)
+			os.Exit(1)
+		}
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot create %v: %v\n", output, err)
+		os.Exit(1)
+	}
+	var w io.WriteCloser = f
+	if strings.HasSuffix(output, ".gz") {
+		w = gzip.NewWriter(f)
+	}
+	exported, err := snapshot.Export(db, header.Root(), offChainData, w)
+	if err == nil {
+		err = w.Close()
+	}
+	if w != f {
+		f.Close()
+	}
+	if err != nil {
+		os.Remove(output)
+		fmt.Fprintf(os.Stderr, "failed to export state of block %v: %v\n", number, err)
+		os.Exit(1)
+	}
+	fmt.Printf("exported %d state entries of block %v to %v\n", exported, number, output)
+	fmt.Printf("checkpoint: %v\n", hash.Hex())
+}
+
+func openChainDB(factory shardchain.DBFactory, shardID uint32) ethdb.Database {
+	db, err := factory.NewChainDB(shardID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot open database of shard %v: %v\n", shardID, err)
+		os.Exit(1)
+	}
+	return db
+}
+
+// readHeadNumber returns the number of the head block of the database, or of
+// the head header if the head block is unknown
+func readHeadNumber(db ethdb.Database, shardID uint32) uint64 {
+	for _, hash := range []common.Hash{rawdb.ReadHeadBlockHash(db), rawdb.ReadHeadHeaderHash(db)} {
+		if number := rawdb.ReadHeaderNumber(db, hash); number != nil {
+			return *number
+		}
+	}
+	fmt.Fprintf(os.Stderr, "head block of shard %v not found, give the block number\n", shardID)
+	os.Exit(1)
+	return 0
+}
+
+// parseDBKey returns the key given in hex with the 0x prefix, or as a string
+func parseDBKey(arg string) []byte {
+	if !strings.HasPrefix(arg, "0x") {
+		return []byte(arg)
+	}
+	key, err := hexutil.Decode(arg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid key %v: %v\n", arg, err)
+		os.Exit(128)
+	}
+	return key
+}
+
+// getDBCmdConfig returns the config of the db commands, and the shards given
+// or found in the data directory
+func getDBCmdConfig(cmd *cobra.Command, args []string) (harmonyConfig, []uint32) {
+	hc, err := getHarmonyConfig(cmd)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		cmd.Help()
+		os.Exit(128)
+	}
+	shardIDs, err := parseShardIDs(args)
+	if err == nil && len(shardIDs) == 0 {
+		shardIDs, err = findShardDBs(hc.General.DataDir, newChainDBFactory(hc, hc.General.DataDir).DirPrefix())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(128)
+	}
+	return hc, shardIDs
+}
+
+func parseShardIDs(args []string) ([]uint32, error) {
+	var shardIDs []uint32
+	for _, arg := range args {
+		shardID, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard id %v", arg)
+		}
+		shardIDs = append(shardIDs, uint32(shardID))
+	}
+	return shardIDs, nil
+}
+
+// findShardDBs returns the shards of the chain databases in the data directory,
+// whose directories have the prefix
+func findShardDBs(dataDir, prefix string) ([]uint32, error) {
+	entries, err := ioutil.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	var shardIDs []uint32
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		shardID, err := strconv.ParseUint(strings.TrimPrefix(entry.Name(), prefix), 10, 32)
+		if err != nil {
+			continue
+		}
+		shardIDs = append(shardIDs, uint32(shardID))
+	}
+	if len(shardIDs) == 0 {
+		return nil, fmt.Errorf("no chain database found in %v", dataDir)
+	}
+	return shardIDs, nil
+}
@@ -1,6 +1,12 @@
 package main
 
-import nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+import (
+	"time"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/shardchain"
+	"github.com/harmony-one/harmony/p2p"
+)
 
 const tomlConfigVersion = "1.0.4"
 
@@ -24,6 +30,11 @@ var defaultConfig = harmonyConfig{
 		Port:    nodeconfig.DefaultP2PPort,
 		IP:      nodeconfig.DefaultPublicListenIP,
 		KeyFile: "./.hmykey",
+
+		ConnLowWater:      p2p.DefaultConnLowWater,
+		ConnHighWater:     p2p.DefaultConnHighWater,
+		ConnGracePeriod:   int(p2p.DefaultConnGracePeriod / time.Second),
+		ConnSilencePeriod: int(p2p.DefaultConnSilencePeriod / time.Second),
 	},
 	HTTP: httpConfig{
 		Enabled:        true,
@@ -38,7 +49,8 @@ var defaultConfig = harmonyConfig{
 		Port:    nodeconfig.DefaultWSPort,
 	},
 	RPCOpt: rpcOptConfig{
-		DebugEnabled: false,
+		DebugEnabled:      false,
+		TraceIndexEnabled: false,
 	},
 	BLSKeys: blsConfig{
 		KeyDir:   "./.hmy/blskeys",
@@ -78,12 +90,6 @@ var defaultDevnetConfig = devnetConfig{
 	HmyNodeSize: 10,
 }
 
-var defaultRevertConfig = revertConfig{
-	RevertBeacon: false,
-	RevertBefore: 0,
-	RevertTo:     0,
-}
-
 var defaultLogContext = logContext{
 	IP:   "127.0.0.1",
 	Port: 9000,
@@ -102,6 +108,27 @@ var defaultPrometheusConfig = prometheusConfig{
 	Gateway:    "https://gateway.harmony.one",
 }
 
+var defaultHealthConfig = healthConfig{
+	RPC:           false,
+	Metrics:       true,
+	MinPeers:      3,
+	MaxBlockAge:   60,
+	MaxRPCBacklog: 1000,
+}
+
+var defaultDBConfig = dbConfig{
+	Backend:          shardchain.LDBBackend,
+	Freezer:          false,
+	FreezerThreshold: 2048,
+}
+
+var defaultGPOConfig = gpoConfig{
+	Blocks:      20,
+	Percentile:  60,
+	IgnorePrice: 2,
+	MaxPrice:    1000e9,
+}
+
 var (
 	defaultMainnetSyncConfig = syncConfig{
 		Downloader:     false,
@@ -170,11 +197,6 @@ func getDefaultDevnetConfigCopy() devnetConfig {
 	return config
 }
 
-func getDefaultRevertConfigCopy() revertConfig {
-	config := defaultRevertConfig
-	return config
-}
-
 func getDefaultLogContextCopy() logContext {
 	config := defaultLogContext
 	return config
@@ -190,6 +212,21 @@ func getDefaultPrometheusConfigCopy() prometheusConfig {
 	return config
 }
 
+func getDefaultGPOConfigCopy() gpoConfig {
+	config := defaultGPOConfig
+	return config
+}
+
+func getDefaultDBConfigCopy() dbConfig {
+	config := defaultDBConfig
+	return config
+}
+
+func getDefaultHealthConfigCopy() healthConfig {
+	config := defaultHealthConfig
+	return config
+}
+
 const (
 	nodeTypeValidator = "validator"
 	nodeTypeExplorer  = "explorer"
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/harmony-one/harmony/internal/cli"
@@ -43,6 +44,17 @@ var (
 		p2pIPFlag,
 		p2pKeyFileFlag,
 		p2pDHTDataStoreFlag,
+		p2pListenAddrsFlag,
+		p2pAnnounceAddrsFlag,
+		p2pStaticPeersFlag,
+		p2pTrustedPeersFlag,
+		p2pNoDiscoveryFlag,
+		p2pDNSTreesFlag,
+		p2pPeersFileFlag,
+		p2pConnLowWaterFlag,
+		p2pConnHighWaterFlag,
+		p2pConnGracePeriodFlag,
+		p2pConnSilencePeriodFlag,
 
 		legacyKeyFileFlag,
 	}
@@ -63,6 +75,12 @@ var (
 
 	rpcOptFlags = []cli.Flag{
 		rpcDebugEnabledFlag,
+		rpcTraceIndexEnabledFlag,
+		rpcBatchLimitFlag,
+		rpcResponseLimitFlag,
+		rpcRateLimitFlag,
+		rpcRateBurstFlag,
+		rpcAPIKeyRequiredFlag,
 	}
 
 	blsFlags = append(newBLSFlags, legacyBLSFlags...)
@@ -121,6 +139,9 @@ var (
 		logContextIPFlag,
 		logContextPortFlag,
 		logVerbosityFlag,
+		logMsgRecordFileFlag,
+		logMsgRecordRotateSizeFlag,
+		logMsgRecordMaxBackupsFlag,
 		legacyVerbosityFlag,
 
 		legacyLogFolderFlag,
@@ -145,20 +166,6 @@ var (
 		legacyDevnetHmyNodeSizeFlag,
 	}
 
-	revertFlags = append(newRevertFlags, legacyRevertFlags...)
-
-	newRevertFlags = []cli.Flag{
-		revertBeaconFlag,
-		revertToFlag,
-		revertBeforeFlag,
-	}
-
-	legacyRevertFlags = []cli.Flag{
-		legacyRevertBeaconFlag,
-		legacyRevertBeforeFlag,
-		legacyRevertToFlag,
-	}
-
 	// legacyMiscFlags are legacy flags that cannot be categorized to a single category.
 	legacyMiscFlags = []cli.Flag{
 		legacyPortFlag,
@@ -176,6 +183,27 @@ var (
 		prometheusEnablePushFlag,
 	}
 
+	gpoFlags = []cli.Flag{
+		gpoBlocksFlag,
+		gpoPercentileFlag,
+		gpoIgnorePriceFlag,
+		gpoMaxPriceFlag,
+	}
+
+	healthFlags = []cli.Flag{
+		healthRPCFlag,
+		healthMetricsFlag,
+		healthMinPeersFlag,
+		healthMaxBlockAgeFlag,
+		healthMaxRPCBacklogFlag,
+	}
+
+	dbFlags = []cli.Flag{
+		dbBackendFlag,
+		dbFreezerFlag,
+		dbFreezerThresholdFlag,
+	}
+
 	syncFlags = []cli.Flag{
 		syncDownloaderFlag,
 		syncLegacyClientFlag,
@@ -187,6 +215,19 @@ var (
 		syncDiscHardLowFlag,
 		syncDiscHighFlag,
 		syncDiscBatchFlag,
+		syncRateLimitFlag,
+		syncStreamRateLimitFlag,
+		syncClientRateLimitFlag,
+		syncResponseBytesLimitFlag,
+		syncLegacyTLSFlag,
+		syncLegacyAllowlistFlag,
+		syncLegacyMaxRequestSizeFlag,
+		syncLegacyMaxConcurrencyFlag,
+		syncLightServerFlag,
+		syncCheckpointFlag,
+		syncCheckpointStateFlag,
+		syncBeaconCheckpointFlag,
+		syncBeaconCheckpointStateFlag,
 	}
 )
 
@@ -277,9 +318,11 @@ func getRootFlags() []cli.Flag {
 	flags = append(flags, logFlags...)
 	flags = append(flags, sysFlags...)
 	flags = append(flags, devnetFlags...)
-	flags = append(flags, revertFlags...)
 	flags = append(flags, legacyMiscFlags...)
 	flags = append(flags, prometheusFlags...)
+	flags = append(flags, gpoFlags...)
+	flags = append(flags, healthFlags...)
+	flags = append(flags, dbFlags...)
 	flags = append(flags, syncFlags...)
 
 	return flags
@@ -432,6 +475,56 @@ var (
 		DefValue: "",
 		Hidden:   true,
 	}
+	p2pListenAddrsFlag = cli.StringSliceFlag{
+		Name:  "p2p.listen-addrs",
+		Usage: "a list of multiaddress to listen for p2p protocols, overrides p2p.ip and p2p.port (delimited by ,), QUIC multiaddrs need a binary built with the quic tag, as by the release build",
+	}
+	p2pAnnounceAddrsFlag = cli.StringSliceFlag{
+		Name:  "p2p.announce-addrs",
+		Usage: "a list of multiaddress announced to the peers in place of the listen ones (delimited by ,)",
+	}
+	p2pStaticPeersFlag = cli.StringSliceFlag{
+		Name:  "p2p.static-peers",
+		Usage: "a list of peer multiaddress to keep connected to (delimited by ,)",
+	}
+	p2pTrustedPeersFlag = cli.StringSliceFlag{
+		Name:  "p2p.trusted-peers",
+		Usage: "a list of peer multiaddress to keep connected to and never block (delimited by ,)",
+	}
+	p2pNoDiscoveryFlag = cli.BoolFlag{
+		Name:     "p2p.no-discovery",
+		Usage:    "disable the DHT peer discovery, only connect to the static and trusted peers, and the DNS trees and peers file ones",
+		DefValue: defaultConfig.P2P.NoDiscovery,
+	}
+	p2pDNSTreesFlag = cli.StringSliceFlag{
+		Name:  "p2p.dns-trees",
+		Usage: "a list of enrtree:// urls of the signed DNS trees to find peers from (delimited by ,)",
+	}
+	p2pPeersFileFlag = cli.StringFlag{
+		Name:     "p2p.peers-file",
+		Usage:    "a JSON file of peer multiaddress to find peers from, reloaded on change",
+		DefValue: defaultConfig.P2P.PeersFile,
+	}
+	p2pConnLowWaterFlag = cli.IntFlag{
+		Name:     "p2p.connmgr.low",
+		Usage:    "number of connections the connection manager trims down to",
+		DefValue: defaultConfig.P2P.ConnLowWater,
+	}
+	p2pConnHighWaterFlag = cli.IntFlag{
+		Name:     "p2p.connmgr.high",
+		Usage:    "number of connections above which the connection manager trims them",
+		DefValue: defaultConfig.P2P.ConnHighWater,
+	}
+	p2pConnGracePeriodFlag = cli.IntFlag{
+		Name:     "p2p.connmgr.grace",
+		Usage:    "seconds the new connections are not trimmed for",
+		DefValue: defaultConfig.P2P.ConnGracePeriod,
+	}
+	p2pConnSilencePeriodFlag = cli.IntFlag{
+		Name:     "p2p.connmgr.silence",
+		Usage:    "minimum seconds between two connection trims",
+		DefValue: defaultConfig.P2P.ConnSilencePeriod,
+	}
 	legacyKeyFileFlag = cli.StringFlag{
 		Name:       "key",
 		Usage:      "the p2p key file of the harmony node",
@@ -461,6 +554,41 @@ func applyP2PFlags(cmd *cobra.Command, config *harmonyConfig) {
 		ds := cli.GetStringFlagValue(cmd, p2pDHTDataStoreFlag)
 		config.P2P.DHTDataStore = &ds
 	}
+
+	if cli.IsFlagChanged(cmd, p2pListenAddrsFlag) {
+		config.P2P.ListenAddrs = cli.GetStringSliceFlagValue(cmd, p2pListenAddrsFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pAnnounceAddrsFlag) {
+		config.P2P.AnnounceAddrs = cli.GetStringSliceFlagValue(cmd, p2pAnnounceAddrsFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pStaticPeersFlag) {
+		config.P2P.StaticPeers = cli.GetStringSliceFlagValue(cmd, p2pStaticPeersFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pTrustedPeersFlag) {
+		config.P2P.TrustedPeers = cli.GetStringSliceFlagValue(cmd, p2pTrustedPeersFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pNoDiscoveryFlag) {
+		config.P2P.NoDiscovery = cli.GetBoolFlagValue(cmd, p2pNoDiscoveryFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pDNSTreesFlag) {
+		config.P2P.DNSTrees = cli.GetStringSliceFlagValue(cmd, p2pDNSTreesFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pPeersFileFlag) {
+		config.P2P.PeersFile = cli.GetStringFlagValue(cmd, p2pPeersFileFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, p2pConnLowWaterFlag) {
+		config.P2P.ConnLowWater = cli.GetIntFlagValue(cmd, p2pConnLowWaterFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pConnHighWaterFlag) {
+		config.P2P.ConnHighWater = cli.GetIntFlagValue(cmd, p2pConnHighWaterFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pConnGracePeriodFlag) {
+		config.P2P.ConnGracePeriod = cli.GetIntFlagValue(cmd, p2pConnGracePeriodFlag)
+	}
+	if cli.IsFlagChanged(cmd, p2pConnSilencePeriodFlag) {
+		config.P2P.ConnSilencePeriod = cli.GetIntFlagValue(cmd, p2pConnSilencePeriodFlag)
+	}
 }
 
 // http flags
@@ -563,12 +691,60 @@ var (
 		DefValue: defaultConfig.RPCOpt.DebugEnabled,
 		Hidden:   true,
 	}
+	rpcTraceIndexEnabledFlag = cli.BoolFlag{
+		Name:     "rpc.traceindex",
+		Usage:    "index the flat traces of every block for the trace apis (archival node only)",
+		DefValue: defaultConfig.RPCOpt.TraceIndexEnabled,
+	}
+	rpcBatchLimitFlag = cli.IntFlag{
+		Name:     "rpc.batchlimit",
+		Usage:    "maximum number of requests in a batch (0 for no limit)",
+		DefValue: defaultConfig.RPCOpt.BatchLimit,
+	}
+	rpcResponseLimitFlag = cli.IntFlag{
+		Name:     "rpc.responselimit",
+		Usage:    "maximum size of a http response in bytes (0 for no limit)",
+		DefValue: defaultConfig.RPCOpt.ResponseLimit,
+	}
+	rpcRateLimitFlag = cli.IntFlag{
+		Name:     "rpc.ratelimit",
+		Usage:    "requests per second of each client ip (0 for no limit)",
+		DefValue: defaultConfig.RPCOpt.RateLimit,
+	}
+	rpcRateBurstFlag = cli.IntFlag{
+		Name:     "rpc.rateburst",
+		Usage:    "requests above the rate limit accepted in a burst",
+		DefValue: defaultConfig.RPCOpt.RateBurst,
+	}
+	rpcAPIKeyRequiredFlag = cli.BoolFlag{
+		Name:     "rpc.apikey.required",
+		Usage:    "reject the requests without an api key of the config file",
+		DefValue: defaultConfig.RPCOpt.APIKeyRequired,
+	}
 )
 
 func applyRPCOptFlags(cmd *cobra.Command, config *harmonyConfig) {
 	if cli.IsFlagChanged(cmd, rpcDebugEnabledFlag) {
 		config.RPCOpt.DebugEnabled = cli.GetBoolFlagValue(cmd, rpcDebugEnabledFlag)
 	}
+	if cli.IsFlagChanged(cmd, rpcTraceIndexEnabledFlag) {
+		config.RPCOpt.TraceIndexEnabled = cli.GetBoolFlagValue(cmd, rpcTraceIndexEnabledFlag)
+	}
+	if cli.IsFlagChanged(cmd, rpcBatchLimitFlag) {
+		config.RPCOpt.BatchLimit = cli.GetIntFlagValue(cmd, rpcBatchLimitFlag)
+	}
+	if cli.IsFlagChanged(cmd, rpcResponseLimitFlag) {
+		config.RPCOpt.ResponseLimit = cli.GetIntFlagValue(cmd, rpcResponseLimitFlag)
+	}
+	if cli.IsFlagChanged(cmd, rpcRateLimitFlag) {
+		config.RPCOpt.RateLimit = cli.GetIntFlagValue(cmd, rpcRateLimitFlag)
+	}
+	if cli.IsFlagChanged(cmd, rpcRateBurstFlag) {
+		config.RPCOpt.RateBurst = cli.GetIntFlagValue(cmd, rpcRateBurstFlag)
+	}
+	if cli.IsFlagChanged(cmd, rpcAPIKeyRequiredFlag) {
+		config.RPCOpt.APIKeyRequired = cli.GetBoolFlagValue(cmd, rpcAPIKeyRequiredFlag)
+	}
 }
 
 // bls flags
@@ -907,6 +1083,21 @@ var (
 		Usage:     "logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
 		DefValue:  defaultConfig.Log.Verbosity,
 	}
+	logMsgRecordFileFlag = cli.StringFlag{
+		Name:     "log.msgrecord.file",
+		Usage:    "file to record the received consensus and node messages to, for offline replay",
+		DefValue: defaultConfig.Log.MsgRecordFile,
+	}
+	logMsgRecordRotateSizeFlag = cli.IntFlag{
+		Name:     "log.msgrecord.max-size",
+		Usage:    "message record rotation size in megabytes",
+		DefValue: defaultConfig.Log.MsgRecordRotateSize,
+	}
+	logMsgRecordMaxBackupsFlag = cli.IntFlag{
+		Name:     "log.msgrecord.max-backups",
+		Usage:    "number of rotated message record files kept, all of them if 0",
+		DefValue: defaultConfig.Log.MsgRecordMaxBackups,
+	}
 	// TODO: remove context (this shall not be in the log)
 	logContextIPFlag = cli.StringFlag{
 		Name:     "log.ctx.ip",
@@ -963,6 +1154,16 @@ func applyLogFlags(cmd *cobra.Command, config *harmonyConfig) {
 		config.Log.Verbosity = cli.GetIntFlagValue(cmd, legacyVerbosityFlag)
 	}
 
+	if cli.IsFlagChanged(cmd, logMsgRecordFileFlag) {
+		config.Log.MsgRecordFile = cli.GetStringFlagValue(cmd, logMsgRecordFileFlag)
+	}
+	if cli.IsFlagChanged(cmd, logMsgRecordRotateSizeFlag) {
+		config.Log.MsgRecordRotateSize = cli.GetIntFlagValue(cmd, logMsgRecordRotateSizeFlag)
+	}
+	if cli.IsFlagChanged(cmd, logMsgRecordMaxBackupsFlag) {
+		config.Log.MsgRecordMaxBackups = cli.GetIntFlagValue(cmd, logMsgRecordMaxBackupsFlag)
+	}
+
 	if cli.HasFlagsChanged(cmd, []cli.Flag{logContextIPFlag, logContextPortFlag}) {
 		ctx := getDefaultLogContextCopy()
 		config.Log.Context = &ctx
@@ -1071,76 +1272,6 @@ func applyDevnetFlags(cmd *cobra.Command, config *harmonyConfig) {
 	}
 }
 
-var (
-	revertBeaconFlag = cli.BoolFlag{
-		Name:     "revert.beacon",
-		Usage:    "revert the beacon chain",
-		DefValue: defaultRevertConfig.RevertBeacon,
-		Hidden:   true,
-	}
-	revertToFlag = cli.IntFlag{
-		Name:     "revert.to",
-		Usage:    "rollback all blocks until and including block number revert.to",
-		DefValue: defaultRevertConfig.RevertTo,
-		Hidden:   true,
-	}
-	revertBeforeFlag = cli.IntFlag{
-		Name:     "revert.do-before",
-		Usage:    "if the current block is less than revert.do-before, revert all blocks until (including) revert_to block",
-		DefValue: defaultRevertConfig.RevertBefore,
-		Hidden:   true,
-	}
-	legacyRevertBeaconFlag = cli.BoolFlag{
-		Name:       "revert_beacon",
-		Usage:      "whether to revert beacon chain or the chain this node is assigned to",
-		DefValue:   defaultRevertConfig.RevertBeacon,
-		Deprecated: "use --revert.beacon",
-	}
-	legacyRevertBeforeFlag = cli.IntFlag{
-		Name:       "do_revert_before",
-		Usage:      "If the current block is less than do_revert_before, revert all blocks until (including) revert_to block",
-		DefValue:   defaultRevertConfig.RevertBefore,
-		Deprecated: "use --revert.do-before",
-	}
-	legacyRevertToFlag = cli.IntFlag{
-		Name:       "revert_to",
-		Usage:      "The revert will rollback all blocks until and including block number revert_to",
-		DefValue:   defaultRevertConfig.RevertTo,
-		Deprecated: "use --revert.to",
-	}
-)
-
-func applyRevertFlags(cmd *cobra.Command, config *harmonyConfig) {
-	if cli.HasFlagsChanged(cmd, revertFlags) {
-		cfg := getDefaultRevertConfigCopy()
-		config.Revert = &cfg
-	}
-
-	if cli.HasFlagsChanged(cmd, newRevertFlags) {
-		if cli.IsFlagChanged(cmd, revertBeaconFlag) {
-			config.Revert.RevertBeacon = cli.GetBoolFlagValue(cmd, revertBeaconFlag)
-		}
-		if cli.IsFlagChanged(cmd, revertBeforeFlag) {
-			config.Revert.RevertBefore = cli.GetIntFlagValue(cmd, revertBeforeFlag)
-		}
-		if cli.IsFlagChanged(cmd, revertToFlag) {
-			config.Revert.RevertTo = cli.GetIntFlagValue(cmd, revertToFlag)
-		}
-	}
-
-	if cli.HasFlagsChanged(cmd, legacyRevertFlags) {
-		if cli.IsFlagChanged(cmd, legacyRevertBeaconFlag) {
-			config.Revert.RevertBeacon = cli.GetBoolFlagValue(cmd, legacyRevertBeaconFlag)
-		}
-		if cli.IsFlagChanged(cmd, legacyRevertBeforeFlag) {
-			config.Revert.RevertBefore = cli.GetIntFlagValue(cmd, legacyRevertBeforeFlag)
-		}
-		if cli.IsFlagChanged(cmd, legacyRevertToFlag) {
-			config.Revert.RevertTo = cli.GetIntFlagValue(cmd, legacyRevertToFlag)
-		}
-	}
-}
-
 var (
 	legacyPortFlag = cli.IntFlag{
 		Name:       "port",
@@ -1284,6 +1415,135 @@ func applyPrometheusFlags(cmd *cobra.Command, config *harmonyConfig) {
 	}
 }
 
+// gas price oracle flags
+var (
+	gpoBlocksFlag = cli.IntFlag{
+		Name:     "gpo.blocks",
+		Usage:    "number of recent blocks sampled for the gas price suggestion",
+		DefValue: defaultGPOConfig.Blocks,
+	}
+	gpoPercentileFlag = cli.IntFlag{
+		Name:     "gpo.percentile",
+		Usage:    "percentile of the sampled gas prices suggested",
+		DefValue: defaultGPOConfig.Percentile,
+	}
+	gpoIgnorePriceFlag = cli.IntFlag{
+		Name:     "gpo.ignoreprice",
+		Usage:    "gas price in wei below which transactions are not sampled",
+		DefValue: int(defaultGPOConfig.IgnorePrice),
+	}
+	gpoMaxPriceFlag = cli.IntFlag{
+		Name:     "gpo.maxprice",
+		Usage:    "highest gas price in wei suggested",
+		DefValue: int(defaultGPOConfig.MaxPrice),
+	}
+)
+
+func applyGPOFlags(cmd *cobra.Command, config *harmonyConfig) {
+	if config.GPO == nil {
+		cfg := getDefaultGPOConfigCopy()
+		config.GPO = &cfg
+	}
+	if cli.IsFlagChanged(cmd, gpoBlocksFlag) {
+		config.GPO.Blocks = cli.GetIntFlagValue(cmd, gpoBlocksFlag)
+	}
+	if cli.IsFlagChanged(cmd, gpoPercentileFlag) {
+		config.GPO.Percentile = cli.GetIntFlagValue(cmd, gpoPercentileFlag)
+	}
+	if cli.IsFlagChanged(cmd, gpoIgnorePriceFlag) {
+		config.GPO.IgnorePrice = int64(cli.GetIntFlagValue(cmd, gpoIgnorePriceFlag))
+	}
+	if cli.IsFlagChanged(cmd, gpoMaxPriceFlag) {
+		config.GPO.MaxPrice = int64(cli.GetIntFlagValue(cmd, gpoMaxPriceFlag))
+	}
+}
+
+// health check flags
+var (
+	healthRPCFlag = cli.BoolFlag{
+		Name:     "health.rpc",
+		Usage:    "serve the /health/live and /health/ready endpoints on the http rpc port",
+		DefValue: defaultHealthConfig.RPC,
+	}
+	healthMetricsFlag = cli.BoolFlag{
+		Name:     "health.metrics",
+		Usage:    "serve the /health/live and /health/ready endpoints on the prometheus port",
+		DefValue: defaultHealthConfig.Metrics,
+	}
+	healthMinPeersFlag = cli.IntFlag{
+		Name:     "health.min-peers",
+		Usage:    "minimum number of connected peers for the node to be ready",
+		DefValue: defaultHealthConfig.MinPeers,
+	}
+	healthMaxBlockAgeFlag = cli.IntFlag{
+		Name:     "health.max-block-age",
+		Usage:    "maximum age in seconds of the head block for the node to be ready (0 to disable)",
+		DefValue: defaultHealthConfig.MaxBlockAge,
+	}
+	healthMaxRPCBacklogFlag = cli.IntFlag{
+		Name:     "health.max-rpc-backlog",
+		Usage:    "maximum number of rpc requests in flight for the node to be ready (0 to disable)",
+		DefValue: defaultHealthConfig.MaxRPCBacklog,
+	}
+)
+
+func applyHealthFlags(cmd *cobra.Command, config *harmonyConfig) {
+	if config.Health == nil {
+		cfg := getDefaultHealthConfigCopy()
+		config.Health = &cfg
+	}
+	if cli.IsFlagChanged(cmd, healthRPCFlag) {
+		config.Health.RPC = cli.GetBoolFlagValue(cmd, healthRPCFlag)
+	}
+	if cli.IsFlagChanged(cmd, healthMetricsFlag) {
+		config.Health.Metrics = cli.GetBoolFlagValue(cmd, healthMetricsFlag)
+	}
+	if cli.IsFlagChanged(cmd, healthMinPeersFlag) {
+		config.Health.MinPeers = cli.GetIntFlagValue(cmd, healthMinPeersFlag)
+	}
+	if cli.IsFlagChanged(cmd, healthMaxBlockAgeFlag) {
+		config.Health.MaxBlockAge = cli.GetIntFlagValue(cmd, healthMaxBlockAgeFlag)
+	}
+	if cli.IsFlagChanged(cmd, healthMaxRPCBacklogFlag) {
+		config.Health.MaxRPCBacklog = cli.GetIntFlagValue(cmd, healthMaxRPCBacklogFlag)
+	}
+}
+
+// database flags
+var (
+	dbBackendFlag = cli.StringFlag{
+		Name:     "db.backend",
+		Usage:    "key value store of the chain and explorer databases (leveldb, badgerdb)",
+		DefValue: defaultDBConfig.Backend,
+	}
+	dbFreezerFlag = cli.BoolFlag{
+		Name:     "db.freezer",
+		Usage:    "move the blocks behind the freezer threshold from the key value store to the append-only freezer (the blocks already frozen are read regardless)",
+		DefValue: defaultDBConfig.Freezer,
+	}
+	dbFreezerThresholdFlag = cli.IntFlag{
+		Name:     "db.freezer.threshold",
+		Usage:    "number of recent blocks kept in the key value store with the freezer",
+		DefValue: defaultDBConfig.FreezerThreshold,
+	}
+)
+
+func applyDBFlags(cmd *cobra.Command, config *harmonyConfig) {
+	if config.DB == nil {
+		cfg := getDefaultDBConfigCopy()
+		config.DB = &cfg
+	}
+	if cli.IsFlagChanged(cmd, dbBackendFlag) {
+		config.DB.Backend = cli.GetStringFlagValue(cmd, dbBackendFlag)
+	}
+	if cli.IsFlagChanged(cmd, dbFreezerFlag) {
+		config.DB.Freezer = cli.GetBoolFlagValue(cmd, dbFreezerFlag)
+	}
+	if cli.IsFlagChanged(cmd, dbFreezerThresholdFlag) {
+		config.DB.FreezerThreshold = cli.GetIntFlagValue(cmd, dbFreezerThresholdFlag)
+	}
+}
+
 var (
 	// TODO: Deprecate this flag, and always set to true after stream sync is fully up.
 	syncDownloaderFlag = cli.BoolFlag{
@@ -1339,11 +1599,76 @@ var (
 		Usage:  "batch size of the sync discovery",
 		Hidden: true,
 	}
+	syncRateLimitFlag = cli.IntFlag{
+		Name:   "sync.ratelimit.global",
+		Usage:  "request cost per second served to all sync streams",
+		Hidden: true,
+	}
+	syncStreamRateLimitFlag = cli.IntFlag{
+		Name:   "sync.ratelimit.stream",
+		Usage:  "request cost per second served to a single sync stream",
+		Hidden: true,
+	}
+	syncClientRateLimitFlag = cli.IntFlag{
+		Name:   "sync.ratelimit.client",
+		Usage:  "request cost per second sent to the sync streams",
+		Hidden: true,
+	}
+	syncResponseBytesLimitFlag = cli.IntFlag{
+		Name:   "sync.ratelimit.response-bytes",
+		Usage:  "response bytes per second served to a single sync stream",
+		Hidden: true,
+	}
+	syncLegacyTLSFlag = cli.BoolFlag{
+		Name:   "sync.legacy.tls",
+		Usage:  "Use mutual TLS with the p2p key certificates for the gRPC sync server and clients. The node cannot sync from or serve the peers not using TLS",
+		Hidden: true,
+	}
+	syncLegacyAllowlistFlag = cli.StringSliceFlag{
+		Name:   "sync.legacy.allowlist",
+		Usage:  "Peer IDs (with TLS) or IPs of the clients allowed by the gRPC sync server",
+		Hidden: true,
+	}
+	syncLegacyMaxRequestSizeFlag = cli.IntFlag{
+		Name:   "sync.legacy.max-request-size",
+		Usage:  "Maximum number of block hashes in a gRPC sync request",
+		Hidden: true,
+	}
+	syncLegacyMaxConcurrencyFlag = cli.IntFlag{
+		Name:   "sync.legacy.max-concurrency",
+		Usage:  "Maximum number of concurrent gRPC sync requests of a client",
+		Hidden: true,
+	}
+	syncLightServerFlag = cli.BoolFlag{
+		Name:   "sync.light.server",
+		Usage:  "Serve the epoch headers, headers and account proofs to light clients through stream protocol",
+		Hidden: true,
+	}
+	syncCheckpointFlag = cli.StringFlag{
+		Name:   "sync.checkpoint",
+		Usage:  "Hash of a trusted last block of an epoch to sync the node shard from, instead of the genesis block",
+		Hidden: true,
+	}
+	syncCheckpointStateFlag = cli.StringFlag{
+		Name:   "sync.checkpoint.state",
+		Usage:  "File path or URL of the state snapshot of the checkpoint block (gzipped if ending with .gz)",
+		Hidden: true,
+	}
+	syncBeaconCheckpointFlag = cli.StringFlag{
+		Name:   "sync.checkpoint.beacon",
+		Usage:  "Hash of a trusted last block of an epoch to sync the beacon chain from on a node of another shard, instead of the genesis block",
+		Hidden: true,
+	}
+	syncBeaconCheckpointStateFlag = cli.StringFlag{
+		Name:   "sync.checkpoint.beacon.state",
+		Usage:  "File path or URL of the state snapshot of the beacon checkpoint block (gzipped if ending with .gz)",
+		Hidden: true,
+	}
 )
 
 // applySyncFlags apply the sync flags.
 func applySyncFlags(cmd *cobra.Command, config *harmonyConfig) {
-	if config.Sync == (syncConfig{}) {
+	if reflect.DeepEqual(config.Sync, syncConfig{}) {
 		nt := nodeconfig.NetworkType(config.Network.NetworkType)
 		config.Sync = getDefaultSyncConfig(nt)
 	}
@@ -1387,4 +1712,56 @@ func applySyncFlags(cmd *cobra.Command, config *harmonyConfig) {
 	if cli.IsFlagChanged(cmd, syncDiscBatchFlag) {
 		config.Sync.DiscBatch = cli.GetIntFlagValue(cmd, syncDiscBatchFlag)
 	}
+
+	if cli.IsFlagChanged(cmd, syncRateLimitFlag) {
+		config.Sync.RateLimit = cli.GetIntFlagValue(cmd, syncRateLimitFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncStreamRateLimitFlag) {
+		config.Sync.StreamRateLimit = cli.GetIntFlagValue(cmd, syncStreamRateLimitFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncClientRateLimitFlag) {
+		config.Sync.ClientRateLimit = cli.GetIntFlagValue(cmd, syncClientRateLimitFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncResponseBytesLimitFlag) {
+		config.Sync.ResponseBytesLimit = cli.GetIntFlagValue(cmd, syncResponseBytesLimitFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncLegacyTLSFlag) {
+		config.Sync.LegacyTLS = cli.GetBoolFlagValue(cmd, syncLegacyTLSFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncLegacyAllowlistFlag) {
+		config.Sync.LegacyAllowlist = cli.GetStringSliceFlagValue(cmd, syncLegacyAllowlistFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncLegacyMaxRequestSizeFlag) {
+		config.Sync.LegacyMaxRequestSize = cli.GetIntFlagValue(cmd, syncLegacyMaxRequestSizeFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncLegacyMaxConcurrencyFlag) {
+		config.Sync.LegacyMaxConcurrency = cli.GetIntFlagValue(cmd, syncLegacyMaxConcurrencyFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncLightServerFlag) {
+		config.Sync.LightServer = cli.GetBoolFlagValue(cmd, syncLightServerFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncCheckpointFlag) {
+		config.Sync.Checkpoint = cli.GetStringFlagValue(cmd, syncCheckpointFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncCheckpointStateFlag) {
+		config.Sync.CheckpointState = cli.GetStringFlagValue(cmd, syncCheckpointStateFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncBeaconCheckpointFlag) {
+		config.Sync.BeaconCheckpoint = cli.GetStringFlagValue(cmd, syncBeaconCheckpointFlag)
+	}
+
+	if cli.IsFlagChanged(cmd, syncBeaconCheckpointStateFlag) {
+		config.Sync.BeaconCheckpointState = cli.GetStringFlagValue(cmd, syncBeaconCheckpointStateFlag)
+	}
 }
@@ -53,6 +53,11 @@ func TestHarmonyFlags(t *testing.T) {
 					Port:    9000,
 					IP:      defaultConfig.P2P.IP,
 					KeyFile: defaultConfig.P2P.KeyFile,
+
+					ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+					ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+					ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+					ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
 				},
 				HTTP: httpConfig{
 					Enabled:        true,
@@ -112,7 +117,10 @@ func TestHarmonyFlags(t *testing.T) {
 					EnablePush: true,
 					Gateway:    "https://gateway.harmony.one",
 				},
-				Sync: defaultMainnetSyncConfig,
+				GPO:    &defaultGPOConfig,
+				Health: &defaultHealthConfig,
+				DB:     &defaultDBConfig,
+				Sync:   defaultMainnetSyncConfig,
 			},
 		},
 	}
@@ -314,18 +322,86 @@ func TestP2PFlags(t *testing.T) {
 			args: []string{"--p2p.port", "9001", "--p2p.keyfile", "./key.file", "--p2p.dht.datastore",
 				defDataStore},
 			expConfig: p2pConfig{
-				Port:         9001,
-				IP:           nodeconfig.DefaultPublicListenIP,
-				KeyFile:      "./key.file",
-				DHTDataStore: &defDataStore,
+				Port:              9001,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./key.file",
+				DHTDataStore:      &defDataStore,
+				ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+				ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+				ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+				ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
 			},
 		},
 		{
 			args: []string{"--port", "9001", "--key", "./key.file"},
 			expConfig: p2pConfig{
-				Port:    9001,
-				IP:      nodeconfig.DefaultPublicListenIP,
-				KeyFile: "./key.file",
+				Port:              9001,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./key.file",
+				ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+				ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+				ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+				ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
+			},
+		},
+		{
+			args: []string{"--p2p.static-peers", "/ip4/1.2.3.4/tcp/9000/p2p/QmPeer1,/ip4/1.2.3.5/tcp/9000/p2p/QmPeer2",
+				"--p2p.trusted-peers", "/ip4/1.2.3.6/tcp/9000/p2p/QmPeer3", "--p2p.no-discovery"},
+			expConfig: p2pConfig{
+				Port:              nodeconfig.DefaultP2PPort,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./.hmykey",
+				StaticPeers:       []string{"/ip4/1.2.3.4/tcp/9000/p2p/QmPeer1", "/ip4/1.2.3.5/tcp/9000/p2p/QmPeer2"},
+				TrustedPeers:      []string{"/ip4/1.2.3.6/tcp/9000/p2p/QmPeer3"},
+				NoDiscovery:       true,
+				ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+				ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+				ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+				ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
+			},
+		},
+		{
+			args: []string{"--p2p.listen-addrs", "/ip4/0.0.0.0/tcp/9000,/ip6/::/tcp/9000,/ip4/0.0.0.0/udp/9000/quic",
+				"--p2p.announce-addrs", "/ip4/1.2.3.4/tcp/9000"},
+			expConfig: p2pConfig{
+				Port:              nodeconfig.DefaultP2PPort,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./.hmykey",
+				ListenAddrs:       []string{"/ip4/0.0.0.0/tcp/9000", "/ip6/::/tcp/9000", "/ip4/0.0.0.0/udp/9000/quic"},
+				AnnounceAddrs:     []string{"/ip4/1.2.3.4/tcp/9000"},
+				ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+				ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+				ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+				ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
+			},
+		},
+		{
+			args: []string{"--p2p.connmgr.low", "50", "--p2p.connmgr.high", "100",
+				"--p2p.connmgr.grace", "30", "--p2p.connmgr.silence", "5"},
+			expConfig: p2pConfig{
+				Port:              nodeconfig.DefaultP2PPort,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./.hmykey",
+				ConnLowWater:      50,
+				ConnHighWater:     100,
+				ConnGracePeriod:   30,
+				ConnSilencePeriod: 5,
+			},
+		},
+		{
+			args: []string{"--p2p.dns-trees", "enrtree://AKA3AM6LPBYEUDMVNU3BSVQJ5AD45Y7YPOHJLEF6W26QOE4VTUDPE@nodes.example.org",
+				"--p2p.peers-file", "./peers.json", "--p2p.no-discovery"},
+			expConfig: p2pConfig{
+				Port:              nodeconfig.DefaultP2PPort,
+				IP:                nodeconfig.DefaultPublicListenIP,
+				KeyFile:           "./.hmykey",
+				NoDiscovery:       true,
+				DNSTrees:          []string{"enrtree://AKA3AM6LPBYEUDMVNU3BSVQJ5AD45Y7YPOHJLEF6W26QOE4VTUDPE@nodes.example.org"},
+				PeersFile:         "./peers.json",
+				ConnLowWater:      defaultConfig.P2P.ConnLowWater,
+				ConnHighWater:     defaultConfig.P2P.ConnHighWater,
+				ConnGracePeriod:   defaultConfig.P2P.ConnGracePeriod,
+				ConnSilencePeriod: defaultConfig.P2P.ConnSilencePeriod,
 			},
 		},
 	}
@@ -507,6 +583,23 @@ func TestRPCOptFlags(t *testing.T) {
 				DebugEnabled: true,
 			},
 		},
+		{
+			args: []string{"--rpc.traceindex"},
+			expConfig: rpcOptConfig{
+				TraceIndexEnabled: true,
+			},
+		},
+		{
+			args: []string{"--rpc.batchlimit", "50", "--rpc.responselimit", "1048576", "--rpc.ratelimit", "20",
+				"--rpc.rateburst", "40", "--rpc.apikey.required"},
+			expConfig: rpcOptConfig{
+				BatchLimit:     50,
+				ResponseLimit:  1048576,
+				RateLimit:      20,
+				RateBurst:      40,
+				APIKeyRequired: true,
+			},
+		},
 	}
 	for i, test := range tests {
 		ts := newFlagTestSuite(t, rpcOptFlags, applyRPCOptFlags)
@@ -760,6 +853,19 @@ func TestLogFlags(t *testing.T) {
 				Context:    nil,
 			},
 		},
+		{
+			args: []string{"--log.msgrecord.file", "./msg.record", "--log.msgrecord.max-size", "50",
+				"--log.msgrecord.max-backups", "3"},
+			expConfig: logConfig{
+				Folder:              defaultConfig.Log.Folder,
+				FileName:            defaultConfig.Log.FileName,
+				RotateSize:          defaultConfig.Log.RotateSize,
+				Verbosity:           defaultConfig.Log.Verbosity,
+				MsgRecordFile:       "./msg.record",
+				MsgRecordRotateSize: 50,
+				MsgRecordMaxBackups: 3,
+			},
+		},
 		{
 			args: []string{"--log.ctx.ip", "8.8.8.8", "--log.ctx.port", "9001"},
 			expConfig: logConfig{
@@ -896,53 +1002,99 @@ func TestDevnetFlags(t *testing.T) {
 	}
 }
 
-func TestRevertFlags(t *testing.T) {
+func TestGPOFlags(t *testing.T) {
 	tests := []struct {
 		args      []string
-		expConfig *revertConfig
-		expErr    error
+		expConfig *gpoConfig
 	}{
 		{
 			args:      []string{},
-			expConfig: nil,
+			expConfig: &defaultGPOConfig,
 		},
 		{
-			args: []string{"--revert.beacon"},
-			expConfig: &revertConfig{
-				RevertBeacon: true,
-				RevertTo:     defaultRevertConfig.RevertTo,
-				RevertBefore: defaultRevertConfig.RevertBefore,
+			args: []string{"--gpo.blocks", "10", "--gpo.percentile", "50", "--gpo.ignoreprice", "1000000000",
+				"--gpo.maxprice", "500000000000"},
+			expConfig: &gpoConfig{
+				Blocks:      10,
+				Percentile:  50,
+				IgnorePrice: 1000000000,
+				MaxPrice:    500000000000,
 			},
 		},
+	}
+	for i, test := range tests {
+		ts := newFlagTestSuite(t, gpoFlags, applyGPOFlags)
+		hc, err := ts.run(test.args)
+		if err != nil {
+			t.Fatalf("Test %v: %v", i, err)
+		}
+		if !reflect.DeepEqual(hc.GPO, test.expConfig) {
+			t.Errorf("Test %v:\n\t%+v\n\t%+v", i, hc.GPO, test.expConfig)
+		}
+		ts.tearDown()
+	}
+}
+
+func TestHealthFlags(t *testing.T) {
+	tests := []struct {
+		args      []string
+		expConfig *healthConfig
+	}{
 		{
-			args: []string{"--revert.beacon", "--revert.to", "100", "--revert.do-before", "10000"},
-			expConfig: &revertConfig{
-				RevertBeacon: true,
-				RevertTo:     100,
-				RevertBefore: 10000,
-			},
+			args:      []string{},
+			expConfig: &defaultHealthConfig,
 		},
 		{
-			args: []string{"--revert_beacon", "--do_revert_before", "10000", "--revert_to", "100"},
-			expConfig: &revertConfig{
-				RevertBeacon: true,
-				RevertTo:     100,
-				RevertBefore: 10000,
+			args: []string{"--health.rpc", "--health.metrics=false", "--health.min-peers", "5",
+				"--health.max-block-age", "30", "--health.max-rpc-backlog", "0"},
+			expConfig: &healthConfig{
+				RPC:           true,
+				Metrics:       false,
+				MinPeers:      5,
+				MaxBlockAge:   30,
+				MaxRPCBacklog: 0,
 			},
 		},
 	}
 	for i, test := range tests {
-		ts := newFlagTestSuite(t, revertFlags, applyRevertFlags)
+		ts := newFlagTestSuite(t, healthFlags, applyHealthFlags)
 		hc, err := ts.run(test.args)
-
-		if assErr := assertError(err, test.expErr); assErr != nil {
-			t.Fatalf("Test %v: %v", i, assErr)
+		if err != nil {
+			t.Fatalf("Test %v: %v", i, err)
 		}
-		if err != nil || test.expErr != nil {
-			continue
+		if !reflect.DeepEqual(hc.Health, test.expConfig) {
+			t.Errorf("Test %v:\n\t%+v\n\t%+v", i, hc.Health, test.expConfig)
 		}
-		if !reflect.DeepEqual(hc.Revert, test.expConfig) {
-			t.Errorf("Test %v:\n\t%+v\n\t%+v", i, hc.Revert, test.expConfig)
+		ts.tearDown()
+	}
+}
+
+func TestDBFlags(t *testing.T) {
+	tests := []struct {
+		args      []string
+		expConfig *dbConfig
+	}{
+		{
+			args:      []string{},
+			expConfig: &defaultDBConfig,
+		},
+		{
+			args: []string{"--db.backend", "badgerdb", "--db.freezer", "--db.freezer.threshold", "128"},
+			expConfig: &dbConfig{
+				Backend:          "badgerdb",
+				Freezer:          true,
+				FreezerThreshold: 128,
+			},
+		},
+	}
+	for i, test := range tests {
+		ts := newFlagTestSuite(t, dbFlags, applyDBFlags)
+		hc, err := ts.run(test.args)
+		if err != nil {
+			t.Fatalf("Test %v: %v", i, err)
+		}
+		if !reflect.DeepEqual(hc.DB, test.expConfig) {
+			t.Errorf("Test %v:\n\t%+v\n\t%+v", i, hc.DB, test.expConfig)
 		}
 		ts.tearDown()
 	}
@@ -1000,6 +1152,63 @@ func TestSyncFlags(t *testing.T) {
 				return cfg
 			}(),
 		},
+		{
+			args: []string{"--sync.ratelimit.global", "2000", "--sync.ratelimit.stream", "300",
+				"--sync.ratelimit.client", "500", "--sync.ratelimit.response-bytes", "1048576",
+			},
+			network: "mainnet",
+			expConfig: func() syncConfig {
+				cfg := defaultMainnetSyncConfig
+				cfg.RateLimit = 2000
+				cfg.StreamRateLimit = 300
+				cfg.ClientRateLimit = 500
+				cfg.ResponseBytesLimit = 1048576
+				return cfg
+			}(),
+		},
+		{
+			args: []string{"--sync.legacy.tls", "--sync.legacy.allowlist", "10.0.0.1,10.0.0.2",
+				"--sync.legacy.max-request-size", "50", "--sync.legacy.max-concurrency", "4",
+			},
+			network: "mainnet",
+			expConfig: func() syncConfig {
+				cfg := defaultMainnetSyncConfig
+				cfg.LegacyTLS = true
+				cfg.LegacyAllowlist = []string{"10.0.0.1", "10.0.0.2"}
+				cfg.LegacyMaxRequestSize = 50
+				cfg.LegacyMaxConcurrency = 4
+				return cfg
+			}(),
+		},
+		{
+			args:    []string{"--sync.light.server"},
+			network: "mainnet",
+			expConfig: func() syncConfig {
+				cfg := defaultMainnetSyncConfig
+				cfg.LightServer = true
+				return cfg
+			}(),
+		},
+		{
+			args:    []string{"--sync.checkpoint", "0x01", "--sync.checkpoint.state", "state.rlp.gz"},
+			network: "mainnet",
+			expConfig: func() syncConfig {
+				cfg := defaultMainnetSyncConfig
+				cfg.Checkpoint = "0x01"
+				cfg.CheckpointState = "state.rlp.gz"
+				return cfg
+			}(),
+		},
+		{
+			args:    []string{"--sync.checkpoint.beacon", "0x02", "--sync.checkpoint.beacon.state", "beacon.rlp.gz"},
+			network: "mainnet",
+			expConfig: func() syncConfig {
+				cfg := defaultMainnetSyncConfig
+				cfg.BeaconCheckpoint = "0x02"
+				cfg.BeaconCheckpointState = "beacon.rlp.gz"
+				return cfg
+			}(),
+		},
 	}
 	for i, test := range tests {
 		ts := newFlagTestSuite(t, syncFlags, func(command *cobra.Command, config *harmonyConfig) {
@@ -24,6 +24,7 @@ import (
 	"github.com/harmony-one/bls/ffi/go/bls"
 	"github.com/harmony-one/harmony/api/service"
 	"github.com/harmony-one/harmony/api/service/legacysync"
+	legdownloader "github.com/harmony-one/harmony/api/service/legacysync/downloader"
 	"github.com/harmony-one/harmony/api/service/prometheus"
 	"github.com/harmony-one/harmony/common/fdlimit"
 	"github.com/harmony-one/harmony/common/ntp"
@@ -36,18 +37,22 @@ import (
 	shardingconfig "github.com/harmony-one/harmony/internal/configs/sharding"
 	"github.com/harmony-one/harmony/internal/genesis"
 	"github.com/harmony-one/harmony/internal/params"
-	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/multibls"
 	"github.com/harmony-one/harmony/node"
 	"github.com/harmony-one/harmony/numeric"
 	"github.com/harmony-one/harmony/p2p"
+	lightproto "github.com/harmony-one/harmony/p2p/stream/protocols/light"
+	syncproto "github.com/harmony-one/harmony/p2p/stream/protocols/sync"
 	"github.com/harmony-one/harmony/shard"
 	"github.com/harmony-one/harmony/webhooks"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+// p2pBanListFile is the file in the data directory the banned peers are persisted to
+const p2pBanListFile = "p2p_banlist.json"
+
 // Host
 var (
 	myHost          p2p.Host
@@ -92,6 +97,8 @@ func init() {
 	})
 	rootCmd.AddCommand(dumpConfigCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(dbCmd)
 
 	if err := registerRootCmdFlags(); err != nil {
 		os.Exit(2)
@@ -99,6 +106,12 @@ func init() {
 	if err := registerDumpConfigFlags(); err != nil {
 		os.Exit(2)
 	}
+	if err := registerReplayFlags(); err != nil {
+		os.Exit(2)
+	}
+	if err := registerDBFlags(); err != nil {
+		os.Exit(2)
+	}
 }
 
 func main() {
@@ -205,8 +218,10 @@ func applyRootFlags(cmd *cobra.Command, config *harmonyConfig) {
 	applyLogFlags(cmd, config)
 	applySysFlags(cmd, config)
 	applyDevnetFlags(cmd, config)
-	applyRevertFlags(cmd, config)
 	applyPrometheusFlags(cmd, config)
+	applyGPOFlags(cmd, config)
+	applyHealthFlags(cmd, config)
+	applyDBFlags(cmd, config)
 	applySyncFlags(cmd, config)
 }
 
@@ -236,53 +251,7 @@ func setupPprof(config harmonyConfig) {
 }
 
 func setupNodeAndRun(hc harmonyConfig) {
-	var err error
-
-	nodeconfigSetShardSchedule(hc)
-	nodeconfig.SetShardingSchedule(shard.Schedule)
-	nodeconfig.SetVersion(getHarmonyVersion())
-
-	if hc.General.NodeType == "validator" {
-		var err error
-		if hc.General.NoStaking {
-			err = setupLegacyNodeAccount(hc)
-		} else {
-			err = setupStakingNodeAccount(hc)
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "cannot set up node account: %s\n", err)
-			os.Exit(1)
-		}
-	}
-	if hc.General.NodeType == "validator" {
-		fmt.Printf("%s mode; node key %s -> shard %d\n",
-			map[bool]string{false: "Legacy", true: "Staking"}[!hc.General.NoStaking],
-			nodeconfig.GetDefaultConfig().ConsensusPriKey.GetPublicKeys().SerializeToHexStr(),
-			initialAccounts[0].ShardID)
-	}
-	if hc.General.NodeType != "validator" && hc.General.ShardID >= 0 {
-		for _, initialAccount := range initialAccounts {
-			utils.Logger().Info().
-				Uint32("original", initialAccount.ShardID).
-				Int("override", hc.General.ShardID).
-				Msg("ShardID Override")
-			initialAccount.ShardID = uint32(hc.General.ShardID)
-		}
-	}
-
-	nodeConfig, err := createGlobalConfig(hc)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR cannot configure node: %s\n", err)
-		os.Exit(1)
-	}
-
-	// Update ethereum compatible chain ids
-	params.UpdateEthChainIDByShard(nodeConfig.ShardID)
-
-	currentNode := setupConsensusAndNode(hc, nodeConfig)
-	nodeconfig.GetDefaultConfig().ShardID = nodeConfig.ShardID
-	nodeconfig.GetDefaultConfig().IsOffline = nodeConfig.IsOffline
-	nodeconfig.GetDefaultConfig().Downloader = nodeConfig.Downloader
+	currentNode, nodeConfig := setupNode(hc)
 
 	// Check NTP configuration
 	accurate, err := ntp.CheckLocalTimeAccurate(nodeConfig.NtpServer)
@@ -308,6 +277,47 @@ func setupNodeAndRun(hc harmonyConfig) {
 		WSIp:         hc.WS.IP,
 		WSPort:       hc.WS.Port,
 		DebugEnabled: hc.RPCOpt.DebugEnabled,
+
+		TraceIndexEnabled: hc.RPCOpt.TraceIndexEnabled && nodeConfig.GetArchival(),
+
+		BatchLimit:     hc.RPCOpt.BatchLimit,
+		ResponseLimit:  hc.RPCOpt.ResponseLimit,
+		RateLimit:      float64(hc.RPCOpt.RateLimit),
+		RateBurst:      hc.RPCOpt.RateBurst,
+		APIKeyRequired: hc.RPCOpt.APIKeyRequired,
+	}
+	for _, limit := range hc.RPCOpt.MethodRateLimits {
+		nodeConfig.RPCServer.MethodRateLimits = append(nodeConfig.RPCServer.MethodRateLimits,
+			nodeconfig.RPCRateLimit{Method: limit.Method, Rate: limit.Rate, Burst: limit.Burst})
+	}
+	for _, key := range hc.RPCOpt.APIKeys {
+		nodeConfig.RPCServer.APIKeys = append(nodeConfig.RPCServer.APIKeys,
+			nodeconfig.RPCAPIKey{Key: key.Key, Rate: key.Rate, Burst: key.Burst})
+	}
+	if hc.RPCOpt.APIKeyRequired && len(hc.RPCOpt.APIKeys) == 0 {
+		utils.Logger().Warn().Msg("rpc api key required but no api key configured, all requests are rejected")
+	}
+	if hc.RPCOpt.TraceIndexEnabled && !nodeConfig.GetArchival() {
+		utils.Logger().Warn().Msg("flat trace index is only supported on archival nodes, ignored")
+	}
+
+	if hc.GPO != nil {
+		nodeConfig.GPO = nodeconfig.GasPriceOracleConfig{
+			Blocks:      hc.GPO.Blocks,
+			Percentile:  hc.GPO.Percentile,
+			IgnorePrice: hc.GPO.IgnorePrice,
+			MaxPrice:    hc.GPO.MaxPrice,
+		}
+	}
+
+	if hc.Health != nil {
+		nodeConfig.Health = nodeconfig.HealthConfig{
+			RPCEnabled:     hc.Health.RPC,
+			MetricsEnabled: hc.Health.Metrics,
+			MinPeers:       hc.Health.MinPeers,
+			MaxBlockAge:    hc.Health.MaxBlockAge,
+			MaxRPCBacklog:  hc.Health.MaxRPCBacklog,
+		}
 	}
 
 	// Parse rosetta config
@@ -317,28 +327,6 @@ func setupNodeAndRun(hc harmonyConfig) {
 		HTTPPort:    hc.HTTP.RosettaPort,
 	}
 
-	if hc.Revert != nil && hc.Revert.RevertBefore != 0 && hc.Revert.RevertTo != 0 {
-		chain := currentNode.Blockchain()
-		if hc.Revert.RevertBeacon {
-			chain = currentNode.Beaconchain()
-		}
-		curNum := chain.CurrentBlock().NumberU64()
-		if curNum < uint64(hc.Revert.RevertBefore) && curNum >= uint64(hc.Revert.RevertTo) {
-			// Remove invalid blocks
-			for chain.CurrentBlock().NumberU64() >= uint64(hc.Revert.RevertTo) {
-				curBlock := chain.CurrentBlock()
-				rollbacks := []ethCommon.Hash{curBlock.Hash()}
-				if err := chain.Rollback(rollbacks); err != nil {
-					fmt.Printf("Revert failed: %v\n", err)
-					os.Exit(1)
-				}
-				lastSig := curBlock.Header().LastCommitSignature()
-				sigAndBitMap := append(lastSig[:], curBlock.Header().LastCommitBitmap()...)
-				chain.WriteCommitSig(curBlock.NumberU64()-1, sigAndBitMap)
-			}
-		}
-	}
-
 	startMsg := "==== New Harmony Node ===="
 	if hc.General.NodeType == nodeTypeExplorer {
 		startMsg = "==== New Explorer Node ===="
@@ -373,7 +361,10 @@ func setupNodeAndRun(hc harmonyConfig) {
 
 	if hc.Sync.LegacyServer && !hc.General.IsOffline {
 		utils.Logger().Info().Msg("support gRPC sync server")
-		currentNode.SupportGRPCSyncServer()
+		if err := currentNode.SupportGRPCSyncServer(); err != nil {
+			fmt.Fprint(os.Stderr, err.Error())
+			os.Exit(-1)
+		}
 	}
 	if hc.Sync.LegacyClient && !hc.General.IsOffline {
 		utils.Logger().Info().Msg("go with gRPC sync client")
@@ -422,6 +413,60 @@ func setupNodeAndRun(hc harmonyConfig) {
 	select {}
 }
 
+// setupNode sets up the accounts, the global config, the p2p host, and the
+// consensus and node
+func setupNode(hc harmonyConfig) (*node.Node, *nodeconfig.ConfigType) {
+	var err error
+
+	nodeconfigSetShardSchedule(hc)
+	nodeconfig.SetShardingSchedule(shard.Schedule)
+	nodeconfig.SetVersion(getHarmonyVersion())
+
+	if hc.General.NodeType == "validator" {
+		var err error
+		if hc.General.NoStaking {
+			err = setupLegacyNodeAccount(hc)
+		} else {
+			err = setupStakingNodeAccount(hc)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot set up node account: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if hc.General.NodeType == "validator" {
+		fmt.Printf("%s mode; node key %s -> shard %d\n",
+			map[bool]string{false: "Legacy", true: "Staking"}[!hc.General.NoStaking],
+			nodeconfig.GetDefaultConfig().ConsensusPriKey.GetPublicKeys().SerializeToHexStr(),
+			initialAccounts[0].ShardID)
+	}
+	if hc.General.NodeType != "validator" && hc.General.ShardID >= 0 {
+		for _, initialAccount := range initialAccounts {
+			utils.Logger().Info().
+				Uint32("original", initialAccount.ShardID).
+				Int("override", hc.General.ShardID).
+				Msg("ShardID Override")
+			initialAccount.ShardID = uint32(hc.General.ShardID)
+		}
+	}
+
+	nodeConfig, err := createGlobalConfig(hc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR cannot configure node: %s\n", err)
+		os.Exit(1)
+	}
+
+	// Update ethereum compatible chain ids
+	params.UpdateEthChainIDByShard(nodeConfig.ShardID)
+
+	currentNode := setupConsensusAndNode(hc, nodeConfig)
+	nodeconfig.GetDefaultConfig().ShardID = nodeConfig.ShardID
+	nodeconfig.GetDefaultConfig().IsOffline = nodeConfig.IsOffline
+	nodeconfig.GetDefaultConfig().Downloader = nodeConfig.Downloader
+
+	return currentNode, nodeConfig
+}
+
 func nodeconfigSetShardSchedule(config harmonyConfig) {
 	switch config.Network.NetworkType {
 	case nodeconfig.Mainnet:
@@ -540,6 +585,17 @@ func createGlobalConfig(hc harmonyConfig) (*nodeconfig.ConfigType, error) {
 	nodeConfig.SetArchival(hc.General.IsBeaconArchival, hc.General.IsArchival)
 	nodeConfig.IsOffline = hc.General.IsOffline
 	nodeConfig.Downloader = hc.Sync.Downloader
+	nodeConfig.MessageRecord = nodeconfig.MessageRecordConfig{
+		File:       hc.Log.MsgRecordFile,
+		MaxSize:    hc.Log.MsgRecordRotateSize,
+		MaxBackups: hc.Log.MsgRecordMaxBackups,
+	}
+	nodeConfig.LegacySync = nodeconfig.LegacySyncConfig{
+		TLS:            hc.Sync.LegacyTLS,
+		Allowlist:      hc.Sync.LegacyAllowlist,
+		MaxRequestSize: hc.Sync.LegacyMaxRequestSize,
+		MaxConcurrency: hc.Sync.LegacyMaxConcurrency,
+	}
 
 	// P2P private key is used for secure message transfer between p2p nodes.
 	nodeConfig.P2PPriKey, _, err = utils.LoadKeyFromFile(hc.P2P.KeyFile)
@@ -547,6 +603,11 @@ func createGlobalConfig(hc harmonyConfig) (*nodeconfig.ConfigType, error) {
 		return nil, errors.Wrapf(err, "cannot load or create P2P key at %#v",
 			hc.P2P.KeyFile)
 	}
+	if hc.Sync.LegacyTLS {
+		if err := legdownloader.EnableClientTLS(nodeConfig.P2PPriKey); err != nil {
+			return nil, errors.Wrap(err, "cannot set up the gRPC sync client TLS")
+		}
+	}
 
 	selfPeer := p2p.Peer{
 		IP:              hc.P2P.IP,
@@ -559,12 +620,29 @@ func createGlobalConfig(hc harmonyConfig) (*nodeconfig.ConfigType, error) {
 		BLSKey:        nodeConfig.P2PPriKey,
 		BootNodes:     hc.Network.BootNodes,
 		DataStoreFile: hc.P2P.DHTDataStore,
+		ListenAddrs:   hc.P2P.ListenAddrs,
+		AnnounceAddrs: hc.P2P.AnnounceAddrs,
+		StaticPeers:   hc.P2P.StaticPeers,
+		TrustedPeers:  hc.P2P.TrustedPeers,
+		NoDiscovery:   hc.P2P.NoDiscovery,
+		DNSTrees:      hc.P2P.DNSTrees,
+		PeersFile:     hc.P2P.PeersFile,
+		BanListFile:   filepath.Join(hc.General.DataDir, p2pBanListFile),
+		ConnManager: p2p.ConnManagerConfig{
+			LowWater:      hc.P2P.ConnLowWater,
+			HighWater:     hc.P2P.ConnHighWater,
+			GracePeriod:   time.Duration(hc.P2P.ConnGracePeriod) * time.Second,
+			SilencePeriod: time.Duration(hc.P2P.ConnSilencePeriod) * time.Second,
+		},
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create P2P network host")
 	}
 
 	nodeConfig.DBDir = hc.General.DataDir
+	if hc.DB != nil {
+		nodeConfig.DBBackend = hc.DB.Backend
+	}
 
 	if hc.Legacy != nil && hc.Legacy.WebHookConfig != nil && len(*hc.Legacy.WebHookConfig) != 0 {
 		p := *hc.Legacy.WebHookConfig
@@ -621,7 +699,7 @@ func setupConsensusAndNode(hc harmonyConfig, nodeConfig *nodeconfig.ConfigType)
 	}
 
 	// Current node.
-	chainDBFactory := &shardchain.LDBFactory{RootDir: nodeConfig.DBDir}
+	chainDBFactory := newChainDBFactory(hc, nodeConfig.DBDir)
 
 	currentNode := node.New(myHost, currentConsensus, chainDBFactory, blacklist, nodeConfig.ArchiveModes())
 
@@ -656,6 +734,7 @@ func setupConsensusAndNode(hc harmonyConfig, nodeConfig *nodeconfig.ConfigType)
 	)
 
 	nodeconfig.GetDefaultConfig().DBDir = nodeConfig.DBDir
+	nodeconfig.GetDefaultConfig().DBBackend = nodeConfig.DBBackend
 	switch hc.General.NodeType {
 	case nodeTypeExplorer:
 		nodeconfig.SetDefaultRole(nodeconfig.ExplorerNode)
@@ -706,7 +785,11 @@ func setupPrometheusService(node *node.Node, hc harmonyConfig, sid uint32) {
 		Shard:      sid,
 		Instance:   myHost.GetID().Pretty(),
 	}
-	p := prometheus.NewService(prometheusConfig)
+	var handlers []prometheus.Handler
+	if node.NodeConfig.Health.MetricsEnabled {
+		handlers = node.HealthHandlers()
+	}
+	p := prometheus.NewService(prometheusConfig, handlers...)
 	node.RegisterService(service.Prometheus, p)
 }
 
@@ -726,6 +809,32 @@ func setupSyncService(node *node.Node, host p2p.Host, hc harmonyConfig) {
 		SmHardLowCap: hc.Sync.DiscHardLowCap,
 		SmHiCap:      hc.Sync.DiscHighCap,
 		SmDiscBatch:  hc.Sync.DiscBatch,
+		RateLimit: syncproto.RateLimitConfig{
+			GlobalRate:        hc.Sync.RateLimit,
+			StreamRate:        hc.Sync.StreamRateLimit,
+			ClientRate:        hc.Sync.ClientRateLimit,
+			ResponseBytesRate: hc.Sync.ResponseBytesLimit,
+			Costs: syncproto.RequestCosts{
+				GetBlockNumber:    hc.Sync.CostBlockNumber,
+				GetBlockHashes:    hc.Sync.CostBlockHashes,
+				GetBlocksByNumber: hc.Sync.CostBlocksByNumber,
+				GetBlocksByHashes: hc.Sync.CostBlocksByHashes,
+			},
+		},
+	}
+	if hc.Sync.Checkpoint != "" {
+		dConfig.Checkpoint = &downloader.CheckpointConfig{
+			ShardID: node.Blockchain().ShardID(),
+			Hash:    ethCommon.HexToHash(hc.Sync.Checkpoint),
+			State:   hc.Sync.CheckpointState,
+		}
+	}
+	if hc.Sync.BeaconCheckpoint != "" && !node.IsRunningBeaconChain() {
+		dConfig.BeaconCheckpoint = &downloader.CheckpointConfig{
+			ShardID: shard.BeaconChainShardID,
+			Hash:    ethCommon.HexToHash(hc.Sync.BeaconCheckpoint),
+			State:   hc.Sync.BeaconCheckpointState,
+		}
 	}
 	// If we are running side chain, we will need to do some extra works for beacon
 	// sync
@@ -741,6 +850,23 @@ func setupSyncService(node *node.Node, host p2p.Host, hc harmonyConfig) {
 
 	d := s.Downloaders.GetShardDownloader(node.Blockchain().ShardID())
 	node.Consensus.SetDownloader(d)
+
+	if hc.Sync.LightServer {
+		for _, bc := range blockchains {
+			host.AddStreamProtocol(lightproto.NewProtocol(lightproto.Config{
+				Chain:     bc,
+				Host:      host.GetP2PHost(),
+				Discovery: host.GetDiscovery(),
+				ShardID:   nodeconfig.ShardID(bc.ShardID()),
+				Network:   nodeconfig.NetworkType(hc.Network.NetworkType),
+
+				SmSoftLowCap: hc.Sync.DiscSoftLowCap,
+				SmHardLowCap: hc.Sync.DiscHardLowCap,
+				SmHiCap:      hc.Sync.DiscHighCap,
+				DiscBatch:    hc.Sync.DiscBatch,
+			}))
+		}
+	}
 }
 
 func setupBlacklist(hc harmonyConfig) (map[ethCommon.Address]struct{}, error) {
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/harmony-one/harmony/internal/cli"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/node/msgrecord"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [record_file...]",
+	Short: "replay recorded consensus messages into the consensus offline",
+	Long: `replay the consensus messages recorded with --log.msgrecord.file into the consensus of
+a node set up with the same config and data directory, without joining the network.
+Stop the node before, or replay on a copy of its data directory. The rotated record files
+shall be given in the order they were written.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runReplay,
+}
+
+var replaySpeedFlag = cli.Float64Flag{
+	Name:     "replay.speed",
+	Usage:    "replay the records at the given positive multiple of the recorded pace, e.g. 0.5 for half the pace, all at once if not set",
+	DefValue: 0,
+}
+
+func registerReplayFlags() error {
+	return cli.RegisterFlags(replayCmd, append(getRootFlags(), replaySpeedFlag))
+}
+
+func runReplay(cmd *cobra.Command, args []string) {
+	if err := prepareRootCmd(cmd); err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(128)
+	}
+	var speed float64
+	if cli.IsFlagChanged(cmd, replaySpeedFlag) {
+		if speed = cli.GetFloat64FlagValue(cmd, replaySpeedFlag); speed <= 0 {
+			fmt.Fprintf(os.Stderr, "invalid --%v %v: shall be positive\n", replaySpeedFlag.Name, speed)
+			os.Exit(128)
+		}
+	}
+	cfg, err := getHarmonyConfig(cmd)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		cmd.Help()
+		os.Exit(128)
+	}
+	// keep the node off the network, and do not record the messages again
+	cfg.General.IsOffline = true
+	cfg.P2P.IP = nodeconfig.DefaultLocalListenIP
+	cfg.P2P.ListenAddrs, cfg.P2P.AnnounceAddrs = nil, nil
+	cfg.Log.MsgRecordFile = ""
+
+	setupNodeLog(cfg)
+	currentNode, _ := setupNode(cfg)
+
+	reader := msgrecord.NewReader(args...)
+	defer reader.Close()
+	stats, err := msgrecord.Replay(context.Background(), currentNode.Consensus,
+		currentNode.Consensus.ShardID, reader, speed)
+	fmt.Printf("replayed %d consensus messages of %d records (%d skipped, %d failed)\n",
+		stats.Replayed, stats.Records, stats.Skipped, stats.Failed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay stopped: %v\n", err)
+		os.Exit(1)
+	}
+}
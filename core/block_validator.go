@@ -17,16 +17,13 @@
 package core
 
 import (
-	"bytes"
-	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/harmony-one/harmony/block"
 	consensus_engine "github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core/cxproof"
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/params"
@@ -191,57 +188,9 @@ func (v *BlockValidator) ValidateCXReceiptsProof(cxp *types.CXReceiptsProof) err
 		return errors.New("[ValidateCXReceiptsProof] cross shard receipt received before cx fork")
 	}
 
-	toShardID, err := cxp.GetToShardID()
-	if err != nil {
-		return errors.Wrapf(err, "[ValidateCXReceiptsProof] invalid shardID")
-	}
-
-	merkleProof := cxp.MerkleProof
-	shardRoot := common.Hash{}
-	foundMatchingShardID := false
-	byteBuffer := bytes.Buffer{}
-
-	// prepare to calculate source shard outgoing cxreceipts root hash
-	for j := 0; j < len(merkleProof.ShardIDs); j++ {
-		sKey := make([]byte, 4)
-		binary.BigEndian.PutUint32(sKey, merkleProof.ShardIDs[j])
-		byteBuffer.Write(sKey)
-		byteBuffer.Write(merkleProof.CXShardHashes[j][:])
-		if merkleProof.ShardIDs[j] == toShardID {
-			shardRoot = merkleProof.CXShardHashes[j]
-			foundMatchingShardID = true
-		}
-	}
-
-	if !foundMatchingShardID {
-		return errors.New("[ValidateCXReceiptsProof] Didn't find matching toShardID (no receipts for my shard)")
-	}
-
-	sha := types.DeriveSha(cxp.Receipts)
-	// (1) verify the CXReceipts trie root match
-	if sha != shardRoot {
-		return errors.New(
-			"[ValidateCXReceiptsProof] Trie Root of ReadCXReceipts Not Match",
-		)
-	}
-
-	// (2) verify the outgoingCXReceiptsHash match
-	outgoingHashFromSourceShard := crypto.Keccak256Hash(byteBuffer.Bytes())
-	if byteBuffer.Len() == 0 {
-		outgoingHashFromSourceShard = types.EmptyRootHash
-	}
-	if outgoingHashFromSourceShard != merkleProof.CXReceiptHash {
-		return errors.New(
-			"[ValidateCXReceiptsProof] IncomingReceiptRootHash from source shard not match",
-		)
-	}
-
-	// (3) verify the block hash matches
-	if cxp.Header.Hash() != merkleProof.BlockHash ||
-		cxp.Header.OutgoingReceiptHash() != merkleProof.CXReceiptHash {
-		return errors.New(
-			"[ValidateCXReceiptsProof] BlockHash or OutgoingReceiptHash not match in block Header",
-		)
+	// (1) - (3) verify the receipts are the outgoing receipts of the block header
+	if err := cxproof.VerifyMerkleProof(cxp); err != nil {
+		return errors.Wrap(err, "[ValidateCXReceiptsProof]")
 	}
 
 	// (4) verify blockHeader with seal
@@ -170,6 +170,7 @@ type BlockChain struct {
 	shouldPreserve         func(*types.Block) bool // Function used to determine whether should preserve the given block.
 	pendingSlashes         slash.Records
 	maxGarbCollectedBlkNum int64
+	signingIndex           *signingIndexBatch // validator signing outcomes not written to db yet
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -846,6 +847,10 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	if err := bc.writeSigningIndex(); err != nil {
+		utils.Logger().Error().Err(err).Msg("Failed to write validator signing index")
+	}
+
 	// Ensure the state of a recent block is also stored to disk before exiting.
 	// We're writing three different states to catch different restart scenarios:
 	//  - HEAD:     So we don't need to reprocess any blocks in the general case
@@ -2267,8 +2272,12 @@ func (bc *BlockChain) WriteCXReceiptsProofSpent(db rawdb.DatabaseWriter, cxps []
 
 // IsSpent checks whether a CXReceiptsProof is unspent
 func (bc *BlockChain) IsSpent(cxp *types.CXReceiptsProof) bool {
-	shardID := cxp.MerkleProof.ShardID
-	blockNum := cxp.MerkleProof.BlockNum.Uint64()
+	return bc.IsCXReceiptsSpent(cxp.MerkleProof.ShardID, cxp.MerkleProof.BlockNum.Uint64())
+}
+
+// IsCXReceiptsSpent checks whether the cross shard receipts of the block of the
+// source shard to this shard are spent
+func (bc *BlockChain) IsCXReceiptsSpent(shardID uint32, blockNum uint64) bool {
 	by, _ := rawdb.ReadCXReceiptsProofSpent(bc.db, shardID, blockNum)
 	return by == rawdb.SpentByte
 }
@@ -2280,6 +2289,14 @@ func (bc *BlockChain) ReadTxLookupEntry(txID common.Hash) (common.Hash, uint64,
 	return rawdb.ReadTxLookupEntry(bc.db, txID)
 }
 
+// ReadCxLookupEntry returns where the cross shard receipt of the given
+// transaction of another shard resides in the chain, as a (block hash, block
+// number, index in cross shard receipt list) triple.
+// returns 0, 0 if not found
+func (bc *BlockChain) ReadCxLookupEntry(txID common.Hash) (common.Hash, uint64, uint64) {
+	return rawdb.ReadCxLookupEntry(bc.db, txID)
+}
+
 // ReadValidatorInformationAt reads staking
 // information of given validatorWrapper at a specific state root
 func (bc *BlockChain) ReadValidatorInformationAt(
@@ -3004,6 +3021,7 @@ func (bc *BlockChain) SuperCommitteeForNextEpoch(
 
 var (
 	leveldbErrSpec         = "leveldb"
+	badgerdbErrSpec        = "badgerdb"
 	tooManyOpenFilesErrStr = "Too many open files"
 )
 
@@ -3017,10 +3035,11 @@ var (
 //  5. OS error when write file (read-only, not enough disk space, ...)
 // Among all the above leveldb errors, only `too many open files` error is known to be recoverable,
 // thus the unrecoverable errors refers to error that is
-//  1. The error is from the lower storage level (from module leveldb)
+//  1. The error is from the lower storage level (from module leveldb, or badgerdb)
 //  2. The error is not too many files error.
 func isUnrecoverableErr(err error) bool {
-	isLeveldbErr := strings.Contains(err.Error(), leveldbErrSpec)
+	isStorageErr := strings.Contains(err.Error(), leveldbErrSpec) ||
+		strings.Contains(err.Error(), badgerdbErrSpec)
 	isTooManyOpenFiles := strings.Contains(err.Error(), tooManyOpenFilesErrStr)
-	return isLeveldbErr && !isTooManyOpenFiles
+	return isStorageErr && !isTooManyOpenFiles
 }
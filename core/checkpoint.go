@@ -0,0 +1,413 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
+	staking "github.com/harmony-one/harmony/staking/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrCheckpointNotEpochBlock is returned when the checkpoint block is not
+	// the last block of an epoch
+	ErrCheckpointNotEpochBlock = errors.New("checkpoint is not the last block of an epoch")
+	// ErrCheckpointStateMissing is returned when the state of the checkpoint
+	// block is not in the database
+	ErrCheckpointStateMissing = errors.New("state of the checkpoint block not found")
+	// ErrCheckpointOffChainMissing is returned when the checkpoint block is a
+	// beacon block of the staking era given without its off-chain staking data
+	ErrCheckpointOffChainMissing = errors.New("off-chain staking data of the beacon checkpoint not found")
+)
+
+// CheckpointOffChain is the off-chain staking data of a beacon checkpoint block
+// of the staking era. It is built by the beacon chain from the whole history,
+// and is needed by the chain started from the checkpoint to process the next
+// blocks, so it is carried by the state snapshot of the checkpoint.
+type CheckpointOffChain struct {
+	Validators []common.Address
+	// snapshots of the validators at the epoch of the checkpoint and the next one
+	Snapshots   []staking.ValidatorSnapshot
+	Delegations []CheckpointDelegations
+	// block reward accumulated up to the checkpoint block
+	BlockReward *big.Int
+	// last crosslink of each shard
+	CrossLinks []types.CrossLink
+}
+
+// CheckpointDelegations is the delegation indexes of a delegator
+type CheckpointDelegations struct {
+	Delegator common.Address
+	Indexes   staking.DelegationIndexes
+}
+
+// ReadCheckpointOffChain reads the off-chain staking data of the checkpoint
+// block from the database of the chain, nil if the block is not a beacon
+// block of the staking era. The state of the block must be in the database.
+func ReadCheckpointOffChain(
+	db ethdb.Database, config *params.ChainConfig, header *block.Header,
+) (*CheckpointOffChain, error) {
+	if header.ShardID() != shard.BeaconChainShardID || !config.IsPreStaking(header.Epoch()) {
+		return nil, nil
+	}
+	stateDB, err := state.New(header.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, ErrCheckpointStateMissing
+	}
+	validators, err := rawdb.ReadValidatorList(db)
+	if err != nil {
+		return nil, err
+	}
+	offChain := &CheckpointOffChain{Validators: validators}
+
+	var (
+		epochs     = []*big.Int{header.Epoch(), new(big.Int).Add(header.Epoch(), common.Big1)}
+		delegators []common.Address
+		seen       = map[common.Address]struct{}{}
+	)
+	for _, addr := range validators {
+		for _, epoch := range epochs {
+			if snapshot, err := rawdb.ReadValidatorSnapshot(db, addr, epoch); err == nil && snapshot != nil {
+				offChain.Snapshots = append(offChain.Snapshots, *snapshot)
+			}
+		}
+		wrapper, err := stateDB.ValidatorWrapper(addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read validator %v", addr.Hex())
+		}
+		for _, delegation := range wrapper.Delegations {
+			if _, ok := seen[delegation.DelegatorAddress]; !ok {
+				seen[delegation.DelegatorAddress] = struct{}{}
+				delegators = append(delegators, delegation.DelegatorAddress)
+			}
+		}
+	}
+	for _, delegator := range delegators {
+		indexes, err := rawdb.ReadDelegationsByDelegator(db, delegator)
+		if err != nil {
+			return nil, err
+		}
+		var atBlock staking.DelegationIndexes
+		for _, index := range indexes {
+			if index.BlockNum.Cmp(header.Number()) <= 0 {
+				atBlock = append(atBlock, index)
+			}
+		}
+		offChain.Delegations = append(offChain.Delegations, CheckpointDelegations{
+			Delegator: delegator,
+			Indexes:   atBlock,
+		})
+	}
+
+	if config.IsStaking(header.Epoch()) {
+		reward, err := rawdb.ReadBlockRewardAccumulator(db, header.Number().Uint64())
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read block reward accumulator")
+		}
+		offChain.BlockReward = reward
+	}
+	if config.IsCrossLink(header.Epoch()) {
+		ss, err := shard.DecodeWrapper(header.ShardState())
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode shard state of checkpoint")
+		}
+		for _, committee := range ss.Shards {
+			if committee.ShardID == shard.BeaconChainShardID {
+				continue
+			}
+			data, err := rawdb.ReadShardLastCrossLink(db, committee.ShardID)
+			if err != nil {
+				// no crosslink of the shard yet
+				continue
+			}
+			cl, err := types.DeserializeCrossLink(data)
+			if err != nil {
+				return nil, err
+			}
+			offChain.CrossLinks = append(offChain.CrossLinks, *cl)
+		}
+	}
+	return offChain, nil
+}
+
+// InitFromCheckpoint makes the checkpoint block the head of the chain, without
+// its ancestors, so that the chain is synced from the checkpoint instead of the
+// genesis block. The checkpoint is the last block of an epoch, trusted by its
+// hash, whose shard state gives the committee verifying the blocks of the next
+// epoch. Its state must be in the database already, imported from a snapshot.
+// The history before the checkpoint is written afterwards by WriteBackfillBlocks.
+//
+// A beacon checkpoint of the staking era needs its off-chain staking data, read
+// from the chain it is exported from by ReadCheckpointOffChain. The validators
+// and delegation indexes are checked against the state of the checkpoint, the
+// rest is trusted with the state snapshot carrying it.
+func (bc *BlockChain) InitFromCheckpoint(block *types.Block, offChain *CheckpointOffChain) error {
+	header := block.Header()
+	if !header.IsLastBlockInEpoch() {
+		return ErrCheckpointNotEpochBlock
+	}
+	isBeaconStaking := header.ShardID() == shard.BeaconChainShardID &&
+		bc.chainConfig.IsPreStaking(header.Epoch())
+	if isBeaconStaking && offChain == nil {
+		return ErrCheckpointOffChainMissing
+	}
+	if hash := types.DeriveSha(
+		block.Transactions(),
+		block.StakingTransactions(),
+	); hash != header.TxHash() {
+		return errors.Errorf("checkpoint transaction root hash mismatch: have %x, want %x",
+			hash, header.TxHash())
+	}
+	stateDB, err := state.New(header.Root(), bc.stateCache)
+	if err != nil {
+		return ErrCheckpointStateMissing
+	}
+	if isBeaconStaking {
+		if err := offChain.verify(stateDB, header); err != nil {
+			return errors.Wrap(err, "invalid off-chain staking data of checkpoint")
+		}
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if current := bc.CurrentBlock(); current.NumberU64() >= block.NumberU64() {
+		return errors.Errorf("chain at block %v already past the checkpoint block %v",
+			current.NumberU64(), block.NumberU64())
+	}
+	nextBlockEpoch, err := bc.getNextBlockEpoch(header)
+	if err != nil {
+		return err
+	}
+
+	batch := bc.db.NewBatch()
+	if err := rawdb.WriteBlock(batch, block); err != nil {
+		return err
+	}
+	if _, err := bc.WriteShardStateBytes(batch, nextBlockEpoch, header.ShardState()); err != nil {
+		return errors.Wrap(err, "cannot store shard state of checkpoint")
+	}
+	if isBeaconStaking {
+		if err := bc.writeCheckpointOffChain(batch, header, offChain); err != nil {
+			return errors.Wrap(err, "cannot store off-chain staking data of checkpoint")
+		}
+	}
+	if err := rawdb.WriteCheckpointHash(batch, block.Hash()); err != nil {
+		return err
+	}
+	if err := rawdb.WriteBackfillTail(batch, block.NumberU64()); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if err := bc.writeHeadBlock(block); err != nil {
+		return errors.Wrap(err, "writeHeadBlock")
+	}
+
+	utils.Logger().Info().
+		Uint64("number", block.NumberU64()).
+		Str("hash", block.Hash().Hex()).
+		Uint64("nextEpoch", nextBlockEpoch.Uint64()).
+		Msg("Chain initialized from checkpoint")
+	return nil
+}
+
+// verify checks the off-chain staking data of the checkpoint block against its
+// state: the validators must be in the state, with the delegations of the
+// delegation indexes, and the snapshots must be of validators of the list at
+// the epoch of the checkpoint or the next one
+func (offChain *CheckpointOffChain) verify(stateDB *state.DB, header *block.Header) error {
+	validators := make(map[common.Address]struct{}, len(offChain.Validators))
+	for _, addr := range offChain.Validators {
+		if !stateDB.IsValidator(addr) {
+			return errors.Errorf("validator %v not in the state", addr.Hex())
+		}
+		validators[addr] = struct{}{}
+	}
+	nextEpoch := new(big.Int).Add(header.Epoch(), common.Big1)
+	for _, snapshot := range offChain.Snapshots {
+		if snapshot.Validator == nil || snapshot.Epoch == nil {
+			return errors.New("empty validator snapshot")
+		}
+		if _, ok := validators[snapshot.Validator.Address]; !ok {
+			return errors.Errorf("snapshot of validator %v not in the list", snapshot.Validator.Address.Hex())
+		}
+		if snapshot.Epoch.Cmp(header.Epoch()) != 0 && snapshot.Epoch.Cmp(nextEpoch) != 0 {
+			return errors.Errorf("snapshot of validator %v at unexpected epoch %v",
+				snapshot.Validator.Address.Hex(), snapshot.Epoch)
+		}
+	}
+	for _, delegations := range offChain.Delegations {
+		for _, index := range delegations.Indexes {
+			wrapper, err := stateDB.ValidatorWrapper(index.ValidatorAddress)
+			if err != nil {
+				return errors.Wrapf(err, "validator %v of delegation index", index.ValidatorAddress.Hex())
+			}
+			if index.Index >= uint64(len(wrapper.Delegations)) ||
+				wrapper.Delegations[index.Index].DelegatorAddress != delegations.Delegator ||
+				index.BlockNum == nil || index.BlockNum.Cmp(header.Number()) > 0 {
+				return errors.Errorf("delegation index %v of delegator %v to validator %v not in the state",
+					index.Index, delegations.Delegator.Hex(), index.ValidatorAddress.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// writeCheckpointOffChain writes the off-chain staking data of the checkpoint
+// block
+func (bc *BlockChain) writeCheckpointOffChain(
+	batch rawdb.DatabaseWriter, header *block.Header, offChain *CheckpointOffChain,
+) error {
+	if err := bc.WriteValidatorList(batch, offChain.Validators); err != nil {
+		return err
+	}
+	for i := range offChain.Snapshots {
+		if err := bc.WriteValidatorSnapshot(batch, &offChain.Snapshots[i]); err != nil {
+			return err
+		}
+	}
+	for _, delegations := range offChain.Delegations {
+		if err := bc.writeDelegationsByDelegator(batch, delegations.Delegator, delegations.Indexes); err != nil {
+			return err
+		}
+	}
+	if bc.chainConfig.IsStaking(header.Epoch()) {
+		if offChain.BlockReward == nil {
+			return errors.New("block reward accumulator not found")
+		}
+		if err := bc.WriteBlockRewardAccumulator(batch, offChain.BlockReward, header.Number().Uint64()); err != nil {
+			return err
+		}
+	}
+	for _, cl := range offChain.CrossLinks {
+		if err := bc.WriteCrossLinks(batch, types.CrossLinks{cl}); err != nil {
+			return err
+		}
+		if err := rawdb.WriteShardLastCrossLink(batch, cl.ShardID(), cl.Serialize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckpointHash returns the hash of the checkpoint block the chain was
+// started from, or the zero hash if the chain was synced from the genesis block
+func (bc *BlockChain) CheckpointHash() common.Hash {
+	return rawdb.ReadCheckpointHash(bc.db)
+}
+
+// BackfillTail returns the number of the lowest block of the history written
+// down from the checkpoint block. The second return value is false if the
+// history is complete, or the chain was not started from a checkpoint.
+func (bc *BlockChain) BackfillTail() (uint64, bool) {
+	tail, ok := rawdb.ReadBackfillTail(bc.db)
+	if !ok || tail <= 1 {
+		return tail, false
+	}
+	return tail, true
+}
+
+// WriteBackfillBlocks writes the blocks before the backfill tail, without
+// their state, receipts and off-chain data, and returns the number of blocks
+// written. The blocks are given in ascending order and must end right before
+// the tail. Each block is verified by the parent hash of its child, down from
+// the trusted checkpoint block, and by the transactions root of its header.
+func (bc *BlockChain) WriteBackfillBlocks(blocks types.Blocks) (int, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	tail, ok := rawdb.ReadBackfillTail(bc.db)
+	if !ok || tail <= 1 {
+		return 0, errors.New("no history to backfill")
+	}
+	child := bc.GetHeaderByNumber(tail)
+	if child == nil {
+		return 0, errors.Errorf("header of backfill tail %v not found", tail)
+	}
+
+	var (
+		batch      = bc.db.NewBatch()
+		parentHash = child.ParentHash()
+		written    int
+	)
+	for i := len(blocks) - 1; i >= 0 && tail > 1; i-- {
+		block := blocks[i]
+		if block == nil || block.NumberU64() != tail-1 {
+			return 0, errors.Errorf("block %v not found", tail-1)
+		}
+		if block.Hash() != parentHash {
+			return 0, errors.Errorf("block %v hash %v not matching the parent hash %v",
+				block.NumberU64(), block.Hash().Hex(), parentHash.Hex())
+		}
+		if hash := types.DeriveSha(
+			block.Transactions(),
+			block.StakingTransactions(),
+		); hash != block.Header().TxHash() {
+			return 0, errors.Errorf("block %v transaction root hash mismatch: have %x, want %x",
+				block.NumberU64(), hash, block.Header().TxHash())
+		}
+		if err := bc.writeBackfillBlock(batch, block, child); err != nil {
+			return 0, err
+		}
+		child = block.Header()
+		parentHash = block.ParentHash()
+		tail--
+		written++
+	}
+	if tail == 1 && parentHash != bc.genesisBlock.Hash() {
+		return 0, errors.Errorf("history of the checkpoint not matching the genesis block %v",
+			bc.genesisBlock.Hash().Hex())
+	}
+	if err := rawdb.WriteBackfillTail(batch, tail); err != nil {
+		return 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// writeBackfillBlock writes the block as canonical, with its transaction
+// lookups, its commit signature from the child header, and the shard state of
+// the next epoch if it is the last block of an epoch
+func (bc *BlockChain) writeBackfillBlock(batch rawdb.DatabaseWriter, block *types.Block, child *block.Header) error {
+	if err := rawdb.WriteBlock(batch, block); err != nil {
+		return err
+	}
+	if err := rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64()); err != nil {
+		return err
+	}
+	if err := rawdb.WriteBlockTxLookUpEntries(batch, block); err != nil {
+		return err
+	}
+	if err := rawdb.WriteBlockStxLookUpEntries(batch, block); err != nil {
+		return err
+	}
+	if err := rawdb.WriteCxLookupEntries(batch, block); err != nil {
+		return err
+	}
+	sig := child.LastCommitSignature()
+	if err := rawdb.WriteBlockCommitSig(batch, block.NumberU64(), append(sig[:], child.LastCommitBitmap()...)); err != nil {
+		return err
+	}
+	if block.IsLastBlockInEpoch() {
+		nextBlockEpoch, err := bc.getNextBlockEpoch(block.Header())
+		if err != nil {
+			return err
+		}
+		if _, err := bc.WriteShardStateBytes(batch, nextBlockEpoch, block.Header().ShardState()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	harmonyrawdb "github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/state/snapshot"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/crypto/bls"
+	chain2 "github.com/harmony-one/harmony/internal/chain"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/shard"
+	staking "github.com/harmony-one/harmony/staking/types"
+)
+
+// checkpointTestIndex is the index of the checkpoint block in the test chain,
+// the last block of epoch 0
+const checkpointTestIndex = 4
+
+// checkpointTestConfig is the config of the test chain, before the staking era
+var checkpointTestConfig = func() *params.ChainConfig {
+	config := *params.TestChainConfig
+	config.PreStakingEpoch = big.NewInt(10)
+	config.StakingEpoch = big.NewInt(10)
+	return &config
+}()
+
+var (
+	checkpointTestValidator = common.Address{2}
+	checkpointTestDelegator = common.Address{3}
+)
+
+// makeCheckpointTestChain makes the empty blocks of the test chain, all with
+// the state of an account and a validator added to the genesis state, and the
+// commit sig of the parent marked with its number
+func makeCheckpointTestChain(
+	t *testing.T, config *params.ChainConfig, extra []byte,
+) (*Genesis, ethdb.Database, []*types.Block) {
+	gspec := &Genesis{
+		Config:   config,
+		Factory:  blockfactory.ForTest,
+		GasLimit: 1e18,
+		ShardID:  0,
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(db))
+	statedb.AddBalance(common.Address{1}, big.NewInt(1))
+	wrapper, err := rlp.EncodeToBytes(makeCheckpointTestValidator())
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.SetCode(checkpointTestValidator, wrapper)
+	statedb.SetValidatorFlag(checkpointTestValidator)
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := statedb.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	ss, err := shard.EncodeWrapper(shard.State{
+		Epoch:  big.NewInt(1),
+		Shards: []shard.Committee{{ShardID: 0}, {ShardID: 1}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []*types.Block
+	parent := genesis
+	for i := 0; i < 8; i++ {
+		var sig bls.SerializedSignature
+		sig[0] = byte(parent.NumberU64())
+		hb := blockfactory.ForTest.NewHeader(common.Big0).With().
+			Number(big.NewInt(int64(i + 1))).
+			ParentHash(parent.Hash()).
+			Root(root).
+			Extra(extra).
+			LastCommitSignature(sig).
+			LastCommitBitmap([]byte{0x01})
+		if i == checkpointTestIndex {
+			hb = hb.ShardState(ss)
+		}
+		block := types.NewBlock(hb.Header(), nil, nil, nil, nil, nil)
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return gspec, db, blocks
+}
+
+// makeCheckpointTestValidator makes the validator of the test chain, with a
+// self delegation and a delegation of the test delegator
+func makeCheckpointTestValidator() *staking.ValidatorWrapper {
+	return &staking.ValidatorWrapper{
+		Validator: staking.Validator{
+			Address:        checkpointTestValidator,
+			CreationHeight: big.NewInt(0),
+		},
+		Delegations: staking.Delegations{
+			staking.NewDelegation(checkpointTestValidator, big.NewInt(1)),
+			staking.NewDelegation(checkpointTestDelegator, big.NewInt(1)),
+		},
+		BlockReward: big.NewInt(0),
+	}
+}
+
+func newCheckpointTestBlockChain(t *testing.T, gspec *Genesis, db ethdb.Database) *BlockChain {
+	bc, err := NewBlockChain(db, nil, gspec.Config, chain2.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc
+}
+
+func TestInitFromCheckpoint(t *testing.T) {
+	gspec, srcDB, blocks := makeCheckpointTestChain(t, checkpointTestConfig, nil)
+	checkpoint := blocks[checkpointTestIndex]
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	bc := newCheckpointTestBlockChain(t, gspec, db)
+
+	if err := bc.InitFromCheckpoint(checkpoint, nil); err != ErrCheckpointStateMissing {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := snapshot.Export(srcDB, checkpoint.Root(), nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := snapshot.Import(db, checkpoint.Root(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.InitFromCheckpoint(blocks[checkpointTestIndex-1], nil); err != ErrCheckpointNotEpochBlock {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := bc.InitFromCheckpoint(checkpoint, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if current := bc.CurrentBlock(); current.Hash() != checkpoint.Hash() {
+		t.Errorf("unexpected current block %v", current.NumberU64())
+	}
+	if current := bc.CurrentHeader(); current.Hash() != checkpoint.Hash() {
+		t.Errorf("unexpected current header %v", current.Number())
+	}
+	if hash := bc.CheckpointHash(); hash != checkpoint.Hash() {
+		t.Errorf("unexpected checkpoint hash %v", hash.Hex())
+	}
+	if tail, ok := bc.BackfillTail(); !ok || tail != checkpoint.NumberU64() {
+		t.Errorf("unexpected backfill tail %v, %v", tail, ok)
+	}
+	if _, err := bc.ReadShardState(big.NewInt(1)); err != nil {
+		t.Errorf("shard state of the next epoch not found: %v", err)
+	}
+	if bc.GetBlockByNumber(checkpoint.NumberU64()-1) != nil {
+		t.Errorf("unexpected block before the checkpoint")
+	}
+	if err := bc.InitFromCheckpoint(checkpoint, nil); err == nil {
+		t.Errorf("unexpected checkpoint at the current block")
+	}
+
+	// the checkpoint is kept as the head on restart
+	bc.Stop()
+	bc = newCheckpointTestBlockChain(t, gspec, db)
+	if current := bc.CurrentBlock(); current.Hash() != checkpoint.Hash() {
+		t.Errorf("unexpected current block %v after restart", current.NumberU64())
+	}
+}
+
+func TestInitFromCheckpointBeaconStaking(t *testing.T) {
+	gspec, srcDB, blocks := makeCheckpointTestChain(t, params.TestChainConfig, nil)
+	checkpoint := blocks[checkpointTestIndex]
+
+	// off-chain staking data of the source chain
+	wrapper := makeCheckpointTestValidator()
+	cl := types.CrossLink{BlockNumberF: big.NewInt(20), ShardIDF: 1, EpochF: big.NewInt(0), ViewIDF: big.NewInt(20)}
+	writes := []error{
+		harmonyrawdb.WriteValidatorList(srcDB, []common.Address{checkpointTestValidator}),
+		harmonyrawdb.WriteValidatorSnapshot(srcDB, wrapper, big.NewInt(0)),
+		harmonyrawdb.WriteValidatorSnapshot(srcDB, wrapper, big.NewInt(1)),
+		harmonyrawdb.WriteDelegationsByDelegator(srcDB, checkpointTestValidator, staking.DelegationIndexes{
+			{ValidatorAddress: checkpointTestValidator, Index: 0, BlockNum: big.NewInt(1)},
+		}),
+		harmonyrawdb.WriteDelegationsByDelegator(srcDB, checkpointTestDelegator, staking.DelegationIndexes{
+			{ValidatorAddress: checkpointTestValidator, Index: 1, BlockNum: big.NewInt(2)},
+			// delegation after the checkpoint
+			{ValidatorAddress: common.Address{4}, Index: 0, BlockNum: big.NewInt(7)},
+		}),
+		harmonyrawdb.WriteBlockRewardAccumulator(srcDB, big.NewInt(100), checkpoint.NumberU64()),
+		harmonyrawdb.WriteShardLastCrossLink(srcDB, 1, cl.Serialize()),
+	}
+	for _, err := range writes {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	offChain, err := ReadCheckpointOffChain(srcDB, params.TestChainConfig, checkpoint.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offChain.Validators) != 1 || len(offChain.Snapshots) != 2 ||
+		len(offChain.Delegations) != 2 || len(offChain.Delegations[1].Indexes) != 1 ||
+		offChain.BlockReward.Int64() != 100 || len(offChain.CrossLinks) != 1 {
+		t.Fatalf("unexpected off-chain data %+v", offChain)
+	}
+	data, err := rlp.EncodeToBytes(offChain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	var buf bytes.Buffer
+	if _, err := snapshot.Export(srcDB, checkpoint.Root(), data, &buf); err != nil {
+		t.Fatal(err)
+	}
+	_, data, err = snapshot.Import(db, checkpoint.Root(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := new(CheckpointOffChain)
+	if err := rlp.DecodeBytes(data, imported); err != nil {
+		t.Fatal(err)
+	}
+	bc := newCheckpointTestBlockChain(t, gspec, db)
+	if err := bc.InitFromCheckpoint(checkpoint, nil); err != ErrCheckpointOffChainMissing {
+		t.Fatalf("unexpected error %v", err)
+	}
+	invalid := *imported
+	invalid.Validators = append([]common.Address{{5}}, invalid.Validators...)
+	if err := bc.InitFromCheckpoint(checkpoint, &invalid); err == nil {
+		t.Fatalf("unexpected checkpoint with a validator not in the state")
+	}
+	invalid = *imported
+	invalid.Delegations = []CheckpointDelegations{{
+		Delegator: checkpointTestDelegator,
+		Indexes:   staking.DelegationIndexes{{ValidatorAddress: checkpointTestValidator, Index: 0, BlockNum: big.NewInt(2)}},
+	}}
+	if err := bc.InitFromCheckpoint(checkpoint, &invalid); err == nil {
+		t.Fatalf("unexpected checkpoint with a delegation index not in the state")
+	}
+	if current := bc.CurrentBlock(); current.NumberU64() != 0 {
+		t.Errorf("unexpected current block %v", current.NumberU64())
+	}
+	if err := bc.InitFromCheckpoint(checkpoint, imported); err != nil {
+		t.Fatal(err)
+	}
+
+	if current := bc.CurrentBlock(); current.Hash() != checkpoint.Hash() {
+		t.Errorf("unexpected current block %v", current.NumberU64())
+	}
+	if validators, err := bc.ReadValidatorList(); err != nil || len(validators) != 1 || validators[0] != checkpointTestValidator {
+		t.Errorf("unexpected validators %v: %v", validators, err)
+	}
+	for _, epoch := range []int64{0, 1} {
+		snapshot, err := bc.ReadValidatorSnapshotAtEpoch(big.NewInt(epoch), checkpointTestValidator)
+		if err != nil || snapshot == nil || len(snapshot.Validator.Delegations) != 2 {
+			t.Errorf("unexpected snapshot at epoch %v: %v", epoch, err)
+		}
+	}
+	if indexes, err := bc.ReadDelegationsByDelegator(checkpointTestDelegator); err != nil ||
+		len(indexes) != 1 || indexes[0].Index != 1 {
+		t.Errorf("unexpected delegation indexes %+v: %v", indexes, err)
+	}
+	if reward, err := bc.ReadBlockRewardAccumulator(checkpoint.NumberU64()); err != nil || reward.Int64() != 100 {
+		t.Errorf("unexpected block reward accumulator %v: %v", reward, err)
+	}
+	if last, err := bc.ReadShardLastCrossLink(1); err != nil || last.BlockNum() != 20 {
+		t.Errorf("unexpected last crosslink %v: %v", last, err)
+	}
+	if _, err := bc.ReadCrossLink(1, 20); err != nil {
+		t.Errorf("last crosslink not found: %v", err)
+	}
+}
+
+func TestWriteBackfillBlocks(t *testing.T) {
+	gspec, srcDB, blocks := makeCheckpointTestChain(t, checkpointTestConfig, nil)
+	_, _, others := makeCheckpointTestChain(t, checkpointTestConfig, []byte("other"))
+	checkpoint := blocks[checkpointTestIndex]
+
+	db := rawdb.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	bc := newCheckpointTestBlockChain(t, gspec, db)
+	if _, err := bc.WriteBackfillBlocks(blocks[:checkpointTestIndex]); err == nil {
+		t.Errorf("unexpected backfill without checkpoint")
+	}
+	var buf bytes.Buffer
+	if _, err := snapshot.Export(srcDB, checkpoint.Root(), nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := snapshot.Import(db, checkpoint.Root(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.InitFromCheckpoint(checkpoint, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []types.Blocks{
+		blocks[:checkpointTestIndex-1],                                 // not ending before the tail
+		{blocks[0], blocks[2], blocks[3]},                              // gap
+		{blocks[1], others[2], blocks[3]},                              // not the parent of its child
+		{others[checkpointTestIndex-2], others[checkpointTestIndex-1]}, // not the parent of the checkpoint
+	}
+	for i, bs := range invalid {
+		if _, err := bc.WriteBackfillBlocks(bs); err == nil {
+			t.Errorf("Test %v: unexpected backfill", i)
+		}
+		if tail, _ := bc.BackfillTail(); tail != checkpoint.NumberU64() {
+			t.Errorf("Test %v: unexpected backfill tail %v", i, tail)
+		}
+	}
+
+	if n, err := bc.WriteBackfillBlocks(blocks[2:checkpointTestIndex]); err != nil || n != 2 {
+		t.Fatalf("unexpected backfill %v: %v", n, err)
+	}
+	if tail, ok := bc.BackfillTail(); !ok || tail != 3 {
+		t.Errorf("unexpected backfill tail %v, %v", tail, ok)
+	}
+	if n, err := bc.WriteBackfillBlocks(blocks[:2]); err != nil || n != 2 {
+		t.Fatalf("unexpected backfill %v: %v", n, err)
+	}
+	if tail, ok := bc.BackfillTail(); ok || tail != 1 {
+		t.Errorf("unexpected backfill tail %v, %v", tail, ok)
+	}
+	for _, block := range blocks[:checkpointTestIndex+1] {
+		if b := bc.GetBlockByNumber(block.NumberU64()); b == nil || b.Hash() != block.Hash() {
+			t.Errorf("block %v not backfilled", block.NumberU64())
+		}
+	}
+	child := blocks[2].Header()
+	sig := child.LastCommitSignature()
+	if commitSig, err := bc.ReadCommitSig(2); err != nil || !bytes.Equal(commitSig, append(sig[:], child.LastCommitBitmap()...)) {
+		t.Errorf("unexpected commit sig %x: %v", commitSig, err)
+	}
+	if _, err := bc.WriteBackfillBlocks(blocks[:1]); err == nil {
+		t.Errorf("unexpected backfill of complete history")
+	}
+}
@@ -0,0 +1,367 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CxResendTimeout is the number of blocks of the destination shard, known
+	// final from the beacon crosslinks, after which undelivered receipts are
+	// resent
+	CxResendTimeout = 32
+	// CxMaxResends is the number of resends of undelivered receipts before
+	// they are considered stuck
+	CxMaxResends = 3
+	// CxTrackerSize is the maximum number of tracked receipts of a block to a
+	// destination shard
+	CxTrackerSize = 10000
+)
+
+var (
+	// ErrCxTxNotFound is returned when the cross shard transaction is not in
+	// the chain as either an outgoing transaction or an incoming receipt
+	ErrCxTxNotFound = errors.New("cross shard transaction not found")
+	// ErrNotCrossShardTx is returned when the transaction is not cross shard
+	ErrNotCrossShardTx = errors.New("not a cross shard transaction")
+	// ErrNoCXReceipt is returned when the cross shard transaction has no
+	// cross shard receipt, which is the case of a failed transaction
+	ErrNoCXReceipt = errors.New("no cross shard receipt of the transaction")
+)
+
+// CxStatus is the delivery status of cross shard receipts
+type CxStatus byte
+
+// Delivery statuses of the cross shard receipts
+const (
+	CxPending   CxStatus = iota // not delivered yet, or not known delivered
+	CxDelivered                 // spent by the destination shard
+	CxStuck                     // not delivered after all the resends
+)
+
+func (s CxStatus) String() string {
+	switch s {
+	case CxPending:
+		return "pending"
+	case CxDelivered:
+		return "delivered"
+	case CxStuck:
+		return "stuck"
+	}
+	return "unknown"
+}
+
+// CxChain is the chain read by the CxTracker
+type CxChain interface {
+	ShardID() uint32
+	CurrentBlock() *types.Block
+	GetBlockByHash(hash common.Hash) *types.Block
+	ReadTxLookupEntry(txID common.Hash) (common.Hash, uint64, uint64)
+	ReadCxLookupEntry(txID common.Hash) (common.Hash, uint64, uint64)
+	ReadCXReceipts(shardID uint32, blockNum uint64, blockHash common.Hash) (types.CXReceipts, error)
+	IsCXReceiptsSpent(shardID uint32, blockNum uint64) bool
+	ReadShardLastCrossLink(shardID uint32) (*types.CrossLink, error)
+}
+
+// CxTxStatus is the delivery status of the cross shard receipt of a transaction
+type CxTxStatus struct {
+	Status         CxStatus
+	FromShardID    uint32
+	ToShardID      uint32
+	SourceBlockNum uint64
+	// Block of the destination shard spending the receipt, 0 if not delivered
+	// or not known by the node
+	DestinationBlockNum uint64
+	// Last block of the destination shard known final from the beacon chain,
+	// 0 if not known yet
+	DestinationHeight uint64
+	// Number of automatic resends of the receipt
+	Resends int
+}
+
+// cxRecord is the delivery status of the receipts of a block to a shard
+type cxRecord struct {
+	fromShardID uint32
+	blockNum    uint64
+	// hash of the transaction of one of the receipts, to find the block of
+	// the destination shard spending them
+	txHash common.Hash
+	// height of the destination shard at the tracking or the last resend
+	height  uint64
+	resends int
+	status  CxStatus
+}
+
+// CxTracker tracks the outgoing cross shard receipts of the blocks of the
+// shard until they are spent by the destination shard, and resends them
+// through the CxPool when not delivered in time.
+//
+// The delivery is known from the spent proofs of the chains of the node: the
+// beacon chain for the receipts to the beacon shard. The beacon crosslinks of
+// the other shards carry the headers of their blocks but not the incoming
+// receipts spent by them, so the node cannot tell whether a receipt to another
+// shard was delivered. These receipts are resent on each timeout all the same,
+// which is safe as the destination shard drops the receipts already spent, and
+// are reported pending until all the resends are done, then stuck.
+type CxTracker struct {
+	chain  CxChain
+	beacon CxChain
+	pool   *CxPool
+
+	mu      sync.Mutex
+	records map[CxEntry]*cxRecord
+}
+
+// NewCxTracker creates a new CxTracker of the chain of the shard, reading the
+// crosslinks of the beacon chain and resending through the pool
+func NewCxTracker(chain, beacon CxChain, pool *CxPool) *CxTracker {
+	return &CxTracker{
+		chain:   chain,
+		beacon:  beacon,
+		pool:    pool,
+		records: map[CxEntry]*cxRecord{},
+	}
+}
+
+// Track starts the tracking of the outgoing cross shard receipts of the block
+func (t *CxTracker) Track(block *types.Block) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	done := map[uint32]struct{}{}
+	for _, tx := range block.Transactions() {
+		toShardID := tx.ToShardID()
+		if tx.ShardID() == toShardID {
+			continue
+		}
+		if _, ok := done[toShardID]; ok {
+			continue
+		}
+		done[toShardID] = struct{}{}
+		t.track(block, toShardID)
+	}
+}
+
+// Size returns the number of the tracked receipts of a block to a shard
+func (t *CxTracker) Size() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.records)
+}
+
+// Update checks the delivery of the tracked receipts, and resends the receipts
+// not known delivered within the timeout. The delivered receipts are not
+// tracked anymore.
+func (t *CxTracker) Update() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for entry, rec := range t.records {
+		if _, ok := t.delivered(entry, rec); ok {
+			delete(t.records, entry)
+			continue
+		}
+		if rec.status == CxStuck {
+			continue
+		}
+		height, ok := t.destinationHeight(entry.ToShardID)
+		if !ok {
+			continue
+		}
+		if rec.height == 0 {
+			// destination height unknown when tracked
+			rec.height = height
+			continue
+		}
+		if height < rec.height+CxResendTimeout {
+			continue
+		}
+		if rec.resends >= CxMaxResends {
+			rec.status = CxStuck
+			utils.Logger().Warn().
+				Uint64("blockNum", rec.blockNum).
+				Str("blockHash", entry.BlockHash.Hex()).
+				Uint32("toShardID", entry.ToShardID).
+				Int("resends", rec.resends).
+				Msg("[CxTracker] Cross shard receipts stuck")
+			continue
+		}
+		if !t.pool.Add(entry) {
+			// retried on the next update
+			utils.Logger().Warn().
+				Int("size", t.pool.Size()).
+				Msg("[CxTracker] CxPool full, cannot resend cross shard receipts")
+			continue
+		}
+		rec.resends++
+		rec.height = height
+		utils.Logger().Info().
+			Uint64("blockNum", rec.blockNum).
+			Uint32("toShardID", entry.ToShardID).
+			Int("resends", rec.resends).
+			Msg("[CxTracker] Resending undelivered cross shard receipts")
+	}
+}
+
+// Status returns the delivery status of the cross shard receipt of the
+// transaction, either an incoming receipt spent by this shard or the receipt
+// of an outgoing transaction of this shard
+func (t *CxTracker) Status(txHash common.Hash) (*CxTxStatus, error) {
+	if blockHash, blockNum, index := t.chain.ReadCxLookupEntry(txHash); blockHash != (common.Hash{}) {
+		status := &CxTxStatus{
+			Status:              CxDelivered,
+			ToShardID:           t.chain.ShardID(),
+			DestinationBlockNum: blockNum,
+		}
+		if blk := t.chain.GetBlockByHash(blockHash); blk != nil {
+			for _, cxp := range blk.IncomingReceipts() {
+				if index < uint64(len(cxp.Receipts)) {
+					status.FromShardID = cxp.MerkleProof.ShardID
+					status.SourceBlockNum = cxp.MerkleProof.BlockNum.Uint64()
+					break
+				}
+				index -= uint64(len(cxp.Receipts))
+			}
+		}
+		return status, nil
+	}
+
+	blockHash, _, index := t.chain.ReadTxLookupEntry(txHash)
+	if blockHash == (common.Hash{}) {
+		return nil, ErrCxTxNotFound
+	}
+	blk := t.chain.GetBlockByHash(blockHash)
+	if blk == nil {
+		return nil, ErrCxTxNotFound
+	}
+	txs := blk.Transactions()
+	if int(index) >= len(txs) || txs[index].Hash() != txHash {
+		// a staking transaction
+		return nil, ErrNotCrossShardTx
+	}
+	tx := txs[index]
+	if tx.ShardID() == tx.ToShardID() {
+		return nil, ErrNotCrossShardTx
+	}
+
+	entry := CxEntry{BlockHash: blockHash, ToShardID: tx.ToShardID()}
+	rec := t.record(blk, entry)
+	if rec == nil {
+		return nil, ErrNoCXReceipt
+	}
+	height, _ := t.destinationHeight(entry.ToShardID)
+	status := &CxTxStatus{
+		Status:            rec.status,
+		FromShardID:       rec.fromShardID,
+		ToShardID:         entry.ToShardID,
+		SourceBlockNum:    rec.blockNum,
+		DestinationHeight: height,
+		Resends:           rec.resends,
+	}
+	if destBlockNum, ok := t.delivered(entry, rec); ok {
+		status.Status, status.DestinationBlockNum = CxDelivered, destBlockNum
+	}
+	return status, nil
+}
+
+// record returns a copy of the record of the receipts of the block to the
+// shard, a new record if not tracked. nil is returned if there is no receipt.
+func (t *CxTracker) record(block *types.Block, entry CxEntry) *cxRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rec, ok := t.records[entry]; ok {
+		r := *rec
+		return &r
+	}
+	return t.newRecord(block, entry.ToShardID)
+}
+
+// track returns the record of the receipts of the block to the shard, tracking
+// them if not yet. nil is returned if there is no receipt.
+func (t *CxTracker) track(block *types.Block, toShardID uint32) *cxRecord {
+	entry := CxEntry{BlockHash: block.Hash(), ToShardID: toShardID}
+	if rec, ok := t.records[entry]; ok {
+		return rec
+	}
+	rec := t.newRecord(block, toShardID)
+	if rec == nil {
+		return nil
+	}
+	if len(t.records) >= CxTrackerSize {
+		t.evictOldest()
+	}
+	t.records[entry] = rec
+	return rec
+}
+
+// newRecord returns the untracked record of the receipts of the block to the
+// shard, nil if there is no receipt
+func (t *CxTracker) newRecord(block *types.Block, toShardID uint32) *cxRecord {
+	cxs, err := t.chain.ReadCXReceipts(toShardID, block.NumberU64(), block.Hash())
+	if err != nil || len(cxs) == 0 {
+		return nil
+	}
+	height, _ := t.destinationHeight(toShardID)
+	return &cxRecord{
+		fromShardID: block.ShardID(),
+		blockNum:    block.NumberU64(),
+		txHash:      cxs[0].TxHash,
+		height:      height,
+	}
+}
+
+// destination returns the chain of the shard whose spent proofs are held by
+// the node, nil if the node does not hold the chain of the shard
+func (t *CxTracker) destination(shardID uint32) CxChain {
+	switch shardID {
+	case t.chain.ShardID():
+		return t.chain
+	case t.beacon.ShardID():
+		return t.beacon
+	}
+	return nil
+}
+
+// delivered returns the block of the destination shard spending the receipts
+// of the record, false if they are not known spent
+func (t *CxTracker) delivered(entry CxEntry, rec *cxRecord) (uint64, bool) {
+	dest := t.destination(entry.ToShardID)
+	if dest == nil || !dest.IsCXReceiptsSpent(rec.fromShardID, rec.blockNum) {
+		return 0, false
+	}
+	_, destBlockNum, _ := dest.ReadCxLookupEntry(rec.txHash)
+	return destBlockNum, true
+}
+
+// destinationHeight returns the number of the last block of the shard known
+// final: the head of the beacon chain, or the last crosslink of the shard in
+// the beacon chain
+func (t *CxTracker) destinationHeight(shardID uint32) (uint64, bool) {
+	if shardID == t.beacon.ShardID() {
+		return t.beacon.CurrentBlock().NumberU64(), true
+	}
+	cl, err := t.beacon.ReadShardLastCrossLink(shardID)
+	if err != nil || cl == nil {
+		return 0, false
+	}
+	return cl.BlockNum(), true
+}
+
+// evictOldest stops the tracking of the receipts of the oldest block
+func (t *CxTracker) evictOldest() {
+	var (
+		oldest CxEntry
+		number uint64
+		found  bool
+	)
+	for entry, rec := range t.records {
+		if !found || rec.blockNum < number {
+			oldest, number, found = entry, rec.blockNum, true
+		}
+	}
+	delete(t.records, oldest)
+}
@@ -0,0 +1,326 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/pkg/errors"
+)
+
+type cxLookup struct {
+	hash  common.Hash
+	num   uint64
+	index uint64
+}
+
+type fakeCxChain struct {
+	shardID    uint32
+	head       uint64
+	blocks     map[common.Hash]*types.Block
+	txLookups  map[common.Hash]cxLookup
+	cxLookups  map[common.Hash]cxLookup
+	cxReceipts map[CxEntry]types.CXReceipts
+	spent      map[uint32]map[uint64]bool
+	crossLinks map[uint32]uint64
+}
+
+func newFakeCxChain(shardID uint32) *fakeCxChain {
+	return &fakeCxChain{
+		shardID:    shardID,
+		blocks:     map[common.Hash]*types.Block{},
+		txLookups:  map[common.Hash]cxLookup{},
+		cxLookups:  map[common.Hash]cxLookup{},
+		cxReceipts: map[CxEntry]types.CXReceipts{},
+		spent:      map[uint32]map[uint64]bool{},
+		crossLinks: map[uint32]uint64{},
+	}
+}
+
+func (c *fakeCxChain) ShardID() uint32 { return c.shardID }
+
+func (c *fakeCxChain) CurrentBlock() *types.Block {
+	header := blockfactory.NewTestHeader().With().Number(new(big.Int).SetUint64(c.head)).Header()
+	return types.NewBlock(header, nil, nil, nil, nil, nil)
+}
+
+func (c *fakeCxChain) GetBlockByHash(hash common.Hash) *types.Block { return c.blocks[hash] }
+
+func (c *fakeCxChain) ReadTxLookupEntry(txID common.Hash) (common.Hash, uint64, uint64) {
+	l := c.txLookups[txID]
+	return l.hash, l.num, l.index
+}
+
+func (c *fakeCxChain) ReadCxLookupEntry(txID common.Hash) (common.Hash, uint64, uint64) {
+	l := c.cxLookups[txID]
+	return l.hash, l.num, l.index
+}
+
+func (c *fakeCxChain) ReadCXReceipts(shardID uint32, blockNum uint64, blockHash common.Hash) (types.CXReceipts, error) {
+	cxs, ok := c.cxReceipts[CxEntry{BlockHash: blockHash, ToShardID: shardID}]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return cxs, nil
+}
+
+func (c *fakeCxChain) IsCXReceiptsSpent(shardID uint32, blockNum uint64) bool {
+	return c.spent[shardID][blockNum]
+}
+
+func (c *fakeCxChain) ReadShardLastCrossLink(shardID uint32) (*types.CrossLink, error) {
+	num, ok := c.crossLinks[shardID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &types.CrossLink{BlockNumberF: new(big.Int).SetUint64(num), ShardIDF: shardID}, nil
+}
+
+func (c *fakeCxChain) spend(shardID uint32, blockNum uint64) {
+	if c.spent[shardID] == nil {
+		c.spent[shardID] = map[uint64]bool{}
+	}
+	c.spent[shardID][blockNum] = true
+}
+
+// addCxBlock adds the block of the number with a transaction to each of the
+// destination shards, and returns the block
+func (c *fakeCxChain) addCxBlock(number uint64, toShardIDs ...uint32) *types.Block {
+	var (
+		txs      types.Transactions
+		receipts types.Receipts
+	)
+	to := common.Address{1}
+	for i, toShardID := range toShardIDs {
+		txs = append(txs, types.NewCrossShardTransaction(
+			number*10+uint64(i), &to, c.shardID, toShardID, big.NewInt(1), 21000, big.NewInt(1), nil,
+		))
+		receipts = append(receipts, &types.Receipt{})
+	}
+	header := blockfactory.NewTestHeader().With().
+		Number(new(big.Int).SetUint64(number)).
+		ShardID(c.shardID).
+		Header()
+	blk := types.NewBlock(header, txs, receipts, nil, nil, nil)
+	c.blocks[blk.Hash()] = blk
+	for i, tx := range txs {
+		c.txLookups[tx.Hash()] = cxLookup{blk.Hash(), number, uint64(i)}
+		entry := CxEntry{BlockHash: blk.Hash(), ToShardID: tx.ToShardID()}
+		c.cxReceipts[entry] = append(c.cxReceipts[entry], &types.CXReceipt{
+			TxHash: tx.Hash(), ShardID: c.shardID, ToShardID: tx.ToShardID(), Amount: big.NewInt(1),
+		})
+	}
+	return blk
+}
+
+func checkCxTxStatus(t *testing.T, tracker *CxTracker, txHash common.Hash, expected CxTxStatus) {
+	t.Helper()
+	status, err := tracker.Status(txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *status != expected {
+		t.Errorf("unexpected status %+v, expected %+v", *status, expected)
+	}
+}
+
+func TestCxTrackerDeliveredToBeacon(t *testing.T) {
+	chain, beacon := newFakeCxChain(1), newFakeCxChain(0)
+	beacon.head = 100
+	pool := NewCxPool(CxPoolSize)
+	tracker := NewCxTracker(chain, beacon, pool)
+
+	blk := chain.addCxBlock(5, 0)
+	txHash := blk.Transactions()[0].Hash()
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxPending, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5, DestinationHeight: 100,
+	})
+	if tracker.Size() != 0 {
+		t.Fatalf("receipts tracked by the status query")
+	}
+	tracker.Track(blk)
+	if tracker.Size() != 1 {
+		t.Fatalf("unexpected tracked receipts %v", tracker.Size())
+	}
+
+	beacon.spend(1, 5)
+	beacon.cxLookups[txHash] = cxLookup{common.Hash{1}, 102, 0}
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxDelivered, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5,
+		DestinationBlockNum: 102, DestinationHeight: 100,
+	})
+	tracker.Update()
+	if tracker.Size() != 0 {
+		t.Errorf("delivered receipts still tracked")
+	}
+	if pool.Size() != 0 {
+		t.Errorf("delivered receipts resent")
+	}
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxDelivered, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5,
+		DestinationBlockNum: 102, DestinationHeight: 100,
+	})
+}
+
+func TestCxTrackerResend(t *testing.T) {
+	chain, beacon := newFakeCxChain(1), newFakeCxChain(0)
+	pool := NewCxPool(CxPoolSize)
+	tracker := NewCxTracker(chain, beacon, pool)
+
+	// no beacon block yet
+	blk := chain.addCxBlock(5, 0, 0, 1)
+	tracker.Track(blk)
+	if tracker.Size() != 1 {
+		t.Fatalf("unexpected tracked receipts %v", tracker.Size())
+	}
+	beacon.head = 10
+	tracker.Update()
+
+	txHash := blk.Transactions()[1].Hash()
+	for i := 1; i <= CxMaxResends; i++ {
+		beacon.head += CxResendTimeout - 1
+		tracker.Update()
+		if pool.Size() != 0 {
+			t.Fatalf("unexpected resend before the timeout")
+		}
+		beacon.head++
+		tracker.Update()
+		if !pool.Pool().Contains(CxEntry{BlockHash: blk.Hash(), ToShardID: 0}) {
+			t.Fatalf("receipts not resent after the timeout")
+		}
+		pool.Clear()
+		checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+			Status: CxPending, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5,
+			DestinationHeight: beacon.head, Resends: i,
+		})
+	}
+	beacon.head += CxResendTimeout
+	tracker.Update()
+	if pool.Size() != 0 {
+		t.Errorf("unexpected resend after the last resend")
+	}
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxStuck, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5,
+		DestinationHeight: beacon.head, Resends: CxMaxResends,
+	})
+}
+
+func TestCxTrackerResendToOtherShard(t *testing.T) {
+	chain, beacon := newFakeCxChain(1), newFakeCxChain(0)
+	pool := NewCxPool(CxPoolSize)
+	tracker := NewCxTracker(chain, beacon, pool)
+
+	// no crosslink of the destination shard yet
+	blk := chain.addCxBlock(5, 2)
+	txHash := blk.Transactions()[0].Hash()
+	tracker.Track(blk)
+	if tracker.Size() != 1 {
+		t.Fatalf("unexpected tracked receipts %v", tracker.Size())
+	}
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxPending, FromShardID: 1, ToShardID: 2, SourceBlockNum: 5,
+	})
+	beacon.crossLinks[2] = 10
+	tracker.Update()
+
+	for i := 1; i <= CxMaxResends; i++ {
+		beacon.crossLinks[2] += CxResendTimeout - 1
+		tracker.Update()
+		if pool.Size() != 0 {
+			t.Fatalf("unexpected resend before the timeout")
+		}
+		beacon.crossLinks[2]++
+		tracker.Update()
+		if !pool.Pool().Contains(CxEntry{BlockHash: blk.Hash(), ToShardID: 2}) {
+			t.Fatalf("receipts not resent after the timeout")
+		}
+		pool.Clear()
+		checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+			Status: CxPending, FromShardID: 1, ToShardID: 2, SourceBlockNum: 5,
+			DestinationHeight: beacon.crossLinks[2], Resends: i,
+		})
+	}
+	beacon.crossLinks[2] += CxResendTimeout
+	tracker.Update()
+	if pool.Size() != 0 {
+		t.Errorf("unexpected resend after the last resend")
+	}
+	checkCxTxStatus(t, tracker, txHash, CxTxStatus{
+		Status: CxStuck, FromShardID: 1, ToShardID: 2, SourceBlockNum: 5,
+		DestinationHeight: beacon.crossLinks[2], Resends: CxMaxResends,
+	})
+}
+
+func TestCxTrackerPoolFull(t *testing.T) {
+	chain, beacon := newFakeCxChain(1), newFakeCxChain(0)
+	beacon.head = 10
+	pool := NewCxPool(-1)
+	tracker := NewCxTracker(chain, beacon, pool)
+
+	blk := chain.addCxBlock(5, 0)
+	tracker.Track(blk)
+	beacon.head += CxResendTimeout
+	tracker.Update()
+	checkCxTxStatus(t, tracker, blk.Transactions()[0].Hash(), CxTxStatus{
+		Status: CxPending, FromShardID: 1, ToShardID: 0, SourceBlockNum: 5, DestinationHeight: beacon.head,
+	})
+}
+
+func TestCxTrackerIncoming(t *testing.T) {
+	chain, beacon := newFakeCxChain(2), newFakeCxChain(0)
+	tracker := NewCxTracker(chain, beacon, NewCxPool(CxPoolSize))
+
+	var (
+		proofs []*types.CXReceiptsProof
+		hashes []common.Hash
+	)
+	for i, shardID := range []uint32{0, 1} {
+		proof := &types.CXReceiptsProof{
+			MerkleProof: &types.CXMerkleProof{BlockNum: big.NewInt(int64(i + 7)), ShardID: shardID},
+		}
+		for j := 0; j != 2; j++ {
+			hash := common.Hash{byte(i), byte(j)}
+			proof.Receipts = append(proof.Receipts, &types.CXReceipt{TxHash: hash})
+			hashes = append(hashes, hash)
+		}
+		proofs = append(proofs, proof)
+	}
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(20)).ShardID(2).Header()
+	blk := types.NewBlock(header, nil, nil, nil, proofs, nil)
+	chain.blocks[blk.Hash()] = blk
+	for i, hash := range hashes {
+		chain.cxLookups[hash] = cxLookup{blk.Hash(), 20, uint64(i)}
+	}
+
+	checkCxTxStatus(t, tracker, hashes[1], CxTxStatus{
+		Status: CxDelivered, FromShardID: 0, ToShardID: 2, SourceBlockNum: 7, DestinationBlockNum: 20,
+	})
+	checkCxTxStatus(t, tracker, hashes[2], CxTxStatus{
+		Status: CxDelivered, FromShardID: 1, ToShardID: 2, SourceBlockNum: 8, DestinationBlockNum: 20,
+	})
+}
+
+func TestCxTrackerStatusErrors(t *testing.T) {
+	chain, beacon := newFakeCxChain(1), newFakeCxChain(0)
+	tracker := NewCxTracker(chain, beacon, NewCxPool(CxPoolSize))
+
+	blk := chain.addCxBlock(5, 1, 0)
+	// failed cross shard transaction
+	delete(chain.cxReceipts, CxEntry{BlockHash: blk.Hash(), ToShardID: 0})
+
+	tests := []struct {
+		txHash common.Hash
+		err    error
+	}{
+		{common.Hash{1}, ErrCxTxNotFound},
+		{blk.Transactions()[0].Hash(), ErrNotCrossShardTx},
+		{blk.Transactions()[1].Hash(), ErrNoCXReceipt},
+	}
+	for i, test := range tests {
+		if _, err := tracker.Status(test.txHash); err != test.err {
+			t.Errorf("Test %v: unexpected error %v, expected %v", i, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package cxproof verifies the proofs of the cross shard receipts of a block of
+// a source shard without a node: the receipts are checked against the outgoing
+// receipts root of the block header, and the header against the commit
+// signature of the committee of the source shard, known from its shard state.
+package cxproof
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	bls_core "github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/consensus/quorum"
+	"github.com/harmony-one/harmony/consensus/signature"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/multibls"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrIncompleteProof is returned when a field of the proof is missing
+	ErrIncompleteProof = errors.New("incomplete cross shard receipts proof")
+	// ErrReceiptNotInProof is returned when the proof has no receipt of the
+	// transaction
+	ErrReceiptNotInProof = errors.New("no receipt of the transaction in the proof")
+)
+
+// DecodeProof decodes the RLP encoding of the proof, as returned by the
+// hmyv2_getCXReceiptsProof rpc
+func DecodeProof(data []byte) (*types.CXReceiptsProof, error) {
+	cxp := &types.CXReceiptsProof{}
+	if err := rlp.DecodeBytes(data, cxp); err != nil {
+		return nil, errors.Wrap(err, "cannot decode cross shard receipts proof")
+	}
+	return cxp, nil
+}
+
+// Verifier verifies the proofs of the cross shard receipts of a network
+type Verifier struct {
+	config *params.ChainConfig
+}
+
+// NewVerifier returns the verifier of the proofs of the network: mainnet,
+// testnet, pangaea, partner, stressnet or localnet
+func NewVerifier(network string) *Verifier {
+	config := nodeconfig.NetworkType(network).ChainConfig()
+	return &Verifier{config: &config}
+}
+
+// Verify verifies the proof of the cross shard receipts against the shard state
+// of the epoch of the source block, the committee of the source shard having
+// signed the block
+func (v *Verifier) Verify(cxp *types.CXReceiptsProof, state *shard.State) error {
+	if cxp.ContainsEmptyField() {
+		return ErrIncompleteProof
+	}
+	if err := VerifyMerkleProof(cxp); err != nil {
+		return err
+	}
+	return v.VerifyCommitSig(cxp.Header, state, cxp.CommitSig, cxp.CommitBitmap)
+}
+
+// FindReceipt returns the receipt of the transaction in the proof
+func FindReceipt(cxp *types.CXReceiptsProof, txHash common.Hash) (*types.CXReceipt, error) {
+	for _, cx := range cxp.Receipts {
+		if cx.TxHash == txHash {
+			return cx, nil
+		}
+	}
+	return nil, ErrReceiptNotInProof
+}
+
+// VerifyMerkleProof verifies the receipts of the proof are the outgoing receipts
+// of the block header of the proof to their destination shard
+func VerifyMerkleProof(cxp *types.CXReceiptsProof) error {
+	if cxp.MerkleProof == nil || cxp.Header == nil {
+		return ErrIncompleteProof
+	}
+	toShardID, err := cxp.GetToShardID()
+	if err != nil {
+		return errors.Wrapf(err, "invalid shardID")
+	}
+
+	merkleProof := cxp.MerkleProof
+	if len(merkleProof.ShardIDs) != len(merkleProof.CXShardHashes) {
+		return errors.New("mismatched shard IDs and receipts root hashes")
+	}
+	shardRoot := common.Hash{}
+	foundMatchingShardID := false
+	byteBuffer := bytes.Buffer{}
+
+	// prepare to calculate source shard outgoing cxreceipts root hash
+	for j := 0; j < len(merkleProof.ShardIDs); j++ {
+		sKey := make([]byte, 4)
+		binary.BigEndian.PutUint32(sKey, merkleProof.ShardIDs[j])
+		byteBuffer.Write(sKey)
+		byteBuffer.Write(merkleProof.CXShardHashes[j][:])
+		if merkleProof.ShardIDs[j] == toShardID {
+			shardRoot = merkleProof.CXShardHashes[j]
+			foundMatchingShardID = true
+		}
+	}
+
+	if !foundMatchingShardID {
+		return errors.New("didn't find matching toShardID (no receipts for the shard)")
+	}
+
+	// (1) verify the CXReceipts trie root match
+	if types.DeriveSha(cxp.Receipts) != shardRoot {
+		return errors.New("trie root of the receipts not match")
+	}
+
+	// (2) verify the outgoingCXReceiptsHash match
+	outgoingHashFromSourceShard := crypto.Keccak256Hash(byteBuffer.Bytes())
+	if byteBuffer.Len() == 0 {
+		outgoingHashFromSourceShard = types.EmptyRootHash
+	}
+	if outgoingHashFromSourceShard != merkleProof.CXReceiptHash {
+		return errors.New("outgoing receipts root hash of the source shard not match")
+	}
+
+	// (3) verify the block hash matches
+	if cxp.Header.Hash() != merkleProof.BlockHash ||
+		cxp.Header.OutgoingReceiptHash() != merkleProof.CXReceiptHash {
+		return errors.New("block hash or outgoing receipts root hash not match in block header")
+	}
+	return nil
+}
+
+// VerifyCommitSig verifies the commit signature and bitmap of the header are
+// signed by a quorum of the committee of the shard of the header in the shard
+// state of the epoch of the header: a quorum of the voting power after the
+// staking epoch, or 2/3 of the slots before.
+func (v *Verifier) VerifyCommitSig(
+	header *block.Header, state *shard.State, commitSig []byte, commitBitmap []byte,
+) error {
+	epoch := header.Epoch()
+	if state.Epoch != nil && state.Epoch.Cmp(epoch) != 0 {
+		return errors.Errorf(
+			"shard state of epoch %v, block of epoch %v", state.Epoch, epoch,
+		)
+	}
+	subComm, err := state.FindCommitteeByID(header.ShardID())
+	if err != nil {
+		return err
+	}
+	publicKeys, err := subComm.BLSPublicKeys()
+	if err != nil {
+		return errors.Wrap(err, "cannot read the public keys of the committee")
+	}
+	if len(commitSig) != bls.BLSSignatureSizeInBytes {
+		return errors.New("invalid length of the commit signature")
+	}
+	aggSig := bls_core.Sign{}
+	if err := aggSig.Deserialize(commitSig); err != nil {
+		return errors.New("unable to deserialize the commit signature")
+	}
+	mask, err := bls.NewMask(publicKeys, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to setup the mask of the committee")
+	}
+	if err := mask.SetMask(commitBitmap); err != nil {
+		return errors.Wrap(err, "invalid commit bitmap")
+	}
+
+	if v.config.IsStaking(epoch) {
+		d := quorum.NewDecider(quorum.SuperMajorityStake, subComm.ShardID)
+		d.SetMyPublicKeyProvider(func() (multibls.PublicKeys, error) {
+			return nil, nil
+		})
+		if _, err := d.SetVoters(subComm, epoch); err != nil {
+			return err
+		}
+		if !d.IsQuorumAchievedByMask(mask) {
+			return errors.New("not enough voting power in the commit signature")
+		}
+	} else if count := utils.CountOneBits(mask.Bitmap); count < int64(len(subComm.Slots)*2/3+1) {
+		return errors.New("not enough signatures in the commit signature")
+	}
+
+	commitPayload := signature.ConstructCommitPayload(v,
+		epoch, header.Hash(), header.Number().Uint64(), header.ViewID().Uint64())
+	if !aggSig.VerifyHash(mask.AggregatePublic, commitPayload) {
+		return errors.New("unable to verify the commit signature of the block")
+	}
+	return nil
+}
+
+// Config returns the chain config of the network
+func (v *Verifier) Config() *params.ChainConfig {
+	return v.config
+}
@@ -0,0 +1,205 @@
+package cxproof
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	bls_core "github.com/harmony-one/bls/ffi/go/bls"
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/consensus/signature"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/numeric"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// newTestProof returns the proof of the receipts to shard 1 of a block of shard
+// 0, also having receipts to shard 2, without commit signature
+func newTestProof(epoch int64) *types.CXReceiptsProof {
+	receipts := map[uint32]types.CXReceipts{}
+	to := common.Address{9}
+	for _, toShardID := range []uint32{1, 2} {
+		for i := 0; i != 3; i++ {
+			receipts[toShardID] = append(receipts[toShardID], &types.CXReceipt{
+				TxHash:    common.Hash{byte(toShardID), byte(i)},
+				From:      common.Address{byte(i)},
+				To:        &to,
+				ShardID:   0,
+				ToShardID: toShardID,
+				Amount:    big.NewInt(int64(i)),
+			})
+		}
+	}
+	merkleProof := &types.CXMerkleProof{BlockNum: big.NewInt(10)}
+	var buf []byte
+	for _, toShardID := range []uint32{1, 2} {
+		hash := types.DeriveSha(receipts[toShardID])
+		merkleProof.ShardIDs = append(merkleProof.ShardIDs, toShardID)
+		merkleProof.CXShardHashes = append(merkleProof.CXShardHashes, hash)
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, toShardID)
+		buf = append(append(buf, key...), hash[:]...)
+	}
+	merkleProof.CXReceiptHash = crypto.Keccak256Hash(buf)
+	header := blockfactory.NewTestHeader().With().
+		Number(big.NewInt(10)).
+		Epoch(big.NewInt(epoch)).
+		ViewID(big.NewInt(12)).
+		OutgoingReceiptHash(merkleProof.CXReceiptHash).
+		Header()
+	merkleProof.BlockHash = header.Hash()
+	return &types.CXReceiptsProof{
+		Receipts:    receipts[1],
+		MerkleProof: merkleProof,
+		Header:      header,
+	}
+}
+
+func TestVerifyMerkleProof(t *testing.T) {
+	tests := []struct {
+		update func(cxp *types.CXReceiptsProof)
+		ok     bool
+	}{
+		{func(cxp *types.CXReceiptsProof) {}, true},
+		{func(cxp *types.CXReceiptsProof) { cxp.Receipts[1].Amount = big.NewInt(100) }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.Receipts = cxp.Receipts[1:] }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.Receipts[0].ToShardID = 2 }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.MerkleProof.CXShardHashes[1] = common.Hash{1} }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.MerkleProof.ShardIDs = cxp.MerkleProof.ShardIDs[1:] }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.MerkleProof.BlockHash = common.Hash{1} }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.Header.SetOutgoingReceiptHash(common.Hash{1}) }, false},
+		{func(cxp *types.CXReceiptsProof) { cxp.Header = nil }, false},
+	}
+	for i, test := range tests {
+		cxp := newTestProof(0)
+		test.update(cxp)
+		if err := VerifyMerkleProof(cxp); (err == nil) != test.ok {
+			t.Errorf("Test %v: unexpected result %v", i, err)
+		}
+	}
+}
+
+func TestFindReceipt(t *testing.T) {
+	cxp := newTestProof(0)
+	if cx, err := FindReceipt(cxp, common.Hash{1, 2}); err != nil || cx != cxp.Receipts[2] {
+		t.Errorf("receipt not found: %v", err)
+	}
+	if _, err := FindReceipt(cxp, common.Hash{2, 2}); err != ErrReceiptNotInProof {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDecodeProof(t *testing.T) {
+	cxp := newTestProof(0)
+	cxp.CommitSig, cxp.CommitBitmap = make([]byte, 96), []byte{7}
+	data, err := rlp.EncodeToBytes(cxp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeProof(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMerkleProof(decoded); err != nil {
+		t.Error(err)
+	}
+	if _, err := DecodeProof(data[1:]); err == nil {
+		t.Error("unexpected decoding of invalid data")
+	}
+}
+
+// signTestHeader returns the shard state of the epoch of the header with a
+// committee of the keys, and signs the header with the keys of the signers
+func signTestHeader(
+	v *Verifier, header *block.Header, keys []*bls_core.SecretKey, signers int,
+) (*shard.State, []byte, []byte, error) {
+	committee := shard.Committee{ShardID: header.ShardID()}
+	var publicKeys []bls.PublicKeyWrapper
+	for i, key := range keys {
+		var pub bls.SerializedPublicKey
+		if err := pub.FromLibBLSPublicKey(key.GetPublicKey()); err != nil {
+			return nil, nil, nil, err
+		}
+		stake := numeric.NewDec(100)
+		committee.Slots = append(committee.Slots, shard.Slot{
+			EcdsaAddress:   common.Address{byte(i)},
+			BLSPublicKey:   pub,
+			EffectiveStake: &stake,
+		})
+		publicKeys = append(publicKeys, bls.PublicKeyWrapper{Bytes: pub, Object: key.GetPublicKey()})
+	}
+	mask, err := bls.NewMask(publicKeys, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	payload := signature.ConstructCommitPayload(v, header.Epoch(), header.Hash(),
+		header.Number().Uint64(), header.ViewID().Uint64())
+	aggSig := &bls_core.Sign{}
+	for i := 0; i != signers; i++ {
+		aggSig.Add(keys[i].SignHash(payload))
+		if err := mask.SetKey(publicKeys[i].Bytes, true); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	state := &shard.State{Epoch: header.Epoch(), Shards: []shard.Committee{committee}}
+	return state, aggSig.Serialize(), mask.Bitmap, nil
+}
+
+func TestVerify(t *testing.T) {
+	v := &Verifier{config: params.LocalnetChainConfig}
+	var keys []*bls_core.SecretKey
+	for i := 0; i != 6; i++ {
+		keys = append(keys, bls.RandPrivateKey())
+	}
+	tests := []struct {
+		epoch   int64
+		signers int
+		ok      bool
+	}{
+		{0, 6, true},
+		{0, 5, true},
+		{0, 4, false},
+		{5, 6, true},
+		{5, 4, false},
+	}
+	for i, test := range tests {
+		cxp := newTestProof(test.epoch)
+		state, sig, bitmap, err := signTestHeader(v, cxp.Header, keys, test.signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cxp.CommitSig, cxp.CommitBitmap = sig, bitmap
+		if err := v.Verify(cxp, state); (err == nil) != test.ok {
+			t.Errorf("Test %v: unexpected result %v", i, err)
+		}
+	}
+
+	cxp := newTestProof(0)
+	state, sig, bitmap, err := signTestHeader(v, cxp.Header, keys, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cxp.CommitSig, cxp.CommitBitmap = sig, bitmap
+	if err := v.Verify(cxp, &shard.State{Epoch: big.NewInt(1), Shards: state.Shards}); err == nil {
+		t.Errorf("unexpected verification against the shard state of another epoch")
+	}
+	cxp.CommitSig = nil
+	if err := v.Verify(cxp, state); err != ErrIncompleteProof {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := v.Verify(newTestProof(1), state); err != ErrIncompleteProof {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	if v := NewVerifier("mainnet"); v.Config().ChainID.Cmp(params.MainnetChainConfig.ChainID) != 0 {
+		t.Errorf("unexpected chain config %v", v.Config().ChainID)
+	}
+}
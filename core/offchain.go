@@ -211,6 +211,9 @@ func (bc *BlockChain) CommitOffChainData(
 					Msg("[UpdateValidatorVotingPower] Failed to update voting power")
 			} else {
 				tempValidatorStats = stats
+				if err := bc.writeValidatorEpochAPRs(batch, stats); err != nil {
+					utils.Logger().Info().Err(err).Msg("could not index validator aprs")
+				}
 			}
 		} else {
 			utils.Logger().
@@ -258,6 +261,12 @@ func (bc *BlockChain) CommitOffChainData(
 
 			bc.writeValidatorStats(tempValidatorStats, batch)
 
+			if err := bc.updateSigningIndex(batch, header); err != nil {
+				utils.Logger().Error().Err(err).
+					Uint64("block", header.Number().Uint64()).
+					Msg("could not update validator signing index")
+			}
+
 			records := slash.Records{}
 			if s := header.Slashes(); len(s) > 0 {
 				if err := rlp.DecodeBytes(s, &records); err != nil {
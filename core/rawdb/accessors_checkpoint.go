@@ -0,0 +1,50 @@
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// ReadCheckpointHash retrieves the hash of the checkpoint block the chain was
+// started from, or the zero hash if the chain was synced from the genesis block.
+func ReadCheckpointHash(db DatabaseReader) common.Hash {
+	data, _ := db.Get(checkpointKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteCheckpointHash stores the hash of the checkpoint block the chain was
+// started from.
+func WriteCheckpointHash(db DatabaseWriter, hash common.Hash) error {
+	if err := db.Put(checkpointKey, hash.Bytes()); err != nil {
+		utils.Logger().Error().Msg("Failed to store checkpoint hash")
+		return err
+	}
+	return nil
+}
+
+// ReadBackfillTail retrieves the number of the lowest block of the contiguous
+// history written down from the checkpoint block. The history is complete
+// once the tail is the first block after the genesis block. The second return
+// value is false if the chain was not started from a checkpoint.
+func ReadBackfillTail(db DatabaseReader) (uint64, bool) {
+	data, _ := db.Get(backfillTailKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteBackfillTail stores the number of the lowest block of the contiguous
+// history written down from the checkpoint block.
+func WriteBackfillTail(db DatabaseWriter, number uint64) error {
+	if err := db.Put(backfillTailKey, encodeBlockNumber(number)); err != nil {
+		utils.Logger().Error().Msg("Failed to store backfill tail")
+		return err
+	}
+	return nil
+}
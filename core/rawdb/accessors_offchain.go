@@ -1,6 +1,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -297,11 +298,14 @@ func ReadBlockCommitSig(db DatabaseReader, blockNum uint64) ([]byte, error) {
 	var data []byte
 	data, err := db.Get(blockCommitSigKey(blockNum))
 	if err != nil {
+		if data = readAncientByNumber(db, freezerCommitSigTable, blockNum); len(data) != 0 {
+			return data, nil
+		}
 		// TODO: remove this extra seeking of sig after the mainnet is fully upgraded.
 		//       this is only needed for the compatibility in the migration moment.
 		data, err = db.Get(lastCommitsKey)
 		if err != nil {
-			return nil, errors.New("cannot read commit sig for block " + string(blockNum))
+			return nil, errors.Errorf("cannot read commit sig for block %v", blockNum)
 		}
 	}
 	return data, nil
@@ -350,3 +354,85 @@ func WriteEpochVdfBlockNum(db DatabaseWriter, epoch *big.Int, data []byte) error
 }
 
 //// Resharding ////
+
+// ReadValidatorEpochSigning retrieves the signing outcomes of the validator in the epoch
+func ReadValidatorEpochSigning(
+	db DatabaseReader, addr common.Address, epoch uint64,
+) (*staking.ValidatorEpochSigning, error) {
+	data, err := db.Get(validatorSigningKey(addr, epoch))
+	if err != nil {
+		return nil, err
+	}
+	signing := staking.ValidatorEpochSigning{}
+	if err := rlp.DecodeBytes(data, &signing); err != nil {
+		return nil, err
+	}
+	return &signing, nil
+}
+
+// WriteValidatorEpochSigning stores the signing outcomes of the validator in the epoch
+func WriteValidatorEpochSigning(
+	batch DatabaseWriter, addr common.Address, signing *staking.ValidatorEpochSigning,
+) error {
+	bytes, err := rlp.EncodeToBytes(signing)
+	if err != nil {
+		utils.Logger().Error().Msg("[WriteValidatorEpochSigning] Failed to encode")
+		return err
+	}
+	if err := batch.Put(validatorSigningKey(addr, signing.Epoch.Uint64()), bytes); err != nil {
+		utils.Logger().Error().Msg("[WriteValidatorEpochSigning] Failed to store to database")
+		return err
+	}
+	return nil
+}
+
+// ReadValidatorEpochAPR retrieves the apr of the validator in the epoch
+func ReadValidatorEpochAPR(
+	db DatabaseReader, addr common.Address, epoch uint64,
+) (*staking.APREntry, error) {
+	data, err := db.Get(validatorAPRKey(addr, epoch))
+	if err != nil {
+		return nil, err
+	}
+	entry := staking.APREntry{}
+	if err := rlp.DecodeBytes(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// WriteValidatorEpochAPR stores the apr of the validator in the epoch
+func WriteValidatorEpochAPR(
+	batch DatabaseWriter, addr common.Address, entry staking.APREntry,
+) error {
+	bytes, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		utils.Logger().Error().Msg("[WriteValidatorEpochAPR] Failed to encode")
+		return err
+	}
+	if err := batch.Put(validatorAPRKey(addr, entry.Epoch.Uint64()), bytes); err != nil {
+		utils.Logger().Error().Msg("[WriteValidatorEpochAPR] Failed to store to database")
+		return err
+	}
+	return nil
+}
+
+// ReadSigningIndexHead retrieves the number of the latest block whose validator
+// signing was indexed. The second return value is false if nothing was indexed yet.
+func ReadSigningIndexHead(db DatabaseReader) (uint64, bool) {
+	data, _ := db.Get(signingIndexHeadKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteSigningIndexHead stores the number of the latest block whose validator
+// signing was indexed.
+func WriteSigningIndexHead(db DatabaseWriter, number uint64) error {
+	if err := db.Put(signingIndexHeadKey, encodeBlockNumber(number)); err != nil {
+		utils.Logger().Error().Msg("Failed to store signing index head")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+// ReadBlockFlatTraces retrieves the encoded flat traces of the given block.
+func ReadBlockFlatTraces(db DatabaseReader, number uint64, hash common.Hash) ([]byte, error) {
+	return db.Get(flatTraceKey(number, hash))
+}
+
+// WriteBlockFlatTraces stores the encoded flat traces of the given block.
+func WriteBlockFlatTraces(db DatabaseWriter, number uint64, hash common.Hash, data []byte) error {
+	if err := db.Put(flatTraceKey(number, hash), data); err != nil {
+		utils.Logger().Error().Uint64("number", number).Msg("Failed to store block flat traces")
+		return err
+	}
+	return nil
+}
+
+// DeleteBlockFlatTraces removes the flat traces of the given block.
+func DeleteBlockFlatTraces(db DatabaseDeleter, number uint64, hash common.Hash) error {
+	return db.Delete(flatTraceKey(number, hash))
+}
+
+// ReadFlatTraceIndexHead retrieves the number and hash of the latest block whose
+// flat traces were indexed. The last return value is false if nothing was indexed yet.
+func ReadFlatTraceIndexHead(db DatabaseReader) (uint64, common.Hash, bool) {
+	data, _ := db.Get(flatTraceIndexHeadKey)
+	if len(data) != 8+common.HashLength {
+		return 0, common.Hash{}, false
+	}
+	return binary.BigEndian.Uint64(data[:8]), common.BytesToHash(data[8:]), true
+}
+
+// WriteFlatTraceIndexHead stores the number and hash of the latest block whose
+// flat traces were indexed.
+func WriteFlatTraceIndexHead(db DatabaseWriter, number uint64, hash common.Hash) error {
+	if err := db.Put(flatTraceIndexHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		utils.Logger().Error().Msg("Failed to store flat trace index head")
+		return err
+	}
+	return nil
+}
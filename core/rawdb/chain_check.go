@@ -0,0 +1,208 @@
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/types"
+	staking "github.com/harmony-one/harmony/staking/types"
+	"github.com/pkg/errors"
+)
+
+// ChainIssue is an inconsistency of a canonical block found by CheckChain
+type ChainIssue struct {
+	Number uint64
+	Hash   common.Hash
+	Issue  string
+}
+
+func (issue ChainIssue) String() string {
+	return fmt.Sprintf("block %v (%v): %v", issue.Number, issue.Hash.Hex(), issue.Issue)
+}
+
+// CheckChain checks the canonical blocks of the range, both included: the
+// canonical hash and number mappings, the header hash and parent hash, the
+// transactions root of the body, and the number and root of the receipts.
+// The inconsistencies found are returned, in the order of the blocks.
+func CheckChain(db DatabaseReader, from, to uint64) []ChainIssue {
+	var (
+		issues []ChainIssue
+		parent common.Hash
+	)
+	for number := from; number <= to; number++ {
+		hash := ReadCanonicalHash(db, number)
+		report := func(format string, args ...interface{}) {
+			issues = append(issues, ChainIssue{number, hash, fmt.Sprintf(format, args...)})
+		}
+		if hash == (common.Hash{}) {
+			report("canonical hash not found")
+			parent = common.Hash{}
+			continue
+		}
+		if n := ReadHeaderNumber(db, hash); n == nil {
+			report("header number not found")
+		} else if *n != number {
+			report("header number mapped to %v", *n)
+		}
+
+		header := ReadHeader(db, hash, number)
+		if header == nil {
+			report("header not found")
+			parent = hash
+			continue
+		}
+		if h := header.Hash(); h != hash {
+			report("header hash mismatch %v", h.Hex())
+		}
+		if n := header.Number().Uint64(); n != number {
+			report("header number mismatch %v", n)
+		}
+		if parent != (common.Hash{}) && header.ParentHash() != parent {
+			report("parent hash mismatch %v, canonical %v", header.ParentHash().Hex(), parent.Hex())
+		}
+		parent = hash
+
+		body := ReadBody(db, hash, number)
+		if body == nil {
+			report("body not found")
+			continue
+		}
+		txs, stakingTxs := body.Transactions(), body.StakingTransactions()
+		if root := types.DeriveSha(
+			types.Transactions(txs), staking.StakingTransactions(stakingTxs),
+		); root != header.TxHash() {
+			report("transactions root mismatch %v, header %v", root.Hex(), header.TxHash().Hex())
+		}
+
+		receipts := ReadReceipts(db, hash, number)
+		if receipts == nil {
+			if header.ReceiptHash() != types.EmptyRootHash {
+				report("receipts not found")
+			}
+			continue
+		}
+		if len(receipts) != len(txs)+len(stakingTxs) {
+			report("%v receipts for %v transactions", len(receipts), len(txs)+len(stakingTxs))
+		}
+		if root := types.DeriveSha(receipts); root != header.ReceiptHash() {
+			report("receipts root mismatch %v, header %v", root.Hex(), header.ReceiptHash().Hex())
+		}
+	}
+	return issues
+}
+
+// stateAccount is the consensus encoding of an account of the state trie
+type stateAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// emptyCodeHash is the code hash of the accounts without code
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// hasBlockWithState returns whether the header, body and complete state of the
+// block are in the database
+func hasBlockWithState(db ethdb.KeyValueStore, header *block.Header) bool {
+	hash, number := header.Hash(), header.Number().Uint64()
+	if !HasBody(db, hash, number) {
+		return false
+	}
+	if has, err := db.Has(header.Root().Bytes()); err != nil || !has {
+		return false
+	}
+	return hasCompleteState(db, header.Root())
+}
+
+// hasCompleteState returns whether all the nodes of the state trie of the root
+// are in the database, along with the storage tries and the code of its
+// accounts. It walks the whole state.
+func hasCompleteState(db ethdb.KeyValueStore, root common.Hash) bool {
+	tdb := trie.NewDatabase(db)
+	accounts, err := trie.New(root, tdb)
+	if err != nil {
+		return false
+	}
+	it := accounts.NodeIterator(nil)
+	for it.Next(true) {
+		if !it.Leaf() {
+			continue
+		}
+		var account stateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return false
+		}
+		if account.Root != types.EmptyRootHash {
+			storage, err := trie.New(account.Root, tdb)
+			if err != nil {
+				return false
+			}
+			storageIt := storage.NodeIterator(nil)
+			for storageIt.Next(true) {
+			}
+			if storageIt.Error() != nil {
+				return false
+			}
+		}
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			if has, err := db.Has(account.CodeHash); err != nil || !has {
+				return false
+			}
+		}
+	}
+	return it.Error() == nil
+}
+
+// RepairHead resets the head header, block and fast block to the highest
+// canonical block at or below the number whose header, body and complete state
+// are in the database, and deletes the canonical hashes above it. The commit
+// signature of the new head is restored from the header of its canonical child.
+// The header of the new head is returned.
+func RepairHead(db ethdb.KeyValueStore, number uint64) (*block.Header, error) {
+	var head *block.Header
+	for n := number; ; n-- {
+		hash := ReadCanonicalHash(db, n)
+		if hash != (common.Hash{}) {
+			if header := ReadHeader(db, hash, n); header != nil && hasBlockWithState(db, header) {
+				head = header
+				break
+			}
+		}
+		if n == 0 {
+			return nil, errors.Errorf("no block with state found at or below %v", number)
+		}
+	}
+	headNumber := head.Number().Uint64()
+
+	batch := db.NewBatch()
+	child := ReadCanonicalHash(db, headNumber+1)
+	if header := ReadHeader(db, child, headNumber+1); header != nil {
+		sig := header.LastCommitSignature()
+		if err := WriteBlockCommitSig(batch, headNumber, append(sig[:], header.LastCommitBitmap()...)); err != nil {
+			return nil, err
+		}
+	}
+	for n := headNumber + 1; ReadCanonicalHash(db, n) != (common.Hash{}); n++ {
+		if err := DeleteCanonicalHash(batch, n); err != nil {
+			return nil, err
+		}
+	}
+	if err := WriteHeadHeaderHash(batch, head.Hash()); err != nil {
+		return nil, err
+	}
+	if err := WriteHeadBlockHash(batch, head.Hash()); err != nil {
+		return nil, err
+	}
+	if err := WriteHeadFastBlockHash(batch, head.Hash()); err != nil {
+		return nil, err
+	}
+	return head, batch.Write()
+}
@@ -0,0 +1,198 @@
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+// testCode is the code of the contract of the state of the block i
+func testCode(i int) []byte {
+	return []byte{0x60, byte(i)}
+}
+
+// testState returns the root of the state of the block i, a contract with its
+// code and a storage slot, written to the database if commit is set
+func testState(t *testing.T, db ethdb.KeyValueStore, i int, commit bool) common.Hash {
+	tdb := trie.NewDatabase(db)
+	storage, _ := trie.New(common.Hash{}, tdb)
+	storage.Update(common.Hash{1}.Bytes(), []byte{byte(i + 1)})
+	storageRoot, err := storage.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ := rlp.EncodeToBytes(&stateAccount{
+		Nonce:    uint64(i),
+		Balance:  big.NewInt(1),
+		Root:     storageRoot,
+		CodeHash: crypto.Keccak256(testCode(i)),
+	})
+	accounts, _ := trie.New(common.Hash{}, tdb)
+	accounts.Update(common.Hash{2}.Bytes(), account)
+	root, err := accounts.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit {
+		for _, r := range []common.Hash{storageRoot, root} {
+			if err := tdb.Commit(r, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.Put(crypto.Keccak256(testCode(i)), testCode(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// writeConsistentChain writes a canonical chain of the number of blocks with
+// their bodies and receipts matching the headers, and their states in the
+// database up to the block withState
+func writeConsistentChain(t *testing.T, db ethdb.KeyValueStore, blocks, withState int) []*types.Block {
+	var (
+		chain  []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i != blocks; i++ {
+		root := testState(t, db, i, i <= withState)
+		header := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(int64(i))).
+			ParentHash(parent).
+			Root(root).
+			LastCommitSignature([96]byte{byte(i)}).
+			LastCommitBitmap([]byte{byte(i)}).
+			Header()
+		block := types.NewBlock(header, nil, nil, nil, nil, nil)
+		if err := WriteBlock(db, block); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteReceipts(db, block.Hash(), block.NumberU64(), types.Receipts{}); err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, block)
+		parent = block.Hash()
+	}
+	if err := WriteHeadBlockHash(db, parent); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestCheckChain(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	chain := writeConsistentChain(t, db, 8, 8)
+	if issues := CheckChain(db, 0, 7); len(issues) != 0 {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+
+	if err := DeleteBody(db, chain[2].Hash(), 2); err != nil {
+		t.Fatal(err)
+	}
+	receipts := types.Receipts{&types.Receipt{Logs: []*types.Log{}}}
+	if err := WriteReceipts(db, chain[3].Hash(), 3, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteCanonicalHash(db, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCanonicalHash(db, chain[4].Hash(), 6); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		number uint64
+		issue  string
+	}{
+		{2, "body not found"},
+		{3, "1 receipts for 0 transactions"},
+		{3, "receipts root mismatch"},
+		{5, "canonical hash not found"},
+		{6, "header number mapped to 4"},
+		{6, "header not found"},
+		{7, "parent hash mismatch"},
+	}
+	issues := CheckChain(db, 0, 8)
+	if len(issues) != len(expected)+1 {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+	for i, exp := range expected {
+		if issues[i].Number != exp.number || !strings.HasPrefix(issues[i].Issue, exp.issue) {
+			t.Errorf("unexpected issue %v, expected %v", issues[i], exp)
+		}
+	}
+	if issues[len(expected)].Number != 8 {
+		t.Errorf("unexpected issue %v", issues[len(expected)])
+	}
+}
+
+func TestRepairHead(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	chain := writeConsistentChain(t, db, 10, 6)
+	if err := DeleteBody(db, chain[6].Hash(), 6); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := RepairHead(db, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash() != chain[5].Hash() {
+		t.Fatalf("unexpected head %v", head.Number())
+	}
+	for _, hash := range []common.Hash{
+		ReadHeadBlockHash(db), ReadHeadHeaderHash(db), ReadHeadFastBlockHash(db),
+	} {
+		if hash != chain[5].Hash() {
+			t.Errorf("unexpected head hash %v", hash.Hex())
+		}
+	}
+	for number := uint64(6); number != 10; number++ {
+		if hash := ReadCanonicalHash(db, number); hash != (common.Hash{}) {
+			t.Errorf("unexpected canonical hash of block %v", number)
+		}
+	}
+	sig := [96]byte{6}
+	if data, err := ReadBlockCommitSig(db, 5); err != nil || !bytes.Equal(data, append(sig[:], 6)) {
+		t.Errorf("unexpected commit sig of the head %x: %v", data, err)
+	}
+
+	if _, err := RepairHead(db, 5); err != nil {
+		t.Fatal(err)
+	}
+	// the states missing a contract code or a storage node are not complete
+	if err := db.Delete(crypto.Keccak256(testCode(5))); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := RepairHead(db, 5); err != nil || head.Hash() != chain[4].Hash() {
+		t.Fatalf("unexpected head with missing code: %v", err)
+	}
+	var account stateAccount
+	accounts, _ := trie.New(chain[4].Root(), trie.NewDatabase(db))
+	if err := rlp.DecodeBytes(accounts.Get(common.Hash{2}.Bytes()), &account); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(account.Root.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := RepairHead(db, 4); err != nil || head.Hash() != chain[3].Hash() {
+		t.Fatalf("unexpected head with missing storage: %v", err)
+	}
+	db.Delete(chain[0].Root().Bytes())
+	if _, err := RepairHead(db, 0); err == nil {
+		t.Errorf("unexpected head without state")
+	}
+}
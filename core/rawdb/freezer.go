@@ -0,0 +1,475 @@
+package rawdb
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// The tables of the freezer, one item per block
+const (
+	freezerHashTable      = "hashes"   // canonical hash of the block
+	freezerHeaderTable    = "headers"  // header RLP
+	freezerBodiesTable    = "bodies"   // body RLP
+	freezerReceiptTable   = "receipts" // receipts RLP, empty if the block has none stored
+	freezerCommitSigTable = "sigs"     // commit signature and bitmap, empty if none stored
+)
+
+var freezerTables = []string{
+	freezerHashTable,
+	freezerHeaderTable,
+	freezerBodiesTable,
+	freezerReceiptTable,
+	freezerCommitSigTable,
+}
+
+const (
+	// freezerRecheckInterval is the interval to check for new blocks to freeze
+	freezerRecheckInterval = time.Minute
+	// freezerBatchLimit is the maximum number of blocks frozen at once
+	freezerBatchLimit = 30000
+)
+
+var (
+	errUnknownTable     = errors.New("unknown freezer table")
+	errAppendNotAllowed = errors.New("ancient blocks are only appended by the freezer")
+)
+
+// freezer is an append-only store of the canonical blocks. Each block is an
+// item of all the freezer tables.
+type freezer struct {
+	frozen uint64 // number of blocks frozen, accessed atomically
+	tables map[string]*freezerTable
+}
+
+// newFreezer opens the freezer in the directory, or creates it if it does not
+// exist. The blocks partially written on a crash are discarded.
+func newFreezer(dir string) (*freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			f.close()
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	frozen := f.tables[freezerHashTable].numItems()
+	for _, table := range f.tables {
+		if items := table.numItems(); items < frozen {
+			frozen = items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(frozen); err != nil {
+			f.close()
+			return nil, err
+		}
+	}
+	f.frozen = frozen
+	return f, nil
+}
+
+func (f *freezer) hasAncient(kind string, number uint64) bool {
+	table, ok := f.tables[kind]
+	if !ok {
+		return false
+	}
+	return number < atomic.LoadUint64(&f.frozen) && table.has(number)
+}
+
+func (f *freezer) ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, errUnknownTable
+	}
+	if number >= atomic.LoadUint64(&f.frozen) {
+		return nil, errOutOfBounds
+	}
+	return table.retrieve(number)
+}
+
+func (f *freezer) ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+func (f *freezer) ancientSize(kind string) (uint64, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return 0, errUnknownTable
+	}
+	return table.sizeOnDisk(), nil
+}
+
+// appendBlock appends the data of the block to the tables. The block number
+// shall be the number of blocks frozen.
+func (f *freezer) appendBlock(number uint64, hash, header, body, receipts, sig []byte) error {
+	if frozen := f.ancients(); number != frozen {
+		return fmt.Errorf("appending block %v out of order, expected %v", number, frozen)
+	}
+	blobs := map[string][]byte{
+		freezerHashTable:      hash,
+		freezerHeaderTable:    header,
+		freezerBodiesTable:    body,
+		freezerReceiptTable:   receipts,
+		freezerCommitSigTable: sig,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].append(number, blobs[name]); err != nil {
+			// keep the tables aligned on the blocks fully frozen
+			for _, table := range f.tables {
+				table.truncate(number)
+			}
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, number+1)
+	return nil
+}
+
+// truncate discards all but the first blocks of the number
+func (f *freezer) truncate(items uint64) error {
+	if items >= f.ancients() {
+		return nil
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *freezer) sync() error {
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *freezer) close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// freezerdb is a chain database keeping the blocks older than the threshold in
+// the freezer instead of the key value store
+type freezerdb struct {
+	ethdb.KeyValueStore
+	freezer   *freezer
+	threshold uint64
+
+	freezeLock sync.Mutex
+	quit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewDatabaseWithFreezer creates a chain database on top of the key value
+// store, keeping the headers, bodies, receipts and commit signatures of the
+// canonical blocks more than threshold blocks behind the head in the freezer of
+// the directory. The blocks falling behind are moved to the freezer in the
+// background, none with a zero threshold which only keeps the blocks already
+// frozen. The rawdb accessors read the blocks from either store.
+func NewDatabaseWithFreezer(db ethdb.KeyValueStore, dir string, threshold uint64) (ethdb.Database, error) {
+	f, err := newFreezer(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open freezer %v", dir)
+	}
+	fdb := &freezerdb{
+		KeyValueStore: db,
+		freezer:       f,
+		threshold:     threshold,
+		quit:          make(chan struct{}),
+	}
+	fdb.wg.Add(1)
+	go fdb.loop()
+	return fdb, nil
+}
+
+// MigrateToFreezer moves at once all the blocks behind the threshold of the
+// freezer database to the freezer, and compacts the key value store. It
+// returns the number of blocks moved.
+func MigrateToFreezer(db ethdb.Database) (uint64, error) {
+	fdb, ok := db.(*freezerdb)
+	if !ok {
+		return 0, errors.New("database without freezer")
+	}
+	var total uint64
+	for {
+		moved, err := fdb.freeze(freezerBatchLimit)
+		total += moved
+		if err != nil {
+			return total, err
+		}
+		if moved < freezerBatchLimit {
+			break
+		}
+		utils.Logger().Info().Uint64("frozen", fdb.freezer.ancients()).Msg("[freezer] migrating blocks")
+	}
+	if total > 0 {
+		if err := fdb.Compact(nil, nil); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// HasAncient returns whether the data of the kind of the block is frozen
+func (db *freezerdb) HasAncient(kind string, number uint64) (bool, error) {
+	return db.freezer.hasAncient(kind, number), nil
+}
+
+// Ancient returns the frozen data of the kind of the block
+func (db *freezerdb) Ancient(kind string, number uint64) ([]byte, error) {
+	return db.freezer.ancient(kind, number)
+}
+
+// Ancients returns the number of blocks frozen
+func (db *freezerdb) Ancients() (uint64, error) {
+	return db.freezer.ancients(), nil
+}
+
+// AncientSize returns the size on disk of the frozen data of the kind
+func (db *freezerdb) AncientSize(kind string) (uint64, error) {
+	return db.freezer.ancientSize(kind)
+}
+
+// AppendAncient is not supported, the blocks are only moved by the freezer
+// itself which also covers the commit signatures
+func (db *freezerdb) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	return errAppendNotAllowed
+}
+
+// TruncateAncients discards all but the first n frozen blocks
+func (db *freezerdb) TruncateAncients(n uint64) error {
+	db.freezeLock.Lock()
+	defer db.freezeLock.Unlock()
+
+	return db.freezer.truncate(n)
+}
+
+// Sync flushes the freezer to disk
+func (db *freezerdb) Sync() error {
+	return db.freezer.sync()
+}
+
+// Close stops moving the blocks to the freezer, and closes both stores
+func (db *freezerdb) Close() error {
+	close(db.quit)
+	db.wg.Wait()
+
+	var errs []error
+	if err := db.freezer.close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := db.KeyValueStore.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+func (db *freezerdb) loop() {
+	defer db.wg.Done()
+
+	for {
+		moved, err := db.freeze(freezerBatchLimit)
+		if err != nil {
+			utils.Logger().Error().Err(err).Msg("[freezer] failed to freeze blocks")
+		} else if moved > 0 {
+			utils.Logger().Info().Uint64("moved", moved).Uint64("frozen", db.freezer.ancients()).
+				Msg("[freezer] blocks moved to the freezer")
+		}
+		if err == nil && moved == freezerBatchLimit {
+			// more blocks to freeze
+			select {
+			case <-db.quit:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-time.After(freezerRecheckInterval):
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// freeze moves up to limit canonical blocks behind the threshold from the key
+// value store to the freezer, and returns the number of blocks moved. The
+// genesis block is kept in the key value store as well.
+func (db *freezerdb) freeze(limit uint64) (uint64, error) {
+	db.freezeLock.Lock()
+	defer db.freezeLock.Unlock()
+
+	if err := db.repairRewind(); err != nil {
+		return 0, err
+	}
+	if db.threshold == 0 {
+		return 0, nil
+	}
+	kv := db.KeyValueStore
+	headHash := ReadHeadBlockHash(kv)
+	if headHash == (common.Hash{}) {
+		return 0, nil
+	}
+	head := ReadHeaderNumber(kv, headHash)
+	if head == nil || *head < db.threshold {
+		return 0, nil
+	}
+	if tail, ok := ReadBackfillTail(kv); ok && tail > 1 {
+		// the blocks are frozen from the genesis block once the history
+		// before the checkpoint is backfilled
+		return 0, nil
+	}
+	frozen := db.freezer.ancients()
+	target := *head - db.threshold
+	if target > frozen+limit {
+		target = frozen + limit
+	}
+	if target <= frozen {
+		return 0, nil
+	}
+
+	hashes := make([]common.Hash, 0, target-frozen)
+	for number := frozen; number < target; number++ {
+		hash := ReadCanonicalHash(kv, number)
+		if hash == (common.Hash{}) {
+			return 0, errors.Errorf("canonical hash of block %v not found", number)
+		}
+		header := ReadHeaderRLP(kv, hash, number)
+		if len(header) == 0 {
+			return 0, errors.Errorf("header of block %v not found", number)
+		}
+		body := ReadBodyRLP(kv, hash, number)
+		if len(body) == 0 {
+			return 0, errors.Errorf("body of block %v not found", number)
+		}
+		receipts, _ := kv.Get(blockReceiptsKey(number, hash))
+		sig, _ := kv.Get(blockCommitSigKey(number))
+		if err := db.freezer.appendBlock(number, hash.Bytes(), header, body, receipts, sig); err != nil {
+			return 0, err
+		}
+		hashes = append(hashes, hash)
+	}
+	if err := db.freezer.sync(); err != nil {
+		return 0, err
+	}
+
+	// the blocks are safe on disk, delete them from the key value store
+	batch := kv.NewBatch()
+	for i, hash := range hashes {
+		number := frozen + uint64(i)
+		if number == 0 {
+			continue
+		}
+		for _, key := range [][]byte{
+			headerKey(number, hash),
+			blockBodyKey(number, hash),
+			blockReceiptsKey(number, hash),
+			blockCommitSigKey(number),
+		} {
+			if err := batch.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	return uint64(len(hashes)), nil
+}
+
+// repairRewind discards the frozen blocks which are no longer canonical, after
+// the chain got rewound below the head of the freezer
+func (db *freezerdb) repairRewind() error {
+	frozen := db.freezer.ancients()
+	items := frozen
+	for ; items > 0; items-- {
+		hash, err := db.freezer.ancient(freezerHashTable, items-1)
+		if err != nil {
+			return err
+		}
+		if common.BytesToHash(hash) == ReadCanonicalHash(db.KeyValueStore, items-1) {
+			break
+		}
+	}
+	if items == frozen {
+		return nil
+	}
+	utils.Logger().Warn().Uint64("frozen", frozen).Uint64("canonical", items).
+		Msg("[freezer] discarding the frozen blocks rewound")
+	return db.freezer.truncate(items)
+}
+
+// readAncient returns the frozen data of the kind of the block, or nil if the
+// database has no freezer or the block of the hash is not frozen
+func readAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	adb, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return nil
+	}
+	frozenHash, err := adb.Ancient(freezerHashTable, number)
+	if err != nil || common.BytesToHash(frozenHash) != hash {
+		return nil
+	}
+	data, _ := adb.Ancient(kind, number)
+	return data
+}
+
+// hasAncient returns whether the data of the kind of the block of the hash is
+// frozen
+func hasAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) bool {
+	adb, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return false
+	}
+	frozenHash, err := adb.Ancient(freezerHashTable, number)
+	if err != nil || common.BytesToHash(frozenHash) != hash {
+		return false
+	}
+	has, _ := adb.HasAncient(kind, number)
+	return has
+}
+
+// readAncientByNumber returns the frozen data of the kind of the block number,
+// or nil if the database has no freezer or the block is not frozen
+func readAncientByNumber(db DatabaseReader, kind string, number uint64) []byte {
+	adb, ok := db.(ethdb.AncientReader)
+	if !ok {
+		return nil
+	}
+	data, _ := adb.Ancient(kind, number)
+	return data
+}
@@ -0,0 +1,221 @@
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// indexEntrySize is the size of an index entry, the end offset of an item in
+// the data file as big endian uint64
+const indexEntrySize = 8
+
+var errOutOfBounds = errors.New("out of bounds")
+
+// freezerTable is an append-only table of items numbered from 0. The items are
+// stored one after another in a data file, and the index file keeps the end
+// offset of each item in the data file.
+type freezerTable struct {
+	lock  sync.RWMutex
+	name  string
+	index *os.File
+	data  *os.File
+	items uint64 // number of items in the table
+	size  uint64 // size of the data file
+}
+
+// newFreezerTable opens the table of the name in the directory, or creates it
+// if it does not exist
+func newFreezerTable(dir, name string) (*freezerTable, error) {
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		index.Close()
+		return nil, err
+	}
+	t := &freezerTable{
+		name:  name,
+		index: index,
+		data:  data,
+	}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair drops the item partially written on a crash: the index entries past
+// the end of the data file, and the data past the last index entry
+func (t *freezerTable) repair() error {
+	indexStat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	dataStat, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+	var (
+		items    = uint64(indexStat.Size()) / indexEntrySize
+		dataSize = uint64(dataStat.Size())
+		end      uint64
+	)
+	for ; items > 0; items-- {
+		if end, err = t.readOffset(items - 1); err != nil {
+			return err
+		}
+		if end <= dataSize {
+			break
+		}
+	}
+	if items == 0 {
+		end = 0
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.items, t.size = items, end
+	return nil
+}
+
+// readOffset returns the end offset of the item in the data file
+func (t *freezerTable) readOffset(item uint64) (uint64, error) {
+	var buf [indexEntrySize]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// append writes the blob as the item of the number, which shall be the number
+// of items in the table
+func (t *freezerTable) append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if item != t.items {
+		return fmt.Errorf("%v: appending item %v out of order, expected %v", t.name, item, t.items)
+	}
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	end := t.size + uint64(len(blob))
+	var buf [indexEntrySize]byte
+	binary.BigEndian.PutUint64(buf[:], end)
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.items, t.size = t.items+1, end
+	return nil
+}
+
+// retrieve returns the blob of the item
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	var start uint64
+	if item > 0 {
+		var err error
+		if start, err = t.readOffset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.readOffset(item)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, fmt.Errorf("%v: corrupted index of item %v", t.name, item)
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// has returns whether the item is in the table
+func (t *freezerTable) has(item uint64) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return item < t.items
+}
+
+// truncate discards all the items but the first ones of the number
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if items >= t.items {
+		return nil
+	}
+	var end uint64
+	if items > 0 {
+		var err error
+		if end, err = t.readOffset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.items, t.size = items, end
+	return nil
+}
+
+// numItems returns the number of items in the table
+func (t *freezerTable) numItems() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.items
+}
+
+// sizeOnDisk returns the size of the data and index files
+func (t *freezerTable) sizeOnDisk() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.size + t.items*indexEntrySize
+}
+
+// sync flushes the data file before the index file, so that the index never
+// points past the data written
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) close() error {
+	var errs []error
+	if err := t.data.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := t.index.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v: %v", t.name, errs)
+	}
+	return nil
+}
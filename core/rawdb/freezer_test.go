@@ -0,0 +1,253 @@
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+func TestFreezerTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i != 10; i++ {
+		if err := table.append(i, bytes.Repeat([]byte{byte(i)}, int(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.append(11, []byte{11}); err == nil {
+		t.Errorf("unexpected append out of order")
+	}
+	checkItems := func(items uint64) {
+		t.Helper()
+		if n := table.numItems(); n != items {
+			t.Fatalf("unexpected items %v / %v", n, items)
+		}
+		for i := uint64(0); i != items; i++ {
+			blob, err := table.retrieve(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(blob, bytes.Repeat([]byte{byte(i)}, int(i))) {
+				t.Errorf("unexpected item %v: %x", i, blob)
+			}
+		}
+		if _, err := table.retrieve(items); err != errOutOfBounds {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	checkItems(10)
+
+	if err := table.truncate(8); err != nil {
+		t.Fatal(err)
+	}
+	checkItems(8)
+
+	// the last item partially written is dropped at reopening
+	table.close()
+	if err := os.Truncate(filepath.Join(dir, "test.dat"), int64(1+2+3+4+5+6+7-1)); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = newFreezerTable(dir, "test"); err != nil {
+		t.Fatal(err)
+	}
+	checkItems(7)
+	if err := table.append(7, bytes.Repeat([]byte{7}, 7)); err != nil {
+		t.Fatal(err)
+	}
+	checkItems(8)
+	table.close()
+}
+
+// writeTestChain writes a canonical chain of the number of blocks, with the
+// receipts and commit signatures of the blocks
+func writeTestChain(t *testing.T, db ethdb.KeyValueStore, blocks int) []*types.Block {
+	var (
+		chain  []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i != blocks; i++ {
+		header := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(int64(i))).
+			ParentHash(parent).
+			Extra([]byte(fmt.Sprintf("block %v", i))).
+			Header()
+		block := types.NewBlockWithHeader(header)
+		if err := WriteBlock(db, block); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatal(err)
+		}
+		receipts := types.Receipts{&types.Receipt{CumulativeGasUsed: uint64(i), Logs: []*types.Log{}}}
+		if err := WriteReceipts(db, block.Hash(), block.NumberU64(), receipts); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteBlockCommitSig(db, block.NumberU64(), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, block)
+		parent = block.Hash()
+	}
+	if err := WriteHeadBlockHash(db, parent); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestFreezerDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kv := rawdb.NewMemoryDatabase()
+	chain := writeTestChain(t, kv, 10)
+	db, err := NewDatabaseWithFreezer(kv, dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	moved, err := MigrateToFreezer(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frozen, _ := db.Ancients(); moved != 6 || frozen != 6 {
+		t.Fatalf("unexpected blocks moved %v, frozen %v", moved, frozen)
+	}
+	for _, block := range chain {
+		hash, number := block.Hash(), block.NumberU64()
+		frozen := number > 0 && number < 6
+		if has, _ := kv.Has(headerKey(number, hash)); has == frozen {
+			t.Errorf("block %v: unexpected header in the key value store: %v", number, has)
+		}
+		if has, _ := kv.Has(blockCommitSigKey(number)); has == frozen {
+			t.Errorf("block %v: unexpected commit sig in the key value store: %v", number, has)
+		}
+		if b := ReadBlock(db, hash, number); b == nil || b.Hash() != hash {
+			t.Errorf("block %v: unexpected block read", number)
+		}
+		if !HasHeader(db, hash, number) || !HasBody(db, hash, number) {
+			t.Errorf("block %v: header or body not found", number)
+		}
+		if receipts := ReadReceipts(db, hash, number); len(receipts) != 1 ||
+			receipts[0].CumulativeGasUsed != number {
+			t.Errorf("block %v: unexpected receipts %v", number, receipts)
+		}
+		if sig, err := ReadBlockCommitSig(db, number); err != nil || !bytes.Equal(sig, []byte{byte(number)}) {
+			t.Errorf("block %v: unexpected commit sig %x: %v", number, sig, err)
+		}
+	}
+	if HasHeader(db, common.Hash{1}, 3) || ReadHeader(db, common.Hash{1}, 3) != nil {
+		t.Errorf("unexpected header of unknown hash")
+	}
+
+	// the frozen blocks rewound are discarded
+	for number := uint64(4); number != 10; number++ {
+		if err := DeleteCanonicalHash(db, number); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteHeadBlockHash(db, chain[3].Hash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateToFreezer(db); err != nil {
+		t.Fatal(err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 4 {
+		t.Fatalf("unexpected frozen %v after rewind", frozen)
+	}
+	if ReadHeader(db, chain[5].Hash(), 5) != nil {
+		t.Errorf("unexpected header of block rewound")
+	}
+	if ReadHeader(db, chain[3].Hash(), 3) == nil {
+		t.Errorf("header of block 3 not found")
+	}
+}
+
+func TestFreezerDB_Reopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kv := rawdb.NewMemoryDatabase()
+	chain := writeTestChain(t, kv, 5)
+	f, err := newFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fdb := &freezerdb{KeyValueStore: kv, freezer: f, threshold: 1}
+	if moved, err := fdb.freeze(freezerBatchLimit); err != nil || moved != 3 {
+		t.Fatalf("unexpected blocks moved %v: %v", moved, err)
+	}
+	// a block partially frozen on crash
+	if err := f.tables[freezerHashTable].append(3, chain[3].Hash().Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	f.close()
+
+	if f, err = newFreezer(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+	if frozen := f.ancients(); frozen != 3 {
+		t.Fatalf("unexpected frozen %v", frozen)
+	}
+	for _, table := range f.tables {
+		if items := table.numItems(); items != 3 {
+			t.Errorf("%v: unexpected items %v", table.name, items)
+		}
+	}
+}
+
+func TestFreezerDB_Backfill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kv := rawdb.NewMemoryDatabase()
+	writeTestChain(t, kv, 10)
+	f, err := newFreezer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.close()
+	fdb := &freezerdb{KeyValueStore: kv, freezer: f, threshold: 3}
+
+	// the history before the checkpoint is not backfilled yet
+	if err := WriteBackfillTail(kv, 5); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := fdb.freeze(freezerBatchLimit); err != nil || moved != 0 {
+		t.Fatalf("unexpected blocks moved %v: %v", moved, err)
+	}
+	if err := WriteBackfillTail(kv, 1); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := fdb.freeze(freezerBatchLimit); err != nil || moved != 6 {
+		t.Fatalf("unexpected blocks moved %v: %v", moved, err)
+	}
+}
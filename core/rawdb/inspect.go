@@ -0,0 +1,192 @@
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/shard"
+	staking "github.com/harmony-one/harmony/staking/types"
+)
+
+// KeyStat is the number and total size of the entries of a kind of key
+type KeyStat struct {
+	Name  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+// keyKind is a kind of key of the schema, matched by its prefix and, for the
+// kinds sharing a prefix, by the length of the key
+type keyKind struct {
+	name   string
+	prefix []byte
+	length int // length of the key, any if zero
+}
+
+// keyKinds are the kinds of keys of the schema, in the order of the stats
+var keyKinds = []keyKind{
+	{name: "database version", prefix: databaseVerisionKey},
+	{name: "head header", prefix: headHeaderKey},
+	{name: "head block", prefix: headBlockKey},
+	{name: "head fast block", prefix: headFastBlockKey},
+	{name: "flat trace index head", prefix: flatTraceIndexHeadKey},
+	{name: "checkpoint", prefix: checkpointKey},
+	{name: "backfill tail", prefix: backfillTailKey},
+	{name: "signing index head", prefix: signingIndexHeadKey},
+	{name: "headers", prefix: headerPrefix, length: len(headerKey(0, common.Hash{}))},
+	{name: "total difficulties", prefix: headerPrefix, length: len(headerTDKey(0, common.Hash{}))},
+	{name: "canonical hashes", prefix: headerPrefix, length: len(headerHashKey(0))},
+	{name: "header numbers", prefix: headerNumberPrefix},
+	{name: "bodies", prefix: blockBodyPrefix},
+	{name: "receipts", prefix: blockReceiptsPrefix},
+	{name: "tx lookups", prefix: txLookupPrefix},
+	{name: "cx lookups", prefix: cxLookupPrefix},
+	{name: "bloom bits", prefix: bloomBitsPrefix},
+	{name: "shard states", prefix: shardStatePrefix},
+	{name: "last commits", prefix: lastCommitsKey},
+	{name: "block commit sigs", prefix: blockCommitSigPrefix},
+	{name: "pending crosslinks", prefix: pendingCrosslinkKey},
+	{name: "pending slashings", prefix: pendingSlashingKey},
+	{name: "preimages", prefix: preimagePrefix},
+	{name: "chain configs", prefix: configPrefix},
+	{name: "crosslinks", prefix: crosslinkPrefix},
+	{name: "delegator validator lists", prefix: delegatorValidatorListPrefix},
+	{name: "cx receipts", prefix: cxReceiptPrefix},
+	{name: "cx receipts spent", prefix: cxReceiptSpentPrefix},
+	{name: "validator snapshots", prefix: validatorSnapshotPrefix},
+	{name: "validator stats", prefix: validatorStatsPrefix},
+	{name: "validator list", prefix: validatorListKey},
+	{name: "validator signing", prefix: validatorSigningPrefix},
+	{name: "validator aprs", prefix: validatorAPRPrefix},
+	{name: "flat traces", prefix: flatTracePrefix},
+	{name: "epoch block numbers", prefix: epochBlockNumberPrefix},
+	{name: "epoch vrf block numbers", prefix: epochVrfBlockNumbersPrefix},
+	{name: "epoch vdf block numbers", prefix: epochVdfBlockNumberPrefix},
+	{name: "bloom bits index", prefix: BloomBitsIndexPrefix},
+	{name: "block rewards", prefix: currentRewardGivenOutPrefix},
+	{name: "trie nodes and codes"},
+	{name: "other"},
+}
+
+var (
+	trieKind  = len(keyKinds) - 2
+	otherKind = len(keyKinds) - 1
+)
+
+// keyKindsByPrefix are the indexes of the key kinds of the schema, the longest
+// prefixes first so that a prefix of another prefix is matched last
+var keyKindsByPrefix = func() []int {
+	var indexes []int
+	for i, kind := range keyKinds {
+		if len(kind.prefix) != 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return len(keyKinds[indexes[i]].prefix) > len(keyKinds[indexes[j]].prefix)
+	})
+	return indexes
+}()
+
+// keyKindOf returns the index of the kind of the key. The keys of the hash
+// length are the trie nodes and contract codes.
+func keyKindOf(key []byte) int {
+	if len(key) == common.HashLength {
+		return trieKind
+	}
+	for _, i := range keyKindsByPrefix {
+		kind := keyKinds[i]
+		if bytes.HasPrefix(key, kind.prefix) && (kind.length == 0 || kind.length == len(key)) {
+			return i
+		}
+	}
+	return otherKind
+}
+
+// KeyKind returns the name of the kind of the key in the schema
+func KeyKind(key []byte) string {
+	return keyKinds[keyKindOf(key)].name
+}
+
+// DecodeEntry returns the value of the entry decoded if the key is a header, a
+// shard state, a validator snapshot or a crosslink, or nil if the kind of the
+// key has no typed decoding
+func DecodeEntry(key, value []byte) (interface{}, error) {
+	switch kind := keyKinds[keyKindOf(key)]; {
+	case bytes.Equal(kind.prefix, headerPrefix) && kind.length == len(headerKey(0, common.Hash{})):
+		header := new(block.Header)
+		if err := rlp.DecodeBytes(value, header); err != nil {
+			return nil, err
+		}
+		return header, nil
+	case bytes.Equal(kind.prefix, shardStatePrefix):
+		return shard.DecodeWrapper(value)
+	case bytes.Equal(kind.prefix, validatorSnapshotPrefix):
+		wrapper := new(staking.ValidatorWrapper)
+		if err := rlp.DecodeBytes(value, wrapper); err != nil {
+			return nil, err
+		}
+		// the key is the prefix, the validator address and the epoch
+		var epoch []byte
+		if n := len(validatorSnapshotPrefix) + common.AddressLength; len(key) > n {
+			epoch = key[n:]
+		}
+		return &staking.ValidatorSnapshot{
+			Validator: wrapper,
+			Epoch:     new(big.Int).SetBytes(epoch),
+		}, nil
+	case bytes.Equal(kind.prefix, crosslinkPrefix):
+		return types.DeserializeCrossLink(value)
+	case bytes.Equal(kind.prefix, pendingCrosslinkKey):
+		crossLinks := []types.CrossLink{}
+		if err := rlp.DecodeBytes(value, &crossLinks); err != nil {
+			return nil, err
+		}
+		return crossLinks, nil
+	}
+	return nil, nil
+}
+
+// InspectDatabase iterates over the whole database, and returns the number and
+// size of the entries of every kind of key of the schema, followed by the
+// number of blocks and size of every table of the freezer if any.
+func InspectDatabase(db ethdb.Database) ([]KeyStat, error) {
+	stats := make([]KeyStat, len(keyKinds))
+	for i, kind := range keyKinds {
+		stats[i].Name = kind.name
+	}
+	it := db.NewIterator()
+	defer it.Release()
+	for it.Next() {
+		stat := &stats[keyKindOf(it.Key())]
+		stat.Count++
+		stat.Size += common.StorageSize(len(it.Key()) + len(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	frozen, err := db.Ancients()
+	if err != nil || frozen == 0 {
+		// no freezer
+		return stats, nil
+	}
+	for _, table := range freezerTables {
+		size, err := db.AncientSize(table)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, KeyStat{
+			Name:  "ancient " + table,
+			Count: frozen,
+			Size:  common.StorageSize(size),
+		})
+	}
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+	staking "github.com/harmony-one/harmony/staking/types"
+)
+
+func TestKeyKind(t *testing.T) {
+	tests := []struct {
+		key  []byte
+		kind string
+	}{
+		{headerKey(1, common.Hash{1}), "headers"},
+		{headerTDKey(1, common.Hash{1}), "total difficulties"},
+		{headerHashKey(1), "canonical hashes"},
+		{headerNumberKey(common.Hash{1}), "header numbers"},
+		{cxLookupKey(common.Hash{1}), "cx lookups"},
+		{cxReceiptKey(1, 2, common.Hash{1}), "cx receipts"},
+		{cxReceiptSpentKey(1, 2), "cx receipts spent"},
+		{blockCommitSigKey(1), "block commit sigs"},
+		{blockRewardAccumKey(1), "block rewards"},
+		{epochBlockNumberKey(big.NewInt(1)), "epoch block numbers"},
+		{validatorListKey, "validator list"},
+		{checkpointKey, "checkpoint"},
+		{backfillTailKey, "backfill tail"},
+		{validatorSnapshotKey(common.Address{1}, big.NewInt(1)), "validator snapshots"},
+		{validatorSigningKey(common.Address{1}, 1), "validator signing"},
+		{validatorAPRKey(common.Address{1}, 1), "validator aprs"},
+		{signingIndexHeadKey, "signing index head"},
+		{common.Hash{1}.Bytes(), "trie nodes and codes"},
+		{[]byte("unknown"), "other"},
+	}
+	for i, test := range tests {
+		if kind := KeyKind(test.key); kind != test.kind {
+			t.Errorf("Test %v: unexpected kind %v / %v", i, kind, test.kind)
+		}
+	}
+}
+
+func TestInspectDatabase(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	writeTestChain(t, db, 3)
+	db.Put(common.Hash{1}.Bytes(), []byte{1})
+
+	stats, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]uint64)
+	for _, stat := range stats {
+		counts[stat.Name] = stat.Count
+		if (stat.Count == 0) != (stat.Size == 0) {
+			t.Errorf("%v: unexpected size %v of %v entries", stat.Name, stat.Size, stat.Count)
+		}
+	}
+	expected := map[string]uint64{
+		"head block":           1,
+		"headers":              3,
+		"canonical hashes":     3,
+		"header numbers":       3,
+		"bodies":               3,
+		"receipts":             3,
+		"block commit sigs":    3,
+		"trie nodes and codes": 1,
+		"other":                0,
+	}
+	for name, count := range expected {
+		if counts[name] != count {
+			t.Errorf("%v: unexpected count %v / %v", name, counts[name], count)
+		}
+	}
+}
+
+func TestDecodeEntry(t *testing.T) {
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(3)).Header()
+	headerRLP, _ := rlp.EncodeToBytes(header)
+	decoded, err := DecodeEntry(headerKey(3, header.Hash()), headerRLP)
+	if h, ok := decoded.(*block.Header); err != nil || !ok || h.Hash() != header.Hash() {
+		t.Errorf("unexpected header decoded %v: %v", decoded, err)
+	}
+
+	wrapper := staking.ValidatorWrapper{}
+	wrapper.Address = common.Address{1}
+	wrapper.BlockReward = big.NewInt(0)
+	wrapperRLP, err := rlp.EncodeToBytes(&wrapper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err = DecodeEntry(validatorSnapshotKey(common.Address{1}, big.NewInt(300)), wrapperRLP)
+	if s, ok := decoded.(*staking.ValidatorSnapshot); err != nil || !ok ||
+		s.Epoch.Int64() != 300 || s.Validator.Address != wrapper.Address {
+		t.Errorf("unexpected validator snapshot decoded %v: %v", decoded, err)
+	}
+
+	crossLink := types.CrossLink{ShardIDF: 1, BlockNumberF: big.NewInt(10)}
+	decoded, err = DecodeEntry(crosslinkKey(1, 10), crossLink.Serialize())
+	if cl, ok := decoded.(*types.CrossLink); err != nil || !ok || cl.BlockNum() != 10 {
+		t.Errorf("unexpected crosslink decoded %v: %v", decoded, err)
+	}
+
+	if decoded, err := DecodeEntry(blockCommitSigKey(1), []byte{1}); decoded != nil || err != nil {
+		t.Errorf("unexpected decoding %v: %v", decoded, err)
+	}
+	if _, err := DecodeEntry(headerKey(3, header.Hash()), []byte{1, 2}); err == nil {
+		t.Errorf("unexpected header decoded from invalid RLP")
+	}
+}
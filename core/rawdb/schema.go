@@ -35,6 +35,14 @@ var (
 	headBlockKey = []byte("LastBlock")
 	// headFastBlockKey tracks the latest known incomplete block's hash duirng fast sync.
 	headFastBlockKey = []byte("LastFast")
+	// flatTraceIndexHeadKey tracks the number and hash of the latest block with indexed flat traces.
+	flatTraceIndexHeadKey = []byte("LastFlatTraceIndexed")
+	// checkpointKey tracks the hash of the checkpoint block the chain was started from.
+	checkpointKey = []byte("Checkpoint")
+	// backfillTailKey tracks the number of the lowest block backfilled before the checkpoint.
+	backfillTailKey = []byte("BackfillTail")
+	// signingIndexHeadKey tracks the number of the latest block with indexed validator signing.
+	signingIndexHeadKey = []byte("LastSigningIndexed")
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix                 = []byte("h")  // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix               = []byte("t")  // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -60,6 +68,9 @@ var (
 	validatorSnapshotPrefix = []byte("validator-snapshot") // prefix for staking validator's snapshot information
 	validatorStatsPrefix    = []byte("validator-stats")    // prefix for staking validator's stats information
 	validatorListKey        = []byte("validator-list")     // key for all validators list
+	validatorSigningPrefix  = []byte("validator-signing")  // prefix + addr + epoch (uint64 big endian) -> validator's signing outcomes of the epoch
+	validatorAPRPrefix      = []byte("validator-apr")      // prefix + addr + epoch (uint64 big endian) -> validator's apr of the epoch
+	flatTracePrefix         = []byte("flat-trace-")        // flatTracePrefix + num (uint64 big endian) + hash -> flat traces of the block
 	// epochBlockNumberPrefix + epoch (big.Int.Bytes())
 	// -> epoch block number (big.Int.Bytes())
 	epochBlockNumberPrefix = []byte("harmony-epoch-block-number")
@@ -215,6 +226,14 @@ func validatorStatsKey(addr common.Address) []byte {
 	return append(prefix, addr.Bytes()...)
 }
 
+func validatorSigningKey(addr common.Address, epoch uint64) []byte {
+	return append(append(validatorSigningPrefix, addr.Bytes()...), encodeBlockNumber(epoch)...)
+}
+
+func validatorAPRKey(addr common.Address, epoch uint64) []byte {
+	return append(append(validatorAPRPrefix, addr.Bytes()...), encodeBlockNumber(epoch)...)
+}
+
 func blockRewardAccumKey(number uint64) []byte {
 	return append(currentRewardGivenOutPrefix, encodeBlockNumber(number)...)
 }
@@ -222,3 +241,8 @@ func blockRewardAccumKey(number uint64) []byte {
 func blockCommitSigKey(number uint64) []byte {
 	return append(blockCommitSigPrefix, encodeBlockNumber(number)...)
 }
+
+// flatTraceKey = flatTracePrefix + num (uint64 big endian) + hash
+func flatTraceKey(number uint64, hash common.Hash) []byte {
+	return append(append(flatTracePrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
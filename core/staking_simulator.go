@@ -0,0 +1,574 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/numeric"
+	staking "github.com/harmony-one/harmony/staking/types"
+	"github.com/pkg/errors"
+)
+
+// StakingErrorCode is the code of a failed check of a simulated staking message
+type StakingErrorCode string
+
+// Codes of the failed checks of a simulated staking message
+const (
+	StakingErrInvalidMessage           StakingErrorCode = "invalid-message"
+	StakingErrInvalidSigner            StakingErrorCode = "invalid-signer"
+	StakingErrNonceTooLow              StakingErrorCode = "nonce-too-low"
+	StakingErrIntrinsicGas             StakingErrorCode = "intrinsic-gas-too-low"
+	StakingErrInsufficientBalance      StakingErrorCode = "insufficient-balance"
+	StakingErrNegativeAmount           StakingErrorCode = "negative-amount"
+	StakingErrValidatorExists          StakingErrorCode = "validator-exists"
+	StakingErrValidatorNotFound        StakingErrorCode = "validator-not-found"
+	StakingErrDuplicateIdentity        StakingErrorCode = "duplicate-identity"
+	StakingErrDuplicateBLSKey          StakingErrorCode = "duplicate-bls-key"
+	StakingErrInvalidBLSKeys           StakingErrorCode = "invalid-bls-keys"
+	StakingErrInvalidDescription       StakingErrorCode = "invalid-description"
+	StakingErrCommissionRateOutOfRange StakingErrorCode = "commission-rate-out-of-range"
+	StakingErrCommissionRateTooHigh    StakingErrorCode = "commission-rate-too-high"
+	StakingErrCommissionRateTooFast    StakingErrorCode = "commission-rate-change-too-fast"
+	StakingErrMinSelfDelegationTooLow  StakingErrorCode = "min-self-delegation-too-low"
+	StakingErrBelowMinSelfDelegation   StakingErrorCode = "below-min-self-delegation"
+	StakingErrAboveMaxTotalDelegation  StakingErrorCode = "above-max-total-delegation"
+	StakingErrDelegationTooSmall       StakingErrorCode = "delegation-too-small"
+	StakingErrNoDelegation             StakingErrorCode = "no-delegation"
+	StakingErrInsufficientDelegation   StakingErrorCode = "insufficient-delegation"
+	StakingErrNoRewards                StakingErrorCode = "no-rewards"
+	StakingErrRejected                 StakingErrorCode = "rejected"
+)
+
+// stakingErrorCodes are the codes of the errors returned by the staking verifier
+var stakingErrorCodes = map[error]StakingErrorCode{
+	errInvalidSigner:                 StakingErrInvalidSigner,
+	errInsufficientBalanceForGas:     StakingErrInsufficientBalance,
+	errInsufficientBalanceForStake:   StakingErrInsufficientBalance,
+	errValidatorExist:                StakingErrValidatorExists,
+	errValidatorNotExist:             StakingErrValidatorNotFound,
+	errNoDelegationToUndelegate:      StakingErrNoDelegation,
+	errCommissionRateChangeTooFast:   StakingErrCommissionRateTooFast,
+	errCommissionRateChangeTooHigh:   StakingErrCommissionRateTooHigh,
+	errNoRewardsToCollect:            StakingErrNoRewards,
+	errNegativeAmount:                StakingErrNegativeAmount,
+	errDupIdentity:                   StakingErrDuplicateIdentity,
+	errDupBlsKey:                     StakingErrDuplicateBLSKey,
+	errDelegationTooSmall:            StakingErrDelegationTooSmall,
+	staking.ErrInvalidSelfDelegation: StakingErrBelowMinSelfDelegation,
+	staking.ErrExcessiveBLSKeys:      StakingErrInvalidBLSKeys,
+}
+
+var minimumSelfDelegation = new(big.Int).Mul(oneAsBigInt, big.NewInt(staking.TenThousand))
+
+// StakingFailure is a failed check of a simulated staking message
+type StakingFailure struct {
+	Code    StakingErrorCode `json:"code"`
+	Message string           `json:"message"`
+}
+
+// StakingSimulation is the result of a staking message simulated against a state
+type StakingSimulation struct {
+	Directive staking.Directive
+	Gas       uint64 // intrinsic gas of the staking transaction
+	// Validators changed by the message, if valid
+	Validators []*staking.ValidatorWrapper
+	// Balance added to (positive) or deducted from (negative) the sender, if valid
+	BalanceChange *big.Int
+	// Locked undelegated tokens redelegated from each validator, if valid
+	Redelegated map[common.Address]*big.Int
+	Failures    []StakingFailure
+}
+
+// Valid returns whether the simulated staking message passed all the checks
+func (sim *StakingSimulation) Valid() bool {
+	return len(sim.Failures) == 0
+}
+
+// HasFailure returns whether a check of the code failed
+func (sim *StakingSimulation) HasFailure(code StakingErrorCode) bool {
+	for _, f := range sim.Failures {
+		if f.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (sim *StakingSimulation) fail(code StakingErrorCode, format string, args ...interface{}) {
+	sim.Failures = append(sim.Failures, StakingFailure{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// dropChangesIfInvalid drops the changes of the simulated message if a check failed
+func (sim *StakingSimulation) dropChangesIfInvalid() {
+	if !sim.Valid() {
+		sim.Validators, sim.BalanceChange, sim.Redelegated = nil, nil, nil
+	}
+}
+
+// failVerifier records the error of the staking verifier, unless a check of
+// the same code already failed
+func (sim *StakingSimulation) failVerifier(err error) {
+	code, ok := stakingErrorCodes[errors.Cause(err)]
+	if !ok {
+		code = StakingErrRejected
+	}
+	if !sim.HasFailure(code) {
+		sim.fail(code, "%v", err)
+	}
+}
+
+// SimulateStakingTransaction simulates the signed staking transaction against
+// the state at the epoch and block number, without changing the state. Along
+// with the checks of the staking message, the signer, nonce, gas and balance
+// paying for the gas are checked.
+func SimulateStakingTransaction(
+	stateDB vm.StateDB, chainContext ChainContext, config *params.ChainConfig,
+	epoch, blockNum *big.Int, tx *staking.StakingTransaction,
+) *StakingSimulation {
+	msg, err := staking.RLPDecodeStakeMsg(tx.Data(), tx.StakingType())
+	if err != nil {
+		sim := &StakingSimulation{Directive: tx.StakingType()}
+		sim.fail(StakingErrInvalidMessage, "cannot decode staking message: %v", err)
+		return sim
+	}
+	stakeMsg, ok := msg.(staking.StakeMsg)
+	if !ok {
+		sim := &StakingSimulation{Directive: tx.StakingType()}
+		sim.fail(StakingErrInvalidMessage, "unknown staking message %v", tx.StakingType())
+		return sim
+	}
+	sim := SimulateStakingMessage(stateDB, chainContext, config, epoch, blockNum, stakeMsg)
+
+	sender, err := tx.SenderAddress()
+	if err != nil {
+		sim.fail(StakingErrInvalidSigner, "cannot recover sender: %v", err)
+		sim.dropChangesIfInvalid()
+		return sim
+	}
+	if signer := stakingMsgSigner(stakeMsg); sender != signer {
+		sim.fail(StakingErrInvalidSigner, "sender %v not matching the message address %v",
+			sender.Hex(), signer.Hex())
+	}
+	if nonce := stateDB.GetNonce(sender); tx.Nonce() < nonce {
+		sim.fail(StakingErrNonceTooLow, "nonce %v lower than the account nonce %v", tx.Nonce(), nonce)
+	}
+	if tx.GasLimit() < sim.Gas {
+		sim.fail(StakingErrIntrinsicGas, "gas limit %v lower than the intrinsic gas %v", tx.GasLimit(), sim.Gas)
+	}
+	fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.GasLimit()))
+	cost := new(big.Int).Set(fee)
+	if sim.BalanceChange != nil && sim.BalanceChange.Sign() < 0 {
+		cost.Sub(cost, sim.BalanceChange)
+	}
+	if balance := stateDB.GetBalance(sender); balance.Cmp(cost) < 0 &&
+		!sim.HasFailure(StakingErrInsufficientBalance) {
+		sim.fail(StakingErrInsufficientBalance, "balance %v lower than the gas fee %v and amount staked",
+			balance, fee)
+	}
+	sim.dropChangesIfInvalid()
+	return sim
+}
+
+// SimulateStakingMessage simulates the unsigned staking message against the
+// state at the epoch and block number, without changing the state. Every check
+// of the message is reported in the failures, and the changes of the validators
+// and the sender balance are given if the message is valid.
+func SimulateStakingMessage(
+	stateDB vm.StateDB, chainContext ChainContext, config *params.ChainConfig,
+	epoch, blockNum *big.Int, msg staking.StakeMsg,
+) *StakingSimulation {
+	sim := &StakingSimulation{Directive: msg.Type()}
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		sim.fail(StakingErrInvalidMessage, "cannot encode staking message: %v", err)
+		return sim
+	}
+	sim.Gas, err = IntrinsicGas(data, false, config.IsS3(epoch), config.IsIstanbul(epoch),
+		msg.Type() == staking.DirectiveCreateValidator)
+	if err != nil {
+		sim.fail(StakingErrInvalidMessage, "%v", err)
+		return sim
+	}
+
+	switch msg := msg.(type) {
+	case *staking.CreateValidator:
+		simulateCreateValidator(sim, stateDB, chainContext, epoch, blockNum, msg)
+	case *staking.EditValidator:
+		simulateEditValidator(sim, stateDB, chainContext, epoch, blockNum, msg)
+	case *staking.Delegate:
+		simulateDelegate(sim, stateDB, chainContext, config, epoch, msg)
+	case *staking.Undelegate:
+		simulateUndelegate(sim, stateDB, epoch, msg)
+	case *staking.CollectRewards:
+		simulateCollectRewards(sim, stateDB, chainContext, msg)
+	default:
+		sim.fail(StakingErrInvalidMessage, "unknown staking message %v", msg.Type())
+	}
+	sim.dropChangesIfInvalid()
+	return sim
+}
+
+func simulateCreateValidator(
+	sim *StakingSimulation, stateDB vm.StateDB, chainContext ChainContext,
+	epoch, blockNum *big.Int, msg *staking.CreateValidator,
+) {
+	if msg.Amount == nil || msg.MinSelfDelegation == nil || msg.MaxTotalDelegation == nil ||
+		msg.Rate.IsNil() || msg.MaxRate.IsNil() || msg.MaxChangeRate.IsNil() {
+		sim.fail(StakingErrInvalidMessage, "amount, delegation limits and commission rates are required")
+		return
+	}
+	if msg.Amount.Sign() < 0 {
+		sim.fail(StakingErrNegativeAmount, "amount %v is negative", msg.Amount)
+	}
+	if stateDB.IsValidator(msg.ValidatorAddress) {
+		sim.fail(StakingErrValidatorExists, "validator %v already exists", msg.ValidatorAddress.Hex())
+	}
+	if err := checkDuplicateFieldsAll(sim, chainContext, stateDB, msg.ValidatorAddress, msg.Identity, msg.SlotPubKeys); err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	if balance := stateDB.GetBalance(msg.ValidatorAddress); balance.Cmp(msg.Amount) < 0 {
+		sim.fail(StakingErrInsufficientBalance, "balance %v lower than the amount %v", balance, msg.Amount)
+	}
+	checkDescription(sim, msg.Description)
+	checkCommissionRates(sim, msg.CommissionRates)
+	checkDelegationLimits(sim, msg.MinSelfDelegation, msg.MaxTotalDelegation)
+	if msg.Amount.Cmp(msg.MinSelfDelegation) < 0 {
+		sim.fail(StakingErrBelowMinSelfDelegation, "amount %v lower than the min self delegation %v",
+			msg.Amount, msg.MinSelfDelegation)
+	}
+	if msg.Amount.Cmp(msg.MaxTotalDelegation) > 0 {
+		sim.fail(StakingErrAboveMaxTotalDelegation, "amount %v higher than the max total delegation %v",
+			msg.Amount, msg.MaxTotalDelegation)
+	}
+	checkSlotKeys(sim, msg.SlotPubKeys)
+	if err := staking.VerifyBLSKeys(msg.SlotPubKeys, msg.SlotKeySigs); err != nil {
+		sim.fail(StakingErrInvalidBLSKeys, "%v", err)
+	}
+
+	wrapper, err := VerifyAndCreateValidatorFromMsg(stateDB, chainContext, epoch, blockNum, msg)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	sim.Validators = []*staking.ValidatorWrapper{wrapper}
+	sim.BalanceChange = new(big.Int).Neg(msg.Amount)
+}
+
+func simulateEditValidator(
+	sim *StakingSimulation, stateDB vm.StateDB, chainContext ChainContext,
+	epoch, blockNum *big.Int, msg *staking.EditValidator,
+) {
+	if !stateDB.IsValidator(msg.ValidatorAddress) {
+		sim.fail(StakingErrValidatorNotFound, "validator %v not found", msg.ValidatorAddress.Hex())
+		return
+	}
+	wrapper, err := stateDB.ValidatorWrapperCopy(msg.ValidatorAddress)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	var newKeys []bls.SerializedPublicKey
+	if msg.SlotKeyToAdd != nil {
+		newKeys = append(newKeys, *msg.SlotKeyToAdd)
+	}
+	if err := checkDuplicateFieldsAll(sim, chainContext, stateDB, msg.ValidatorAddress, msg.Identity, newKeys); err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	if _, err := staking.UpdateDescription(wrapper.Description, msg.Description); err != nil {
+		sim.fail(StakingErrInvalidDescription, "%v", err)
+	}
+
+	if msg.CommissionRate != nil && !msg.CommissionRate.IsNil() {
+		rate := *msg.CommissionRate
+		if rate.IsNegative() || rate.GT(numeric.OneDec()) {
+			sim.fail(StakingErrCommissionRateOutOfRange, "commission rate %v out of range [0, 1]", rate)
+		}
+		if rate.GT(wrapper.MaxRate) {
+			sim.fail(StakingErrCommissionRateTooHigh, "commission rate %v higher than the max rate %v",
+				rate, wrapper.MaxRate)
+		}
+		if snapshot, err := chainContext.ReadValidatorSnapshot(msg.ValidatorAddress); err == nil &&
+			!snapshot.Validator.Rate.IsNil() &&
+			rate.Sub(snapshot.Validator.Rate).Abs().GT(wrapper.MaxChangeRate) {
+			sim.fail(StakingErrCommissionRateTooFast, "commission rate change from %v to %v more than the max change rate %v",
+				snapshot.Validator.Rate, rate, wrapper.MaxChangeRate)
+		}
+	}
+
+	minSelf, maxTotal := wrapper.MinSelfDelegation, wrapper.MaxTotalDelegation
+	if msg.MinSelfDelegation != nil && msg.MinSelfDelegation.Sign() != 0 {
+		minSelf = msg.MinSelfDelegation
+	}
+	if msg.MaxTotalDelegation != nil && msg.MaxTotalDelegation.Sign() != 0 {
+		maxTotal = msg.MaxTotalDelegation
+	}
+	checkDelegationLimits(sim, minSelf, maxTotal)
+	if total := wrapper.TotalDelegation(); total.Cmp(maxTotal) > 0 {
+		sim.fail(StakingErrAboveMaxTotalDelegation, "total delegation %v higher than the max total delegation %v",
+			total, maxTotal)
+	}
+
+	if msg.SlotKeyToRemove != nil && !hasSlotKey(wrapper.SlotPubKeys, *msg.SlotKeyToRemove) {
+		sim.fail(StakingErrInvalidBLSKeys, "slot key to remove %v not found", msg.SlotKeyToRemove.Hex())
+	}
+	if msg.SlotKeyToAdd != nil {
+		if hasSlotKey(wrapper.SlotPubKeys, *msg.SlotKeyToAdd) {
+			sim.fail(StakingErrInvalidBLSKeys, "slot key to add %v already exists", msg.SlotKeyToAdd.Hex())
+		}
+		if msg.SlotKeyToAddSig == nil {
+			sim.fail(StakingErrInvalidBLSKeys, "signature of slot key to add %v missing", msg.SlotKeyToAdd.Hex())
+		} else if err := staking.VerifyBLSKey(msg.SlotKeyToAdd, msg.SlotKeyToAddSig); err != nil {
+			sim.fail(StakingErrInvalidBLSKeys, "%v", err)
+		}
+	}
+
+	edited, err := VerifyAndEditValidatorFromMsg(stateDB, chainContext, epoch, blockNum, msg)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	sim.Validators = []*staking.ValidatorWrapper{edited}
+	sim.BalanceChange = big.NewInt(0)
+}
+
+func simulateDelegate(
+	sim *StakingSimulation, stateDB vm.StateDB, chainContext ChainContext,
+	config *params.ChainConfig, epoch *big.Int, msg *staking.Delegate,
+) {
+	if msg.Amount == nil {
+		sim.fail(StakingErrInvalidMessage, "amount is required")
+		return
+	}
+	if !stateDB.IsValidator(msg.ValidatorAddress) {
+		sim.fail(StakingErrValidatorNotFound, "validator %v not found", msg.ValidatorAddress.Hex())
+	}
+	if msg.Amount.Sign() < 0 {
+		sim.fail(StakingErrNegativeAmount, "amount %v is negative", msg.Amount)
+	}
+	if msg.Amount.Cmp(minimumDelegation) < 0 {
+		sim.fail(StakingErrDelegationTooSmall, "amount %v lower than the minimum delegation %v",
+			msg.Amount, minimumDelegation)
+	}
+	if !sim.HasFailure(StakingErrValidatorNotFound) {
+		wrapper, err := stateDB.ValidatorWrapperCopy(msg.ValidatorAddress)
+		if err != nil {
+			sim.failVerifier(err)
+			return
+		}
+		total := new(big.Int).Add(wrapper.TotalDelegation(), msg.Amount)
+		if total.Cmp(wrapper.MaxTotalDelegation) > 0 {
+			sim.fail(StakingErrAboveMaxTotalDelegation, "total delegation %v higher than the max total delegation %v",
+				total, wrapper.MaxTotalDelegation)
+		}
+	}
+
+	delegations, err := chainContext.ReadDelegationsByDelegator(msg.DelegatorAddress)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	if !sim.Valid() {
+		return
+	}
+	wrappers, deducted, redelegated, err := VerifyAndDelegateFromMsg(
+		stateDB, epoch, msg, delegations, config.IsRedelegation(epoch),
+	)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	sim.Validators = wrappers
+	sim.BalanceChange = new(big.Int).Neg(deducted)
+	sim.Redelegated = redelegated
+}
+
+func simulateUndelegate(
+	sim *StakingSimulation, stateDB vm.StateDB, epoch *big.Int, msg *staking.Undelegate,
+) {
+	if msg.Amount == nil {
+		sim.fail(StakingErrInvalidMessage, "amount is required")
+		return
+	}
+	if msg.Amount.Sign() < 0 {
+		sim.fail(StakingErrNegativeAmount, "amount %v is negative", msg.Amount)
+	}
+	if !stateDB.IsValidator(msg.ValidatorAddress) {
+		sim.fail(StakingErrValidatorNotFound, "validator %v not found", msg.ValidatorAddress.Hex())
+		return
+	}
+	wrapper, err := stateDB.ValidatorWrapperCopy(msg.ValidatorAddress)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	var delegation *staking.Delegation
+	for i := range wrapper.Delegations {
+		if wrapper.Delegations[i].DelegatorAddress == msg.DelegatorAddress {
+			delegation = &wrapper.Delegations[i]
+			break
+		}
+	}
+	if delegation == nil {
+		sim.fail(StakingErrNoDelegation, "no delegation of %v to validator %v",
+			msg.DelegatorAddress.Hex(), msg.ValidatorAddress.Hex())
+		return
+	}
+	if delegation.Amount.Cmp(msg.Amount) < 0 {
+		sim.fail(StakingErrInsufficientDelegation, "amount %v higher than the delegation %v",
+			msg.Amount, delegation.Amount)
+	}
+	if !sim.Valid() {
+		return
+	}
+
+	undelegated, err := VerifyAndUndelegateFromMsg(stateDB, epoch, msg)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	sim.Validators = []*staking.ValidatorWrapper{undelegated}
+	sim.BalanceChange = big.NewInt(0)
+}
+
+func simulateCollectRewards(
+	sim *StakingSimulation, stateDB vm.StateDB, chainContext ChainContext, msg *staking.CollectRewards,
+) {
+	delegations, err := chainContext.ReadDelegationsByDelegator(msg.DelegatorAddress)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	if len(delegations) == 0 {
+		sim.fail(StakingErrNoDelegation, "no delegation of %v", msg.DelegatorAddress.Hex())
+		return
+	}
+	wrappers, rewards, err := VerifyAndCollectRewardsFromDelegation(stateDB, delegations)
+	if err != nil {
+		sim.failVerifier(err)
+		return
+	}
+	sim.Validators = wrappers
+	sim.BalanceChange = rewards
+}
+
+// checkDuplicateFieldsAll reports the identity and every BLS key of the
+// validator already used by another validator, where checkDuplicateFields
+// stops at the first one
+func checkDuplicateFieldsAll(
+	sim *StakingSimulation, bc ChainContext, state vm.StateDB,
+	validator common.Address, identity string, blsKeys []bls.SerializedPublicKey,
+) error {
+	addrs, err := bc.ReadValidatorList()
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if bytes.Equal(validator.Bytes(), addr.Bytes()) {
+			continue
+		}
+		wrapper, err := state.ValidatorWrapperCopy(addr)
+		if err != nil {
+			return err
+		}
+		if identity != "" && wrapper.Identity == identity {
+			sim.fail(StakingErrDuplicateIdentity, "identity %v used by validator %v", identity, addr.Hex())
+		}
+		for _, key := range blsKeys {
+			if hasSlotKey(wrapper.SlotPubKeys, key) {
+				sim.fail(StakingErrDuplicateBLSKey, "bls key %v used by validator %v", key.Hex(), addr.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+func checkDescription(sim *StakingSimulation, desc staking.Description) {
+	if _, err := desc.EnsureLength(); err != nil {
+		sim.fail(StakingErrInvalidDescription, "%v", err)
+	}
+}
+
+func checkCommissionRates(sim *StakingSimulation, rates staking.CommissionRates) {
+	for _, r := range []struct {
+		name string
+		rate numeric.Dec
+	}{
+		{"commission rate", rates.Rate},
+		{"max commission rate", rates.MaxRate},
+		{"max change rate", rates.MaxChangeRate},
+	} {
+		if r.rate.IsNegative() || r.rate.GT(numeric.OneDec()) {
+			sim.fail(StakingErrCommissionRateOutOfRange, "%v %v out of range [0, 1]", r.name, r.rate)
+		}
+	}
+	if rates.Rate.GT(rates.MaxRate) {
+		sim.fail(StakingErrCommissionRateTooHigh, "commission rate %v higher than the max rate %v",
+			rates.Rate, rates.MaxRate)
+	}
+	if rates.MaxChangeRate.GT(rates.MaxRate) {
+		sim.fail(StakingErrCommissionRateTooHigh, "max change rate %v higher than the max rate %v",
+			rates.MaxChangeRate, rates.MaxRate)
+	}
+}
+
+func checkDelegationLimits(sim *StakingSimulation, minSelf, maxTotal *big.Int) {
+	if minSelf.Cmp(minimumSelfDelegation) < 0 {
+		sim.fail(StakingErrMinSelfDelegationTooLow, "min self delegation %v lower than %v",
+			minSelf, minimumSelfDelegation)
+	}
+	if maxTotal.Cmp(minSelf) < 0 {
+		sim.fail(StakingErrAboveMaxTotalDelegation, "min self delegation %v higher than the max total delegation %v",
+			minSelf, maxTotal)
+	}
+}
+
+func checkSlotKeys(sim *StakingSimulation, keys []bls.SerializedPublicKey) {
+	if len(keys) == 0 {
+		sim.fail(StakingErrInvalidBLSKeys, "at least one slot key is required")
+	}
+	if len(keys) > staking.MaxBLSPerValidator {
+		sim.fail(StakingErrInvalidBLSKeys, "%v slot keys more than the maximum %v",
+			len(keys), staking.MaxBLSPerValidator)
+	}
+	seen := make(map[bls.SerializedPublicKey]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			sim.fail(StakingErrInvalidBLSKeys, "slot key %v given twice", key.Hex())
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+func hasSlotKey(keys []bls.SerializedPublicKey, key bls.SerializedPublicKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// stakingMsgSigner returns the address which must sign the staking message
+func stakingMsgSigner(msg staking.StakeMsg) common.Address {
+	switch msg := msg.(type) {
+	case *staking.CreateValidator:
+		return msg.ValidatorAddress
+	case *staking.EditValidator:
+		return msg.ValidatorAddress
+	case *staking.Delegate:
+		return msg.DelegatorAddress
+	case *staking.Undelegate:
+		return msg.DelegatorAddress
+	case *staking.CollectRewards:
+		return msg.DelegatorAddress
+	}
+	return common.Address{}
+}
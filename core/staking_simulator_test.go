@@ -0,0 +1,233 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/numeric"
+	staking "github.com/harmony-one/harmony/staking/types"
+	staketest "github.com/harmony-one/harmony/staking/types/test"
+)
+
+func TestSimulateStakingMessage(t *testing.T) {
+	tests := []struct {
+		msg      staking.StakeMsg
+		expCodes []StakingErrorCode
+		expDelta *big.Int
+	}{
+		{
+			// every failed check of create validator reported
+			msg: func() staking.StakeMsg {
+				msg := defaultMsgCreateValidator()
+				msg.Identity = makeIdentityStr(0)
+				msg.SlotPubKeys = []bls.SerializedPublicKey{makeSimTestKey(0, 0), makeSimTestKey(0, 0)}
+				msg.CommissionRates = staking.CommissionRates{
+					Rate:          numeric.NewDecWithPrec(15, 1),
+					MaxRate:       pointNineDec,
+					MaxChangeRate: pointFiveDec,
+				}
+				msg.MinSelfDelegation = fiveKOnes
+				msg.Amount = new(big.Int).Mul(hundredKOnes, big.NewInt(2))
+				return &msg
+			}(),
+			expCodes: []StakingErrorCode{
+				StakingErrDuplicateIdentity,
+				StakingErrDuplicateBLSKey,
+				StakingErrInsufficientBalance,
+				StakingErrCommissionRateOutOfRange,
+				StakingErrCommissionRateTooHigh,
+				StakingErrMinSelfDelegationTooLow,
+				StakingErrAboveMaxTotalDelegation,
+				StakingErrInvalidBLSKeys,
+			},
+		},
+		{
+			msg: func() staking.StakeMsg {
+				msg := defaultMsgCreateValidator()
+				msg.ValidatorAddress = validatorAddr
+				return &msg
+			}(),
+			expCodes: []StakingErrorCode{StakingErrValidatorExists},
+		},
+		{
+			msg: func() staking.StakeMsg {
+				msg := defaultMsgDelegate()
+				return &msg
+			}(),
+			expDelta: new(big.Int).Neg(tenKOnes),
+		},
+		{
+			msg: func() staking.StakeMsg {
+				msg := defaultMsgDelegate()
+				msg.ValidatorAddress = makeTestAddr("not a validator")
+				msg.Amount = big.NewInt(1)
+				return &msg
+			}(),
+			expCodes: []StakingErrorCode{StakingErrValidatorNotFound, StakingErrDelegationTooSmall},
+		},
+		{
+			msg: func() staking.StakeMsg {
+				msg := defaultMsgDelegate()
+				msg.Amount = new(big.Int).Add(hundredKOnes, big.NewInt(1))
+				return &msg
+			}(),
+			expCodes: []StakingErrorCode{StakingErrAboveMaxTotalDelegation},
+		},
+		{
+			msg: &staking.Undelegate{
+				DelegatorAddress: delegatorAddr,
+				ValidatorAddress: validatorAddr,
+				Amount:           tenKOnes,
+			},
+			expCodes: []StakingErrorCode{StakingErrNoDelegation},
+		},
+		{
+			msg: &staking.Undelegate{
+				DelegatorAddress: validatorAddr,
+				ValidatorAddress: validatorAddr,
+				Amount:           hundredKOnes,
+			},
+			expCodes: []StakingErrorCode{StakingErrInsufficientDelegation},
+		},
+		{
+			msg: &staking.Undelegate{
+				DelegatorAddress: validatorAddr,
+				ValidatorAddress: validatorAddr,
+				Amount:           fiveKOnes,
+			},
+			expDelta: big.NewInt(0),
+		},
+		{
+			msg:      &staking.CollectRewards{DelegatorAddress: delegatorAddr},
+			expCodes: []StakingErrorCode{StakingErrNoDelegation},
+		},
+	}
+	for i, test := range tests {
+		sdb, bc := makeStateForSimulation(t)
+		root := sdb.IntermediateRoot(true)
+		sim := SimulateStakingMessage(sdb, bc, params.TestChainConfig,
+			big.NewInt(defaultEpoch), big.NewInt(defaultBlockNumber), test.msg)
+
+		for _, code := range test.expCodes {
+			if !sim.HasFailure(code) {
+				t.Errorf("Test %v: failure %v not reported in %+v", i, code, sim.Failures)
+			}
+		}
+		if sim.Gas == 0 {
+			t.Errorf("Test %v: no gas", i)
+		}
+		if len(test.expCodes) != 0 {
+			if sim.Valid() || sim.Validators != nil || sim.BalanceChange != nil {
+				t.Errorf("Test %v: unexpected valid simulation", i)
+			}
+		} else {
+			if !sim.Valid() {
+				t.Errorf("Test %v: unexpected failures %+v", i, sim.Failures)
+			}
+			if len(sim.Validators) != 1 {
+				t.Errorf("Test %v: unexpected validators changed %v", i, len(sim.Validators))
+			}
+			if sim.BalanceChange == nil || sim.BalanceChange.Cmp(test.expDelta) != 0 {
+				t.Errorf("Test %v: unexpected balance change %v / %v", i, sim.BalanceChange, test.expDelta)
+			}
+		}
+		if newRoot := sdb.IntermediateRoot(true); newRoot != root {
+			t.Errorf("Test %v: state changed by simulation", i)
+		}
+	}
+}
+
+func TestSimulateStakingTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	makeTx := func(delegator bool, nonce, gasLimit uint64) *staking.StakingTransaction {
+		msg := defaultMsgDelegate()
+		if !delegator {
+			msg.DelegatorAddress = sender
+		}
+		tx, _ := staking.NewStakingTransaction(nonce, gasLimit, big.NewInt(1), func() (staking.Directive, interface{}) {
+			return staking.DirectiveDelegate, msg
+		})
+		signed, err := staking.Sign(tx, staking.NewEIP155Signer(params.TestChainConfig.ChainID), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	tests := []struct {
+		tx       *staking.StakingTransaction
+		expCodes []StakingErrorCode
+	}{
+		{
+			tx:       makeTx(false, 1, 1e6),
+			expCodes: nil,
+		},
+		{
+			tx:       makeTx(true, 1, 1e6),
+			expCodes: []StakingErrorCode{StakingErrInvalidSigner},
+		},
+		{
+			tx:       makeTx(false, 0, 1e6),
+			expCodes: []StakingErrorCode{StakingErrNonceTooLow},
+		},
+		{
+			tx:       makeTx(false, 1, 1),
+			expCodes: []StakingErrorCode{StakingErrIntrinsicGas},
+		},
+	}
+	for i, test := range tests {
+		sdb, bc := makeStateForSimulation(t)
+		sdb.AddBalance(sender, hundredKOnes)
+		sdb.SetNonce(sender, 1)
+		sim := SimulateStakingTransaction(sdb, bc, params.TestChainConfig,
+			big.NewInt(defaultEpoch), big.NewInt(defaultBlockNumber), test.tx)
+
+		if len(sim.Failures) != len(test.expCodes) {
+			t.Errorf("Test %v: unexpected failures %+v", i, sim.Failures)
+			continue
+		}
+		for _, code := range test.expCodes {
+			if !sim.HasFailure(code) {
+				t.Errorf("Test %v: failure %v not reported in %+v", i, code, sim.Failures)
+			}
+		}
+	}
+}
+
+// makeStateForSimulation makes the state of the default validators, with slot
+// keys of their own not generated by the BLS library
+func makeStateForSimulation(t *testing.T) (*state.DB, *fakeChainContext) {
+	sdb, err := newTestStateDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := make([]*staking.ValidatorWrapper, 0, defNumWrappersInState)
+	for i := 0; i != defNumWrappersInState; i++ {
+		pubs := make([]bls.SerializedPublicKey, 0, defNumPubPerAddr)
+		for j := 0; j != defNumPubPerAddr; j++ {
+			pubs = append(pubs, makeSimTestKey(i, j))
+		}
+		w := staketest.GetDefaultValidatorWrapperWithAddr(makeTestAddr(i), pubs)
+		w.Identity = makeIdentityStr(i)
+		w.UpdateHeight = big.NewInt(defaultSnapBlockNumber)
+		ws = append(ws, &w)
+	}
+	if err := updateStateValidators(sdb, ws); err != nil {
+		t.Fatal(err)
+	}
+	sdb.AddBalance(createValidatorAddr, hundredKOnes)
+	sdb.AddBalance(delegatorAddr, hundredKOnes)
+	sdb.IntermediateRoot(true)
+	return sdb, makeFakeChainContext(ws)
+}
+
+func makeSimTestKey(validator, index int) bls.SerializedPublicKey {
+	var key bls.SerializedPublicKey
+	key[0], key[1] = byte(validator+1), byte(index+1)
+	return key
+}
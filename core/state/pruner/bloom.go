@@ -0,0 +1,47 @@
+package pruner
+
+import "encoding/binary"
+
+// stateBloomHashes is the number of bits set in the bloom filter per hash
+const stateBloomHashes = 4
+
+// stateBloom is a bloom filter of the trie node and contract code hashes. As
+// the hashes are uniformly distributed, the bit positions are taken from the
+// bytes of the hash itself.
+type stateBloom struct {
+	bits []uint64
+	size uint64 // number of bits
+}
+
+// newStateBloom creates a bloom filter of the size in megabytes
+func newStateBloom(sizeMB uint64) *stateBloom {
+	if sizeMB == 0 {
+		sizeMB = 1
+	}
+	words := sizeMB * 1024 * 1024 / 8
+	return &stateBloom{
+		bits: make([]uint64, words),
+		size: words * 64,
+	}
+}
+
+// add adds the 32 bytes hash to the filter
+func (b *stateBloom) add(hash []byte) {
+	for i := 0; i != stateBloomHashes; i++ {
+		pos := binary.BigEndian.Uint64(hash[i*8:]) % b.size
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// contains returns whether the 32 bytes hash might have been added to the
+// filter. A false positive keeps an unreachable node in the database, which is
+// safe.
+func (b *stateBloom) contains(hash []byte) bool {
+	for i := 0; i != stateBloomHashes; i++ {
+		pos := binary.BigEndian.Uint64(hash[i*8:]) % b.size
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+// Package pruner deletes offline the state of a non-archival chain database
+// which is no longer reachable from the states kept.
+package pruner
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+var (
+	emptyRoot     = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCodeHash = crypto.Keccak256(nil)
+)
+
+// logInterval is the interval of the progress logs
+const logInterval = 8 * time.Second
+
+// Config is the config of the state pruning
+type Config struct {
+	KeepEpochs int    // Number of the last epochs whose last block state is kept
+	BloomSize  uint64 // Size of the bloom filter of the reachable nodes in megabytes
+	DryRun     bool   // Only count the entries which would be deleted
+}
+
+// Result is the result of the state pruning
+type Result struct {
+	Blocks  []uint64           // Blocks whose state is kept
+	Marked  uint64             // Trie nodes and codes reachable from the kept states
+	Deleted uint64             // Entries deleted, or to be deleted on dry run
+	Size    common.StorageSize // Size of the entries deleted
+}
+
+// Prune deletes the trie nodes and contract codes of the database which are
+// not reachable from the state of the head block, or from the states of the
+// last blocks of the last epochs. The head state is the one of the latest block
+// with its state in the database. The reachable nodes are marked in a bloom
+// filter first, and any node not marked is deleted. Nothing is deleted if a
+// kept state is incomplete, and an interrupted pruning can be run again.
+func Prune(db ethdb.Database, config Config) (Result, error) {
+	var result Result
+	headers, err := keptHeaders(db, config.KeepEpochs)
+	if err != nil {
+		return result, err
+	}
+
+	bloom := newStateBloom(config.BloomSize)
+	sdb := state.NewDatabase(db)
+	storageRoots := make(map[common.Hash]struct{})
+	for _, header := range headers {
+		utils.Logger().Info().Uint64("block", header.Number().Uint64()).
+			Str("root", header.Root().Hex()).Msg("[pruner] marking state")
+		marked, err := markState(sdb, header.Root(), bloom, storageRoots)
+		if err != nil {
+			return result, errors.Wrapf(err, "cannot mark state of block %v", header.Number())
+		}
+		result.Blocks = append(result.Blocks, header.Number().Uint64())
+		result.Marked += marked
+	}
+
+	if err := sweep(db, bloom, config.DryRun, &result); err != nil {
+		return result, err
+	}
+	if result.Deleted > 0 && !config.DryRun {
+		utils.Logger().Info().Msg("[pruner] compacting database")
+		if err := db.Compact(nil, nil); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// keptHeaders returns the headers of the blocks whose state is kept: the latest
+// block with its state in the database, and the last blocks of the last epochs
+// before it
+func keptHeaders(db ethdb.Database, keepEpochs int) ([]*block.Header, error) {
+	headHash := rawdb.ReadHeadBlockHash(db)
+	if headHash == (common.Hash{}) {
+		return nil, errors.New("head block not found")
+	}
+	number := rawdb.ReadHeaderNumber(db, headHash)
+	if number == nil {
+		return nil, errors.Errorf("number of head block %v not found", headHash.Hex())
+	}
+
+	var headers []*block.Header
+	for n := *number; ; n-- {
+		header := readCanonicalHeader(db, n)
+		if header == nil {
+			return nil, errors.Errorf("header of block %v not found", n)
+		}
+		if len(headers) == 0 {
+			if hasState(db, header.Root()) {
+				if n != *number {
+					utils.Logger().Warn().Uint64("head", *number).Uint64("block", n).
+						Msg("[pruner] state of head block not found, keeping the latest state found")
+				}
+				headers = append(headers, header)
+			}
+		} else if header.IsLastBlockInEpoch() {
+			if hasState(db, header.Root()) {
+				headers = append(headers, header)
+			} else {
+				utils.Logger().Warn().Uint64("block", n).Msg("[pruner] state of epoch block not found")
+			}
+			keepEpochs--
+		}
+		if (len(headers) != 0 && keepEpochs <= 0) || n == 0 {
+			break
+		}
+	}
+	if len(headers) == 0 {
+		return nil, errors.New("no state found in the database")
+	}
+	return headers, nil
+}
+
+func readCanonicalHeader(db ethdb.Database, number uint64) *block.Header {
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadHeader(db, hash, number)
+}
+
+func hasState(db ethdb.Database, root common.Hash) bool {
+	has, err := db.Has(root.Bytes())
+	return err == nil && has
+}
+
+// markState adds the hashes of the trie nodes and contract codes of the state
+// to the bloom filter, and returns the number of hashes added. The storage
+// tries already marked for another state are skipped.
+func markState(
+	sdb state.Database, root common.Hash, bloom *stateBloom, storageRoots map[common.Hash]struct{},
+) (uint64, error) {
+	var (
+		marked  uint64
+		lastLog = time.Now()
+	)
+	tr, err := sdb.OpenTrie(root)
+	if err != nil {
+		return 0, err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			bloom.add(hash.Bytes())
+			marked++
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var account state.Account
+		if err := rlp.Decode(bytes.NewReader(it.LeafBlob()), &account); err != nil {
+			return marked, err
+		}
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			bloom.add(account.CodeHash)
+			marked++
+		}
+		if _, ok := storageRoots[account.Root]; ok || account.Root == emptyRoot {
+			continue
+		}
+		storageRoots[account.Root] = struct{}{}
+		st, err := sdb.OpenStorageTrie(common.BytesToHash(it.LeafKey()), account.Root)
+		if err != nil {
+			return marked, err
+		}
+		sit := st.NodeIterator(nil)
+		for sit.Next(true) {
+			if hash := sit.Hash(); hash != (common.Hash{}) {
+				bloom.add(hash.Bytes())
+				marked++
+			}
+		}
+		if err := sit.Error(); err != nil {
+			return marked, err
+		}
+		if time.Since(lastLog) > logInterval {
+			utils.Logger().Info().Uint64("nodes", marked).Msg("[pruner] marking state")
+			lastLog = time.Now()
+		}
+	}
+	return marked, it.Error()
+}
+
+// sweep deletes the trie nodes and contract codes not marked in the bloom
+// filter. Only the entries keyed by the hash of their value are considered, so
+// that no other data of the database is deleted.
+func sweep(db ethdb.Database, bloom *stateBloom, dryRun bool, result *Result) error {
+	var (
+		it      = db.NewIterator()
+		batch   = db.NewBatch()
+		lastLog = time.Now()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || bloom.contains(key) {
+			continue
+		}
+		value := it.Value()
+		if !bytes.Equal(crypto.Keccak256(value), key) {
+			continue
+		}
+		result.Deleted++
+		result.Size += common.StorageSize(len(key) + len(value))
+		if time.Since(lastLog) > logInterval {
+			utils.Logger().Info().Uint64("deleted", result.Deleted).
+				Str("size", result.Size.String()).Msg("[pruner] sweeping state")
+			lastLog = time.Now()
+		}
+		if dryRun {
+			continue
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	return batch.Write()
+}
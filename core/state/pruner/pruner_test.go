@@ -0,0 +1,190 @@
+package pruner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethrawdb "github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+)
+
+var (
+	testAddr1 = common.Address{1}
+	testAddr2 = common.Address{2}
+)
+
+// writeTestBlock commits the state updated by the function on top of the
+// parent root, and writes the canonical head block of the state
+func writeTestBlock(
+	t *testing.T, db ethdb.Database, number int64, parent common.Hash, epochBlock bool,
+	update func(s *state.DB),
+) common.Hash {
+	sdb := state.NewDatabase(db)
+	s, err := state.New(parent, sdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update(s)
+	root, err := s.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+
+	hs := blockfactory.NewTestHeader().With().Number(big.NewInt(number)).Root(root)
+	if epochBlock {
+		hs = hs.ShardState([]byte{1})
+	}
+	header := hs.Header()
+	if err := rawdb.WriteHeader(db, header); err != nil {
+		t.Fatal(err)
+	}
+	if err := rawdb.WriteCanonicalHash(db, header.Hash(), uint64(number)); err != nil {
+		t.Fatal(err)
+	}
+	if err := rawdb.WriteHeadBlockHash(db, header.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// writeTestChain writes the states of 3 blocks, the block 1 being the last
+// block of an epoch
+func writeTestChain(t *testing.T, db ethdb.Database) []common.Hash {
+	root0 := writeTestBlock(t, db, 0, common.Hash{}, false, func(s *state.DB) {
+		s.SetBalance(testAddr1, big.NewInt(1))
+		s.SetCode(testAddr2, []byte{0x60, 0x00})
+		s.SetState(testAddr2, common.Hash{1}, common.Hash{1})
+	})
+	root1 := writeTestBlock(t, db, 1, root0, true, func(s *state.DB) {
+		s.SetBalance(testAddr1, big.NewInt(2))
+		s.SetState(testAddr2, common.Hash{1}, common.Hash{2})
+	})
+	root2 := writeTestBlock(t, db, 2, root1, false, func(s *state.DB) {
+		s.SetBalance(testAddr1, big.NewInt(3))
+		s.SetState(testAddr2, common.Hash{2}, common.Hash{3})
+	})
+	return []common.Hash{root0, root1, root2}
+}
+
+func checkState(t *testing.T, db ethdb.Database, root common.Hash, expected bool) {
+	t.Helper()
+	s, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		if expected {
+			t.Errorf("state %v not found: %v", root.Hex(), err)
+		}
+		return
+	}
+	if !expected {
+		t.Errorf("unexpected state %v", root.Hex())
+		return
+	}
+	if s.GetBalance(testAddr1).Sign() <= 0 || len(s.GetCode(testAddr2)) == 0 {
+		t.Errorf("incomplete state %v", root.Hex())
+	}
+	if err := s.Error(); err != nil {
+		t.Errorf("state %v: %v", root.Hex(), err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		config    Config
+		expBlocks []uint64
+		expStates []bool
+	}{
+		{
+			config:    Config{KeepEpochs: 0, BloomSize: 1},
+			expBlocks: []uint64{2},
+			expStates: []bool{false, false, true},
+		},
+		{
+			config:    Config{KeepEpochs: 1, BloomSize: 1},
+			expBlocks: []uint64{2, 1},
+			expStates: []bool{false, true, true},
+		},
+		{
+			config:    Config{KeepEpochs: 0, BloomSize: 1, DryRun: true},
+			expBlocks: []uint64{2},
+			expStates: []bool{true, true, true},
+		},
+	}
+	for i, test := range tests {
+		db := ethrawdb.NewMemoryDatabase()
+		roots := writeTestChain(t, db)
+		result, err := Prune(db, test.config)
+		if err != nil {
+			t.Fatalf("Test %v: %v", i, err)
+		}
+		if len(result.Blocks) != len(test.expBlocks) {
+			t.Fatalf("Test %v: unexpected blocks kept %v", i, result.Blocks)
+		}
+		for j, number := range test.expBlocks {
+			if result.Blocks[j] != number {
+				t.Errorf("Test %v: unexpected blocks kept %v", i, result.Blocks)
+			}
+		}
+		if result.Deleted == 0 || result.Size == 0 {
+			t.Errorf("Test %v: nothing deleted", i)
+		}
+		for j, root := range roots {
+			if has := hasState(db, root); has != test.expStates[j] {
+				t.Errorf("Test %v: unexpected root %v in database: %v", i, j, has)
+			}
+			if test.expStates[j] {
+				checkState(t, db, root, true)
+			}
+		}
+	}
+}
+
+func TestPrune_KeepsOtherData(t *testing.T) {
+	db := ethrawdb.NewMemoryDatabase()
+	writeTestChain(t, db)
+	// a 32 bytes key which is not the hash of its value
+	key := common.Hash{0xff}.Bytes()
+	if err := db.Put(key, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Prune(db, Config{BloomSize: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := db.Has(key); !has {
+		t.Errorf("unexpected deletion of data not in the state")
+	}
+}
+
+func TestPrune_HeadStateMissing(t *testing.T) {
+	db := ethrawdb.NewMemoryDatabase()
+	roots := writeTestChain(t, db)
+	if err := db.Delete(roots[2].Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Prune(db, Config{BloomSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Blocks) != 1 || result.Blocks[0] != 1 {
+		t.Errorf("unexpected blocks kept %v", result.Blocks)
+	}
+	checkState(t, db, roots[1], true)
+}
+
+func TestStateBloom(t *testing.T) {
+	bloom := newStateBloom(1)
+	for i := 0; i != 1000; i++ {
+		bloom.add(common.BigToHash(big.NewInt(int64(i))).Bytes())
+	}
+	for i := 0; i != 1000; i++ {
+		if !bloom.contains(common.BigToHash(big.NewInt(int64(i))).Bytes()) {
+			t.Fatalf("hash %v not found", i)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Package snapshot exports and imports the state of a block as a file of its
+// trie nodes and contract codes, so that a node can start from the state of a
+// trusted checkpoint block instead of executing the chain from the genesis.
+// Every entry of the file is keyed by the hash of its value, and the imported
+// state is checked complete from the state root, so that the state itself needs
+// no trust beyond the state root of the checkpoint header. The file also carries
+// the off-chain data of the checkpoint block, opaque to the snapshot, which the
+// chain checks against the state where it can.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the snapshot file format. The snapshots of version
+// 1 have no off-chain data.
+const Version = 2
+
+// logInterval is the interval of the progress logs
+const logInterval = 8 * time.Second
+
+var (
+	emptyRoot     = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// Header is the first item of a snapshot file, followed by the off-chain data
+// of the block, empty if none, and then by the entries
+type Header struct {
+	Version uint64
+	Root    common.Hash // state root the snapshot is exported from
+}
+
+// entry is a trie node or a contract code of a snapshot file, keyed by the
+// hash of its value
+type entry struct {
+	Hash common.Hash
+	Blob []byte
+}
+
+// Export writes the snapshot of the state of the root in the database, with the
+// off-chain data of its block, to the writer, and returns the number of entries
+// written. The storage tries and contract codes shared by several accounts are
+// written once.
+func Export(db ethdb.Database, root common.Hash, offChain []byte, w io.Writer) (uint64, error) {
+	if err := rlp.Encode(w, &Header{Version: Version, Root: root}); err != nil {
+		return 0, err
+	}
+	if err := rlp.Encode(w, offChain); err != nil {
+		return 0, err
+	}
+	triedb := state.NewDatabase(db).TrieDB()
+	seen := make(map[common.Hash]struct{})
+	var written uint64
+	err := iterateState(db, root, func(hash common.Hash, isCode bool) error {
+		if _, ok := seen[hash]; ok {
+			return nil
+		}
+		seen[hash] = struct{}{}
+		var (
+			blob []byte
+			err  error
+		)
+		if isCode {
+			blob, err = db.Get(hash.Bytes())
+		} else {
+			blob, err = triedb.Node(hash)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %v", hash.Hex())
+		}
+		if err := rlp.Encode(w, &entry{Hash: hash, Blob: blob}); err != nil {
+			return err
+		}
+		written++
+		return nil
+	})
+	return written, err
+}
+
+// Import writes the entries of the snapshot read from the reader to the
+// database, and checks that the state of the root is complete afterwards. It
+// returns the number of entries imported and the off-chain data of the block.
+// An entry whose hash is not the one of its value is rejected, as well as a
+// snapshot of another state root.
+func Import(db ethdb.Database, root common.Hash, r io.Reader) (uint64, []byte, error) {
+	stream := rlp.NewStream(bufio.NewReader(r), 0)
+	offChain, err := readHeader(stream, root)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var (
+		imported uint64
+		batch    = db.NewBatch()
+		lastLog  = time.Now()
+	)
+	for {
+		var e entry
+		if err := stream.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, nil, errors.Wrapf(err, "cannot decode snapshot entry %v", imported)
+		}
+		if crypto.Keccak256Hash(e.Blob) != e.Hash {
+			return imported, nil, errors.Errorf("snapshot entry %v not matching its hash", e.Hash.Hex())
+		}
+		if err := batch.Put(e.Hash.Bytes(), e.Blob); err != nil {
+			return imported, nil, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return imported, nil, err
+			}
+			batch.Reset()
+		}
+		imported++
+		if time.Since(lastLog) > logInterval {
+			utils.Logger().Info().Uint64("entries", imported).Msg("[snapshot] importing state")
+			lastLog = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return imported, nil, err
+	}
+	if err := Verify(db, root); err != nil {
+		return imported, nil, errors.Wrap(err, "incomplete state snapshot")
+	}
+	return imported, offChain, nil
+}
+
+// ReadOffChain returns the off-chain data of the block of the snapshot read
+// from the reader, without reading its entries
+func ReadOffChain(root common.Hash, r io.Reader) ([]byte, error) {
+	return readHeader(rlp.NewStream(bufio.NewReader(r), 0), root)
+}
+
+// readHeader decodes the header of the snapshot of the root, and returns the
+// off-chain data of the block following it
+func readHeader(stream *rlp.Stream, root common.Hash) ([]byte, error) {
+	var header Header
+	if err := stream.Decode(&header); err != nil {
+		return nil, errors.Wrap(err, "cannot decode snapshot header")
+	}
+	if header.Version != 1 && header.Version != Version {
+		return nil, errors.Errorf("unsupported snapshot version %v", header.Version)
+	}
+	if header.Root != root {
+		return nil, errors.Errorf("snapshot of state root %v, expected %v", header.Root.Hex(), root.Hex())
+	}
+	if header.Version == 1 {
+		return nil, nil
+	}
+	offChain, err := stream.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode snapshot off-chain data")
+	}
+	return offChain, nil
+}
+
+// Verify checks that all the trie nodes and contract codes of the state of the
+// root are in the database
+func Verify(db ethdb.Database, root common.Hash) error {
+	return iterateState(db, root, func(hash common.Hash, isCode bool) error {
+		if !isCode {
+			return nil
+		}
+		if has, err := db.Has(hash.Bytes()); err != nil || !has {
+			return errors.Errorf("code %v not found", hash.Hex())
+		}
+		return nil
+	})
+}
+
+// Open opens the snapshot file of the source, a http or https URL or a file
+// path. The snapshot is decompressed if the source ends with .gz.
+func Open(src string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		resp, err := http.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, errors.Errorf("cannot download snapshot: %v", resp.Status)
+		}
+		rc = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		rc = f
+	}
+	if !strings.HasSuffix(src, ".gz") {
+		return rc, nil
+	}
+	gz, err := gzip.NewReader(rc)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return &gzipReadCloser{Reader: gz, under: rc}, nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	under io.Closer
+}
+
+func (rc *gzipReadCloser) Close() error {
+	rc.Reader.Close()
+	return rc.under.Close()
+}
+
+// iterateState calls the callback with the hashes of the trie nodes of the
+// state and storage tries, and of the contract codes, of the state of the
+// root. The storage tries shared by several accounts are iterated once. A
+// missing trie node fails the iteration.
+func iterateState(db ethdb.Database, root common.Hash, cb func(hash common.Hash, isCode bool) error) error {
+	var (
+		sdb          = state.NewDatabase(db)
+		storageRoots = make(map[common.Hash]struct{})
+		nodes        uint64
+		lastLog      = time.Now()
+	)
+	tr, err := sdb.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if err := cb(hash, false); err != nil {
+				return err
+			}
+			nodes++
+		}
+		if !it.Leaf() {
+			continue
+		}
+		var account state.Account
+		if err := rlp.Decode(bytes.NewReader(it.LeafBlob()), &account); err != nil {
+			return err
+		}
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+			if err := cb(codeHash, true); err != nil {
+				return err
+			}
+		}
+		if _, ok := storageRoots[account.Root]; ok || account.Root == emptyRoot {
+			continue
+		}
+		storageRoots[account.Root] = struct{}{}
+		st, err := sdb.OpenStorageTrie(common.BytesToHash(it.LeafKey()), account.Root)
+		if err != nil {
+			return err
+		}
+		sit := st.NodeIterator(nil)
+		for sit.Next(true) {
+			if hash := sit.Hash(); hash != (common.Hash{}) {
+				if err := cb(hash, false); err != nil {
+					return err
+				}
+				nodes++
+			}
+		}
+		if err := sit.Error(); err != nil {
+			return err
+		}
+		if time.Since(lastLog) > logInterval {
+			utils.Logger().Info().Uint64("nodes", nodes).Msg("[snapshot] iterating state")
+			lastLog = time.Now()
+		}
+	}
+	return it.Error()
+}
@@ -0,0 +1,209 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core/state"
+)
+
+func makeTestState(t *testing.T) (ethdb.Database, common.Hash) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for i := byte(1); i <= 50; i++ {
+		addr := common.Address{i}
+		statedb.AddBalance(addr, big.NewInt(int64(i)))
+		if i%5 == 0 {
+			statedb.SetCode(addr, []byte{i, i, i})
+			statedb.SetState(addr, common.Hash{i}, common.Hash{i, i})
+		}
+	}
+	// contract code shared by two accounts
+	statedb.SetCode(common.Address{51}, []byte{5, 5, 5})
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	return db, root
+}
+
+func TestExportImport(t *testing.T) {
+	src, root := makeTestState(t)
+	var buf bytes.Buffer
+	exported, err := Export(src, root, nil, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported == 0 {
+		t.Fatal("nothing exported")
+	}
+
+	dst := rawdb.NewMemoryDatabase()
+	imported, offChain, err := Import(dst, root, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != exported {
+		t.Errorf("unexpected entries imported %v / %v", imported, exported)
+	}
+	if len(offChain) != 0 {
+		t.Errorf("unexpected off-chain data %x", offChain)
+	}
+	statedb, err := state.New(root, state.NewDatabase(dst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := byte(1); i <= 50; i++ {
+		addr := common.Address{i}
+		if balance := statedb.GetBalance(addr); balance.Int64() != int64(i) {
+			t.Errorf("account %v: unexpected balance %v", i, balance)
+		}
+		if i%5 != 0 {
+			continue
+		}
+		if code := statedb.GetCode(addr); !bytes.Equal(code, []byte{i, i, i}) {
+			t.Errorf("account %v: unexpected code %x", i, code)
+		}
+		if value := statedb.GetState(addr, common.Hash{i}); value != (common.Hash{i, i}) {
+			t.Errorf("account %v: unexpected storage %v", i, value.Hex())
+		}
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	src, root := makeTestState(t)
+	var buf bytes.Buffer
+	if _, err := Export(src, root, nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := buf.Bytes()
+
+	// snapshot of another root
+	if _, _, err := Import(rawdb.NewMemoryDatabase(), common.Hash{1}, bytes.NewReader(snapshot)); err == nil {
+		t.Errorf("unexpected import of another state root")
+	}
+
+	// entry not matching its hash
+	var tampered bytes.Buffer
+	entries := decodeTestSnapshot(t, snapshot)
+	rlp.Encode(&tampered, &Header{Version: Version, Root: root})
+	rlp.Encode(&tampered, []byte{})
+	for i, e := range entries {
+		if i == len(entries)/2 {
+			e.Blob = append(common.CopyBytes(e.Blob), 0)
+		}
+		rlp.Encode(&tampered, e)
+	}
+	_, _, err := Import(rawdb.NewMemoryDatabase(), root, &tampered)
+	if err == nil || !strings.Contains(err.Error(), "not matching its hash") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// missing entries
+	for _, skipped := range []int{0, len(entries) - 1} {
+		var incomplete bytes.Buffer
+		rlp.Encode(&incomplete, &Header{Version: Version, Root: root})
+		rlp.Encode(&incomplete, []byte{})
+		for i, e := range entries {
+			if i != skipped {
+				rlp.Encode(&incomplete, e)
+			}
+		}
+		if _, _, err := Import(rawdb.NewMemoryDatabase(), root, &incomplete); err == nil {
+			t.Errorf("unexpected import of incomplete snapshot skipping %v", skipped)
+		}
+	}
+}
+
+func TestExportImport_OffChain(t *testing.T) {
+	src, root := makeTestState(t)
+	var buf bytes.Buffer
+	if _, err := Export(src, root, []byte("off-chain"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := buf.Bytes()
+
+	offChain, err := ReadOffChain(root, bytes.NewReader(snapshot))
+	if err != nil || string(offChain) != "off-chain" {
+		t.Errorf("unexpected off-chain data %q: %v", offChain, err)
+	}
+	_, offChain, err = Import(rawdb.NewMemoryDatabase(), root, bytes.NewReader(snapshot))
+	if err != nil || string(offChain) != "off-chain" {
+		t.Errorf("unexpected imported off-chain data %q: %v", offChain, err)
+	}
+
+	// snapshot of version 1, without off-chain data
+	var v1 bytes.Buffer
+	rlp.Encode(&v1, &Header{Version: 1, Root: root})
+	for _, e := range decodeTestSnapshot(t, snapshot) {
+		rlp.Encode(&v1, e)
+	}
+	_, offChain, err = Import(rawdb.NewMemoryDatabase(), root, &v1)
+	if err != nil || offChain != nil {
+		t.Errorf("unexpected import of version 1 %x: %v", offChain, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	src, root := makeTestState(t)
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.rlp.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := Export(src, root, nil, gz); err != nil {
+		t.Fatal(err)
+	}
+	gz.Close()
+	f.Close()
+
+	rc, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	if _, _, err := Import(rawdb.NewMemoryDatabase(), root, rc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decodeTestSnapshot(t *testing.T, snapshot []byte) []*entry {
+	stream := rlp.NewStream(bytes.NewReader(snapshot), 0)
+	var header Header
+	if err := stream.Decode(&header); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	var entries []*entry
+	for {
+		e := new(entry)
+		if err := stream.Decode(e); err != nil {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/harmony-one/harmony/staking/availability"
+	staking "github.com/harmony-one/harmony/staking/types"
+	"github.com/pkg/errors"
+)
+
+// ReadValidatorEpochSigning reads the signing outcomes of the BLS keys of a
+// validator in the epoch, from the signing index of the beacon chain as of
+// SigningIndexHead
+func (bc *BlockChain) ReadValidatorEpochSigning(
+	addr common.Address, epoch uint64,
+) (*staking.ValidatorEpochSigning, error) {
+	return rawdb.ReadValidatorEpochSigning(bc.db, addr, epoch)
+}
+
+// ReadValidatorEpochAPR reads the apr of a validator computed for the epoch
+func (bc *BlockChain) ReadValidatorEpochAPR(
+	addr common.Address, epoch uint64,
+) (*staking.APREntry, error) {
+	return rawdb.ReadValidatorEpochAPR(bc.db, addr, epoch)
+}
+
+// SigningIndexHead returns the number of the latest block whose validator
+// signing was written to the index, false if nothing was written yet
+func (bc *BlockChain) SigningIndexHead() (uint64, bool) {
+	return rawdb.ReadSigningIndexHead(bc.db)
+}
+
+// signingIndexFlushInterval is the number of beacon blocks whose signing
+// outcomes are kept in memory before written to db. The outcomes are also
+// written at the end of each epoch.
+const signingIndexFlushInterval = 64
+
+type signingRecordKey struct {
+	addr  common.Address
+	epoch uint64
+}
+
+// signingBallot is the signing outcome of a block by the staked keys of its
+// committee
+type signingBallot struct {
+	epoch    *big.Int
+	blockNum uint64
+	shardID  uint32
+	signers  shard.SlotList
+	missing  shard.SlotList
+}
+
+// newSigningBallot returns the ballot of the block of the committee, as given
+// by the bitmap of the commit signature
+func newSigningBallot(
+	epoch *big.Int, blockNum uint64, committee *shard.Committee, bitmap []byte,
+) (*signingBallot, error) {
+	signers, missing, err := availability.BlockSigners(bitmap, committee)
+	if err != nil {
+		return nil, errors.Wrapf(err, "shard %v block %v", committee.ShardID, blockNum)
+	}
+	return &signingBallot{
+		epoch:    epoch,
+		blockNum: blockNum,
+		shardID:  committee.ShardID,
+		signers:  signers,
+		missing:  missing,
+	}, nil
+}
+
+// signingIndexBatch collects the signing outcomes of the beacon blocks since
+// it was last written, on top of the outcomes already indexed in db
+type signingIndexBatch struct {
+	db        rawdb.DatabaseReader
+	records   map[signingRecordKey]*staking.ValidatorEpochSigning
+	head      uint64 // number of the latest beacon block added
+	numBlocks int    // number of the beacon blocks added since the last write
+}
+
+func newSigningIndexBatch(db rawdb.DatabaseReader) *signingIndexBatch {
+	return &signingIndexBatch{
+		db:      db,
+		records: map[signingRecordKey]*staking.ValidatorEpochSigning{},
+	}
+}
+
+func (b *signingIndexBatch) record(
+	addr common.Address, epoch *big.Int,
+) *staking.ValidatorEpochSigning {
+	key := signingRecordKey{addr, epoch.Uint64()}
+	if r, ok := b.records[key]; ok {
+		return r
+	}
+	r, err := rawdb.ReadValidatorEpochSigning(b.db, addr, key.epoch)
+	if err != nil {
+		r = staking.NewValidatorEpochSigning(epoch)
+	}
+	b.records[key] = r
+	return r
+}
+
+// addBallot counts the block of the ballot as signed or missed by each staked
+// BLS key of the committee
+func (b *signingIndexBatch) addBallot(ballot *signingBallot) {
+	for _, subset := range []struct {
+		slots  shard.SlotList
+		signed bool
+	}{{ballot.signers, true}, {ballot.missing, false}} {
+		for _, slot := range subset.slots {
+			// harmony operated keys are not staked
+			if slot.EffectiveStake == nil {
+				continue
+			}
+			b.record(slot.EcdsaAddress, ballot.epoch).
+				KeyEntry(slot.BLSPublicKey, ballot.shardID).
+				Record(ballot.blockNum, subset.signed)
+		}
+	}
+}
+
+// addBlock adds the ballots whose commit signatures are in the beacon block
+func (b *signingIndexBatch) addBlock(num uint64, ballots []*signingBallot) {
+	for _, ballot := range ballots {
+		b.addBallot(ballot)
+	}
+	b.head = num
+	b.numBlocks++
+}
+
+// write writes the collected outcomes along with the number of the latest
+// beacon block added, and empties the batch
+func (b *signingIndexBatch) write(batch rawdb.DatabaseWriter) error {
+	for key, r := range b.records {
+		if err := rawdb.WriteValidatorEpochSigning(batch, key.addr, r); err != nil {
+			return err
+		}
+	}
+	if err := rawdb.WriteSigningIndexHead(batch, b.head); err != nil {
+		return err
+	}
+	b.records = map[signingRecordKey]*staking.ValidatorEpochSigning{}
+	b.numBlocks = 0
+	return nil
+}
+
+// updateSigningIndex indexes the signing outcomes of the blocks whose commit
+// signature is in the beacon block. The outcomes are collected in memory, and
+// written with the batch every signingIndexFlushInterval blocks and at the end
+// of the epoch. The index does not move past a block failing to be indexed,
+// the blocks from the failed one on are indexed again with the next blocks.
+func (bc *BlockChain) updateSigningIndex(
+	batch rawdb.DatabaseWriter, header *block.Header,
+) error {
+	num := header.Number().Uint64()
+	if bc.signingIndex == nil {
+		bc.signingIndex = bc.loadSigningIndex(num)
+	}
+	b := bc.signingIndex
+	if num <= b.head {
+		return nil
+	}
+	// catch up with the blocks not indexed since the last stop of the node or
+	// a failure, a bounded number of them with each block
+	last := b.head + signingIndexFlushInterval
+	for n := b.head + 1; n < num; n++ {
+		if n > last {
+			return nil
+		}
+		missed := bc.GetHeaderByNumber(n)
+		if missed == nil {
+			return errors.Errorf("block %v not found", n)
+		}
+		if err := bc.addSigningBlock(batch, missed); err != nil {
+			return err
+		}
+	}
+	return bc.addSigningBlock(batch, header)
+}
+
+// addSigningBlock adds the ballots whose commit signatures are in the beacon
+// block to the signing index batch, and writes the batch when due
+func (bc *BlockChain) addSigningBlock(
+	batch rawdb.DatabaseWriter, header *block.Header,
+) error {
+	b := bc.signingIndex
+	ballots, err := bc.signingBallots(header)
+	if err != nil {
+		return errors.Wrapf(err, "block %v", header.Number().Uint64())
+	}
+	b.addBlock(header.Number().Uint64(), ballots)
+
+	if b.numBlocks >= signingIndexFlushInterval || header.IsLastBlockInEpoch() {
+		return b.write(batch)
+	}
+	return nil
+}
+
+// loadSigningIndex returns the batch of the signing index on top of db. The
+// blocks after the index head, not written before the last stop of the node,
+// are indexed again from the given block on.
+func (bc *BlockChain) loadSigningIndex(num uint64) *signingIndexBatch {
+	b := newSigningIndexBatch(bc.db)
+	if head, ok := rawdb.ReadSigningIndexHead(bc.db); ok {
+		b.head = head
+	} else if num > 0 {
+		// the index starts from the given block
+		b.head = num - 1
+	}
+	return b
+}
+
+// writeSigningIndex writes the signing outcomes collected in memory
+func (bc *BlockChain) writeSigningIndex() error {
+	if bc.signingIndex == nil || bc.signingIndex.numBlocks == 0 {
+		return nil
+	}
+	batch := bc.db.NewBatch()
+	if err := bc.signingIndex.write(batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// signingBallots returns the ballots whose commit signature is in the beacon
+// block: of the parent block by the last commit bitmap, and of the shard blocks
+// by the bitmaps of the crosslinks.
+func (bc *BlockChain) signingBallots(header *block.Header) ([]*signingBallot, error) {
+	var ballots []*signingBallot
+	if parent := bc.GetHeaderByHash(header.ParentHash()); parent != nil &&
+		bc.chainConfig.IsStaking(parent.Epoch()) {
+		committee, err := bc.readCommittee(parent.Epoch(), shard.BeaconChainShardID)
+		if err != nil {
+			return nil, err
+		}
+		ballot, err := newSigningBallot(
+			parent.Epoch(), parent.Number().Uint64(), committee, header.LastCommitBitmap(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		ballots = append(ballots, ballot)
+	}
+
+	if cxLinks := header.CrossLinks(); len(cxLinks) > 0 {
+		crossLinks := types.CrossLinks{}
+		if err := rlp.DecodeBytes(cxLinks, &crossLinks); err != nil {
+			return nil, err
+		}
+		for i := range crossLinks {
+			cl := &crossLinks[i]
+			if !bc.chainConfig.IsStaking(cl.Epoch()) {
+				continue
+			}
+			committee, err := bc.readCommittee(cl.Epoch(), cl.ShardID())
+			if err != nil {
+				return nil, err
+			}
+			ballot, err := newSigningBallot(cl.Epoch(), cl.BlockNum(), committee, cl.Bitmap())
+			if err != nil {
+				return nil, err
+			}
+			ballots = append(ballots, ballot)
+		}
+	}
+	return ballots, nil
+}
+
+func (bc *BlockChain) readCommittee(epoch *big.Int, shardID uint32) (*shard.Committee, error) {
+	shardState, err := bc.ReadShardState(epoch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read shard state %v", epoch)
+	}
+	return shardState.FindCommitteeByID(shardID)
+}
+
+// writeValidatorEpochAPRs indexes the latest apr of the validators by its epoch
+func (bc *BlockChain) writeValidatorEpochAPRs(
+	batch rawdb.DatabaseWriter, stats map[common.Address]*staking.ValidatorStats,
+) error {
+	for addr, s := range stats {
+		if l := len(s.APRs); l != 0 {
+			if err := rawdb.WriteValidatorEpochAPR(batch, addr, s.APRs[l-1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethRawDB "github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	chain2 "github.com/harmony-one/harmony/internal/chain"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/harmony-one/harmony/numeric"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/harmony-one/harmony/staking/network"
+	staking "github.com/harmony-one/harmony/staking/types"
+)
+
+func TestSigningIndexBatch(t *testing.T) {
+	var (
+		addrA, addrB = makeTestAddr("a"), makeTestAddr("b")
+		stake        = numeric.OneDec()
+		epoch        = big.NewInt(3)
+	)
+	committee := &shard.Committee{
+		ShardID: 1,
+		Slots: shard.SlotList{
+			{EcdsaAddress: addrA, BLSPublicKey: makeSimTestKey(0, 0), EffectiveStake: &stake},
+			{EcdsaAddress: addrA, BLSPublicKey: makeSimTestKey(0, 1), EffectiveStake: &stake},
+			{EcdsaAddress: addrB, BLSPublicKey: makeSimTestKey(1, 0), EffectiveStake: &stake},
+			{EcdsaAddress: common.Address{}, BLSPublicKey: makeSimTestKey(2, 0)},
+		},
+	}
+	db := ethRawDB.NewMemoryDatabase()
+	// slot 1 misses block 100 and 101, slot 2 misses block 101
+	for i, ballot := range []struct {
+		blockNum uint64
+		bitmap   []byte
+	}{{100, []byte{0x0d}}, {101, []byte{0x09}}, {102, []byte{0x0f}}} {
+		b := newSigningIndexBatch(db)
+		signing, err := newSigningBallot(epoch, ballot.blockNum, committee, ballot.bitmap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.addBlock(uint64(i+1), []*signingBallot{signing})
+		if err := b.write(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if head, ok := rawdb.ReadSigningIndexHead(db); !ok || head != 3 {
+		t.Errorf("unexpected signing index head %v %v", head, ok)
+	}
+
+	signingA, err := rawdb.ReadValidatorEpochSigning(db, addrA, epoch.Uint64())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signingA.Keys) != 2 {
+		t.Fatalf("unexpected keys %v", len(signingA.Keys))
+	}
+	if e := signingA.Keys[0]; e.NumBlocksToSign != 3 || e.NumBlocksSigned != 3 || e.ShardID != 1 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	e := signingA.Keys[1]
+	if e.NumBlocksToSign != 3 || e.NumBlocksSigned != 1 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if len(e.MissedBlocks) != 1 || e.MissedBlocks[0] != (staking.BlockRange{From: 100, To: 101}) {
+		t.Errorf("unexpected missed blocks %v", e.MissedBlocks)
+	}
+
+	signingB, err := rawdb.ReadValidatorEpochSigning(db, addrB, epoch.Uint64())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := signingB.Keys[0]; e.NumBlocksMissed() != 1 || e.MissedBlocks[0].From != 101 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if _, err := rawdb.ReadValidatorEpochSigning(db, common.Address{}, epoch.Uint64()); err == nil {
+		t.Errorf("unexpected signing indexed for harmony key")
+	}
+}
+
+// makeSigningTestChain makes a beacon chain whose committee of epoch 0 is made
+// of a staked key of addrA and of addrB
+func makeSigningTestChain(
+	t *testing.T, addrA, addrB common.Address,
+) (*Genesis, ethdb.Database, *BlockChain) {
+	stake := numeric.OneDec()
+	gspec := &Genesis{
+		Config:  params.TestChainConfig,
+		Factory: blockfactory.ForTest,
+		ShardID: shard.BeaconChainShardID,
+	}
+	db := ethRawDB.NewMemoryDatabase()
+	gspec.MustCommit(db)
+	ss, err := shard.EncodeWrapper(shard.State{
+		Epoch: common.Big0,
+		Shards: []shard.Committee{{
+			ShardID: shard.BeaconChainShardID,
+			Slots: shard.SlotList{
+				{EcdsaAddress: addrA, BLSPublicKey: makeSimTestKey(0, 0), EffectiveStake: &stake},
+				{EcdsaAddress: addrB, BLSPublicKey: makeSimTestKey(1, 0), EffectiveStake: &stake},
+			},
+		}},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rawdb.WriteShardStateBytes(db, common.Big0, ss); err != nil {
+		t.Fatal(err)
+	}
+	return gspec, db, newCheckpointTestBlockChain(t, gspec, db)
+}
+
+// commitSigningTestBlocks commits the off chain data of the blocks after the
+// current block, the parent block signed by addrB only every other block
+func commitSigningTestBlocks(t *testing.T, bc *BlockChain, db ethdb.Database, n int) {
+	parent := bc.CurrentBlock()
+	for i := 0; i < n; i++ {
+		bitmap := []byte{0x03}
+		if parent.NumberU64()%2 == 1 {
+			bitmap = []byte{0x01}
+		}
+		header := blockfactory.ForTest.NewHeader(common.Big0).With().
+			Number(new(big.Int).SetUint64(parent.NumberU64() + 1)).
+			ParentHash(parent.Hash()).
+			Root(parent.Root()).
+			LastCommitBitmap(bitmap).
+			Header()
+		block := types.NewBlock(header, nil, nil, nil, nil, nil)
+		statedb, err := state.New(parent.Root(), state.NewDatabase(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch := db.NewBatch()
+		if _, err := bc.CommitOffChainData(
+			batch, block, nil, nil, network.EmptyPayout, statedb,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if err := rawdb.WriteBlock(batch, block); err != nil {
+			t.Fatal(err)
+		}
+		if err := rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatal(err)
+		}
+		if err := batch.Write(); err != nil {
+			t.Fatal(err)
+		}
+		bc.insert(block)
+		parent = block
+	}
+}
+
+func checkSigningTestKey(
+	t *testing.T, bc *BlockChain, addr common.Address, toSign, signed uint64,
+) {
+	signing, err := bc.ReadValidatorEpochSigning(addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signing.Keys) != 1 {
+		t.Fatalf("unexpected keys %v", len(signing.Keys))
+	}
+	if e := signing.Keys[0]; e.NumBlocksToSign != toSign || e.NumBlocksSigned != signed {
+		t.Errorf("unexpected entry of %v: %+v", addr.Hex(), e)
+	}
+}
+
+func TestCommitOffChainDataSigningIndex(t *testing.T) {
+	addrA, addrB := makeTestAddr("a"), makeTestAddr("b")
+	_, db, bc := makeSigningTestChain(t, addrA, addrB)
+
+	// the outcomes are kept in memory until the flush interval
+	commitSigningTestBlocks(t, bc, db, 3)
+	if _, ok := bc.SigningIndexHead(); ok {
+		t.Errorf("signing index written before the flush interval")
+	}
+	commitSigningTestBlocks(t, bc, db, signingIndexFlushInterval-3)
+	if head, ok := bc.SigningIndexHead(); !ok || head != signingIndexFlushInterval {
+		t.Fatalf("unexpected signing index head %v %v", head, ok)
+	}
+	// blocks 0 to 63 signed by addrA, the even ones by addrB
+	checkSigningTestKey(t, bc, addrA, signingIndexFlushInterval, signingIndexFlushInterval)
+	checkSigningTestKey(t, bc, addrB, signingIndexFlushInterval, signingIndexFlushInterval/2)
+
+	// the outcomes not written are written on stop
+	commitSigningTestBlocks(t, bc, db, 2)
+	bc.Stop()
+	if head, ok := bc.SigningIndexHead(); !ok || head != signingIndexFlushInterval+2 {
+		t.Fatalf("unexpected signing index head %v %v", head, ok)
+	}
+	checkSigningTestKey(t, bc, addrA, signingIndexFlushInterval+2, signingIndexFlushInterval+2)
+
+	// the outcomes lost without a stop are replayed after restart
+	bc, err := NewBlockChain(db, nil, params.TestChainConfig, chain2.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitSigningTestBlocks(t, bc, db, 2)
+	bc, err = NewBlockChain(db, nil, params.TestChainConfig, chain2.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitSigningTestBlocks(t, bc, db, 1)
+	if err := bc.writeSigningIndex(); err != nil {
+		t.Fatal(err)
+	}
+	const toSign = signingIndexFlushInterval + 5
+	checkSigningTestKey(t, bc, addrA, toSign, toSign)
+	checkSigningTestKey(t, bc, addrB, toSign, toSign/2+1)
+}
+
+func TestCommitOffChainDataSigningIndexFailure(t *testing.T) {
+	addrA, addrB := makeTestAddr("a"), makeTestAddr("b")
+	_, db, bc := makeSigningTestChain(t, addrA, addrB)
+
+	commitSigningTestBlocks(t, bc, db, 2)
+
+	// the committee of epoch 0 cannot be found for the next blocks
+	cacheKey := string(common.Big0.Bytes())
+	bc.shardStateCache.Add(cacheKey, &shard.State{Epoch: common.Big0})
+	commitSigningTestBlocks(t, bc, db, 2)
+	if err := bc.writeSigningIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if head, ok := bc.SigningIndexHead(); !ok || head != 2 {
+		t.Fatalf("signing index moved past failed blocks: %v %v", head, ok)
+	}
+
+	// the failed blocks are indexed again with the next block
+	bc.shardStateCache.Remove(cacheKey)
+	commitSigningTestBlocks(t, bc, db, 1)
+	if err := bc.writeSigningIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if head, ok := bc.SigningIndexHead(); !ok || head != 5 {
+		t.Fatalf("unexpected signing index head %v %v", head, ok)
+	}
+	checkSigningTestKey(t, bc, addrA, 5, 5)
+	checkSigningTestKey(t, bc, addrB, 5, 3)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/crypto/bls"
 	internal_bls "github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/hmy/gasprice"
 	internal_common "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/internal/params"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -378,3 +379,14 @@ func (hmy *Harmony) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) e
 func (hmy *Harmony) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return hmy.BlockChain.SubscribeLogsEvent(ch)
 }
+
+// SuggestPrice returns a gas price suggestion based on the recent blocks.
+func (hmy *Harmony) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return hmy.gpo.SuggestPrice(ctx)
+}
+
+// FeeHistory returns the gas used ratios and gas price percentiles of up to
+// blocks blocks ending with lastBlock.
+func (hmy *Harmony) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*gasprice.FeeHistory, error) {
+	return hmy.gpo.FeeHistory(ctx, blocks, lastBlock, percentiles)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/harmony-one/harmony/consensus/engine"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/p2p/stream/common/streammanager"
 	syncproto "github.com/harmony-one/harmony/p2p/stream/protocols/sync"
@@ -29,6 +31,13 @@ type blockChain interface {
 
 	InsertChain(chain types.Blocks, verifyHeaders bool) (int, error)
 	WriteCommitSig(blockNum uint64, lastCommits []byte) error
+
+	ChainDb() ethdb.Database
+	HasState(root common.Hash) bool
+	InitFromCheckpoint(block *types.Block, offChain *core.CheckpointOffChain) error
+	CheckpointHash() common.Hash
+	BackfillTail() (uint64, bool)
+	WriteBackfillBlocks(blocks types.Blocks) (int, error)
 }
 
 // insertHelper is the interface help to verify and insert a block.
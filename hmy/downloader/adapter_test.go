@@ -12,8 +12,10 @@ import (
 	"github.com/harmony-one/harmony/block"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/params"
 	"github.com/harmony-one/harmony/p2p/stream/common/streammanager"
@@ -97,6 +99,14 @@ func (bc *testBlockChain) ReadCommitSig(blockNum uint64) ([]byte, error)
 func (bc *testBlockChain) ReadBlockRewardAccumulator(uint64) (*big.Int, error)      { return nil, nil }
 func (bc *testBlockChain) ValidatorCandidates() []common.Address                    { return nil }
 func (bc *testBlockChain) Engine() engine.Engine                                    { return nil }
+func (bc *testBlockChain) ChainDb() ethdb.Database                                  { return nil }
+func (bc *testBlockChain) HasState(root common.Hash) bool                           { return true }
+func (bc *testBlockChain) InitFromCheckpoint(*types.Block, *core.CheckpointOffChain) error {
+	return nil
+}
+func (bc *testBlockChain) CheckpointHash() common.Hash                          { return common.Hash{} }
+func (bc *testBlockChain) BackfillTail() (uint64, bool)                         { return 0, false }
+func (bc *testBlockChain) WriteBackfillBlocks(blocks types.Blocks) (int, error) { return 0, nil }
 func (bc *testBlockChain) ReadValidatorInformation(addr common.Address) (*staking.ValidatorWrapper, error) {
 	return nil, nil
 }
@@ -0,0 +1,173 @@
+package downloader
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/state/snapshot"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/pkg/errors"
+)
+
+var errCheckpointNotFound = errors.New("checkpoint block not found")
+
+// initFromCheckpoint starts the chain from the checkpoint block if the chain is
+// behind it. It is retried until done, or the checkpoint turns out invalid.
+func (d *Downloader) initFromCheckpoint() {
+	for {
+		err := d.doInitFromCheckpoint()
+		if err == nil {
+			return
+		}
+		if cause := errors.Cause(err); cause == core.ErrCheckpointNotEpochBlock ||
+			cause == core.ErrCheckpointOffChainMissing {
+			d.logger.Error().Err(err).Msg("invalid checkpoint, sync from the current block")
+			return
+		}
+		d.logger.Warn().Err(err).Msg("failed to init from checkpoint")
+		select {
+		case <-time.After(checkpointRetryInterval):
+		case <-d.closeC:
+			return
+		}
+	}
+}
+
+func (d *Downloader) doInitFromCheckpoint() error {
+	cp := d.config.Checkpoint
+	if d.bc.CheckpointHash() == cp.Hash || d.bc.GetHeaderByHash(cp.Hash) != nil {
+		return nil
+	}
+	block, err := d.getCheckpointBlock(cp.Hash)
+	if err != nil {
+		return err
+	}
+	if curBN := d.bc.CurrentBlock().NumberU64(); curBN >= block.NumberU64() {
+		d.logger.Info().Uint64("current number", curBN).Uint64("checkpoint", block.NumberU64()).
+			Msg("chain already past the checkpoint")
+		return nil
+	}
+	offChain, err := d.readCheckpointSnapshot(block, cp.State)
+	if err != nil {
+		return err
+	}
+	return d.bc.InitFromCheckpoint(block, offChain)
+}
+
+// getCheckpointBlock requests the checkpoint block of the hash from a stream.
+// A stream delivering another block is removed.
+func (d *Downloader) getCheckpointBlock(hash common.Hash) (*types.Block, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, checkpointTimeout)
+	defer cancel()
+
+	blocks, stid, err := d.syncProtocol.GetBlocksByHashes(ctx, []common.Hash{hash})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get checkpoint block")
+	}
+	if len(blocks) != 1 || blocks[0] == nil {
+		return nil, errCheckpointNotFound
+	}
+	block := blocks[0]
+	if block.Hash() != hash {
+		d.syncProtocol.RemoveStream(stid)
+		return nil, errors.Errorf("unexpected checkpoint block hash %v", block.Hash().Hex())
+	}
+	return block, nil
+}
+
+// readCheckpointSnapshot imports the state of the checkpoint block from the
+// snapshot of the source if not in the database, and returns the off-chain data
+// of the block carried by the snapshot, nil if none
+func (d *Downloader) readCheckpointSnapshot(block *types.Block, src string) (*core.CheckpointOffChain, error) {
+	hasState := d.bc.HasState(block.Root())
+	if hasState && (src == "" || block.ShardID() != shard.BeaconChainShardID) {
+		return nil, nil
+	}
+	if src == "" {
+		return nil, errors.New("state of checkpoint not found and no state snapshot given")
+	}
+	rc, err := snapshot.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var data []byte
+	if hasState {
+		if data, err = snapshot.ReadOffChain(block.Root(), rc); err != nil {
+			return nil, errors.Wrap(err, "cannot read checkpoint off-chain data")
+		}
+	} else {
+		d.logger.Info().Str("root", block.Root().Hex()).Str("source", src).Msg("importing checkpoint state")
+		var n uint64
+		if n, data, err = snapshot.Import(d.bc.ChainDb(), block.Root(), rc); err != nil {
+			return nil, errors.Wrap(err, "cannot import checkpoint state")
+		}
+		d.logger.Info().Uint64("entries", n).Msg("checkpoint state imported")
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	offChain := new(core.CheckpointOffChain)
+	if err := rlp.DecodeBytes(data, offChain); err != nil {
+		return nil, errors.Wrap(err, "cannot decode checkpoint off-chain data")
+	}
+	return offChain, nil
+}
+
+// backfillLoop writes the history before the checkpoint block in background,
+// down to the genesis block
+func (d *Downloader) backfillLoop() {
+	for {
+		tail, ok := d.bc.BackfillTail()
+		if !ok {
+			d.logger.Info().Msg("history before checkpoint backfilled")
+			return
+		}
+		wait := backfillInterval
+		if err := d.doBackfill(tail); err != nil {
+			d.logger.Warn().Err(err).Uint64("tail", tail).Msg("failed to backfill")
+			wait = checkpointRetryInterval
+		}
+		select {
+		case <-time.After(wait):
+		case <-d.closeC:
+			return
+		}
+	}
+}
+
+// doBackfill requests the blocks right before the backfill tail from a stream and
+// writes them. A stream delivering blocks not matching the chain is removed.
+func (d *Downloader) doBackfill(tail uint64) error {
+	start := uint64(1)
+	if tail > numBlocksPerBackfill+1 {
+		start = tail - numBlocksPerBackfill
+	}
+	bns := make([]uint64, 0, tail-start)
+	for bn := start; bn < tail; bn++ {
+		bns = append(bns, bn)
+	}
+
+	ctx, cancel := context.WithTimeout(d.ctx, checkpointTimeout)
+	defer cancel()
+
+	blocks, stid, err := d.syncProtocol.GetBlocksByNumber(ctx, bns)
+	if err != nil {
+		return errors.Wrap(err, "cannot get backfill blocks")
+	}
+	for i, block := range blocks {
+		if block == nil {
+			return errors.Errorf("backfill block %v not delivered", bns[i])
+		}
+	}
+	if _, err := d.bc.WriteBackfillBlocks(blocks); err != nil {
+		d.syncProtocol.RemoveStream(stid)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+type testCheckpointChain struct {
+	*testBlockChain
+	checkpoint common.Hash
+	tail       uint64
+}
+
+func newTestCheckpointChain(curBN uint64) *testCheckpointChain {
+	return &testCheckpointChain{testBlockChain: newTestBlockChain(curBN, nil)}
+}
+
+func (bc *testCheckpointChain) InitFromCheckpoint(block *types.Block, offChain *core.CheckpointOffChain) error {
+	bc.changeBlockNumber(block.NumberU64())
+	bc.checkpoint = block.Hash()
+	bc.tail = block.NumberU64()
+	return nil
+}
+
+func (bc *testCheckpointChain) CheckpointHash() common.Hash { return bc.checkpoint }
+
+func (bc *testCheckpointChain) BackfillTail() (uint64, bool) {
+	return bc.tail, bc.checkpoint != (common.Hash{}) && bc.tail > 1
+}
+
+func (bc *testCheckpointChain) WriteBackfillBlocks(blocks types.Blocks) (int, error) {
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].NumberU64() != bc.tail-1 {
+			return 0, errors.New("unexpected backfill block")
+		}
+		bc.tail--
+	}
+	return len(blocks), nil
+}
+
+func TestDownloader_doInitFromCheckpoint(t *testing.T) {
+	tests := []struct {
+		curBN, remoteBN, cpBN uint64
+		expErr                error
+		expBN                 uint64
+		expCheckpoint         bool
+	}{
+		{curBN: 0, remoteBN: 100, cpBN: 50, expBN: 50, expCheckpoint: true},
+		{curBN: 60, remoteBN: 100, cpBN: 50, expBN: 60},
+		{curBN: 0, remoteBN: 40, cpBN: 50, expErr: errCheckpointNotFound, expBN: 0},
+	}
+	for i, test := range tests {
+		bc := newTestCheckpointChain(test.curBN)
+		d := &Downloader{
+			bc:           bc,
+			syncProtocol: newTestSyncProtocol(test.remoteBN, 32, nil),
+			ctx:          context.Background(),
+			config: Config{
+				Checkpoint: &CheckpointConfig{Hash: makeTestBlockHash(test.cpBN)},
+			},
+		}
+		err := d.doInitFromCheckpoint()
+		if assErr := assertError(err, test.expErr); assErr != nil {
+			t.Errorf("Test %v: %v", i, assErr)
+		}
+		if curBN := bc.currentBlockNumber(); curBN != test.expBN {
+			t.Errorf("Test %v: unexpected current number %v / %v", i, curBN, test.expBN)
+		}
+		if got := bc.checkpoint != (common.Hash{}); got != test.expCheckpoint {
+			t.Errorf("Test %v: unexpected checkpoint %v / %v", i, got, test.expCheckpoint)
+		}
+	}
+}
+
+func TestDownloader_doBackfill(t *testing.T) {
+	bc := newTestCheckpointChain(0)
+	if err := bc.InitFromCheckpoint(makeTestBlock(25), nil); err != nil {
+		t.Fatal(err)
+	}
+	d := &Downloader{
+		bc:           bc,
+		syncProtocol: newTestSyncProtocol(100, 32, nil),
+		ctx:          context.Background(),
+	}
+	for rounds := 0; ; rounds++ {
+		tail, ok := bc.BackfillTail()
+		if !ok {
+			if rounds != 3 {
+				t.Errorf("unexpected backfill rounds %v", rounds)
+			}
+			break
+		}
+		if rounds > 3 {
+			t.Fatalf("backfill not finished at tail %v", tail)
+		}
+		if err := d.doBackfill(tail); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bc.tail != 1 {
+		t.Errorf("unexpected backfill tail %v", bc.tail)
+	}
+}
+
+func TestConfig_checkpoint(t *testing.T) {
+	config := Config{
+		Checkpoint:       &CheckpointConfig{ShardID: 1, Hash: common.Hash{1}},
+		BeaconCheckpoint: &CheckpointConfig{ShardID: 0, Hash: common.Hash{2}},
+	}
+	if cp := config.checkpoint(1); cp != config.Checkpoint {
+		t.Errorf("unexpected checkpoint of the node shard %+v", cp)
+	}
+	if cp := config.checkpoint(0); cp != config.BeaconCheckpoint {
+		t.Errorf("unexpected checkpoint of the beacon shard %+v", cp)
+	}
+	if cp := config.checkpoint(2); cp != nil {
+		t.Errorf("unexpected checkpoint of another shard %+v", cp)
+	}
+}
@@ -1,8 +1,12 @@
 package downloader
 
 import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/harmony-one/harmony/core/types"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	syncproto "github.com/harmony-one/harmony/p2p/stream/protocols/sync"
 )
 
 const (
@@ -20,6 +24,11 @@ const (
 	softQueueCap int = 100
 
 	defaultConcurrency = 16
+
+	numBlocksPerBackfill    uint64 = 10 // number of blocks for each backfill request
+	backfillInterval               = 1 * time.Second
+	checkpointRetryInterval        = 10 * time.Second
+	checkpointTimeout              = 30 * time.Second
 )
 
 type (
@@ -41,8 +50,17 @@ type (
 		SmHiCap      int
 		SmDiscBatch  int
 
+		// rate limiter config of the sync protocol
+		RateLimit syncproto.RateLimitConfig
+
 		// config for beacon config
 		BHConfig *BeaconHelperConfig
+
+		// config for the sync started from a checkpoint
+		Checkpoint *CheckpointConfig
+		// config for the beacon sync of a side chain node started from a
+		// checkpoint
+		BeaconCheckpoint *CheckpointConfig
 	}
 
 	// BeaconHelperConfig is the extra config used for beaconHelper which uses
@@ -51,8 +69,26 @@ type (
 		BlockC     <-chan *types.Block
 		InsertHook func()
 	}
+
+	// CheckpointConfig is the config of the checkpoint the chain is synced from,
+	// instead of the genesis block, if the chain is behind it.
+	CheckpointConfig struct {
+		ShardID uint32
+		Hash    common.Hash // hash of the trusted last block of an epoch
+		State   string      // URL or file path of the state snapshot of the block
+	}
 )
 
+// checkpoint returns the config of the checkpoint of the shard, nil if none
+func (c *Config) checkpoint(shardID uint32) *CheckpointConfig {
+	for _, cp := range []*CheckpointConfig{c.Checkpoint, c.BeaconCheckpoint} {
+		if cp != nil && cp.ShardID == shardID {
+			return cp
+		}
+	}
+	return nil
+}
+
 func (c *Config) fixValues() {
 	if c.Concurrency == 0 {
 		c.Concurrency = defaultConcurrency
@@ -39,6 +39,9 @@ type (
 // NewDownloader creates a new downloader
 func NewDownloader(host p2p.Host, bc *core.BlockChain, config Config) *Downloader {
 	config.fixValues()
+	// The checkpoint of the node shard, or the beacon checkpoint for the beacon
+	// downloader of a side chain node
+	config.Checkpoint = config.checkpoint(bc.ShardID())
 
 	ih := newInsertHelper(bc)
 
@@ -53,6 +56,7 @@ func NewDownloader(host p2p.Host, bc *core.BlockChain, config Config) *Downloade
 		SmHardLowCap: config.SmHardLowCap,
 		SmHiCap:      config.SmHiCap,
 		DiscBatch:    config.SmDiscBatch,
+		RateLimit:    config.RateLimit,
 	})
 	host.AddStreamProtocol(sp)
 
@@ -143,6 +147,12 @@ func (d *Downloader) SubscribeDownloadFinished(ch chan struct{}) event.Subscript
 
 func (d *Downloader) run() {
 	d.waitForBootFinish()
+	if d.config.Checkpoint != nil {
+		d.initFromCheckpoint()
+	}
+	if _, ok := d.bc.BackfillTail(); ok {
+		go d.backfillLoop()
+	}
 	d.loop()
 }
 
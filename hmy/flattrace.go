@@ -0,0 +1,470 @@
+package hmy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/internal/utils"
+)
+
+const (
+	// flatTraceTracer is the tracer the flat traces are assembled from.
+	flatTraceTracer = "callTracer"
+	// flatTraceIndexerChanSize is the size of the channel listening to chain
+	// head events in the flat trace indexer.
+	flatTraceIndexerChanSize = 10
+)
+
+// TraceAction is the action of a flat trace. The populated fields depend on
+// the trace type: calls have a callType, from, to, gas, input and value,
+// creates have a from, gas, init and value and suicides have an address,
+// refundAddress and balance.
+type TraceAction struct {
+	CallType      string          `json:"callType,omitempty"`
+	From          *common.Address `json:"from,omitempty"`
+	To            *common.Address `json:"to,omitempty"`
+	Gas           *hexutil.Uint64 `json:"gas,omitempty"`
+	Input         *hexutil.Bytes  `json:"input,omitempty"`
+	Init          *hexutil.Bytes  `json:"init,omitempty"`
+	Value         *hexutil.Big    `json:"value,omitempty"`
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+}
+
+// TraceResult is the result of a successful call or create flat trace.
+type TraceResult struct {
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Output  *hexutil.Bytes  `json:"output,omitempty"`
+	Address *common.Address `json:"address,omitempty"`
+	Code    *hexutil.Bytes  `json:"code,omitempty"`
+}
+
+// FlatTrace is a single internal transaction in the Parity (OpenEthereum)
+// flat trace format.
+type FlatTrace struct {
+	Action              TraceAction  `json:"action"`
+	BlockHash           common.Hash  `json:"blockHash"`
+	BlockNumber         uint64       `json:"blockNumber"`
+	Error               string       `json:"error,omitempty"`
+	Result              *TraceResult `json:"result"`
+	Subtraces           int          `json:"subtraces"`
+	TraceAddress        []int        `json:"traceAddress"`
+	TransactionHash     common.Hash  `json:"transactionHash"`
+	TransactionPosition uint64       `json:"transactionPosition"`
+	Type                string       `json:"type"`
+}
+
+// FlatTraceFilter selects the flat traces returned by FilterFlatTraces.
+type FlatTraceFilter struct {
+	FromBlock   uint64
+	ToBlock     uint64
+	FromAddress []common.Address // Matches the sender of the trace, any if empty
+	ToAddress   []common.Address // Matches the receiver of the trace, any if empty
+	After       uint64           // Number of matching traces to skip
+	Count       uint64           // Maximum number of traces to return, unlimited if zero
+	MaxTraces   uint64           // Fails if more traces are returned, unlimited if zero
+	MaxReexec   uint64           // Fails if more blocks are not indexed, unlimited if zero
+}
+
+// callFrame is a call of the call tracer result the flat traces are built from.
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     *hexutil.Uint64 `json:"gas"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error"`
+	Calls   []*callFrame    `json:"calls"`
+}
+
+// TraceBlockFlat returns the flat traces of all transactions in the block,
+// read from the flat trace index if the block was indexed and re-executed
+// otherwise.
+func (hmy *Harmony) TraceBlockFlat(ctx context.Context, block *types.Block) ([]*FlatTrace, error) {
+	if traces, ok := hmy.readFlatTraces(block.NumberU64(), block.Hash()); ok {
+		return traces, nil
+	}
+	return hmy.computeFlatTraces(ctx, block)
+}
+
+// TraceTransactionFlat returns the flat traces of the given transaction.
+func (hmy *Harmony) TraceTransactionFlat(ctx context.Context, hash common.Hash) ([]*FlatTrace, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(hmy.chainDb, hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	if traces, ok := hmy.readFlatTraces(blockNumber, blockHash); ok {
+		txTraces := []*FlatTrace{}
+		for _, trace := range traces {
+			if trace.TransactionHash == hash {
+				txTraces = append(txTraces, trace)
+			}
+		}
+		return txTraces, nil
+	}
+	block := hmy.BlockChain.GetBlock(blockHash, blockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	msg, vmctx, statedb, err := hmy.ComputeTxEnv(block, int(index), defaultTraceReexec)
+	if err != nil {
+		return nil, err
+	}
+	tracer := flatTraceTracer
+	res, err := hmy.TraceTx(ctx, msg, vmctx, statedb, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	return flattenTxTrace(res, block, tx.Hash(), index)
+}
+
+// FilterFlatTraces returns the flat traces of the given block range matching
+// the filter. Blocks missing from the flat trace index are re-executed.
+func (hmy *Harmony) FilterFlatTraces(ctx context.Context, filter *FlatTraceFilter) ([]*FlatTrace, error) {
+	var (
+		fromAddrs  = addressSet(filter.FromAddress)
+		toAddrs    = addressSet(filter.ToAddress)
+		skipped    uint64
+		reexecuted uint64
+		traces     = []*FlatTrace{}
+	)
+	for number := filter.FromBlock; number <= filter.ToBlock; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := hmy.BlockChain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		blockTraces, ok := hmy.readFlatTraces(number, block.Hash())
+		if !ok {
+			// the blocks before the index start and the blocks the indexer
+			// skipped are re-executed, as the blocks above the index head
+			if filter.MaxReexec != 0 && reexecuted >= filter.MaxReexec {
+				return nil, fmt.Errorf("more than %d blocks of the range are not indexed, narrow the range", filter.MaxReexec)
+			}
+			reexecuted++
+			var err error
+			if blockTraces, err = hmy.computeFlatTraces(ctx, block); err != nil {
+				return nil, err
+			}
+		}
+		for _, trace := range blockTraces {
+			if !trace.matches(fromAddrs, toAddrs) {
+				continue
+			}
+			if skipped < filter.After {
+				skipped++
+				continue
+			}
+			if filter.MaxTraces != 0 && uint64(len(traces)) >= filter.MaxTraces {
+				return nil, fmt.Errorf("more than %d traces matched, narrow the filter", filter.MaxTraces)
+			}
+			traces = append(traces, trace)
+			if filter.Count != 0 && uint64(len(traces)) >= filter.Count {
+				return traces, nil
+			}
+		}
+	}
+	return traces, nil
+}
+
+// FlatTraceIndexHead returns the number of the latest block with indexed flat
+// traces, and false if no block was indexed yet.
+func (hmy *Harmony) FlatTraceIndexHead() (uint64, bool) {
+	number, _, ok := rawdb.ReadFlatTraceIndexHead(hmy.chainDb)
+	return number, ok
+}
+
+// readFlatTraces returns the indexed flat traces of the given block, if any.
+func (hmy *Harmony) readFlatTraces(number uint64, hash common.Hash) ([]*FlatTrace, bool) {
+	data, err := rawdb.ReadBlockFlatTraces(hmy.chainDb, number, hash)
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	var traces []*FlatTrace
+	if err := json.Unmarshal(data, &traces); err != nil {
+		utils.Logger().Warn().Err(err).Uint64("number", number).Msg("[flatTrace] invalid indexed traces")
+		return nil, false
+	}
+	return traces, true
+}
+
+// computeFlatTraces re-executes the block and assembles its flat traces.
+func (hmy *Harmony) computeFlatTraces(ctx context.Context, block *types.Block) ([]*FlatTrace, error) {
+	traces := []*FlatTrace{}
+	if block.NumberU64() == 0 || len(block.Transactions()) == 0 {
+		return traces, nil
+	}
+	tracer := flatTraceTracer
+	results, err := hmy.TraceBlock(ctx, block, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range results {
+		tx := block.Transactions()[i]
+		if res.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %#x failed: %s", tx.Hash(), res.Error)
+		}
+		txTraces, err := flattenTxTrace(res.Result, block, tx.Hash(), uint64(i))
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, txTraces...)
+	}
+	return traces, nil
+}
+
+// flattenTxTrace converts the call tracer result of a transaction into flat traces.
+func flattenTxTrace(res interface{}, block *types.Block, txHash common.Hash, index uint64) ([]*FlatTrace, error) {
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", res)
+	}
+	var root callFrame
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	traces := []*FlatTrace{}
+	flattenCallFrame(&root, []int{}, &traces, func(trace *FlatTrace) {
+		trace.BlockHash = block.Hash()
+		trace.BlockNumber = block.NumberU64()
+		trace.TransactionHash = txHash
+		trace.TransactionPosition = index
+	})
+	return traces, nil
+}
+
+// flattenCallFrame appends the flat trace of frame and all of its sub calls,
+// depth first, to traces.
+func flattenCallFrame(frame *callFrame, traceAddress []int, traces *[]*FlatTrace, fill func(*FlatTrace)) {
+	trace := &FlatTrace{
+		Subtraces:    len(frame.Calls),
+		TraceAddress: traceAddress,
+		Error:        frame.Error,
+	}
+	if trace.Error == "execution reverted" {
+		trace.Error = "Reverted"
+	}
+	fill(trace)
+
+	from := frame.From
+	switch frame.Type {
+	case "CREATE", "CREATE2":
+		init := frame.Input
+		trace.Type = "create"
+		trace.Action = TraceAction{From: &from, Gas: gasOrZero(frame.Gas), Init: &init, Value: frame.Value}
+		if trace.Error == "" {
+			code := frame.Output
+			trace.Result = &TraceResult{GasUsed: gasOrZero(frame.GasUsed), Address: frame.To, Code: &code}
+		}
+	case "SELFDESTRUCT":
+		trace.Type = "suicide"
+		trace.Action = TraceAction{Address: &from, RefundAddress: frame.To, Balance: frame.Value}
+	default:
+		input := frame.Input
+		value := frame.Value
+		if value == nil {
+			value = new(hexutil.Big)
+		}
+		trace.Type = "call"
+		trace.Action = TraceAction{
+			CallType: strings.ToLower(frame.Type),
+			From:     &from,
+			To:       frame.To,
+			Gas:      gasOrZero(frame.Gas),
+			Input:    &input,
+			Value:    value,
+		}
+		if trace.Error == "" {
+			output := frame.Output
+			trace.Result = &TraceResult{GasUsed: gasOrZero(frame.GasUsed), Output: &output}
+		}
+	}
+	*traces = append(*traces, trace)
+
+	for i, call := range frame.Calls {
+		subAddress := make([]int, len(traceAddress)+1)
+		copy(subAddress, traceAddress)
+		subAddress[len(traceAddress)] = i
+		flattenCallFrame(call, subAddress, traces, fill)
+	}
+}
+
+// matches reports whether the trace is sent from one of fromAddrs and received
+// by one of toAddrs. An empty set matches any address.
+func (trace *FlatTrace) matches(fromAddrs, toAddrs map[common.Address]struct{}) bool {
+	var from, to *common.Address
+	switch trace.Type {
+	case "create":
+		from = trace.Action.From
+		if trace.Result != nil {
+			to = trace.Result.Address
+		}
+	case "suicide":
+		from, to = trace.Action.Address, trace.Action.RefundAddress
+	default:
+		from, to = trace.Action.From, trace.Action.To
+	}
+	return addressMatches(fromAddrs, from) && addressMatches(toAddrs, to)
+}
+
+func addressMatches(set map[common.Address]struct{}, addr *common.Address) bool {
+	if len(set) == 0 {
+		return true
+	}
+	if addr == nil {
+		return false
+	}
+	_, ok := set[*addr]
+	return ok
+}
+
+func addressSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
+
+func gasOrZero(gas *hexutil.Uint64) *hexutil.Uint64 {
+	if gas == nil {
+		return new(hexutil.Uint64)
+	}
+	return gas
+}
+
+// FlatTraceIndexer stores the flat traces of every canonical block, so the
+// trace namespace can serve them without re-executing the block. It needs the
+// historical state of every block and is thus only meant for archival nodes.
+type FlatTraceIndexer struct {
+	hmy  *Harmony
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFlatTraceIndexer creates a new flat trace indexer.
+func NewFlatTraceIndexer(hmy *Harmony) *FlatTraceIndexer {
+	return &FlatTraceIndexer{
+		hmy:  hmy,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start indexes the blocks missed while the indexer was not running, then
+// keeps indexing new blocks in the background as they are inserted.
+func (idx *FlatTraceIndexer) Start() {
+	idx.wg.Add(1)
+	go idx.loop()
+}
+
+// Stop terminates the indexer and waits for the block in progress.
+func (idx *FlatTraceIndexer) Stop() {
+	close(idx.quit)
+	idx.wg.Wait()
+}
+
+func (idx *FlatTraceIndexer) loop() {
+	defer idx.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, flatTraceIndexerChanSize)
+	sub := idx.hmy.BlockChain.SubscribeChainHeadEvent(headCh)
+	defer sub.Unsubscribe()
+
+	for {
+		idx.catchUp()
+		select {
+		case <-headCh:
+		case <-sub.Err():
+			return
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// catchUp indexes all blocks from the index head up to the current block. A block
+// failing to be traced is skipped, its traces are re-executed on request.
+func (idx *FlatTraceIndexer) catchUp() {
+	var (
+		db      = idx.hmy.chainDb
+		current = idx.hmy.BlockChain.CurrentBlock().NumberU64()
+	)
+	for next := flatTraceIndexStart(db, current); next <= current; next++ {
+		select {
+		case <-idx.quit:
+			return
+		default:
+		}
+		block := idx.hmy.BlockChain.GetBlockByNumber(next)
+		if block == nil {
+			return
+		}
+		batch := db.NewBatch()
+		if data, err := idx.encodeFlatTraces(block); err != nil {
+			utils.Logger().Warn().Err(err).Uint64("number", next).
+				Msg("[flatTrace] failed to index block, skipped")
+		} else if err := rawdb.WriteBlockFlatTraces(batch, block.NumberU64(), block.Hash(), data); err != nil {
+			return
+		}
+		if err := rawdb.WriteFlatTraceIndexHead(batch, block.NumberU64(), block.Hash()); err != nil {
+			return
+		}
+		if err := batch.Write(); err != nil {
+			utils.Logger().Error().Err(err).Uint64("number", next).Msg("[flatTrace] failed to write traces")
+			return
+		}
+	}
+}
+
+func (idx *FlatTraceIndexer) encodeFlatTraces(block *types.Block) ([]byte, error) {
+	traces, err := idx.hmy.computeFlatTraces(context.Background(), block)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(traces)
+}
+
+// flatTraceIndexStart returns the number of the next block to index. If the chain
+// was reorganized, the traces indexed on the old fork are removed and the index
+// head is moved back to the last block still canonical.
+func flatTraceIndexStart(db ethdb.Database, current uint64) uint64 {
+	head, headHash, ok := rawdb.ReadFlatTraceIndexHead(db)
+	if !ok {
+		return 0
+	}
+	number, hash := head, headHash
+	for rawdb.ReadCanonicalHash(db, number) != hash {
+		rawdb.DeleteBlockFlatTraces(db, number, hash)
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil || number == 0 {
+			// The old fork is gone, as after a rewind of the chain which keeps
+			// the canonical blocks up to the current block.
+			if number > current {
+				return current + 1
+			}
+			return number
+		}
+		number, hash = number-1, header.ParentHash()
+	}
+	if number != head {
+		utils.Logger().Info().Uint64("from", head).Uint64("to", number).
+			Msg("[flatTrace] chain reorganized, re-indexing the new blocks")
+		rawdb.WriteFlatTraceIndexHead(db, number, hash)
+	}
+	return number + 1
+}
@@ -0,0 +1,218 @@
+package hmy
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethRawDB "github.com/ethereum/go-ethereum/core/rawdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+var testCallTrace = json.RawMessage(`{
+	"type": "CALL",
+	"from": "0x00000000000000000000000000000000000000aa",
+	"to": "0x00000000000000000000000000000000000000bb",
+	"value": "0x5",
+	"gas": "0xf4240",
+	"gasUsed": "0x1a2b",
+	"input": "0x",
+	"output": "0x2a",
+	"calls": [
+		{
+			"type": "DELEGATECALL",
+			"from": "0x00000000000000000000000000000000000000bb",
+			"to": "0x00000000000000000000000000000000000000cc",
+			"gas": "0x100",
+			"gasUsed": "0x10",
+			"input": "0x12345678",
+			"output": "0x",
+			"calls": [
+				{
+					"type": "SELFDESTRUCT",
+					"from": "0x00000000000000000000000000000000000000bb",
+					"to": "0x00000000000000000000000000000000000000aa",
+					"value": "0x2a"
+				}
+			]
+		},
+		{
+			"type": "CREATE",
+			"from": "0x00000000000000000000000000000000000000bb",
+			"to": "0x00000000000000000000000000000000000000dd",
+			"value": "0x0",
+			"gas": "0x200",
+			"gasUsed": "0x20",
+			"input": "0x6000",
+			"output": "0x00"
+		},
+		{
+			"type": "CALL",
+			"from": "0x00000000000000000000000000000000000000bb",
+			"to": "0x00000000000000000000000000000000000000ee",
+			"value": "0x1",
+			"input": "0x",
+			"error": "execution reverted"
+		}
+	]
+}`)
+
+func TestFlattenTxTrace(t *testing.T) {
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(7)).Header()
+	block := types.NewBlockWithHeader(header)
+	txHash := common.HexToHash("0x1234")
+
+	traces, err := flattenTxTrace(testCallTrace, block, txHash, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		expTypes     = []string{"call", "call", "suicide", "create", "call"}
+		expAddresses = [][]int{{}, {0}, {0, 0}, {1}, {2}}
+		expSubtraces = []int{3, 1, 0, 0, 0}
+	)
+	if len(traces) != len(expTypes) {
+		t.Fatalf("unexpected number of traces: have %d, want %d", len(traces), len(expTypes))
+	}
+	for i, trace := range traces {
+		if trace.Type != expTypes[i] {
+			t.Errorf("trace %d: type mismatch: have %v, want %v", i, trace.Type, expTypes[i])
+		}
+		if !reflect.DeepEqual(trace.TraceAddress, expAddresses[i]) {
+			t.Errorf("trace %d: trace address mismatch: have %v, want %v", i, trace.TraceAddress, expAddresses[i])
+		}
+		if trace.Subtraces != expSubtraces[i] {
+			t.Errorf("trace %d: subtraces mismatch: have %v, want %v", i, trace.Subtraces, expSubtraces[i])
+		}
+		if trace.BlockNumber != 7 || trace.BlockHash != block.Hash() {
+			t.Errorf("trace %d: block mismatch: have #%d %x", i, trace.BlockNumber, trace.BlockHash)
+		}
+		if trace.TransactionHash != txHash || trace.TransactionPosition != 3 {
+			t.Errorf("trace %d: transaction mismatch: have %x at %d", i, trace.TransactionHash, trace.TransactionPosition)
+		}
+	}
+	if have := traces[1].Action.CallType; have != "delegatecall" {
+		t.Errorf("call type mismatch: have %v, want delegatecall", have)
+	}
+	if traces[1].Action.Value == nil || traces[1].Action.Value.ToInt().Sign() != 0 {
+		t.Errorf("delegate call value not zero: %v", traces[1].Action.Value)
+	}
+	if suicide := traces[2].Action; *suicide.Address != common.HexToAddress("0xbb") ||
+		*suicide.RefundAddress != common.HexToAddress("0xaa") || suicide.Balance.ToInt().Int64() != 42 {
+		t.Errorf("suicide action mismatch: %+v", suicide)
+	}
+	if traces[2].Result != nil {
+		t.Errorf("suicide has a result: %+v", traces[2].Result)
+	}
+	if create := traces[3]; create.Result == nil || *create.Result.Address != common.HexToAddress("0xdd") {
+		t.Errorf("create result mismatch: %+v", create.Result)
+	}
+	if reverted := traces[4]; reverted.Error != "Reverted" || reverted.Result != nil {
+		t.Errorf("reverted call mismatch: error %q, result %+v", reverted.Error, reverted.Result)
+	}
+}
+
+func TestFlatTraceMatches(t *testing.T) {
+	traces, err := flattenTxTrace(testCallTrace, types.NewBlockWithHeader(blockfactory.NewTestHeader()), common.Hash{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from, to []common.Address
+		exp      []bool
+	}{
+		{nil, nil, []bool{true, true, true, true, true}},
+		{[]common.Address{common.HexToAddress("0xbb")}, nil, []bool{false, true, true, true, true}},
+		{nil, []common.Address{common.HexToAddress("0xaa")}, []bool{false, false, true, false, false}},
+		{nil, []common.Address{common.HexToAddress("0xdd")}, []bool{false, false, false, true, false}},
+		{[]common.Address{common.HexToAddress("0xaa")}, []common.Address{common.HexToAddress("0xbb")}, []bool{true, false, false, false, false}},
+	}
+	for i, test := range tests {
+		for j, trace := range traces {
+			if have := trace.matches(addressSet(test.from), addressSet(test.to)); have != test.exp[j] {
+				t.Errorf("test %d trace %d: match mismatch: have %v, want %v", i, j, have, test.exp[j])
+			}
+		}
+	}
+}
+
+func TestFlatTraceIndexStart(t *testing.T) {
+	db := ethRawDB.NewMemoryDatabase()
+	// makeChain writes the headers of blocks from..to on top of parent, canonical or not
+	makeChain := func(parent common.Hash, from, to uint64, extra byte, canonical bool) []common.Hash {
+		hashes := []common.Hash{}
+		for n := from; n <= to; n++ {
+			header := blockfactory.NewTestHeader().With().
+				Number(new(big.Int).SetUint64(n)).ParentHash(parent).Extra([]byte{extra}).Header()
+			rawdb.WriteHeader(db, header)
+			if canonical {
+				rawdb.WriteCanonicalHash(db, header.Hash(), n)
+			}
+			parent = header.Hash()
+			hashes = append(hashes, parent)
+		}
+		return hashes
+	}
+	chain := makeChain(common.Hash{}, 0, 10, 0, true)
+
+	if next := flatTraceIndexStart(db, 10); next != 0 {
+		t.Errorf("unexpected start without index %v", next)
+	}
+	rawdb.WriteFlatTraceIndexHead(db, 8, chain[8])
+	if next := flatTraceIndexStart(db, 10); next != 9 {
+		t.Errorf("unexpected start %v / 9", next)
+	}
+
+	// blocks 7..8 indexed on a fork off block 6
+	fork := makeChain(chain[6], 7, 8, 1, false)
+	rawdb.WriteBlockFlatTraces(db, 8, fork[1], []byte("[]"))
+	rawdb.WriteFlatTraceIndexHead(db, 8, fork[1])
+	if next := flatTraceIndexStart(db, 10); next != 7 {
+		t.Errorf("unexpected start after reorg %v / 7", next)
+	}
+	if number, hash, _ := rawdb.ReadFlatTraceIndexHead(db); number != 6 || hash != chain[6] {
+		t.Errorf("unexpected index head after reorg %v %v", number, hash.Hex())
+	}
+	if data, _ := rawdb.ReadBlockFlatTraces(db, 8, fork[1]); len(data) != 0 {
+		t.Errorf("traces of the old fork not removed")
+	}
+
+	// indexed head removed by a rewind of the chain to block 5
+	rawdb.WriteFlatTraceIndexHead(db, 9, common.Hash{9})
+	if next := flatTraceIndexStart(db, 5); next != 6 {
+		t.Errorf("unexpected start after rewind %v / 6", next)
+	}
+}
+
+func TestFilterFlatTracesReexecLimit(t *testing.T) {
+	db, bc := makeTestBlockChain(t)
+	defer bc.Stop()
+	// empty blocks 1..4, only block 2 indexed
+	parent := bc.Genesis().Hash()
+	for n := uint64(1); n <= 4; n++ {
+		header := blockfactory.NewTestHeader().With().
+			Number(new(big.Int).SetUint64(n)).ParentHash(parent).Header()
+		block := types.NewBlockWithHeader(header)
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), n)
+		if n == 2 {
+			rawdb.WriteBlockFlatTraces(db, n, block.Hash(), []byte("[]"))
+		}
+		parent = block.Hash()
+	}
+	hmy := &Harmony{BlockChain: bc, chainDb: db}
+
+	filter := &FlatTraceFilter{FromBlock: 0, ToBlock: 4, MaxReexec: 3}
+	if _, err := hmy.FilterFlatTraces(context.Background(), filter); err == nil {
+		t.Errorf("range with 4 blocks not indexed accepted")
+	}
+	filter.MaxReexec = 4
+	if _, err := hmy.FilterFlatTraces(context.Background(), filter); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
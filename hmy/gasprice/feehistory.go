@@ -0,0 +1,175 @@
+package gasprice
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	errInvalidPercentile = errors.New("invalid reward percentile")
+	errRequestBeyondHead = errors.New("request beyond head block")
+)
+
+// FeeHistory is the fee market history of a range of blocks. Harmony has no
+// base fee, so BaseFee is always zero. It holds one entry more than the
+// other fields, for the block following the range.
+type FeeHistory struct {
+	OldestBlock  uint64
+	Reward       [][]*big.Int // Effective gas price percentiles of each block, nil if none were requested
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+}
+
+// blockFees is the fee history of a single block.
+type blockFees struct {
+	reward       []*big.Int
+	gasUsedRatio float64
+}
+
+// txGasAndPrice is the gas used by a transaction and the gas price it paid.
+type txGasAndPrice struct {
+	gasUsed uint64
+	price   *big.Int
+}
+
+// FeeHistory returns the gas used ratio and the given percentiles of the gas
+// prices, weighted by gas used, of up to blocks blocks ending with lastBlock.
+// The results are cached until a new head block is inserted.
+func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*FeeHistory, error) {
+	if blocks < 1 {
+		return &FeeHistory{}, nil
+	}
+	if blocks > oracle.config.MaxHeaderHistory {
+		blocks = oracle.config.MaxHeaderHistory
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, errors.Wrapf(errInvalidPercentile, "%f", p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, errors.Wrapf(errInvalidPercentile, "#%d:%f > #%d:%f", i-1, percentiles[i-1], i, p)
+		}
+	}
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	headNumber := head.Number().Uint64()
+	last := headNumber
+	switch lastBlock {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+	default:
+		if lastBlock < 0 || uint64(lastBlock) > headNumber {
+			return nil, errors.Wrapf(errRequestBeyondHead, "requested %d, head %d", lastBlock, headNumber)
+		}
+		last = uint64(lastBlock)
+	}
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	history := &FeeHistory{
+		OldestBlock:  oldest,
+		BaseFee:      make([]*big.Int, blocks+1),
+		GasUsedRatio: make([]float64, blocks),
+	}
+	if len(percentiles) > 0 {
+		history.Reward = make([][]*big.Int, blocks)
+	}
+	for i := range history.BaseFee {
+		history.BaseFee[i] = new(big.Int)
+	}
+	for i := 0; i < blocks; i++ {
+		fees, err := oracle.blockFees(ctx, oldest+uint64(i), percentiles)
+		if err != nil {
+			return nil, err
+		}
+		history.GasUsedRatio[i] = fees.gasUsedRatio
+		if history.Reward != nil {
+			history.Reward[i] = fees.reward
+		}
+	}
+	return history, nil
+}
+
+// blockFees returns the fee history of a single block, from the cache if it
+// was computed since the last head block was inserted.
+func (oracle *Oracle) blockFees(ctx context.Context, number uint64, percentiles []float64) (*blockFees, error) {
+	key := feeCacheKey(number, percentiles)
+	if fees, ok := oracle.historyCache.Get(key); ok {
+		return fees.(*blockFees), nil
+	}
+	blk, err := oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+	if err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	fees := &blockFees{}
+	if blk.GasLimit() > 0 {
+		fees.gasUsedRatio = float64(blk.GasUsed()) / float64(blk.GasLimit())
+	}
+	if len(percentiles) > 0 {
+		receipts, err := oracle.backend.GetReceipts(ctx, blk.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if fees.reward, err = rewardPercentiles(blk, receipts, percentiles); err != nil {
+			return nil, err
+		}
+	}
+	oracle.historyCache.Add(key, fees)
+	return fees, nil
+}
+
+// rewardPercentiles returns the given percentiles of the gas prices paid in
+// the block, weighted by the gas used by each transaction.
+func rewardPercentiles(blk *types.Block, receipts types.Receipts, percentiles []float64) ([]*big.Int, error) {
+	reward := make([]*big.Int, len(percentiles))
+	prices := gasPrices(blk)
+	if len(prices) == 0 {
+		// Return an all zero row if there are no transactions to gather data from
+		for i := range reward {
+			reward[i] = new(big.Int)
+		}
+		return reward, nil
+	}
+	if len(receipts) != len(prices) {
+		return nil, fmt.Errorf("receipts of block #%d not found", blk.NumberU64())
+	}
+	txs := make([]txGasAndPrice, len(prices))
+	for i, price := range prices {
+		txs[i] = txGasAndPrice{gasUsed: receipts[i].GasUsed, price: price}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].price.Cmp(txs[j].price) < 0 })
+
+	var txIndex int
+	sumGasUsed := txs[0].gasUsed
+	for i, p := range percentiles {
+		thresholdGasUsed := uint64(float64(blk.GasUsed()) * p / 100)
+		for sumGasUsed < thresholdGasUsed && txIndex < len(txs)-1 {
+			txIndex++
+			sumGasUsed += txs[txIndex].gasUsed
+		}
+		reward[i] = new(big.Int).Set(txs[txIndex].price)
+	}
+	return reward, nil
+}
+
+func feeCacheKey(number uint64, percentiles []float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d", number)
+	for _, p := range percentiles {
+		fmt.Fprintf(&b, ":%g", p)
+	}
+	return b.String()
+}
@@ -0,0 +1,248 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gasprice suggests gas prices and reports the fee history based on
+// the transactions of the recent blocks.
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	sampleNumber = 3 // Number of transactions sampled in a block
+
+	// DefaultBlocks is the default number of recent blocks sampled.
+	DefaultBlocks = 20
+	// DefaultPercentile is the default percentile of the sampled gas prices suggested.
+	DefaultPercentile = 60
+	// DefaultMaxHeaderHistory is the default maximum number of blocks of a fee history.
+	DefaultMaxHeaderHistory = 1024
+
+	feeHistoryCacheSize = 2048
+	chainHeadChanSize   = 10
+)
+
+var (
+	// DefaultMaxPrice is the default maximum gas price suggested.
+	DefaultMaxPrice = big.NewInt(1000 * 1e9)
+	// DefaultIgnorePrice is the default gas price below which transactions
+	// are not sampled.
+	DefaultIgnorePrice = big.NewInt(2)
+)
+
+// Config is the configuration of the gas price oracle.
+type Config struct {
+	Blocks           int      // Number of recent blocks sampled
+	Percentile       int      // Percentile of the sampled gas prices suggested
+	MaxHeaderHistory int      // Maximum number of blocks of a fee history
+	Default          *big.Int // Suggested when no transaction could be sampled, and the lowest suggestion
+	MaxPrice         *big.Int // Highest suggestion
+	IgnorePrice      *big.Int // Gas price below which transactions are not sampled
+}
+
+// OracleBackend is the chain access needed by the oracle.
+type OracleBackend interface {
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*block.Header, error)
+	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Oracle recommends gas prices based on the content of recent blocks. Results
+// are cached until a new head block is inserted.
+type Oracle struct {
+	backend OracleBackend
+	config  Config
+
+	lock      sync.RWMutex
+	lastHead  common.Hash
+	lastPrice *big.Int
+	fetchLock sync.Mutex
+
+	historyCache *lru.Cache // Fee history results of the current head
+
+	sub  event.Subscription
+	quit chan struct{}
+}
+
+// NewOracle returns a new gas price oracle, filling in the defaults of the
+// zero fields of config.
+func NewOracle(backend OracleBackend, config Config) *Oracle {
+	if config.Blocks < 1 {
+		config.Blocks = DefaultBlocks
+	}
+	if config.Percentile <= 0 || config.Percentile > 100 {
+		config.Percentile = DefaultPercentile
+	}
+	if config.MaxHeaderHistory < 1 {
+		config.MaxHeaderHistory = DefaultMaxHeaderHistory
+	}
+	if config.Default == nil {
+		config.Default = big.NewInt(1)
+	}
+	if config.MaxPrice == nil || config.MaxPrice.Sign() <= 0 {
+		config.MaxPrice = DefaultMaxPrice
+	}
+	if config.IgnorePrice == nil || config.IgnorePrice.Sign() < 0 {
+		config.IgnorePrice = DefaultIgnorePrice
+	}
+	historyCache, _ := lru.New(feeHistoryCacheSize)
+	return &Oracle{
+		backend:      backend,
+		config:       config,
+		lastPrice:    config.Default,
+		historyCache: historyCache,
+	}
+}
+
+// Start invalidates the cached results on every new head block.
+func (oracle *Oracle) Start() {
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	oracle.sub = oracle.backend.SubscribeChainHeadEvent(headCh)
+	quit := make(chan struct{})
+	oracle.quit = quit
+	go func() {
+		defer oracle.sub.Unsubscribe()
+		for {
+			select {
+			case <-headCh:
+				oracle.historyCache.Purge()
+			case <-oracle.sub.Err():
+				return
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the cache invalidation. It is a no-op if the oracle is not
+// started or already stopped.
+func (oracle *Oracle) Stop() {
+	if oracle.quit != nil {
+		close(oracle.quit)
+		oracle.quit = nil
+	}
+}
+
+// SuggestPrice returns the recommended gas price: the configured percentile
+// of the lowest gas prices paid in the recent blocks, bounded by the default
+// and the maximum price.
+func (oracle *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	headHash := head.Hash()
+
+	// If the latest gas price is still available, return it
+	oracle.lock.RLock()
+	lastHead, lastPrice := oracle.lastHead, oracle.lastPrice
+	oracle.lock.RUnlock()
+	if headHash == lastHead {
+		return new(big.Int).Set(lastPrice), nil
+	}
+	oracle.fetchLock.Lock()
+	defer oracle.fetchLock.Unlock()
+
+	// Try checking the cache again, maybe the last fetch fetched what we need
+	oracle.lock.RLock()
+	lastHead, lastPrice = oracle.lastHead, oracle.lastPrice
+	oracle.lock.RUnlock()
+	if headHash == lastHead {
+		return new(big.Int).Set(lastPrice), nil
+	}
+
+	var (
+		number = head.Number().Uint64()
+		prices []*big.Int
+	)
+	for sampled := 0; sampled < oracle.config.Blocks && number > 0; sampled++ {
+		blk, err := oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return new(big.Int).Set(lastPrice), err
+		}
+		if blk == nil {
+			break
+		}
+		prices = append(prices, oracle.blockPrices(blk)...)
+		number--
+	}
+	price := new(big.Int).Set(oracle.config.Default)
+	if len(prices) > 0 {
+		sort.Sort(bigIntArray(prices))
+		if sampled := prices[(len(prices)-1)*oracle.config.Percentile/100]; sampled.Cmp(price) > 0 {
+			price.Set(sampled)
+		}
+	}
+	if price.Cmp(oracle.config.MaxPrice) > 0 {
+		price.Set(oracle.config.MaxPrice)
+	}
+	oracle.lock.Lock()
+	oracle.lastHead = headHash
+	oracle.lastPrice = price
+	oracle.lock.Unlock()
+
+	return new(big.Int).Set(price), nil
+}
+
+// blockPrices returns the lowest gas prices paid in the block, ignoring the
+// transactions priced below the ignore price.
+func (oracle *Oracle) blockPrices(blk *types.Block) []*big.Int {
+	var prices []*big.Int
+	for _, price := range gasPrices(blk) {
+		if price.Cmp(oracle.config.IgnorePrice) < 0 {
+			continue
+		}
+		prices = append(prices, price)
+	}
+	sort.Sort(bigIntArray(prices))
+	if len(prices) > sampleNumber {
+		prices = prices[:sampleNumber]
+	}
+	return prices
+}
+
+// gasPrices returns the gas prices of all plain and staking transactions of
+// the block, in receipt order.
+func gasPrices(blk *types.Block) []*big.Int {
+	var prices []*big.Int
+	for _, tx := range blk.Transactions() {
+		prices = append(prices, tx.GasPrice())
+	}
+	for _, tx := range blk.StakingTransactions() {
+		prices = append(prices, tx.GasPrice())
+	}
+	return prices
+}
+
+type bigIntArray []*big.Int
+
+func (s bigIntArray) Len() int           { return len(s) }
+func (s bigIntArray) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigIntArray) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
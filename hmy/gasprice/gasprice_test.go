@@ -0,0 +1,172 @@
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+type testBackend struct {
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	feed     event.Feed
+}
+
+// newTestBackend creates a chain where block i holds transactions priced
+// i, 2i, ... , 5i gwei, each using 21000 gas of a 1050000 gas limit.
+func newTestBackend(length int) *testBackend {
+	b := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	for i := 0; i < length; i++ {
+		var (
+			txs      []*types.Transaction
+			receipts types.Receipts
+		)
+		if i > 0 {
+			for j := 1; j <= 5; j++ {
+				price := big.NewInt(int64(i*j) * 1e9)
+				txs = append(txs, types.NewTransaction(uint64(j), common.Address{}, 0, big.NewInt(0), 21000, price, nil))
+				receipt := types.NewReceipt(nil, false, uint64(j*21000))
+				receipt.GasUsed = 21000
+				receipts = append(receipts, receipt)
+			}
+		}
+		header := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(int64(i))).
+			GasLimit(1050000).
+			GasUsed(uint64(len(txs)) * 21000).
+			Header()
+		blk := types.NewBlock(header, txs, receipts, nil, nil, nil)
+		b.blocks = append(b.blocks, blk)
+		b.receipts[blk.Hash()] = receipts
+	}
+	return b
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*block.Header, error) {
+	blk, err := b.BlockByNumber(ctx, number)
+	if err != nil || blk == nil {
+		return nil, err
+	}
+	return blk.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.blocks[len(b.blocks)-1], nil
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+func TestSuggestPrice(t *testing.T) {
+	tests := []struct {
+		config Config
+		exp    int64
+	}{
+		// Lowest 3 prices of blocks 21..30, 60th percentile
+		{Config{Blocks: 10, Percentile: 60}, 56},
+		{Config{Blocks: 10, Percentile: 100}, 90},
+		{Config{Blocks: 10, Percentile: 60, MaxPrice: big.NewInt(40e9)}, 40},
+		{Config{Blocks: 10, Percentile: 60, Default: big.NewInt(60e9)}, 60},
+		{Config{Blocks: 10, Percentile: 60, IgnorePrice: big.NewInt(50e9)}, 90},
+	}
+	backend := newTestBackend(31)
+	for i, test := range tests {
+		oracle := NewOracle(backend, test.config)
+		price, err := oracle.SuggestPrice(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if exp := new(big.Int).Mul(big.NewInt(test.exp), big.NewInt(1e9)); price.Cmp(exp) != 0 {
+			t.Errorf("test %d: suggested price mismatch: have %v, want %v", i, price, exp)
+		}
+	}
+}
+
+func TestFeeHistory(t *testing.T) {
+	backend := newTestBackend(31)
+	oracle := NewOracle(backend, Config{MaxHeaderHistory: 8})
+
+	history, err := oracle.FeeHistory(context.Background(), 4, 10, []float64{0, 50, 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock != 7 {
+		t.Errorf("oldest block mismatch: have %d, want 7", history.OldestBlock)
+	}
+	if len(history.BaseFee) != 5 || len(history.GasUsedRatio) != 4 || len(history.Reward) != 4 {
+		t.Fatalf("history length mismatch: %d base fees, %d gas used ratios, %d rewards",
+			len(history.BaseFee), len(history.GasUsedRatio), len(history.Reward))
+	}
+	for i, ratio := range history.GasUsedRatio {
+		if ratio != 0.1 {
+			t.Errorf("block %d: gas used ratio mismatch: have %v, want 0.1", i, ratio)
+		}
+	}
+	// Block 7 paid 7, 14, 21, 28 and 35 gwei with equal gas
+	for i, exp := range []int64{7, 21, 35} {
+		if have := history.Reward[0][i]; have.Cmp(big.NewInt(exp*1e9)) != 0 {
+			t.Errorf("reward %d mismatch: have %v, want %d gwei", i, have, exp)
+		}
+	}
+
+	// The range is capped by the maximum history and the genesis block
+	if history, err = oracle.FeeHistory(context.Background(), 100, rpc.LatestBlockNumber, nil); err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock != 23 || len(history.GasUsedRatio) != 8 || history.Reward != nil {
+		t.Errorf("unexpected capped history: oldest %d, %d blocks", history.OldestBlock, len(history.GasUsedRatio))
+	}
+	if history, err = oracle.FeeHistory(context.Background(), 5, 2, []float64{50}); err != nil {
+		t.Fatal(err)
+	}
+	if history.OldestBlock != 0 || history.Reward[0][0].Sign() != 0 {
+		t.Errorf("unexpected history from genesis: oldest %d, reward %v", history.OldestBlock, history.Reward[0])
+	}
+
+	if _, err := oracle.FeeHistory(context.Background(), 4, 10, []float64{50, 10}); err == nil {
+		t.Error("unsorted percentiles accepted")
+	}
+	if _, err := oracle.FeeHistory(context.Background(), 4, 10, []float64{101}); err == nil {
+		t.Error("out of range percentile accepted")
+	}
+	if _, err := oracle.FeeHistory(context.Background(), 4, 31, nil); err == nil {
+		t.Error("request beyond head accepted")
+	}
+}
+
+func TestOracleStop(t *testing.T) {
+	backend := newTestBackend(1)
+	oracle := NewOracle(backend, Config{})
+	oracle.Start()
+	oracle.Stop()
+	oracle.Stop()
+
+	// the head subscription is released by the stopped oracle
+	deadline := time.Now().Add(time.Second)
+	for backend.feed.Send(core.ChainHeadEvent{}) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("oracle still subscribed to the chain head after stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
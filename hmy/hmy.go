@@ -3,6 +3,7 @@ package hmy
 import (
 	"context"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -16,7 +17,9 @@ import (
 	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/hmy/gasprice"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/p2p"
 	commonRPC "github.com/harmony-one/harmony/rpc/common"
 	"github.com/harmony-one/harmony/shard"
 	staking "github.com/harmony-one/harmony/staking/types"
@@ -49,7 +52,8 @@ type Harmony struct {
 	BlockChain    *core.BlockChain
 	BeaconChain   *core.BlockChain
 	TxPool        *core.TxPool
-	CxPool        *core.CxPool // CxPool is used to store the blockHashes of blocks containing cx receipts to be sent
+	CxPool        *core.CxPool    // CxPool is used to store the blockHashes of blocks containing cx receipts to be sent
+	CxTracker     *core.CxTracker // CxTracker tracks the delivery of the outgoing cx receipts
 	// DB interfaces
 	BloomIndexer *core.ChainIndexer // Bloom indexer operating during block imports
 	NodeAPI      NodeAPI
@@ -74,6 +78,13 @@ type Harmony struct {
 	preStakingBlockRewardsCache *lru.Cache
 	// totalStakeCache to save on recomputation for `totalStakeCacheDuration` blocks.
 	totalStakeCache *totalStakeCache
+	// gpo suggests gas prices based on the recent blocks.
+	gpo *gasprice.Oracle
+	// chainTraces are the chain tracing jobs writing to disk, by job id.
+	chainTraces    map[string]*chainTraceJob
+	chainTraceLock sync.Mutex
+	// chainTracesRunning is the number of running chain tracing jobs.
+	chainTracesRunning int
 }
 
 // NodeAPI is the list of functions from node used to call rpc apis.
@@ -98,6 +109,7 @@ type NodeAPI interface {
 	ListPeer(topic string) []peer.ID
 	ListTopic() []string
 	ListBlockedPeer() []peer.ID
+	ListPeerScore() []p2p.PeerScore
 
 	GetConsensusInternal() commonRPC.ConsensusInternal
 
@@ -112,7 +124,7 @@ type NodeAPI interface {
 // New creates a new Harmony object (including the
 // initialisation of the common Harmony object)
 func New(
-	nodeAPI NodeAPI, txPool *core.TxPool, cxPool *core.CxPool, shardID uint32,
+	nodeAPI NodeAPI, txPool *core.TxPool, cxPool *core.CxPool, cxTracker *core.CxTracker, shardID uint32,
 ) *Harmony {
 	chainDb := nodeAPI.Blockchain().ChainDb()
 	leaderCache, _ := lru.New(leaderCacheSize)
@@ -121,7 +133,7 @@ func New(
 	totalStakeCache := newTotalStakeCache(totalStakeCacheDuration)
 	bloomIndexer := NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms)
 	bloomIndexer.Start(nodeAPI.Blockchain())
-	return &Harmony{
+	harmony := &Harmony{
 		ShutdownChan:                make(chan bool),
 		BloomRequests:               make(chan chan *bloombits.Retrieval),
 		BloomIndexer:                bloomIndexer,
@@ -129,6 +141,7 @@ func New(
 		BeaconChain:                 nodeAPI.Beaconchain(),
 		TxPool:                      txPool,
 		CxPool:                      cxPool,
+		CxTracker:                   cxTracker,
 		eventMux:                    new(event.TypeMux),
 		chainDb:                     chainDb,
 		NodeAPI:                     nodeAPI,
@@ -139,7 +152,36 @@ func New(
 		totalStakeCache:             totalStakeCache,
 		undelegationPayoutsCache:    undelegationPayoutsCache,
 		preStakingBlockRewardsCache: preStakingBlockRewardsCache,
+		chainTraces:                 make(map[string]*chainTraceJob),
 	}
+	harmony.gpo = gasprice.NewOracle(harmony, gasPriceOracleConfig(txPool, shardID))
+	harmony.gpo.Start()
+	return harmony
+}
+
+// Stop terminates the background work of the service.
+func (hmy *Harmony) Stop() {
+	hmy.gpo.Stop()
+}
+
+// gasPriceOracleConfig returns the configuration of the gas price oracle,
+// suggesting at least the gas price floor of the transaction pool.
+func gasPriceOracleConfig(txPool *core.TxPool, shardID uint32) gasprice.Config {
+	gpo := nodeconfig.GetShardConfig(shardID).GPO
+	config := gasprice.Config{
+		Blocks:     gpo.Blocks,
+		Percentile: gpo.Percentile,
+	}
+	if txPool != nil {
+		config.Default = txPool.GasPrice()
+	}
+	if gpo.IgnorePrice > 0 {
+		config.IgnorePrice = big.NewInt(gpo.IgnorePrice)
+	}
+	if gpo.MaxPrice > 0 {
+		config.MaxPrice = big.NewInt(gpo.MaxPrice)
+	}
+	return config
 }
 
 // SingleFlightRequest ..
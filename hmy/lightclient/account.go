@@ -0,0 +1,125 @@
+package lightclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/pkg/errors"
+)
+
+// GetAccount returns the account in the state of the block number, verified
+// against the state root of the verified header. A missing account is empty.
+func (c *Client) GetAccount(ctx context.Context, addr common.Address, bn uint64) (*state.Account, error) {
+	account, _, err := c.getAccountAndStorage(ctx, addr, nil, bn)
+	return account, err
+}
+
+// GetBalance returns the balance of the account in the state of the block number
+func (c *Client) GetBalance(ctx context.Context, addr common.Address, bn uint64) (*big.Int, error) {
+	account, err := c.GetAccount(ctx, addr, bn)
+	if err != nil {
+		return nil, err
+	}
+	return account.Balance, nil
+}
+
+// GetNonce returns the nonce of the account in the state of the block number
+func (c *Client) GetNonce(ctx context.Context, addr common.Address, bn uint64) (uint64, error) {
+	account, err := c.GetAccount(ctx, addr, bn)
+	if err != nil {
+		return 0, err
+	}
+	return account.Nonce, nil
+}
+
+// GetStorageAt returns the values of the storage slots of the account in the
+// state of the block number
+func (c *Client) GetStorageAt(ctx context.Context, addr common.Address, keys []common.Hash, bn uint64) ([]common.Hash, error) {
+	_, values, err := c.getAccountAndStorage(ctx, addr, keys, bn)
+	return values, err
+}
+
+func (c *Client) getAccountAndStorage(
+	ctx context.Context, addr common.Address, keys []common.Hash, bn uint64,
+) (*state.Account, []common.Hash, error) {
+	header, err := c.GetHeaderByNumber(ctx, bn)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, stid, err := c.backend.GetAccountProof(ctx, bn, addr, keys)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get account proof")
+	}
+	account, values, err := VerifyAccountProof(header, proof.Address, proof.Proof, keys, proof.StorageProofs)
+	if err != nil {
+		c.backend.RemoveStream(stid)
+		return nil, nil, err
+	}
+	return account, values, nil
+}
+
+// VerifyAccountProof verifies the proof of the account, and of the storage
+// slots of the keys, against the state root of the header. It returns the
+// account and the values of the slots. A missing account is empty, with no
+// storage proofs.
+func VerifyAccountProof(
+	header *block.Header, addr common.Address, proof [][]byte,
+	keys []common.Hash, storageProofs [][][]byte,
+) (*state.Account, []common.Hash, error) {
+	value, err := verifyProof(header.Root(), crypto.Keccak256(addr.Bytes()), proof)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid proof of account %v", addr.Hex())
+	}
+	values := make([]common.Hash, len(keys))
+	if value == nil {
+		return &state.Account{
+			Balance:  new(big.Int),
+			Root:     types.EmptyRootHash,
+			CodeHash: crypto.Keccak256(nil),
+		}, values, nil
+	}
+	account := &state.Account{}
+	if err := rlp.DecodeBytes(value, account); err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot decode account %v", addr.Hex())
+	}
+	if len(storageProofs) != len(keys) {
+		return nil, nil, errors.Errorf("storage proofs size not expected: %v / %v",
+			len(storageProofs), len(keys))
+	}
+	for i, key := range keys {
+		value, err := verifyProof(account.Root, crypto.Keccak256(key.Bytes()), storageProofs[i])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid proof of storage slot %v", key.Hex())
+		}
+		if value == nil {
+			continue
+		}
+		_, content, _, err := rlp.Split(value)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot decode storage slot %v", key.Hex())
+		}
+		values[i] = common.BytesToHash(content)
+	}
+	return account, values, nil
+}
+
+// verifyProof returns the value of the key in the trie of the root from the
+// trie nodes of the proof, nil for a missing key
+func verifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db := memorydb.New()
+	for _, node := range proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return nil, err
+		}
+	}
+	value, _, err := trie.VerifyProof(root, key, db)
+	return value, err
+}
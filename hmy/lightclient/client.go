@@ -0,0 +1,286 @@
+// Package lightclient is a light client of a shard, following its committees
+// epoch by epoch from a trusted shard state through the light stream protocol.
+// The headers are verified with the commit signatures of their committee, and
+// the accounts read from state proofs against the verified headers.
+package lightclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/block"
+	lightproto "github.com/harmony-one/harmony/p2p/stream/protocols/light"
+	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	"github.com/harmony-one/harmony/shard"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnknownCommittee is returned when the committee of the epoch of a
+	// header is not known yet, the epoch header not being served
+	ErrUnknownCommittee = errors.New("committee of the epoch not known")
+	// ErrBeforeTrustedEpoch is returned for a header before the trusted epoch
+	ErrBeforeTrustedEpoch = errors.New("header before the trusted epoch")
+	// ErrHeaderNotFound is returned when the header is not served
+	ErrHeaderNotFound = errors.New("header not found")
+)
+
+// Backend is the source of the headers and the state proofs, the light stream
+// protocol
+type Backend interface {
+	GetCurrentHeader(ctx context.Context, opts ...lightproto.Option) (*lightproto.HeaderWithSig, sttypes.StreamID, error)
+	GetHeadersByNumber(ctx context.Context, bns []uint64, opts ...lightproto.Option) ([]*lightproto.HeaderWithSig, sttypes.StreamID, error)
+	GetEpochHeaders(ctx context.Context, epochs []uint64, opts ...lightproto.Option) ([]*lightproto.HeaderWithSig, sttypes.StreamID, error)
+	GetAccountProof(ctx context.Context, bn uint64, addr common.Address, keys []common.Hash, opts ...lightproto.Option) (*lightproto.AccountProof, sttypes.StreamID, error)
+
+	RemoveStream(stID sttypes.StreamID) // If a stream delivers invalid data, remove the stream
+}
+
+// CommitSigVerifier verifies the commit signature and bitmap of a header
+// against the shard state of the epoch of the header, as cxproof.Verifier
+type CommitSigVerifier interface {
+	VerifyCommitSig(header *block.Header, state *shard.State, commitSig []byte, commitBitmap []byte) error
+}
+
+// Config is the config of the light client
+type Config struct {
+	ShardID  uint32
+	Verifier CommitSigVerifier
+	// TrustedState is the shard state of the first epoch followed, with its
+	// epoch set: the genesis shard state, or the one of a trusted epoch header
+	TrustedState *shard.State
+}
+
+// Client is the light client of a shard
+type Client struct {
+	backend  Backend
+	verifier CommitSigVerifier
+	shardID  uint32
+
+	syncLock sync.Mutex // serializes the committee syncs
+
+	lock       sync.RWMutex
+	trusted    uint64
+	latest     uint64                  // epoch of the latest known committee
+	committees map[uint64]*shard.State // shard states of the epochs with the committee of the shard only
+	head       *block.Header           // latest verified current header
+}
+
+// NewClient creates a light client of the shard starting at the trusted state
+func NewClient(backend Backend, config Config) (*Client, error) {
+	if config.TrustedState == nil || config.TrustedState.Epoch == nil {
+		return nil, errors.New("no epoch of the trusted shard state")
+	}
+	epoch := config.TrustedState.Epoch.Uint64()
+	state, err := shardCommitteeState(config.TrustedState, config.ShardID)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		backend:    backend,
+		verifier:   config.Verifier,
+		shardID:    config.ShardID,
+		trusted:    epoch,
+		latest:     epoch,
+		committees: map[uint64]*shard.State{epoch: state},
+	}, nil
+}
+
+// LatestEpoch returns the epoch of the latest known committee
+func (c *Client) LatestEpoch() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.latest
+}
+
+// Committee returns the committee of the shard in the epoch
+func (c *Client) Committee(epoch uint64) (*shard.Committee, error) {
+	state, err := c.shardState(epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &state.Shards[0], nil
+}
+
+// SyncCommittees follows the committees from the latest known one to the one
+// of the current epoch of the served chain, verifying the last header of each
+// epoch, carrying the committee of the next, with the committee of its epoch
+func (c *Client) SyncCommittees(ctx context.Context) error {
+	c.syncLock.Lock()
+	defer c.syncLock.Unlock()
+
+	for {
+		latest := c.LatestEpoch()
+		epochs := make([]uint64, 0, lightproto.GetEpochHeadersAmountCap)
+		for i := uint64(0); i != lightproto.GetEpochHeadersAmountCap; i++ {
+			epochs = append(epochs, latest+i)
+		}
+		headers, stid, err := c.backend.GetEpochHeaders(ctx, epochs)
+		if err != nil {
+			return errors.Wrap(err, "cannot get epoch headers")
+		}
+		for _, h := range headers {
+			if h == nil {
+				// epoch not over yet
+				return nil
+			}
+			if err := c.addEpochHeader(h); err != nil {
+				c.backend.RemoveStream(stid)
+				return errors.Wrapf(err, "epoch header %v", h.Header.Number())
+			}
+		}
+		if c.LatestEpoch() == latest {
+			return nil
+		}
+	}
+}
+
+// VerifyHeader verifies the header with its commit signature, signed by the
+// committee of the epoch of the header, syncing the committees when needed
+func (c *Client) VerifyHeader(ctx context.Context, h *lightproto.HeaderWithSig) error {
+	return c.verifyHeader(ctx, h, "")
+}
+
+// CurrentHeader returns the verified current header of the served chain
+func (c *Client) CurrentHeader(ctx context.Context) (*block.Header, error) {
+	h, stid, err := c.backend.GetCurrentHeader(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get current header")
+	}
+	if err := c.verifyHeader(ctx, h, stid); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.head == nil || h.Header.Number().Cmp(c.head.Number()) > 0 {
+		c.head = h.Header
+	}
+	return c.head, nil
+}
+
+// GetHeaderByNumber returns the verified header of the block number
+func (c *Client) GetHeaderByNumber(ctx context.Context, bn uint64) (*block.Header, error) {
+	headers, stid, err := c.backend.GetHeadersByNumber(ctx, []uint64{bn})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get header")
+	}
+	if headers[0] == nil {
+		return nil, ErrHeaderNotFound
+	}
+	if headers[0].Header.Number().Uint64() != bn {
+		c.backend.RemoveStream(stid)
+		return nil, errors.Errorf("header %v served for %v", headers[0].Header.Number(), bn)
+	}
+	if err := c.verifyHeader(ctx, headers[0], stid); err != nil {
+		return nil, err
+	}
+	return headers[0].Header, nil
+}
+
+// verifyHeader verifies the header served by the stream, removing the stream
+// if the header is invalid
+func (c *Client) verifyHeader(ctx context.Context, h *lightproto.HeaderWithSig, stid sttypes.StreamID) error {
+	if h.Header.ShardID() != c.shardID {
+		c.backend.RemoveStream(stid)
+		return errors.Errorf("header of shard %v", h.Header.ShardID())
+	}
+	epoch := h.Header.Epoch().Uint64()
+	if epoch > c.LatestEpoch() {
+		if err := c.SyncCommittees(ctx); err != nil {
+			return err
+		}
+	}
+	state, err := c.shardState(epoch)
+	if err != nil {
+		return err
+	}
+	if err := c.verifier.VerifyCommitSig(h.Header, state, h.CommitSig, h.CommitBitmap); err != nil {
+		c.backend.RemoveStream(stid)
+		return err
+	}
+	return nil
+}
+
+// addEpochHeader verifies the last header of the latest known epoch, and adds
+// the committee of the next epoch it carries. Headers of earlier epochs are
+// skipped.
+func (c *Client) addEpochHeader(h *lightproto.HeaderWithSig) error {
+	c.lock.RLock()
+	latest, state := c.latest, c.committees[c.latest]
+	c.lock.RUnlock()
+
+	header := h.Header
+	epoch := header.Epoch().Uint64()
+	if epoch < latest {
+		return nil
+	}
+	if epoch > latest {
+		return errors.Errorf("missing committee of epoch %v", epoch)
+	}
+	if header.ShardID() != c.shardID {
+		return errors.Errorf("header of shard %v", header.ShardID())
+	}
+	if !header.IsLastBlockInEpoch() {
+		return errors.New("not the last header of the epoch")
+	}
+	if err := c.verifier.VerifyCommitSig(header, state, h.CommitSig, h.CommitBitmap); err != nil {
+		return err
+	}
+	next, err := shard.DecodeWrapper(header.ShardState())
+	if err != nil {
+		return errors.Wrap(err, "cannot decode shard state")
+	}
+	if next.Epoch == nil {
+		// legacy shard state, of the next epoch before staking
+		next.Epoch = new(big.Int).Add(header.Epoch(), common.Big1)
+	}
+	if next.Epoch.Uint64() <= latest {
+		return errors.Errorf("shard state of past epoch %v", next.Epoch)
+	}
+	next, err = shardCommitteeState(next, c.shardID)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.latest = next.Epoch.Uint64()
+	c.committees[c.latest] = next
+	return nil
+}
+
+// shardState returns the shard state of the epoch with the committee of the
+// shard. The shard states being of the epochs of the next blocks, there is no
+// committee of an epoch skipped by the shard.
+func (c *Client) shardState(epoch uint64) (*shard.State, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if epoch < c.trusted {
+		return nil, ErrBeforeTrustedEpoch
+	}
+	if epoch > c.latest {
+		return nil, ErrUnknownCommittee
+	}
+	state, ok := c.committees[epoch]
+	if !ok {
+		return nil, ErrUnknownCommittee
+	}
+	return state, nil
+}
+
+// shardCommitteeState returns the shard state with the committee of the shard
+// only
+func shardCommitteeState(state *shard.State, shardID uint32) (*shard.State, error) {
+	committee, err := state.FindCommitteeByID(shardID)
+	if err != nil {
+		return nil, err
+	}
+	return &shard.State{
+		Epoch:  state.Epoch,
+		Shards: []shard.Committee{*committee},
+	}, nil
+}
@@ -0,0 +1,435 @@
+package lightclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/state"
+	lightproto "github.com/harmony-one/harmony/p2p/stream/protocols/light"
+	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	"github.com/harmony-one/harmony/shard"
+)
+
+const testStreamID = sttypes.StreamID("[test stream]")
+
+var (
+	testAddr    = common.Address{1}
+	testKey     = common.Hash{2}
+	testValue   = common.Hash{3}
+	testBalance = big.NewInt(100)
+)
+
+func TestClient_SyncCommittees(t *testing.T) {
+	backend := makeTestBackend()
+	c := makeTestClient(t, backend)
+
+	if err := c.SyncCommittees(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if latest := c.LatestEpoch(); latest != 4 {
+		t.Fatalf("unexpected latest epoch %v", latest)
+	}
+	// no block in epoch 2
+	for _, epoch := range []uint64{0, 1, 3, 4} {
+		committee, err := c.Committee(epoch)
+		if err != nil {
+			t.Fatalf("epoch %v: %v", epoch, err)
+		}
+		if marker := committee.Slots[0].EcdsaAddress[0]; marker != byte(epoch) {
+			t.Errorf("epoch %v: unexpected committee %v", epoch, marker)
+		}
+	}
+	if _, err := c.Committee(2); err != ErrUnknownCommittee {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := c.Committee(5); err != ErrUnknownCommittee {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(backend.removed) != 0 {
+		t.Errorf("unexpected removed streams")
+	}
+}
+
+func TestClient_SyncCommitteesFromTrustedEpoch(t *testing.T) {
+	backend := makeTestBackend()
+	c, err := NewClient(backend, Config{
+		Verifier:     &testVerifier{},
+		TrustedState: makeTestShardState(3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncCommittees(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if latest := c.LatestEpoch(); latest != 4 {
+		t.Fatalf("unexpected latest epoch %v", latest)
+	}
+	if _, err := c.Committee(1); err != ErrBeforeTrustedEpoch {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestClient_SyncCommitteesInvalidSig(t *testing.T) {
+	backend := makeTestBackend()
+	// last header of epoch 1 signed by the committee of epoch 0
+	backend.epochHeaders[1] = makeTestHeaderWithSig(5, 1, 0, 3)
+	backend.epochHeaders[2] = backend.epochHeaders[1]
+	c := makeTestClient(t, backend)
+
+	if err := c.SyncCommittees(context.Background()); err == nil {
+		t.Fatalf("unexpected sync with invalid commit sig")
+	}
+	if latest := c.LatestEpoch(); latest != 1 {
+		t.Errorf("unexpected latest epoch %v", latest)
+	}
+	if len(backend.removed) != 1 || backend.removed[0] != testStreamID {
+		t.Errorf("stream not removed")
+	}
+}
+
+func TestClient_SyncCommitteesMissingEpoch(t *testing.T) {
+	backend := makeTestBackend()
+	// last header of epoch 0 served as the one of epoch 1 as well, skipping the
+	// committee of epoch 1
+	backend.epochHeaders[0] = makeTestHeaderWithSig(2, 0, 0, 1)
+	backend.epochHeaders[1] = makeTestHeaderWithSig(8, 3, 3, 4)
+	c := makeTestClient(t, backend)
+
+	if err := c.SyncCommittees(context.Background()); err == nil {
+		t.Fatalf("unexpected sync with missing epoch")
+	}
+	if latest := c.LatestEpoch(); latest != 1 {
+		t.Errorf("unexpected latest epoch %v", latest)
+	}
+}
+
+func TestClient_GetHeaderByNumber(t *testing.T) {
+	tests := []struct {
+		bn      uint64
+		header  *lightproto.HeaderWithSig
+		removed bool
+		expErr  error
+	}{
+		{
+			bn:     7,
+			header: makeTestHeaderWithSig(7, 3, 3, 0),
+		},
+		{
+			// syncing the committees of the epoch
+			bn:     9,
+			header: makeTestHeaderWithSig(9, 4, 4, 0),
+		},
+		{
+			bn:      7,
+			header:  makeTestHeaderWithSig(7, 3, 1, 0),
+			removed: true,
+			expErr:  errTestInvalidSig,
+		},
+		{
+			bn:      7,
+			header:  makeTestHeaderWithSig(6, 3, 3, 0),
+			removed: true,
+			expErr:  errors.New("header 6 served for 7"),
+		},
+		{
+			bn:     10,
+			expErr: ErrHeaderNotFound,
+		},
+		{
+			bn:     11,
+			header: makeTestHeaderWithSig(11, 5, 5, 0),
+			expErr: ErrUnknownCommittee,
+		},
+	}
+	for i, test := range tests {
+		backend := makeTestBackend()
+		backend.headers[test.bn] = test.header
+		c := makeTestClient(t, backend)
+
+		header, err := c.GetHeaderByNumber(context.Background(), test.bn)
+		if assErr := assertError(err, test.expErr); assErr != nil {
+			t.Errorf("Test %v: %v", i, assErr)
+			continue
+		}
+		if err == nil && header.Number().Uint64() != test.bn {
+			t.Errorf("Test %v: unexpected header %v", i, header.Number())
+		}
+		if removed := len(backend.removed) != 0; removed != test.removed {
+			t.Errorf("Test %v: unexpected stream removed %v", i, removed)
+		}
+	}
+}
+
+func TestClient_CurrentHeader(t *testing.T) {
+	backend := makeTestBackend()
+	c := makeTestClient(t, backend)
+
+	header, err := c.CurrentHeader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Number().Uint64() != 9 {
+		t.Errorf("unexpected header %v", header.Number())
+	}
+
+	// an older current header does not move the head back
+	backend.current = makeTestHeaderWithSig(8, 3, 3, 4)
+	if header, err = c.CurrentHeader(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if header.Number().Uint64() != 9 {
+		t.Errorf("unexpected header %v", header.Number())
+	}
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	db, root := makeTestState()
+	header := blockfactory.NewTestHeader().With().Root(root).Header()
+
+	proof, err := db.GetProof(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storageProof, err := db.GetStorageProof(testAddr, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []common.Hash{testKey}
+	account, values, err := VerifyAccountProof(header, testAddr, proof, keys, [][][]byte{storageProof})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance.Cmp(testBalance) != 0 {
+		t.Errorf("unexpected balance %v", account.Balance)
+	}
+	if values[0] != testValue {
+		t.Errorf("unexpected storage value %v", values[0].Hex())
+	}
+
+	// missing account
+	missing := common.Address{9}
+	proof, err = db.GetProof(missing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, values, err = VerifyAccountProof(header, missing, proof, keys, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Balance.Sign() != 0 || account.Nonce != 0 || values[0] != (common.Hash{}) {
+		t.Errorf("unexpected missing account %+v", account)
+	}
+
+	// proof against another state root
+	header = blockfactory.NewTestHeader().With().Root(common.Hash{1}).Header()
+	if _, _, err := VerifyAccountProof(header, testAddr, proof, keys, nil); err == nil {
+		t.Errorf("unexpected proof against another state root")
+	}
+}
+
+func TestClient_GetStorageAt(t *testing.T) {
+	db, root := makeTestState()
+	backend := makeTestBackend()
+	header := makeTestHeaderWithSig(7, 3, 3, 0)
+	header.Header = blockfactory.NewTestHeader().With().
+		Number(big.NewInt(7)).
+		Epoch(big.NewInt(3)).
+		Root(root).
+		Header()
+	backend.headers[7] = header
+	backend.state = db
+	c := makeTestClient(t, backend)
+
+	values, err := c.GetStorageAt(context.Background(), testAddr, []common.Hash{testKey}, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0] != testValue {
+		t.Errorf("unexpected storage value %v", values[0].Hex())
+	}
+	balance, err := c.GetBalance(context.Background(), testAddr, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(testBalance) != 0 {
+		t.Errorf("unexpected balance %v", balance)
+	}
+
+	// proof of the state of another block
+	header.Header = blockfactory.NewTestHeader().With().
+		Number(big.NewInt(7)).
+		Epoch(big.NewInt(3)).
+		Root(common.Hash{1}).
+		Header()
+	if _, err := c.GetBalance(context.Background(), testAddr, 7); err == nil {
+		t.Errorf("unexpected balance of invalid proof")
+	}
+	if len(backend.removed) != 1 {
+		t.Errorf("stream not removed")
+	}
+}
+
+var errTestInvalidSig = errors.New("invalid commit sig")
+
+// testVerifier verifies the commit sig with the first byte of the sig being
+// the marker of the committee, the first byte of its first slot address
+type testVerifier struct{}
+
+func (v *testVerifier) VerifyCommitSig(header *block.Header, state *shard.State, commitSig []byte, commitBitmap []byte) error {
+	if state.Epoch.Cmp(header.Epoch()) > 0 {
+		return fmt.Errorf("committee of epoch %v for header of epoch %v", state.Epoch, header.Epoch())
+	}
+	if len(commitSig) == 0 || commitSig[0] != state.Shards[0].Slots[0].EcdsaAddress[0] {
+		return errTestInvalidSig
+	}
+	return nil
+}
+
+// testBackend serves the chain of the epochs {0,0,0,1,1,1,3,3,3,4}, the shard
+// having no block in epoch 2
+type testBackend struct {
+	epochHeaders map[uint64]*lightproto.HeaderWithSig
+	headers      map[uint64]*lightproto.HeaderWithSig
+	current      *lightproto.HeaderWithSig
+	state        *state.DB
+	removed      []sttypes.StreamID
+}
+
+func makeTestBackend() *testBackend {
+	epoch1 := makeTestHeaderWithSig(5, 1, 1, 3)
+	return &testBackend{
+		epochHeaders: map[uint64]*lightproto.HeaderWithSig{
+			0: makeTestHeaderWithSig(2, 0, 0, 1),
+			1: epoch1,
+			2: epoch1,
+			3: makeTestHeaderWithSig(8, 3, 3, 4),
+		},
+		headers: make(map[uint64]*lightproto.HeaderWithSig),
+		current: makeTestHeaderWithSig(9, 4, 4, 0),
+	}
+}
+
+func (b *testBackend) GetCurrentHeader(ctx context.Context, opts ...lightproto.Option) (*lightproto.HeaderWithSig, sttypes.StreamID, error) {
+	return b.current, testStreamID, nil
+}
+
+func (b *testBackend) GetHeadersByNumber(ctx context.Context, bns []uint64, opts ...lightproto.Option) ([]*lightproto.HeaderWithSig, sttypes.StreamID, error) {
+	headers := make([]*lightproto.HeaderWithSig, 0, len(bns))
+	for _, bn := range bns {
+		headers = append(headers, b.headers[bn])
+	}
+	return headers, testStreamID, nil
+}
+
+func (b *testBackend) GetEpochHeaders(ctx context.Context, epochs []uint64, opts ...lightproto.Option) ([]*lightproto.HeaderWithSig, sttypes.StreamID, error) {
+	if len(epochs) > lightproto.GetEpochHeadersAmountCap {
+		return nil, "", errors.New("number of epochs exceed cap")
+	}
+	headers := make([]*lightproto.HeaderWithSig, 0, len(epochs))
+	for _, epoch := range epochs {
+		headers = append(headers, b.epochHeaders[epoch])
+	}
+	return headers, testStreamID, nil
+}
+
+func (b *testBackend) GetAccountProof(ctx context.Context, bn uint64, addr common.Address, keys []common.Hash, opts ...lightproto.Option) (*lightproto.AccountProof, sttypes.StreamID, error) {
+	if b.state == nil {
+		return nil, "", errors.New("no state")
+	}
+	proof, err := b.state.GetProof(addr)
+	if err != nil {
+		return nil, "", err
+	}
+	storageProofs := make([][][]byte, 0, len(keys))
+	for _, key := range keys {
+		storageProof, err := b.state.GetStorageProof(addr, key)
+		if err != nil {
+			return nil, "", err
+		}
+		storageProofs = append(storageProofs, storageProof)
+	}
+	return &lightproto.AccountProof{
+		BlockNum:      bn,
+		Address:       addr,
+		Proof:         proof,
+		StorageKeys:   keys,
+		StorageProofs: storageProofs,
+	}, testStreamID, nil
+}
+
+func (b *testBackend) RemoveStream(stID sttypes.StreamID) {
+	b.removed = append(b.removed, stID)
+}
+
+func makeTestClient(t *testing.T, backend Backend) *Client {
+	c, err := NewClient(backend, Config{
+		Verifier:     &testVerifier{},
+		TrustedState: makeTestShardState(0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// makeTestHeaderWithSig makes the header of the block number in the epoch,
+// signed by the committee of the signer epoch. A non-zero next epoch makes the
+// header the last one of its epoch, carrying the shard state of the next epoch.
+func makeTestHeaderWithSig(bn, epoch, signer, next uint64) *lightproto.HeaderWithSig {
+	hb := blockfactory.NewTestHeader().With().
+		Number(new(big.Int).SetUint64(bn)).
+		Epoch(new(big.Int).SetUint64(epoch))
+	if next != 0 {
+		ss, _ := shard.EncodeWrapper(*makeTestShardState(next), true)
+		hb = hb.ShardState(ss)
+	}
+	return &lightproto.HeaderWithSig{
+		Header:       hb.Header(),
+		CommitSig:    []byte{byte(signer)},
+		CommitBitmap: []byte{0x01},
+	}
+}
+
+// makeTestShardState makes the shard state of the epoch, the committee of the
+// shard 0 marked with the epoch
+func makeTestShardState(epoch uint64) *shard.State {
+	return &shard.State{
+		Epoch: new(big.Int).SetUint64(epoch),
+		Shards: []shard.Committee{{
+			ShardID: 0,
+			Slots:   shard.SlotList{{EcdsaAddress: common.Address{byte(epoch)}}},
+		}},
+	}
+}
+
+func makeTestState() (*state.DB, common.Hash) {
+	sdb := state.NewDatabase(rawdb.NewMemoryDatabase())
+	db, _ := state.New(common.Hash{}, sdb)
+	db.AddBalance(testAddr, testBalance)
+	db.SetState(testAddr, testKey, testValue)
+	root, _ := db.Commit(false)
+	sdb.TrieDB().Commit(root, false)
+	db, _ = state.New(root, sdb)
+	return db, root
+}
+
+func assertError(got, expect error) error {
+	if (got == nil) != (expect == nil) {
+		return fmt.Errorf("unexpected error: %v / %v", got, expect)
+	}
+	if got == nil {
+		return nil
+	}
+	if got.Error() != expect.Error() {
+		return fmt.Errorf("unexpected error: %v / %v", got, expect)
+	}
+	return nil
+}
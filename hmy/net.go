@@ -2,6 +2,7 @@ package hmy
 
 import (
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/p2p"
 	commonRPC "github.com/harmony-one/harmony/rpc/common"
 	"github.com/harmony-one/harmony/staking/network"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -31,3 +32,8 @@ func (hmy *Harmony) GetPeerInfo() commonRPC.NodePeerInfo {
 		P:            p,
 	}
 }
+
+// GetPeerScores returns the scores of the peers known to the node, lowest first
+func (hmy *Harmony) GetPeerScores() []p2p.PeerScore {
+	return hmy.NodeAPI.ListPeerScore()
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/harmony-one/harmony/block"
 	"github.com/harmony-one/harmony/consensus/quorum"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/internal/chain"
 	internalCommon "github.com/harmony-one/harmony/internal/common"
@@ -163,6 +165,79 @@ func (hmy *Harmony) SendStakingTx(ctx context.Context, signedStakingTx *staking.
 	return ErrFinalizedTransaction
 }
 
+// stakingChainAt is the chain context of the staking checks as of a past block,
+// the off-chain validator list, snapshots and delegation indexes being read as
+// of the block instead of the current head
+type stakingChainAt struct {
+	*core.BlockChain
+	stateDB *state.DB
+	header  *block.Header
+}
+
+// ReadValidatorList returns the validators of the current list already created
+// at the block, as validators are never removed from the list
+func (c *stakingChainAt) ReadValidatorList() ([]common.Address, error) {
+	addrs, err := c.BlockChain.ReadValidatorList()
+	if err != nil {
+		return nil, err
+	}
+	var existing []common.Address
+	for _, addr := range addrs {
+		if c.stateDB.IsValidator(addr) {
+			existing = append(existing, addr)
+		}
+	}
+	return existing, nil
+}
+
+// ReadValidatorSnapshot returns the snapshot of the validator at the beginning
+// of the epoch of the block
+func (c *stakingChainAt) ReadValidatorSnapshot(
+	addr common.Address,
+) (*staking.ValidatorSnapshot, error) {
+	return c.BlockChain.ReadValidatorSnapshotAtEpoch(c.header.Epoch(), addr)
+}
+
+// ReadDelegationsByDelegator returns the delegations of the delegator made up
+// to the block
+func (c *stakingChainAt) ReadDelegationsByDelegator(
+	delegator common.Address,
+) (staking.DelegationIndexes, error) {
+	return c.BlockChain.ReadDelegationsByDelegatorAt(delegator, c.header.Number())
+}
+
+// SimulateStakingTransaction runs the checks of a staking transaction, or of an
+// unsigned staking message when tx is nil, against the state of the given block
+// without changing it. The transaction is simulated as included in the next block.
+// The state of past blocks is only kept by archival nodes.
+func (hmy *Harmony) SimulateStakingTransaction(
+	ctx context.Context, blockNum rpc.BlockNumber,
+	tx *staking.StakingTransaction, msg staking.StakeMsg,
+) (*core.StakingSimulation, *block.Header, error) {
+	stateDB, header, err := hmy.StateAndHeaderByNumber(ctx, blockNum)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stateDB == nil || header == nil {
+		return nil, nil, errors.Errorf("state of block %v not found", blockNum)
+	}
+	var chainContext core.ChainContext = hmy.BlockChain
+	if header.Hash() != hmy.BlockChain.CurrentHeader().Hash() {
+		chainContext = &stakingChainAt{hmy.BlockChain, stateDB, header}
+	}
+	epoch := header.Epoch()
+	if header.IsLastBlockInEpoch() {
+		epoch = new(big.Int).Add(epoch, common.Big1)
+	}
+	nextNum := new(big.Int).Add(header.Number(), common.Big1)
+	if tx != nil {
+		sim := core.SimulateStakingTransaction(stateDB, chainContext, hmy.ChainConfig(), epoch, nextNum, tx)
+		return sim, header, nil
+	}
+	sim := core.SimulateStakingMessage(stateDB, chainContext, hmy.ChainConfig(), epoch, nextNum, msg)
+	return sim, header, nil
+}
+
 // GetStakingTransactionsHistory returns list of staking transactions hashes of address.
 func (hmy *Harmony) GetStakingTransactionsHistory(address, txType, order string) ([]common.Hash, error) {
 	return hmy.NodeAPI.GetStakingTransactionsHistory(address, txType, order)
@@ -427,6 +502,34 @@ func (hmy *Harmony) GetValidatorInformation(
 	return defaultReply, nil
 }
 
+// GetValidatorSigningHistory returns the indexed signing outcomes of the validator in
+// the epochs from fromEpoch to toEpoch, skipping the epochs without outcomes
+func (hmy *Harmony) GetValidatorSigningHistory(
+	addr common.Address, fromEpoch, toEpoch uint64,
+) []*staking.ValidatorEpochSigning {
+	history := []*staking.ValidatorEpochSigning{}
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		if signing, err := hmy.BlockChain.ReadValidatorEpochSigning(addr, epoch); err == nil {
+			history = append(history, signing)
+		}
+	}
+	return history
+}
+
+// GetValidatorAPRHistory returns the indexed aprs of the validator in the epochs
+// from fromEpoch to toEpoch, skipping the epochs without apr
+func (hmy *Harmony) GetValidatorAPRHistory(
+	addr common.Address, fromEpoch, toEpoch uint64,
+) []staking.APREntry {
+	history := []staking.APREntry{}
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		if entry, err := hmy.BlockChain.ReadValidatorEpochAPR(addr, epoch); err == nil {
+			history = append(history, *entry)
+		}
+	}
+	return history
+}
+
 // GetMedianRawStakeSnapshot ..
 func (hmy *Harmony) GetMedianRawStakeSnapshot() (
 	*committee.CompletedEPoSRound, error,
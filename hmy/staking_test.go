@@ -0,0 +1,74 @@
+package hmy
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethRawDB "github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/chain"
+	"github.com/harmony-one/harmony/internal/params"
+	staking "github.com/harmony-one/harmony/staking/types"
+)
+
+// makeTestBlockChain returns a blockchain of the genesis block only
+func makeTestBlockChain(t *testing.T) (ethdb.Database, *core.BlockChain) {
+	db := ethRawDB.NewMemoryDatabase()
+	gspec := &core.Genesis{Config: params.TestChainConfig, Factory: blockfactory.ForTest}
+	gspec.MustCommit(db)
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, chain.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, bc
+}
+
+func TestStakingChainAt(t *testing.T) {
+	db, bc := makeTestBlockChain(t)
+	defer bc.Stop()
+
+	var (
+		created   = common.HexToAddress("0x01")
+		later     = common.HexToAddress("0x02")
+		delegator = common.HexToAddress("0x03")
+	)
+	if err := bc.WriteValidatorList(db, []common.Address{created, later}); err != nil {
+		t.Fatal(err)
+	}
+	indexes := staking.DelegationIndexes{
+		{ValidatorAddress: created, Index: 1, BlockNum: big.NewInt(2)},
+		{ValidatorAddress: later, Index: 1, BlockNum: big.NewInt(4)},
+	}
+	if err := rawdb.WriteDelegationsByDelegator(db, delegator, indexes); err != nil {
+		t.Fatal(err)
+	}
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateDB.SetValidatorFlag(created)
+	header := blockfactory.NewTestHeader().With().Number(big.NewInt(3)).Header()
+	c := &stakingChainAt{bc, stateDB, header}
+
+	addrs, err := c.ReadValidatorList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(addrs, []common.Address{created}) {
+		t.Errorf("unexpected validators at block 3: %v", addrs)
+	}
+	delegations, err := c.ReadDelegationsByDelegator(delegator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(delegations, indexes[:1]) {
+		t.Errorf("unexpected delegations at block 3: %+v", delegations)
+	}
+}
@@ -0,0 +1,645 @@
+package hmy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultChainTraceChunkSize is the default number of blocks per output file.
+	defaultChainTraceChunkSize = uint64(1000)
+	// defaultChainTraceMemoryLimit is the default size of the in memory tries
+	// above which the job waits for the tracers to catch up.
+	defaultChainTraceMemoryLimit = uint64(512 * 1024 * 1024)
+	// chainTraceDir is the directory, under the node data directory, holding
+	// the output of all chain tracing jobs.
+	chainTraceDir = "chaintrace"
+	// chainTraceCheckpointFile is the file holding the progress of a job.
+	chainTraceCheckpointFile = "checkpoint.json"
+	// maxRunningChainTraces is the number of chain tracing jobs allowed to run
+	// at the same time, each of them using all CPUs by default.
+	maxRunningChainTraces = 2
+)
+
+// ChainTraceStatus is the state of a chain tracing job.
+type ChainTraceStatus string
+
+// Chain tracing job states
+const (
+	ChainTraceRunning   ChainTraceStatus = "running"
+	ChainTracePaused    ChainTraceStatus = "paused"
+	ChainTraceCancelled ChainTraceStatus = "cancelled"
+	ChainTraceFinished  ChainTraceStatus = "finished"
+	ChainTraceFailed    ChainTraceStatus = "failed"
+)
+
+var (
+	// ErrChainTraceNotFound is returned for an unknown chain tracing job.
+	ErrChainTraceNotFound = errors.New("chain trace job not found")
+	// ErrChainTraceRunning is returned when resuming a running job.
+	ErrChainTraceRunning = errors.New("chain trace job is already running")
+	// ErrChainTraceNotRunning is returned when pausing a job which is not running.
+	ErrChainTraceNotRunning = errors.New("chain trace job is not running")
+	// ErrChainTraceDone is returned when resuming a finished or cancelled job.
+	ErrChainTraceDone = errors.New("chain trace job is finished or cancelled")
+	// ErrChainTraceLimit is returned when running a job while the maximum
+	// number of jobs are running.
+	ErrChainTraceLimit = errors.Errorf("no more than %d chain trace jobs can run at once", maxRunningChainTraces)
+)
+
+// ChainTraceConfig holds the parameters of a chain tracing job writing to disk.
+type ChainTraceConfig struct {
+	*TraceConfig
+	ChunkSize   *uint64 // Number of blocks per output file
+	Threads     *int    // Number of blocks traced concurrently
+	MemoryLimit *uint64 // Size of the in memory tries in bytes above which tracing is throttled
+}
+
+// ChainTraceProgress is the progress of a chain tracing job. It is persisted
+// as the checkpoint the job resumes from.
+type ChainTraceProgress struct {
+	ID     string            `json:"id"`
+	Start  uint64            `json:"start"`
+	End    uint64            `json:"end"`
+	Next   uint64            `json:"next"` // First block not yet written to a finished chunk
+	Status ChainTraceStatus  `json:"status"`
+	Error  string            `json:"error,omitempty"`
+	Dir    string            `json:"dir"`
+	Files  []string          `json:"files"`
+	Config *ChainTraceConfig `json:"config"`
+}
+
+// chainTraceJob is a chain tracing job, running or not.
+type chainTraceJob struct {
+	lock     sync.Mutex
+	progress ChainTraceProgress
+
+	cancel context.CancelFunc // Interrupts the running job
+	reason ChainTraceStatus   // Status to set once the running job is interrupted
+	done   chan struct{}      // Closed once the running job returned
+}
+
+// StartChainTrace starts a chain tracing job tracing the blocks from start to
+// end, both included, in the background. The traces are written to chunked
+// JSONL files under the node data directory.
+func (hmy *Harmony) StartChainTrace(start, end *types.Block, config *ChainTraceConfig) (*ChainTraceProgress, error) {
+	if start.NumberU64() == 0 || start.NumberU64() > end.NumberU64() {
+		return nil, fmt.Errorf("invalid block range [%d, %d]", start.NumberU64(), end.NumberU64())
+	}
+	if config == nil {
+		config = &ChainTraceConfig{}
+	}
+	if err := hmy.reserveChainTrace(); err != nil {
+		return nil, err
+	}
+	id := uuid.New()
+	dir := filepath.Join(nodeconfig.GetDefaultConfig().DBDir, chainTraceDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		hmy.releaseChainTrace()
+		return nil, err
+	}
+	job := &chainTraceJob{
+		progress: ChainTraceProgress{
+			ID:     id,
+			Start:  start.NumberU64(),
+			End:    end.NumberU64(),
+			Next:   start.NumberU64(),
+			Status: ChainTracePaused,
+			Dir:    dir,
+			Files:  []string{},
+			Config: config,
+		},
+	}
+	if err := job.saveCheckpoint(); err != nil {
+		hmy.releaseChainTrace()
+		return nil, err
+	}
+	hmy.chainTraceLock.Lock()
+	hmy.chainTraces[id] = job
+	hmy.chainTraceLock.Unlock()
+
+	progress, err := hmy.runChainTrace(job)
+	if err != nil {
+		hmy.releaseChainTrace()
+	}
+	return progress, err
+}
+
+// ResumeChainTrace resumes a paused or failed chain tracing job from its last
+// checkpoint. Jobs of previous node runs are loaded from their checkpoint.
+func (hmy *Harmony) ResumeChainTrace(id string) (*ChainTraceProgress, error) {
+	job, err := hmy.chainTraceJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := hmy.reserveChainTrace(); err != nil {
+		return nil, err
+	}
+	progress, err := hmy.runChainTrace(job)
+	if err != nil {
+		hmy.releaseChainTrace()
+	}
+	return progress, err
+}
+
+// reserveChainTrace takes one of the slots of the running jobs, to be released
+// once the job stops or fails to start.
+func (hmy *Harmony) reserveChainTrace() error {
+	hmy.chainTraceLock.Lock()
+	defer hmy.chainTraceLock.Unlock()
+
+	if hmy.chainTracesRunning >= maxRunningChainTraces {
+		return ErrChainTraceLimit
+	}
+	hmy.chainTracesRunning++
+	return nil
+}
+
+// releaseChainTrace releases the slot of a running job.
+func (hmy *Harmony) releaseChainTrace() {
+	hmy.chainTraceLock.Lock()
+	defer hmy.chainTraceLock.Unlock()
+
+	hmy.chainTracesRunning--
+}
+
+// PauseChainTrace interrupts a running chain tracing job. The blocks of the
+// chunk in progress are traced again once the job is resumed.
+func (hmy *Harmony) PauseChainTrace(id string) (*ChainTraceProgress, error) {
+	return hmy.stopChainTrace(id, ChainTracePaused)
+}
+
+// CancelChainTrace interrupts a chain tracing job for good. The files of the
+// already finished chunks are kept.
+func (hmy *Harmony) CancelChainTrace(id string) (*ChainTraceProgress, error) {
+	return hmy.stopChainTrace(id, ChainTraceCancelled)
+}
+
+// ChainTrace returns the progress of a chain tracing job.
+func (hmy *Harmony) ChainTrace(id string) (*ChainTraceProgress, error) {
+	job, err := hmy.chainTraceJob(id)
+	if err != nil {
+		return nil, err
+	}
+	return job.snapshot(), nil
+}
+
+// ChainTraces returns the progress of the chain tracing jobs of this node run.
+func (hmy *Harmony) ChainTraces() []*ChainTraceProgress {
+	hmy.chainTraceLock.Lock()
+	defer hmy.chainTraceLock.Unlock()
+
+	jobs := make([]*ChainTraceProgress, 0, len(hmy.chainTraces))
+	for _, job := range hmy.chainTraces {
+		jobs = append(jobs, job.snapshot())
+	}
+	return jobs
+}
+
+// chainTraceJob returns the job with the given id, loading it from its
+// checkpoint if it is not known to this node run.
+func (hmy *Harmony) chainTraceJob(id string) (*chainTraceJob, error) {
+	hmy.chainTraceLock.Lock()
+	defer hmy.chainTraceLock.Unlock()
+
+	if job, ok := hmy.chainTraces[id]; ok {
+		return job, nil
+	}
+	if uuid.Parse(id) == nil {
+		return nil, ErrChainTraceNotFound
+	}
+	dir := filepath.Join(nodeconfig.GetDefaultConfig().DBDir, chainTraceDir, id)
+	data, err := ioutil.ReadFile(filepath.Join(dir, chainTraceCheckpointFile))
+	if err != nil {
+		return nil, ErrChainTraceNotFound
+	}
+	job := &chainTraceJob{}
+	if err := json.Unmarshal(data, &job.progress); err != nil {
+		return nil, errors.Wrap(err, "invalid chain trace checkpoint")
+	}
+	if job.progress.Status == ChainTraceRunning {
+		// The node stopped while the job was running
+		job.progress.Status = ChainTracePaused
+	}
+	hmy.chainTraces[id] = job
+	return job, nil
+}
+
+// stopChainTrace interrupts a chain tracing job and waits for it to return.
+func (hmy *Harmony) stopChainTrace(id string, reason ChainTraceStatus) (*ChainTraceProgress, error) {
+	job, err := hmy.chainTraceJob(id)
+	if err != nil {
+		return nil, err
+	}
+	job.lock.Lock()
+	switch {
+	case job.progress.Status == ChainTraceRunning:
+		job.reason = reason
+		job.cancel()
+		done := job.done
+		job.lock.Unlock()
+		<-done
+	case reason == ChainTraceCancelled && job.progress.Status != ChainTraceFinished:
+		job.progress.Status = ChainTraceCancelled
+		err = job.saveCheckpoint()
+		job.lock.Unlock()
+	default:
+		job.lock.Unlock()
+		return nil, ErrChainTraceNotRunning
+	}
+	return job.snapshot(), err
+}
+
+// runChainTrace starts tracing the remaining blocks of the job in the background.
+// The caller reserves the slot of the job, released once the job stops.
+func (hmy *Harmony) runChainTrace(job *chainTraceJob) (*ChainTraceProgress, error) {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	switch job.progress.Status {
+	case ChainTraceRunning:
+		return nil, ErrChainTraceRunning
+	case ChainTraceFinished, ChainTraceCancelled:
+		return nil, ErrChainTraceDone
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job.progress.Status = ChainTraceRunning
+	job.progress.Error = ""
+	job.cancel = cancel
+	job.reason = ""
+	job.done = make(chan struct{})
+	if err := job.saveCheckpoint(); err != nil {
+		cancel()
+		job.progress.Status = ChainTracePaused
+		return nil, err
+	}
+	go func() {
+		defer close(job.done)
+		err := hmy.traceChainToFiles(ctx, job)
+		hmy.releaseChainTrace()
+
+		job.lock.Lock()
+		defer job.lock.Unlock()
+		switch {
+		case job.reason != "":
+			job.progress.Status = job.reason
+		case err != nil:
+			job.progress.Status = ChainTraceFailed
+			job.progress.Error = err.Error()
+		default:
+			job.progress.Status = ChainTraceFinished
+		}
+		if err := job.saveCheckpoint(); err != nil {
+			utils.Logger().Error().Err(err).Str("id", job.progress.ID).Msg("Failed to save chain trace checkpoint")
+		}
+		utils.Logger().Info().
+			Str("id", job.progress.ID).
+			Str("status", string(job.progress.Status)).
+			Uint64("next", job.progress.Next).
+			Msg("Chain tracing job stopped")
+	}()
+	return job.progressCopy(), nil
+}
+
+// traceChainToFiles traces the blocks from the job checkpoint on and writes
+// the results to one file per chunk, updating the checkpoint after each chunk.
+func (hmy *Harmony) traceChainToFiles(ctx context.Context, job *chainTraceJob) error {
+	var (
+		progress    = job.snapshot()
+		config      = progress.Config
+		traceConfig = config.TraceConfig
+		chunkSize   = defaultChainTraceChunkSize
+		memoryLimit = defaultChainTraceMemoryLimit
+		threads     = runtime.NumCPU()
+		reexec      = defaultTraceReexec
+	)
+	if config.ChunkSize != nil && *config.ChunkSize > 0 {
+		chunkSize = *config.ChunkSize
+	}
+	if config.MemoryLimit != nil && *config.MemoryLimit > 0 {
+		memoryLimit = *config.MemoryLimit
+	}
+	if config.Threads != nil && *config.Threads > 0 && *config.Threads < threads {
+		threads = *config.Threads
+	}
+	if traceConfig != nil && traceConfig.Reexec != nil {
+		reexec = *traceConfig.Reexec
+	}
+	from, end := progress.Next, progress.End
+	if from > end {
+		return nil
+	}
+	if blocks := int(end - from + 1); threads > blocks {
+		threads = blocks
+	}
+
+	// Find the most recent block at or before the parent of the first block
+	// to trace that has state available
+	database := state.NewDatabaseWithCache(hmy.ChainDb(), 16)
+	base := hmy.BlockChain.GetBlockByNumber(from - 1)
+	if base == nil {
+		return fmt.Errorf("block #%d not found", from-1)
+	}
+	statedb, err := state.New(base.Root(), database)
+	for i := uint64(0); err != nil && i < reexec && base.NumberU64() > 0; i++ {
+		number := base.NumberU64() - 1
+		if base = hmy.BlockChain.GetBlock(base.ParentHash(), number); base == nil {
+			return fmt.Errorf("block #%d not found", number)
+		}
+		statedb, err = state.New(base.Root(), database)
+	}
+	if err != nil {
+		return fmt.Errorf("required historical state unavailable (reexec=%d)", reexec)
+	}
+
+	// Tear down the tracers and the feeder on any exit path
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		pending  = new(sync.WaitGroup)
+		tasks    = make(chan *blockTraceTask, threads)
+		results  = make(chan *blockTraceTask, threads)
+		inflight int64
+	)
+	for th := 0; th < threads; th++ {
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+
+			for task := range tasks {
+				hmySigner := types.MakeSigner(hmy.BlockChain.Config(), task.block.Number())
+				ethSigner := types.NewEIP155Signer(hmy.BlockChain.Config().EthCompatibleChainID)
+
+				for i, tx := range task.block.Transactions() {
+					signer := hmySigner
+					if tx.IsEthCompatible() {
+						signer = ethSigner
+					}
+					msg, _ := tx.AsMessage(signer)
+					vmCtx := core.NewEVMContext(msg, task.block.Header(), hmy.BlockChain, nil)
+
+					res, err := hmy.TraceTx(ctx, msg, vmCtx, task.statedb, traceConfig)
+					if err != nil {
+						task.results[i] = &TxTraceResult{Error: err.Error()}
+						break
+					}
+					task.statedb.Finalise(true)
+					task.results[i] = &TxTraceResult{Result: res}
+				}
+				select {
+				case results <- task:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Feed all the blocks into the tracers, regenerating the state on the way
+	feedErr := make(chan error, 1)
+	go func() {
+		var (
+			failed error
+			proot  common.Hash
+		)
+		defer func() {
+			close(tasks)
+			pending.Wait()
+			close(results)
+			feedErr <- failed
+		}()
+		for number := base.NumberU64() + 1; number <= end; number++ {
+			if ctx.Err() != nil {
+				return
+			}
+			// Throttle until the tracers release their tries if too much memory is used
+			for {
+				nodes, imgs := database.TrieDB().Size()
+				if uint64(nodes+imgs) <= memoryLimit || atomic.LoadInt64(&inflight) == 0 {
+					break
+				}
+				select {
+				case <-time.After(100 * time.Millisecond):
+				case <-ctx.Done():
+					return
+				}
+			}
+			block := hmy.BlockChain.GetBlockByNumber(number)
+			if block == nil {
+				failed = fmt.Errorf("block #%d not found", number)
+				return
+			}
+			if number >= from {
+				atomic.AddInt64(&inflight, 1)
+				task := &blockTraceTask{
+					statedb: statedb.Copy(),
+					block:   block,
+					rootRef: proot,
+					results: make([]*TxTraceResult, len(block.Transactions())),
+				}
+				select {
+				case tasks <- task:
+				case <-ctx.Done():
+					return
+				}
+			}
+			// Generate the next state snapshot fast without tracing
+			if _, _, _, _, _, err := hmy.BlockChain.Processor().Process(block, statedb, vm.Config{}); err != nil {
+				failed = err
+				return
+			}
+			root, err := statedb.Commit(true)
+			if err != nil {
+				failed = err
+				return
+			}
+			if err := statedb.Reset(root); err != nil {
+				failed = err
+				return
+			}
+			// Reference the trie twice, once for us, once for the tracer
+			database.TrieDB().Reference(root, common.Hash{})
+			if number+1 >= from {
+				database.TrieDB().Reference(root, common.Hash{})
+			}
+			if proot != (common.Hash{}) {
+				database.TrieDB().Dereference(proot)
+			}
+			proot = root
+		}
+	}()
+
+	// Write the results in order, one file per chunk
+	var (
+		done   = make(map[uint64]*blockTraceTask)
+		next   = from
+		writer *chainTraceChunkWriter
+	)
+	defer func() {
+		if writer != nil {
+			writer.discard()
+		}
+	}()
+	for res := range results {
+		database.TrieDB().Dereference(res.rootRef)
+		atomic.AddInt64(&inflight, -1)
+
+		// Results traced after an interruption are incomplete, drop them
+		if ctx.Err() != nil {
+			continue
+		}
+		done[res.block.NumberU64()] = res
+
+		for task, ok := done[next]; ok; task, ok = done[next] {
+			delete(done, next)
+			if writer == nil {
+				first := next
+				last := progress.Start + ((next-progress.Start)/chunkSize+1)*chunkSize - 1
+				if last > end {
+					last = end
+				}
+				if writer, err = newChainTraceChunkWriter(progress.Dir, first, last); err != nil {
+					return err
+				}
+			}
+			if len(task.results) > 0 {
+				result := &blockTraceResult{
+					Block:  hexutil.Uint64(task.block.NumberU64()),
+					Hash:   task.block.Hash(),
+					Traces: task.results,
+				}
+				if err := writer.write(result); err != nil {
+					return err
+				}
+			}
+			if next == writer.last {
+				file, err := writer.commit()
+				writer = nil
+				if err != nil {
+					return err
+				}
+				if err := job.checkpoint(file, next+1); err != nil {
+					return err
+				}
+			}
+			next++
+		}
+	}
+	if err := <-feedErr; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// checkpoint records a finished chunk file and the next block to trace.
+func (job *chainTraceJob) checkpoint(file string, next uint64) error {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	job.progress.Files = append(job.progress.Files, file)
+	job.progress.Next = next
+	return job.saveCheckpoint()
+}
+
+// saveCheckpoint persists the job progress. It must be called with the lock held.
+func (job *chainTraceJob) saveCheckpoint() error {
+	data, err := json.MarshalIndent(&job.progress, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(job.progress.Dir, chainTraceCheckpointFile)
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// snapshot returns a copy of the job progress.
+func (job *chainTraceJob) snapshot() *ChainTraceProgress {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	return job.progressCopy()
+}
+
+// progressCopy returns a copy of the job progress. It must be called with the
+// lock held.
+func (job *chainTraceJob) progressCopy() *ChainTraceProgress {
+	progress := job.progress
+	progress.Files = append([]string{}, job.progress.Files...)
+	return &progress
+}
+
+// chainTraceChunkWriter writes the traces of a chunk of blocks to a temporary
+// file, renamed to its final name once the chunk is complete.
+type chainTraceChunkWriter struct {
+	last   uint64
+	path   string
+	file   *os.File
+	buffer *bufio.Writer
+}
+
+func newChainTraceChunkWriter(dir string, first, last uint64) (*chainTraceChunkWriter, error) {
+	path := filepath.Join(dir, fmt.Sprintf("traces_%d-%d.jsonl", first, last))
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &chainTraceChunkWriter{
+		last:   last,
+		path:   path,
+		file:   file,
+		buffer: bufio.NewWriter(file),
+	}, nil
+}
+
+// write appends the traces of a block as a single line.
+func (w *chainTraceChunkWriter) write(result *blockTraceResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buffer.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// commit flushes the chunk and moves it to its final name.
+func (w *chainTraceChunkWriter) commit() (string, error) {
+	if err := w.buffer.Flush(); err != nil {
+		w.discard()
+		return "", err
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return "", err
+	}
+	return w.path, os.Rename(w.file.Name(), w.path)
+}
+
+// discard removes the incomplete chunk.
+func (w *chainTraceChunkWriter) discard() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
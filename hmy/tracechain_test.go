@@ -0,0 +1,132 @@
+package hmy
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/types"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/pborman/uuid"
+)
+
+func TestChainTraceChunkWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chaintrace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := newChainTraceChunkWriter(dir, 10, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, number := range []uint64{10, 12} {
+		result := &blockTraceResult{Block: hexutil.Uint64(number), Traces: []*TxTraceResult{{Result: "ok"}}}
+		if err := w.write(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := w.commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := filepath.Join(dir, "traces_10-19.jsonl"); file != exp {
+		t.Errorf("unexpected chunk file: have %v, want %v", file, exp)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("unexpected number of lines: have %d, want 2", lines)
+	}
+
+	// Discarded chunks leave no file behind
+	w, err = newChainTraceChunkWriter(dir, 20, 29)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.discard()
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("unexpected files left in chunk directory: %d", len(files))
+	}
+}
+
+func TestChainTraceCheckpoint(t *testing.T) {
+	dbDir, err := ioutil.TempDir("", "chaintrace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dbDir)
+	prevDBDir := nodeconfig.GetDefaultConfig().DBDir
+	nodeconfig.GetDefaultConfig().DBDir = dbDir
+	defer func() { nodeconfig.GetDefaultConfig().DBDir = prevDBDir }()
+
+	// A job interrupted by a node restart is checkpointed as running
+	id := uuid.New()
+	dir := filepath.Join(dbDir, chainTraceDir, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	job := &chainTraceJob{progress: ChainTraceProgress{
+		ID: id, Start: 1, End: 100, Next: 1, Status: ChainTraceRunning, Dir: dir, Files: []string{},
+	}}
+	if err := job.saveCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.checkpoint(filepath.Join(dir, "traces_1-50.jsonl"), 51); err != nil {
+		t.Fatal(err)
+	}
+
+	hmy := &Harmony{chainTraces: make(map[string]*chainTraceJob)}
+	progress, err := hmy.ChainTrace(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Status != ChainTracePaused || progress.Next != 51 || len(progress.Files) != 1 {
+		t.Errorf("unexpected progress loaded from checkpoint: %+v", progress)
+	}
+	hmy.chainTracesRunning = maxRunningChainTraces
+	if _, err := hmy.ResumeChainTrace(id); err != ErrChainTraceLimit {
+		t.Errorf("unexpected resume error: have %v, want %v", err, ErrChainTraceLimit)
+	}
+	start := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Number(big.NewInt(1)).Header())
+	end := types.NewBlockWithHeader(blockfactory.NewTestHeader().With().Number(big.NewInt(2)).Header())
+	if _, err := hmy.StartChainTrace(start, end, nil); err != ErrChainTraceLimit {
+		t.Errorf("unexpected start error: have %v, want %v", err, ErrChainTraceLimit)
+	}
+	if jobs := hmy.ChainTraces(); len(jobs) != 1 {
+		t.Errorf("job registered above the limit: %d jobs", len(jobs))
+	}
+	hmy.chainTracesRunning = 0
+	if _, err := hmy.PauseChainTrace(id); err != ErrChainTraceNotRunning {
+		t.Errorf("unexpected pause error: have %v, want %v", err, ErrChainTraceNotRunning)
+	}
+	if progress, err = hmy.CancelChainTrace(id); err != nil || progress.Status != ChainTraceCancelled {
+		t.Errorf("unexpected cancel result: %+v, %v", progress, err)
+	}
+	if _, err := hmy.ResumeChainTrace(id); err != ErrChainTraceDone {
+		t.Errorf("unexpected resume error: have %v, want %v", err, ErrChainTraceDone)
+	}
+	if hmy.chainTracesRunning != 0 {
+		t.Errorf("slot of the job failing to resume not released")
+	}
+	if _, err := hmy.ChainTrace("../../etc"); err != ErrChainTraceNotFound {
+		t.Errorf("unexpected error for invalid id: have %v, want %v", err, ErrChainTraceNotFound)
+	}
+}
@@ -19,6 +19,7 @@ package hmy
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -37,6 +38,7 @@ import (
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/core/vm"
 	"github.com/harmony-one/harmony/hmy/tracers"
+	"github.com/harmony-one/harmony/hmy/tracers/native"
 	"github.com/harmony-one/harmony/internal/utils"
 )
 
@@ -59,6 +61,9 @@ type TraceConfig struct {
 	Tracer  *string
 	Timeout *string
 	Reexec  *uint64
+	// TracerConfig is the configuration of a native tracer, e.g.
+	// {"diffMode": true} for the prestateTracer.
+	TracerConfig json.RawMessage
 }
 
 // StdTraceConfig holds extra parameters to standard-json trace functions.
@@ -628,9 +633,10 @@ func (hmy *Harmony) ComputeStateDB(block *types.Block, reexec uint64) (*state.DB
 // TraceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
-// NOTE: Only support default StructLogger tracer
+// Named tracers with a native Go implementation (callTracer, prestateTracer and
+// 4byteTracer) run natively, any other tracer runs on the JavaScript engine.
 func (hmy *Harmony) TraceTx(ctx context.Context, message core.Message, vmctx vm.Context, statedb *state.DB, config *TraceConfig) (interface{}, error) {
-	// Assemble the structured logger or the JavaScript tracer
+	// Assemble the structured logger, the native or the JavaScript tracer
 	var (
 		tracer vm.Tracer
 		err    error
@@ -644,15 +650,17 @@ func (hmy *Harmony) TraceTx(ctx context.Context, message core.Message, vmctx vm.
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the native or JavaScript tracer to execute with
+		resultTracer, err := newResultTracer(*config.Tracer, config.TracerConfig)
+		if err != nil {
 			return nil, err
 		}
+		tracer = resultTracer
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			resultTracer.Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
@@ -679,7 +687,7 @@ func (hmy *Harmony) TraceTx(ctx context.Context, message core.Message, vmctx vm.
 			StructLogs:  FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case native.Tracer:
 		return tracer.GetResult()
 
 	default:
@@ -687,6 +695,17 @@ func (hmy *Harmony) TraceTx(ctx context.Context, message core.Message, vmctx vm.
 	}
 }
 
+// newResultTracer creates the native tracer registered under name, falling back
+// to the JavaScript tracer for custom scripts and tracers without a native
+// implementation.
+func newResultTracer(name string, config json.RawMessage) (native.Tracer, error) {
+	tracer, err := native.New(name, config)
+	if err != native.ErrTracerNotFound {
+		return tracer, err
+	}
+	return tracers.New(name)
+}
+
 // ComputeTxEnv returns the execution environment of a certain transaction.
 func (hmy *Harmony) ComputeTxEnv(block *types.Block, txIndex int, reexec uint64) (core.Message, vm.Context, *state.DB, error) {
 	// Create the parent state database
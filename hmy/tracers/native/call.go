@@ -0,0 +1,285 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/harmony/core/vm"
+)
+
+// callFrame is a single call in the call tree, field ordered the same way as
+// the output of the JavaScript call tracer.
+type callFrame struct {
+	Type    string       `json:"type"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	// Bookkeeping for calls still in progress
+	gas     *uint64
+	gasIn   uint64
+	gasCost uint64
+	outOff  uint64
+	outLen  uint64
+}
+
+// callTracer is the native counterpart of call_tracer.js. It rebuilds the call
+// tree from the opcodes executed by the EVM.
+type callTracer struct {
+	callstack []*callFrame
+	// descended tracks whether we've just descended from an outer transaction
+	// into an inner call.
+	descended bool
+
+	typ     string
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	elapsed time.Duration
+	err     error
+
+	stopper
+}
+
+func newCallTracer(cfg json.RawMessage) (Tracer, error) {
+	return &callTracer{callstack: []*callFrame{{}}}, nil
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to = from, to
+	t.input = common.CopyBytes(input)
+	t.gas = gas
+	t.value = new(big.Int)
+	if value != nil {
+		t.value.Set(value)
+	}
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	// We only care about system opcodes, faster if we pre-check once
+	syscall := op&0xf0 == 0xf0
+
+	switch {
+	case syscall && (op == vm.CREATE || op == vm.CREATE2):
+		// If a new contract is being created, add to the call stack
+		inOff, inLen := peekUint64(stack, 1), peekUint64(stack, 2)
+		t.callstack = append(t.callstack, &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(contract.Address()),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inLen)),
+			Value:   bigToHex(stack.Back(0)),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case syscall && op == vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(contract.Address()),
+			To:      addrToHex(peekAddress(stack, 0)),
+			Value:   bigToHex(env.StateDB.GetBalance(contract.Address())),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		return nil
+
+	case syscall && (op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL):
+		// If a new method invocation is being done, add to the call stack
+		to := peekAddress(stack, 1)
+		if isPrecompiled(to) {
+			// Skip any pre-compile invocations, those are just fancy opcodes
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		inOff, inLen := peekUint64(stack, 2+off), peekUint64(stack, 3+off)
+		call := &callFrame{
+			Type:    op.String(),
+			From:    addrToHex(contract.Address()),
+			To:      addrToHex(to),
+			Input:   hexutil.Encode(memorySlice(memory, inOff, inLen)),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peekUint64(stack, 4+off),
+			outLen:  peekUint64(stack, 5+off),
+		}
+		if op != vm.DELEGATECALL && op != vm.STATICCALL {
+			call.Value = bigToHex(stack.Back(2))
+		}
+		t.callstack = append(t.callstack, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance.
+	// We need to extract if from within the call as there may be funky gas
+	// dynamics with regard to requested and actually given gas (2300 stipend,
+	// 63/64 rule). Calls made to plain accounts never get here, so their gas is
+	// left unset.
+	if t.descended {
+		if depth >= len(t.callstack) {
+			allowance := gas
+			t.callstack[len(t.callstack)-1].gas = &allowance
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if syscall && op == vm.REVERT {
+		t.callstack[len(t.callstack)-1].Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.callstack)-1 {
+		// Pop off the last call and get the execution results
+		call := t.callstack[len(t.callstack)-1]
+		t.callstack = t.callstack[:len(t.callstack)-1]
+
+		ret := stack.Back(0)
+		if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+			// If the call was a CREATE, retrieve the contract address and output code
+			used := new(big.Int).SetUint64(call.gasIn)
+			used.Sub(used, new(big.Int).SetUint64(call.gasCost))
+			used.Sub(used, new(big.Int).SetUint64(gas))
+			call.GasUsed = bigToHex(used)
+
+			if ret.Sign() != 0 {
+				addr := common.BigToAddress(ret)
+				call.To = addrToHex(addr)
+				call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else {
+			// If the call was a contract call, retrieve the gas usage and output
+			if call.gas != nil {
+				used := new(big.Int).SetUint64(call.gasIn)
+				used.Sub(used, new(big.Int).SetUint64(call.gasCost))
+				used.Add(used, new(big.Int).SetUint64(*call.gas))
+				used.Sub(used, new(big.Int).SetUint64(gas))
+				call.GasUsed = bigToHex(used)
+			}
+			if ret.Sign() != 0 {
+				call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outLen))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		if call.gas != nil {
+			call.Gas = uint64ToHex(*call.gas)
+		}
+		// Inject the call into the previous one
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	t.fault(err)
+	return nil
+}
+
+// fault flattens the failed topmost call into its parent.
+func (t *callTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.callstack[len(t.callstack)-1].Error != "" {
+		return
+	}
+	// Pop off the just failed call
+	call := t.callstack[len(t.callstack)-1]
+	t.callstack = t.callstack[:len(t.callstack)-1]
+	call.Error = err.Error()
+
+	// Consume all available gas and clean any leftovers
+	if call.gas != nil {
+		call.Gas = uint64ToHex(*call.gas)
+		call.GasUsed = call.Gas
+	}
+	if len(t.callstack) > 0 {
+		parent := t.callstack[len(t.callstack)-1]
+		parent.Calls = append(parent.Calls, call)
+		return
+	}
+	// Last call failed too, leave it in the stack
+	t.callstack = append(t.callstack, call)
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	t.output = common.CopyBytes(output)
+	t.gasUsed = gasUsed
+	t.elapsed = elapsed
+	t.err = err
+	return nil
+}
+
+// GetResult returns the json encoded call tree of the traced transaction.
+func (t *callTracer) GetResult() (json.RawMessage, error) {
+	if t.failed != nil {
+		return nil, t.failed
+	}
+	result := &callFrame{
+		Type:    t.typ,
+		From:    addrToHex(t.from),
+		To:      addrToHex(t.to),
+		Value:   bigToHex(t.value),
+		Gas:     uint64ToHex(t.gas),
+		GasUsed: uint64ToHex(t.gasUsed),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.elapsed.String(),
+		Calls:   t.callstack[0].Calls,
+	}
+	if t.callstack[0].Error != "" {
+		result.Error = t.callstack[0].Error
+	} else if t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Error != "" && (result.Error != "execution reverted" || result.Output == "0x") {
+		result.Output = ""
+	}
+	return json.Marshal(result)
+}
+
+// addrToHex formats an address as lower case hex, as the JavaScript toHex does.
+func addrToHex(addr common.Address) string {
+	return hexutil.Encode(addr[:])
+}
@@ -0,0 +1,96 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/harmony-one/harmony/core/vm"
+)
+
+// fourByteTracer is the native counterpart of 4byte_tracer.js. It counts the
+// 4 byte method identifiers, along with the size of the supplied data, of all
+// the calls made by the transaction. The result is a map of "id-size" keys to
+// the number of times each was seen, e.g.
+//
+//	{"0x27dc297e-128": 1, "0x38cc4831-0": 2}
+type fourByteTracer struct {
+	ids   map[string]int
+	input []byte
+
+	stopper
+}
+
+func newFourByteTracer(cfg json.RawMessage) (Tracer, error) {
+	return &fourByteTracer{ids: make(map[string]int)}, nil
+}
+
+// store saves the given identifier and data size.
+func (t *fourByteTracer) store(id []byte, size uint64) {
+	t.ids[hexutil.Encode(id)+"-"+strconv.FormatUint(size, 10)]++
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *fourByteTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.input = common.CopyBytes(input)
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *fourByteTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	// Skip any opcodes that are not internal calls, find the position of the
+	// input offset on the stack otherwise
+	var inPos int
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		// gas, addr, val, memin, meminsz, memout, memoutsz
+		inPos = 3
+	case vm.DELEGATECALL, vm.STATICCALL:
+		// gas, addr, memin, meminsz, memout, memoutsz
+		inPos = 2
+	default:
+		return nil
+	}
+	if len(stack.Data()) < inPos+2 {
+		return nil
+	}
+	// Skip any pre-compile invocations, those are just fancy opcodes
+	if isPrecompiled(peekAddress(stack, 1)) {
+		return nil
+	}
+	// Gather internal call details
+	if inSize := peekUint64(stack, inPos+1); inSize >= 4 {
+		inOff := peekUint64(stack, inPos)
+		t.store(memorySlice(memory, inOff, 4), inSize-4)
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *fourByteTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *fourByteTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the json encoded method identifier counts.
+func (t *fourByteTracer) GetResult() (json.RawMessage, error) {
+	if t.failed != nil {
+		return nil, t.failed
+	}
+	// Save the outer calldata also
+	if len(t.input) >= 4 {
+		t.store(t.input[:4], uint64(len(t.input)-4))
+	}
+	return json.Marshal(t.ids)
+}
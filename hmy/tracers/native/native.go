@@ -0,0 +1,129 @@
+// Package native is a collection of transaction tracers written in Go. They
+// produce the same output as the built-in JavaScript tracers of the same name,
+// without the cost of running every EVM step through the duktape engine.
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/core/vm"
+)
+
+var (
+	// ErrTracerNotFound is returned when no native tracer is registered under
+	// the requested name.
+	ErrTracerNotFound = errors.New("native tracer not found")
+)
+
+// Tracer is a vm.Tracer that assembles its own result. It is satisfied by the
+// native tracers as well as by the JavaScript tracer, so callers can handle
+// both the same way.
+type Tracer interface {
+	vm.Tracer
+	// GetResult returns the json encoded result of the trace, or any error
+	// that occurred during tracing.
+	GetResult() (json.RawMessage, error)
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
+// ctorFn creates a native tracer from an optional json encoded config.
+type ctorFn func(cfg json.RawMessage) (Tracer, error)
+
+// ctors contains all the native tracers by the name of the JavaScript tracer
+// they replace.
+var ctors = map[string]ctorFn{
+	"callTracer":     newCallTracer,
+	"prestateTracer": newPrestateTracer,
+	"4byteTracer":    newFourByteTracer,
+}
+
+// New returns the native tracer registered under name, configured by cfg.
+// ErrTracerNotFound is returned if there is no such native tracer, in which
+// case the caller should fall back to the JavaScript engine.
+func New(name string, cfg json.RawMessage) (Tracer, error) {
+	ctor, ok := ctors[name]
+	if !ok {
+		return nil, ErrTracerNotFound
+	}
+	return ctor(cfg)
+}
+
+// Has reports whether a native tracer is registered under name.
+func Has(name string) bool {
+	_, ok := ctors[name]
+	return ok
+}
+
+// bigToHex formats a big integer the way the JavaScript tracers do.
+func bigToHex(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	return "0x" + n.Text(16)
+}
+
+// uint64ToHex formats an unsigned integer the way the JavaScript tracers do.
+func uint64ToHex(n uint64) string {
+	return bigToHex(new(big.Int).SetUint64(n))
+}
+
+// peekUint64 returns the nth stack item from the top as an uint64. Values not
+// fitting into 64 bits are saturated.
+func peekUint64(stack *vm.Stack, n int) uint64 {
+	v := stack.Back(n)
+	if !v.IsUint64() {
+		return ^uint64(0)
+	}
+	return v.Uint64()
+}
+
+// peekAddress returns the nth stack item from the top as an address.
+func peekAddress(stack *vm.Stack, n int) common.Address {
+	return common.BigToAddress(stack.Back(n))
+}
+
+// memorySlice copies the [offset, offset+size) range out of the EVM memory.
+// Out of bound accesses return an empty slice, as the JavaScript tracers do.
+func memorySlice(memory *vm.Memory, offset, size uint64) []byte {
+	end := offset + size
+	if end < offset || end > uint64(memory.Len()) {
+		return []byte{}
+	}
+	return memory.GetCopy(int64(offset), int64(size))
+}
+
+// isPrecompiled reports whether addr is a precompiled contract.
+func isPrecompiled(addr common.Address) bool {
+	_, ok := vm.PrecompiledContractsIstanbul[addr]
+	return ok
+}
+
+// stopper implements the interruption of a tracer from another goroutine.
+type stopper struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+	failed    error  // Reason recorded once the interruption was noticed
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (s *stopper) Stop(err error) {
+	s.reason = err
+	atomic.StoreUint32(&s.interrupt, 1)
+}
+
+// stopped reports whether the tracer was interrupted, and if so records the
+// reason of the interruption to be returned as the tracing result.
+func (s *stopper) stopped() bool {
+	if atomic.LoadUint32(&s.interrupt) == 0 {
+		return false
+	}
+	if s.failed == nil {
+		s.failed = s.reason
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/hmy/tracers"
+	"github.com/harmony-one/harmony/internal/params"
+)
+
+var (
+	sender   = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	caller   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	callee   = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	reverter = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	destruct = common.HexToAddress("0x00000000000000000000000000000000000000ee")
+
+	// calleeCode stores the first calldata word in slot 0 and returns slot 1.
+	calleeCode = hexutil.MustDecode("0x60003560005560015460005260206000f3")
+	// reverterCode reverts without data.
+	reverterCode = hexutil.MustDecode("0x60006000fd")
+	// destructCode self destructs to the caller contract.
+	destructCode = hexutil.MustDecode("0x60bbff")
+	// callerCode calls the callee with value and a 4 byte selector, the
+	// callee again with too little gas, the identity precompile, the reverter,
+	// the self destructing contract and finally creates a new contract.
+	callerCode = hexutil.MustDecode("0x" +
+		"63deadbeef600052" + // mstore(0, 0xdeadbeef)
+		"602060406024601c600160cc5af150" + // call(gas, callee, 1, 28, 36, 64, 32)
+		"6000600060006000600060cc6064f150" + // call(100, callee, 0, 0, 0, 0, 0)
+		"602060406020600060045afa50" + // staticcall(gas, 4, 0, 32, 64, 32)
+		"600060006000600060dd5afa50" + // staticcall(gas, reverter, 0, 0, 0, 0)
+		"6000600060006000600060ee5af150" + // call(gas, destruct, 0, 0, 0, 0, 0)
+		"6460006000f3608052" + // mstore(0x80, initcode)
+		"6005609b6000f050" + // create(0, 0x9b, 5)
+		"60206040f3") // return(64, 32)
+	// createCode deploys the callee code.
+	createCode = hexutil.MustDecode("0x6011600c60003960116000f3" + "60003560005560015460005260206000f3")
+)
+
+// sampleBlock returns the state and the transactions of a sample block
+// exercising calls, creates, reverts and self destructs.
+func sampleBlock(t *testing.T) (*state.DB, []types.Message) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.AddBalance(sender, big.NewInt(1e18))
+	statedb.AddBalance(caller, big.NewInt(1000))
+	statedb.AddBalance(destruct, big.NewInt(42))
+	statedb.SetCode(caller, callerCode)
+	statedb.SetCode(callee, calleeCode)
+	statedb.SetState(callee, common.BigToHash(big.NewInt(1)), common.HexToHash("0x2a"))
+	statedb.SetCode(reverter, reverterCode)
+	statedb.SetCode(destruct, destructCode)
+	statedb.Finalise(true)
+
+	gasPrice := big.NewInt(1)
+	msgs := []types.Message{
+		types.NewMessage(sender, &caller, 0, big.NewInt(5), 1000000, gasPrice, nil, false),
+		types.NewMessage(sender, &callee, 1, big.NewInt(0), 1000000, gasPrice, hexutil.MustDecode("0x12345678ff"), false),
+		types.NewMessage(sender, nil, 2, big.NewInt(3), 1000000, gasPrice, createCode, false),
+		types.NewMessage(sender, &reverter, 3, big.NewInt(0), 1000000, gasPrice, nil, false),
+		types.NewMessage(sender, &callee, 4, big.NewInt(0), 21500, gasPrice, nil, false),
+	}
+	return statedb, msgs
+}
+
+// traceMsg executes msg on statedb with the given tracer and returns the raw
+// tracing result.
+func traceMsg(t *testing.T, statedb *state.DB, msg types.Message, tracer Tracer) json.RawMessage {
+	vmctx := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		IsValidator: core.IsValidator,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      msg.From(),
+		GasPrice:    msg.GasPrice(),
+		GasLimit:    10000000,
+		BlockNumber: big.NewInt(1),
+		EpochNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+	}
+	vmenv := vm.NewEVM(vmctx, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	return res
+}
+
+func decode(t *testing.T, res json.RawMessage) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("failed to decode %s: %v", res, err)
+	}
+	// Execution time differs between the tracers
+	if m, ok := v.(map[string]interface{}); ok {
+		delete(m, "time")
+	}
+	return v
+}
+
+func TestNativeMatchesJavaScript(t *testing.T) {
+	for _, name := range []string{"callTracer", "prestateTracer", "4byteTracer"} {
+		statedb, msgs := sampleBlock(t)
+		for i, msg := range msgs {
+			jsTracer, err := tracers.New(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nativeTracer, err := New(name, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := traceMsg(t, statedb.Copy(), msg, jsTracer)
+			got := traceMsg(t, statedb, msg, nativeTracer)
+			statedb.Finalise(true)
+
+			if !reflect.DeepEqual(decode(t, got), decode(t, want)) {
+				t.Errorf("%s tx %d: native result mismatch\nhave %s\nwant %s", name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestPrestateTracerDiffMode(t *testing.T) {
+	statedb, msgs := sampleBlock(t)
+	tracer, err := New("prestateTracer", json.RawMessage(`{"diffMode": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res prestateDiff
+	if err := json.Unmarshal(traceMsg(t, statedb, msgs[1], tracer), &res); err != nil {
+		t.Fatal(err)
+	}
+	pre, ok := res.Pre[callee]
+	if !ok {
+		t.Fatalf("callee missing from pre state")
+	}
+	post, ok := res.Post[callee]
+	if !ok {
+		t.Fatalf("callee missing from post state")
+	}
+	slot := common.Hash{}
+	if have := pre.Storage[slot]; have != (common.Hash{}) {
+		t.Errorf("pre storage mismatch: have %x, want empty", have)
+	}
+	if have, want := post.Storage[slot], common.BytesToHash(common.RightPadBytes(hexutil.MustDecode("0x12345678ff"), 32)); have != want {
+		t.Errorf("post storage mismatch: have %x, want %x", have, want)
+	}
+	if post.Code != nil || post.Balance != nil {
+		t.Errorf("unmodified callee fields in post state: %+v", post)
+	}
+	if _, ok := res.Post[sender]; !ok {
+		t.Errorf("sender missing from post state")
+	}
+}
+
+func TestPrestateTracerRepeatedResult(t *testing.T) {
+	for _, cfg := range []string{`{}`, `{"diffMode": true}`} {
+		statedb, msgs := sampleBlock(t)
+		tracer, err := New("prestateTracer", json.RawMessage(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := traceMsg(t, statedb, msgs[0], tracer)
+		second, err := tracer.GetResult()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(first) != string(second) {
+			t.Errorf("config %s: result changed on second retrieval\nhave %s\nwant %s", cfg, second, first)
+		}
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	if _, err := New("opcountTracer", nil); err != ErrTracerNotFound {
+		t.Errorf("unexpected error: have %v, want %v", err, ErrTracerNotFound)
+	}
+	if !Has("callTracer") || Has("{step: function() {}}") {
+		t.Errorf("native tracer lookup mismatch")
+	}
+}
@@ -0,0 +1,256 @@
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/harmony-one/harmony/core/vm"
+)
+
+// account is the state of a single account touched by the traced transaction.
+type account struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// accountDiff holds the fields of an account modified by the transaction.
+type accountDiff struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   *uint64                     `json:"nonce,omitempty"`
+	Code    *hexutil.Bytes              `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// prestateTracerConfig is the configuration of the prestate tracer, passed
+// through TraceConfig.TracerConfig.
+type prestateTracerConfig struct {
+	// DiffMode makes the tracer return the pre and post state of every
+	// account modified by the transaction instead of just the prestate.
+	DiffMode bool `json:"diffMode"`
+}
+
+// prestateDiff is the result of the prestate tracer in diff mode.
+type prestateDiff struct {
+	Pre  map[common.Address]*account     `json:"pre"`
+	Post map[common.Address]*accountDiff `json:"post"`
+}
+
+// prestateTracer is the native counterpart of prestate_tracer.js. It collects
+// the state of every account and storage slot touched by the transaction as
+// it was before the transaction executed.
+type prestateTracer struct {
+	config   prestateTracerConfig
+	prestate map[common.Address]*account
+	db       vm.StateDB
+
+	typ   string
+	from  common.Address
+	to    common.Address
+	value *big.Int
+
+	stopper
+}
+
+func newPrestateTracer(cfg json.RawMessage) (Tracer, error) {
+	t := &prestateTracer{prestate: make(map[common.Address]*account)}
+	if len(cfg) > 0 {
+		if err := json.Unmarshal(cfg, &t.config); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.typ = "CALL"
+	if create {
+		t.typ = "CREATE"
+	}
+	t.from, t.to = from, to
+	t.value = new(big.Int)
+	if value != nil {
+		t.value.Set(value)
+	}
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.stopped() {
+		return nil
+	}
+	// Add the current account if we just started tracing. Balance will
+	// potentially be wrong here, since this will include the value sent along
+	// with the message. We fix that in GetResult.
+	if t.db == nil {
+		t.db = env.StateDB
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.EXTCODEHASH, vm.BALANCE:
+		if len(stack.Data()) >= 1 {
+			t.lookupAccount(peekAddress(stack, 0))
+		}
+	case vm.SELFDESTRUCT:
+		if len(stack.Data()) >= 1 {
+			t.lookupAccount(peekAddress(stack, 0))
+		}
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+	case vm.CREATE2:
+		if len(stack.Data()) >= 4 {
+			// stack: endowment, offset, size, salt
+			offset, size := peekUint64(stack, 1), peekUint64(stack, 2)
+			salt := common.BigToHash(stack.Back(3))
+			code := memorySlice(memory, offset, size)
+			t.lookupAccount(crypto.CreateAddress2(contract.Address(), salt, crypto.Keccak256(code)))
+		}
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		if len(stack.Data()) >= 2 {
+			t.lookupAccount(peekAddress(stack, 1))
+		}
+	case vm.SSTORE, vm.SLOAD:
+		if len(stack.Data()) >= 1 {
+			t.lookupStorage(contract.Address(), common.BigToHash(stack.Back(0)))
+		}
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, elapsed time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the json encoded prestate, or the pre and post state of
+// the modified accounts in diff mode.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.failed != nil {
+		return nil, t.failed
+	}
+	if t.db == nil {
+		// No code was executed, there is no state to report
+		if t.config.DiffMode {
+			return json.Marshal(&prestateDiff{
+				Pre:  map[common.Address]*account{},
+				Post: map[common.Address]*accountDiff{},
+			})
+		}
+		return json.Marshal(t.prestate)
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin. The adjustments are made on a copy, so
+	// that the result is the same however many times it is retrieved.
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+	prestate := t.prestateCopy()
+
+	fromBal := prestate[t.from].Balance.ToInt()
+	toBal := prestate[t.to].Balance.ToInt()
+	toBal.Sub(toBal, t.value)
+	fromBal.Add(fromBal, t.value)
+
+	// Decrement the caller's nonce, and remove empty create targets
+	prestate[t.from].Nonce--
+	if t.config.DiffMode {
+		// The creation target still needs to show up in the post state
+		return json.Marshal(t.diff(prestate))
+	}
+	if t.typ == "CREATE" {
+		// We can blindly delete the contract prestate, as any existing state
+		// would have caused the transaction to be rejected as invalid in the
+		// first place.
+		delete(prestate, t.to)
+	}
+	return json.Marshal(prestate)
+}
+
+// prestateCopy returns a copy of the prestate whose balances and nonces can be
+// adjusted without altering the collected prestate.
+func (t *prestateTracer) prestateCopy() map[common.Address]*account {
+	prestate := make(map[common.Address]*account, len(t.prestate))
+	for addr, acc := range t.prestate {
+		cpy := *acc
+		cpy.Balance = (*hexutil.Big)(new(big.Int).Set(acc.Balance.ToInt()))
+		prestate[addr] = &cpy
+	}
+	return prestate
+}
+
+// diff assembles the pre and post state of every account whose state differs
+// from the given prestate after the transaction executed.
+func (t *prestateTracer) diff(prestate map[common.Address]*account) *prestateDiff {
+	result := &prestateDiff{
+		Pre:  make(map[common.Address]*account),
+		Post: make(map[common.Address]*accountDiff),
+	}
+	for addr, pre := range prestate {
+		var (
+			post     = new(accountDiff)
+			modified bool
+		)
+		if balance := t.db.GetBalance(addr); balance.Cmp(pre.Balance.ToInt()) != 0 {
+			post.Balance, modified = (*hexutil.Big)(new(big.Int).Set(balance)), true
+		}
+		if nonce := t.db.GetNonce(addr); nonce != pre.Nonce {
+			post.Nonce, modified = &nonce, true
+		}
+		if code := hexutil.Bytes(t.db.GetCode(addr)); string(code) != string(pre.Code) {
+			post.Code, modified = &code, true
+		}
+		for key, val := range pre.Storage {
+			if now := t.db.GetState(addr, key); now != val {
+				if post.Storage == nil {
+					post.Storage = make(map[common.Hash]common.Hash)
+				}
+				post.Storage[key], modified = now, true
+			}
+		}
+		if !modified {
+			continue
+		}
+		if t.typ != "CREATE" || addr != t.to {
+			result.Pre[addr] = pre
+		}
+		result.Post[addr] = post
+	}
+	return result
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.prestate[addr] = &account{
+		Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+		Nonce:   t.db.GetNonce(addr),
+		Code:    common.CopyBytes(t.db.GetCode(addr)),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.prestate[addr].Storage[key]; ok {
+		return
+	}
+	t.prestate[addr].Storage[key] = t.db.GetState(addr, key)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/rawdb"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
 )
 
 // SendTx ...
@@ -46,9 +49,71 @@ func (hmy *Harmony) ResendCx(ctx context.Context, txID common.Hash) (uint64, boo
 	}
 	entry := core.CxEntry{blockHash, tx.ToShardID()}
 	success := hmy.CxPool.Add(entry)
+	if !success {
+		utils.Logger().Warn().
+			Str("txID", txID.Hex()).
+			Int("size", hmy.CxPool.Size()).
+			Msg("[ResendCx] CxPool full, cannot resend cross shard receipts")
+	}
 	return blockNum, success
 }
 
+// GetCrossShardTxStatus returns the delivery status of the cross shard receipt of the transaction
+func (hmy *Harmony) GetCrossShardTxStatus(txID common.Hash) (*core.CxTxStatus, error) {
+	return hmy.CxTracker.Status(txID)
+}
+
+// GetCXReceiptsProof returns the proof of the cross shard receipts of the block of
+// the transaction to the destination shard of the transaction, with the commit
+// signature and bitmap of the block
+func (hmy *Harmony) GetCXReceiptsProof(ctx context.Context, txID common.Hash) (*types.CXReceiptsProof, error) {
+	blockHash, blockNum, index := hmy.BlockChain.ReadTxLookupEntry(txID)
+	if blockHash == (common.Hash{}) {
+		return nil, core.ErrCxTxNotFound
+	}
+	blk := hmy.BlockChain.GetBlockByHash(blockHash)
+	if blk == nil {
+		return nil, core.ErrCxTxNotFound
+	}
+	txs := blk.Transactions()
+	if int(index) >= len(txs) || txs[index].Hash() != txID {
+		return nil, core.ErrNotCrossShardTx
+	}
+	tx := txs[index]
+	if tx.ShardID() == tx.ToShardID() {
+		return nil, core.ErrNotCrossShardTx
+	}
+
+	receipts, err := hmy.BlockChain.ReadCXReceipts(tx.ToShardID(), blockNum, blockHash)
+	if err != nil || len(receipts) == 0 {
+		return nil, core.ErrNoCXReceipt
+	}
+	merkleProof, err := hmy.BlockChain.CXMerkleProof(tx.ToShardID(), blk)
+	if err != nil {
+		return nil, err
+	}
+
+	// the commit signature of the block is in the header of the next block,
+	// or stored separately for the head block
+	var sigAndBitmap []byte
+	if next := hmy.BlockChain.GetHeaderByNumber(blockNum + 1); next != nil && next.ParentHash() == blockHash {
+		sig := next.LastCommitSignature()
+		sigAndBitmap = append(sig[:], next.LastCommitBitmap()...)
+	} else if sigAndBitmap, err = hmy.BlockChain.ReadCommitSig(blockNum); err != nil {
+		return nil, errors.Wrapf(err, "cannot read the commit signature of block %v", blockNum)
+	}
+	if len(sigAndBitmap) <= bls.BLSSignatureSizeInBytes {
+		return nil, errors.Errorf("invalid commit signature of block %v", blockNum)
+	}
+	return &types.CXReceiptsProof{
+		Receipts:     receipts,
+		MerkleProof:  merkleProof,
+		Header:       blk.Header(),
+		CommitSig:    sigAndBitmap[:bls.BLSSignatureSizeInBytes],
+		CommitBitmap: sigAndBitmap[bls.BLSSignatureSizeInBytes:],
+	}, nil
+}
+
 // GetReceipts ...
 func (hmy *Harmony) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	return hmy.BlockChain.GetReceiptsByHash(hash), nil
@@ -0,0 +1,324 @@
+// Package badgerdb implements the key value store of the chain databases on
+// top of BadgerDB, as an alternative to LevelDB.
+package badgerdb
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// errSpec prefixes the errors of the storage level, so that they are known as
+// errors of the database by the blockchain
+const errSpec = "badgerdb"
+
+const (
+	// valueLogGCDiscardRatio is the ratio of discardable data of a value log
+	// file for it to be rewritten on compaction
+	valueLogGCDiscardRatio = 0.5
+	// compactionWorkers is the number of concurrent compactions on compaction
+	compactionWorkers = 4
+	// batchEntryOverhead is the size Badger counts in a transaction for each
+	// entry on top of its key and value, for the metas and the entry header
+	batchEntryOverhead = 12
+)
+
+// ErrTxnTooBig is the cause of the error of the batches too large to be written
+// in a single transaction
+var ErrTxnTooBig = badger.ErrTxnTooBig
+
+// Database is a BadgerDB key value store
+type Database struct {
+	db *badger.DB
+
+	closeOnce sync.Once
+}
+
+var _ ethdb.KeyValueStore = (*Database)(nil)
+
+// New opens the BadgerDB database in the directory, creating it if needed
+func New(dir string) (*Database, error) {
+	opts := badger.DefaultOptions(dir).
+		WithLogger(logger{}).
+		// truncate the value log corrupted by a crash instead of failing to open
+		WithTruncate(true)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, errSpec)
+	}
+	return &Database{db: db}, nil
+}
+
+// Has retrieves if a key is present in the database
+func (db *Database) Has(key []byte) (bool, error) {
+	err := db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Get retrieves the value of the key. An error is returned if the key is not
+// in the database.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	return value, err
+}
+
+// Put inserts the value of the key into the database
+func (db *Database) Put(key []byte, value []byte) error {
+	err := db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(common.CopyBytes(key), common.CopyBytes(value))
+	})
+	return wrapErr(err)
+}
+
+// Delete removes the key from the database
+func (db *Database) Delete(key []byte) error {
+	err := db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(common.CopyBytes(key))
+	})
+	return wrapErr(err)
+}
+
+// NewBatch creates a batch of writes applied to the database on Write
+func (db *Database) NewBatch() ethdb.Batch {
+	return &batch{db: db.db}
+}
+
+// NewIterator creates an iterator over the entire database
+func (db *Database) NewIterator() ethdb.Iterator {
+	return newIterator(db.db, nil, nil)
+}
+
+// NewIteratorWithStart creates an iterator over the database content starting
+// at the key, or after it if not in the database
+func (db *Database) NewIteratorWithStart(start []byte) ethdb.Iterator {
+	return newIterator(db.db, start, nil)
+}
+
+// NewIteratorWithPrefix creates an iterator over the database content with the
+// key prefix
+func (db *Database) NewIteratorWithPrefix(prefix []byte) ethdb.Iterator {
+	return newIterator(db.db, prefix, prefix)
+}
+
+// Stat returns the sizes of the LSM tree and value log of the database. The
+// property is ignored.
+func (db *Database) Stat(property string) (string, error) {
+	lsm, vlog := db.db.Size()
+	return fmt.Sprintf("lsm: %v, vlog: %v, tables: %v",
+		common.StorageSize(lsm), common.StorageSize(vlog), len(db.db.Tables(false))), nil
+}
+
+// Compact flattens the LSM tree of the whole database and rewrites the value
+// log files with enough data discarded. The range is ignored, BadgerDB having
+// no compaction of a key range.
+func (db *Database) Compact(start []byte, limit []byte) error {
+	if err := db.db.Flatten(compactionWorkers); err != nil {
+		return wrapErr(err)
+	}
+	for {
+		if err := db.db.RunValueLogGC(valueLogGCDiscardRatio); err == badger.ErrNoRewrite {
+			return nil
+		} else if err != nil {
+			return wrapErr(err)
+		}
+	}
+}
+
+// Close closes the database
+func (db *Database) Close() error {
+	var err error
+	db.closeOnce.Do(func() {
+		err = wrapErr(db.db.Close())
+	})
+	return err
+}
+
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.Wrap(err, errSpec)
+}
+
+// batch buffers the writes until Write, where they are applied at once
+type batch struct {
+	db     *badger.DB
+	writes []keyValue
+	size   int
+}
+
+type keyValue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// Put inserts the value of the key into the batch
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyValue{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(key) + len(value) + batchEntryOverhead
+	return nil
+}
+
+// Delete adds the removal of the key to the batch
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyValue{common.CopyBytes(key), nil, true})
+	b.size += len(key) + batchEntryOverhead
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing, as counted
+// by Badger against the transaction size limit
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write applies the writes of the batch to the database atomically, in a single
+// transaction. A batch too large for a transaction is not written and fails
+// with ErrTxnTooBig, the callers keeping their batches within ethdb.IdealBatchSize.
+func (b *batch) Write() error {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		for _, kv := range b.writes {
+			var err error
+			if kv.delete {
+				err = txn.Delete(kv.key)
+			} else {
+				err = txn.Set(kv.key, kv.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return wrapErr(err)
+}
+
+// Reset resets the batch for reuse
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the writes of the batch
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, kv := range b.writes {
+		if kv.delete {
+			if err := w.Delete(kv.key); err != nil {
+				return err
+			}
+		} else if err := w.Put(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator iterates over a snapshot of the database, in the order of the keys
+type iterator struct {
+	txn    *badger.Txn
+	it     *badger.Iterator
+	prefix []byte
+
+	started bool
+	done    bool
+	key     []byte
+	value   []byte
+	err     error
+}
+
+func newIterator(db *badger.DB, start, prefix []byte) *iterator {
+	txn := db.NewTransaction(false)
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	it.Seek(start)
+	return &iterator{txn: txn, it: it, prefix: prefix}
+}
+
+// Next moves the iterator to the next key value pair, and returns whether the
+// iterator is not exhausted
+func (it *iterator) Next() bool {
+	if it.err != nil || it.done || it.it == nil {
+		return false
+	}
+	if it.started {
+		it.it.Next()
+	}
+	it.started = true
+	if !it.it.ValidForPrefix(it.prefix) {
+		it.done = true
+		it.key, it.value = nil, nil
+		return false
+	}
+	item := it.it.Item()
+	it.key = item.KeyCopy(it.key[:0])
+	if it.value, it.err = item.ValueCopy(it.value[:0]); it.err != nil {
+		it.key, it.value = nil, nil
+		return false
+	}
+	return true
+}
+
+// Error returns the error of the iteration if any
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key value pair
+func (it *iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key value pair
+func (it *iterator) Value() []byte {
+	return it.value
+}
+
+// Release releases the snapshot of the iterator
+func (it *iterator) Release() {
+	if it.it == nil {
+		return
+	}
+	it.it.Close()
+	it.txn.Discard()
+	it.it, it.txn = nil, nil
+}
+
+// logger logs the messages of BadgerDB with the logger of the node
+type logger struct{}
+
+func (logger) Errorf(format string, args ...interface{}) {
+	utils.Logger().Error().Msgf("[badgerdb] "+format, args...)
+}
+
+func (logger) Warningf(format string, args ...interface{}) {
+	utils.Logger().Warn().Msgf("[badgerdb] "+format, args...)
+}
+
+func (logger) Infof(format string, args ...interface{}) {
+	utils.Logger().Info().Msgf("[badgerdb] "+format, args...)
+}
+
+func (logger) Debugf(format string, args ...interface{}) {
+	utils.Logger().Debug().Msgf("[badgerdb] "+format, args...)
+}
@@ -0,0 +1,167 @@
+package badgerdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/pkg/errors"
+)
+
+func newTestDatabase(t *testing.T) (*Database, func()) {
+	dir, err := ioutil.TempDir("", "badgerdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := New(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	if has, err := db.Has([]byte("key")); has || err != nil {
+		t.Errorf("unexpected key found: %v", err)
+	}
+	if _, err := db.Get([]byte("key")); err == nil {
+		t.Errorf("unexpected value of missing key")
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := db.Has([]byte("key")); !has || err != nil {
+		t.Errorf("key not found: %v", err)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Errorf("unexpected value %q: %v", value, err)
+	}
+	if err := db.Delete([]byte("key")); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := db.Has([]byte("key")); has {
+		t.Errorf("unexpected key found after deletion")
+	}
+	if err := db.Compact(nil, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Stat(""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	if err := db.Put([]byte("deleted"), []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	batch := db.NewBatch()
+	batch.Put([]byte("a"), []byte{1})
+	batch.Put([]byte("b"), []byte{2, 2})
+	batch.Delete([]byte("deleted"))
+	if size := batch.ValueSize(); size != (1+1+7)+(1+2)+3*batchEntryOverhead {
+		t.Errorf("unexpected value size %v", size)
+	}
+	if has, _ := db.Has([]byte("a")); has {
+		t.Errorf("unexpected write before the batch is written")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := db.Get([]byte("b")); !bytes.Equal(value, []byte{2, 2}) {
+		t.Errorf("unexpected value %x", value)
+	}
+	if has, _ := db.Has([]byte("deleted")); has {
+		t.Errorf("unexpected key found after deletion")
+	}
+
+	replayed := memorydb.New()
+	replayed.Put([]byte("deleted"), []byte{1})
+	if err := batch.Replay(replayed); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := replayed.Has([]byte("a")); !has {
+		t.Errorf("key not replayed")
+	}
+	if has, _ := replayed.Has([]byte("deleted")); has {
+		t.Errorf("deletion not replayed")
+	}
+
+	batch.Reset()
+	if batch.ValueSize() != 0 {
+		t.Errorf("unexpected value size after reset")
+	}
+}
+
+func TestBatchTooBig(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	batch := db.NewBatch()
+	for i := 0; i != 500000; i++ {
+		batch.Put([]byte(fmt.Sprintf("key-%d", i)), []byte{1})
+	}
+	if err := batch.Write(); errors.Cause(err) != ErrTxnTooBig {
+		t.Fatalf("unexpected error of a batch too big: %v", err)
+	}
+	// nothing of the batch is written
+	if has, _ := db.Has([]byte("key-0")); has {
+		t.Errorf("batch too big partially written")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	for _, key := range []string{"a1", "a2", "b1", "b2", "c"} {
+		if err := db.Put([]byte(key), []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		it   ethdb.Iterator
+		keys []string
+	}{
+		{db.NewIterator(), []string{"a1", "a2", "b1", "b2", "c"}},
+		{db.NewIteratorWithStart([]byte("a3")), []string{"b1", "b2", "c"}},
+		{db.NewIteratorWithPrefix([]byte("b")), []string{"b1", "b2"}},
+		{db.NewIteratorWithPrefix([]byte("d")), nil},
+	}
+	for i, test := range tests {
+		it := test.it
+		var keys []string
+		for it.Next() {
+			keys = append(keys, string(it.Key()))
+			if !bytes.Equal(it.Key(), it.Value()) {
+				t.Errorf("Test %v: unexpected value %q of %q", i, it.Value(), it.Key())
+			}
+		}
+		if it.Next() || it.Error() != nil {
+			t.Errorf("Test %v: unexpected iteration after the end: %v", i, it.Error())
+		}
+		it.Release()
+		it.Release()
+		if len(keys) != len(test.keys) {
+			t.Fatalf("Test %v: unexpected keys %v", i, keys)
+		}
+		for j := range keys {
+			if keys[j] != test.keys[j] {
+				t.Errorf("Test %v: unexpected keys %v", i, keys)
+			}
+		}
+	}
+}
@@ -62,6 +62,23 @@ func (f IntFlag) RegisterTo(fs *pflag.FlagSet) error {
 	return markHiddenOrDeprecated(fs, f.Name, f.Deprecated, f.Hidden)
 }
 
+// Float64Flag is the flag with float64 value
+type Float64Flag struct {
+	Name       string
+	Shorthand  string
+	Usage      string
+	Deprecated string
+	Hidden     bool
+
+	DefValue float64
+}
+
+// RegisterTo register the float64 flag to FlagSet
+func (f Float64Flag) RegisterTo(fs *pflag.FlagSet) error {
+	fs.Float64P(f.Name, f.Shorthand, f.DefValue, f.Usage)
+	return markHiddenOrDeprecated(fs, f.Name, f.Deprecated, f.Hidden)
+}
+
 // StringSliceFlag is the flag with string slice value
 type StringSliceFlag struct {
 	Name       string
@@ -99,6 +116,8 @@ func getFlagName(flag Flag) string {
 		return f.Name
 	case IntFlag:
 		return f.Name
+	case Float64Flag:
+		return f.Name
 	case BoolFlag:
 		return f.Name
 	case StringSliceFlag:
@@ -84,6 +84,27 @@ func getIntFlagValue(fs *pflag.FlagSet, flag IntFlag) int {
 	return val
 }
 
+// GetFloat64FlagValue get the float64 value for the given Float64Flag from the local
+// flags of the cobra command.
+func GetFloat64FlagValue(cmd *cobra.Command, flag Float64Flag) float64 {
+	return getFloat64FlagValue(cmd.Flags(), flag)
+}
+
+// GetFloat64PersistentFlagValue get the float64 value for the given Float64Flag from
+// the persistent flags of the cobra command.
+func GetFloat64PersistentFlagValue(cmd *cobra.Command, flag Float64Flag) float64 {
+	return getFloat64FlagValue(cmd.PersistentFlags(), flag)
+}
+
+func getFloat64FlagValue(fs *pflag.FlagSet, flag Float64Flag) float64 {
+	val, err := fs.GetFloat64(flag.Name)
+	if err != nil {
+		handleParseError(err)
+		return 0
+	}
+	return val
+}
+
 // GetStringSliceFlagValue get the string slice value for the given StringSliceFlag from
 // the local flags of the cobra command.
 func GetStringSliceFlagValue(cmd *cobra.Command, flag StringSliceFlag) []string {
@@ -87,6 +87,7 @@ type ConfigType struct {
 	ConsensusPriKey multibls.PrivateKeys
 	// Database directory
 	DBDir            string
+	DBBackend        string // Backend of the chain and explorer databases
 	networkType      NetworkType
 	shardingSchedule shardingconfig.Schedule
 	DNSZone          string
@@ -94,6 +95,14 @@ type ConfigType struct {
 	WebHooks         struct {
 		Hooks *webhooks.Hooks
 	}
+	// Gas price oracle of the rpc server
+	GPO GasPriceOracleConfig
+	// Legacy gRPC sync server and clients
+	LegacySync LegacySyncConfig
+	// Recording of the received p2p messages, for offline replay
+	MessageRecord MessageRecordConfig
+	// Health check endpoints for load balancers
+	Health HealthConfig
 }
 
 // RPCServerConfig is the config for rpc listen addresses
@@ -107,6 +116,74 @@ type RPCServerConfig struct {
 	WSPort    int
 
 	DebugEnabled bool
+
+	TraceIndexEnabled bool
+
+	// Limits of the requests served, zero values for no limit
+	BatchLimit       int            // Maximum number of requests in a batch
+	ResponseLimit    int            // Maximum size of a http response in bytes
+	RateLimit        float64        // Requests per second of each client ip or api key
+	RateBurst        int            // Requests above the rate accepted in a burst
+	MethodRateLimits []RPCRateLimit // Rates of a method or namespace for each client
+
+	// API keys of the clients, given in the X-API-Key header or as the url path
+	APIKeys        []RPCAPIKey
+	APIKeyRequired bool // Rejects the requests without a known api key
+}
+
+// RPCRateLimit is the request rate of the rpc methods matching Method, which
+// is either a full method name (eth_call) or a namespace (debug).
+type RPCRateLimit struct {
+	Method string
+	Rate   float64
+	Burst  int
+}
+
+// RPCAPIKey is an api key of the rpc server and its request quota, which
+// replaces the rate limit of the client ip.
+type RPCAPIKey struct {
+	Key   string
+	Rate  float64
+	Burst int
+}
+
+// GasPriceOracleConfig is the config of the gas price oracle behind the gas
+// price and fee history rpcs
+type GasPriceOracleConfig struct {
+	Blocks      int   // Number of recent blocks sampled
+	Percentile  int   // Percentile of the sampled gas prices suggested
+	IgnorePrice int64 // Gas price in wei below which transactions are not sampled
+	MaxPrice    int64 // Highest gas price in wei suggested
+}
+
+// LegacySyncConfig is the config of the legacy gRPC sync server and clients
+type LegacySyncConfig struct {
+	// Mutual TLS with the certificates tied to the p2p keys of the nodes, on
+	// both the server and the clients
+	TLS bool
+	// Peer IDs or IPs of the clients allowed by the server, empty for any.
+	// Peer IDs are only known with TLS.
+	Allowlist      []string
+	MaxRequestSize int // Maximum number of block hashes in a request
+	MaxConcurrency int // Maximum number of concurrent requests of a client
+}
+
+// MessageRecordConfig is the config of the recorder of the consensus and node
+// p2p messages
+type MessageRecordConfig struct {
+	File       string // File the messages are written to, disabled if empty
+	MaxSize    int    // Size in megabytes of the file before it gets rotated
+	MaxBackups int    // Number of rotated files kept, all of them if 0
+}
+
+// HealthConfig is the config of the liveness and readiness endpoints, and the
+// thresholds of the readiness checks
+type HealthConfig struct {
+	RPCEnabled     bool // Serve the endpoints on the http rpc port
+	MetricsEnabled bool // Serve the endpoints on the prometheus port
+	MinPeers       int  // Minimum number of connected peers to be ready
+	MaxBlockAge    int  // Maximum age in seconds of the head block to be ready, unchecked if 0
+	MaxRPCBacklog  int  // Maximum number of rpc requests in flight to be ready, unchecked if 0
 }
 
 // RosettaServerConfig is the config for the rosetta server
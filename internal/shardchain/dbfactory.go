@@ -2,11 +2,30 @@ package shardchain
 
 import (
 	"fmt"
+	"os"
 	"path"
 
 	"github.com/ethereum/go-ethereum/core/rawdb"
-
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	hmy_rawdb "github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/internal/badgerdb"
+)
+
+// freezerDir is the directory of the freezer in the shard database directory
+const freezerDir = "ancient"
+
+// Backends of the databases
+const (
+	LDBBackend      = "leveldb"
+	BadgerDBBackend = "badgerdb"
+)
+
+// Prefixes of the directories of the shard databases of the backends, followed
+// by the shard ID
+const (
+	LDBDirPrefix      = "harmony_db_"
+	BadgerDBDirPrefix = "harmony_badgerdb_"
 )
 
 // DBFactory is a blockchain database factory.
@@ -16,15 +35,121 @@ type DBFactory interface {
 	NewChainDB(shardID uint32) (ethdb.Database, error)
 }
 
+// DiskDBFactory is a blockchain database factory keeping the database of each
+// shard in its directory.
+type DiskDBFactory interface {
+	DBFactory
+
+	// ShardDBDir returns the directory of the database of the shard.
+	ShardDBDir(shardID uint32) string
+
+	// DirPrefix returns the prefix of the directories of the shard databases.
+	DirPrefix() string
+
+	// Backend returns the backend of the databases.
+	Backend() string
+}
+
+// NewDiskDBFactory returns the factory of the databases of the backend in the
+// root directory, with the freezer threshold of the databases.
+func NewDiskDBFactory(backend, rootDir string, freezerThreshold uint64) (DiskDBFactory, error) {
+	switch backend {
+	case LDBBackend:
+		return &LDBFactory{RootDir: rootDir, FreezerThreshold: freezerThreshold}, nil
+	case BadgerDBBackend:
+		return &BadgerDBFactory{RootDir: rootDir, FreezerThreshold: freezerThreshold}, nil
+	}
+	return nil, fmt.Errorf("unknown database backend %v", backend)
+}
+
+// NewKeyValueStore opens the key value store of the backend in the directory.
+func NewKeyValueStore(backend, dir string) (ethdb.KeyValueStore, error) {
+	switch backend {
+	case LDBBackend:
+		return leveldb.New(dir, 128, 64, "")
+	case BadgerDBBackend:
+		return badgerdb.New(dir)
+	}
+	return nil, fmt.Errorf("unknown database backend %v", backend)
+}
+
+// newChainDB returns the chain database of the key value store of the backend
+// in the directory, with its freezer if the threshold is positive. The freezer
+// of a database frozen before is opened even with a zero threshold, as it holds
+// the old blocks, but no more blocks are moved to it.
+func newChainDB(backend, dir string, freezerThreshold uint64) (ethdb.Database, error) {
+	kv, err := NewKeyValueStore(backend, dir)
+	if err != nil {
+		return nil, err
+	}
+	ancientDir := path.Join(dir, freezerDir)
+	if freezerThreshold == 0 {
+		if _, err := os.Stat(ancientDir); os.IsNotExist(err) {
+			return rawdb.NewDatabase(kv), nil
+		}
+	}
+	fdb, err := hmy_rawdb.NewDatabaseWithFreezer(kv, ancientDir, freezerThreshold)
+	if err != nil {
+		kv.Close()
+		return nil, err
+	}
+	return fdb, nil
+}
+
 // LDBFactory is a LDB-backed blockchain database factory.
 type LDBFactory struct {
 	RootDir string // directory in which to put shard databases in.
+	// Number of recent blocks kept in the LDB, the older blocks being moved
+	// to the freezer of the shard database. No freezer if 0.
+	FreezerThreshold uint64
 }
 
 // NewChainDB returns a new LDB for the blockchain for given shard.
 func (f *LDBFactory) NewChainDB(shardID uint32) (ethdb.Database, error) {
-	dir := path.Join(f.RootDir, fmt.Sprintf("harmony_db_%d", shardID))
-	return rawdb.NewLevelDBDatabase(dir, 128, 64, "")
+	return newChainDB(LDBBackend, f.ShardDBDir(shardID), f.FreezerThreshold)
+}
+
+// ShardDBDir returns the directory of the database of the shard
+func (f *LDBFactory) ShardDBDir(shardID uint32) string {
+	return path.Join(f.RootDir, fmt.Sprintf("%s%d", LDBDirPrefix, shardID))
+}
+
+// DirPrefix returns the prefix of the directories of the shard databases
+func (f *LDBFactory) DirPrefix() string {
+	return LDBDirPrefix
+}
+
+// Backend returns the backend of the databases
+func (f *LDBFactory) Backend() string {
+	return LDBBackend
+}
+
+// BadgerDBFactory is a BadgerDB-backed blockchain database factory.
+type BadgerDBFactory struct {
+	RootDir string // directory in which to put shard databases in.
+	// Number of recent blocks kept in the BadgerDB, the older blocks being
+	// moved to the freezer of the shard database. No freezer if 0.
+	FreezerThreshold uint64
+}
+
+// NewChainDB returns a new BadgerDB for the blockchain for given shard.
+func (f *BadgerDBFactory) NewChainDB(shardID uint32) (ethdb.Database, error) {
+	return newChainDB(BadgerDBBackend, f.ShardDBDir(shardID), f.FreezerThreshold)
+}
+
+// ShardDBDir returns the directory of the database of the shard
+func (f *BadgerDBFactory) ShardDBDir(shardID uint32) string {
+	return path.Join(f.RootDir, fmt.Sprintf("%s%d", BadgerDBDirPrefix, shardID))
+}
+
+// DirPrefix returns the prefix of the directories of the shard databases
+func (f *BadgerDBFactory) DirPrefix() string {
+	return BadgerDBDirPrefix
+}
+
+// Backend returns the backend of the databases
+func (f *BadgerDBFactory) Backend() string {
+	return BadgerDBBackend
 }
 
 // MemDBFactory is a memory-backed blockchain database factory.
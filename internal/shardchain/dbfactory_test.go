@@ -0,0 +1,315 @@
+package shardchain
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/rawdb"
+	"github.com/harmony-one/harmony/core/types"
+)
+
+var testBackends = []string{LDBBackend, BadgerDBBackend}
+
+// newTestBlock returns the block of the number with the number of transactions,
+// and the receipts of the transactions
+func newTestBlock(number uint64, parent common.Hash, txs int) (*types.Block, types.Receipts) {
+	var (
+		transactions types.Transactions
+		receipts     types.Receipts
+	)
+	for i := 0; i != txs; i++ {
+		tx := types.NewTransaction(
+			number*uint64(txs)+uint64(i), common.Address{byte(i)}, 0, big.NewInt(1), 21000, big.NewInt(1), nil,
+		)
+		transactions = append(transactions, tx)
+		receipts = append(receipts, &types.Receipt{
+			CumulativeGasUsed: uint64(i+1) * 21000,
+			TxHash:            tx.Hash(),
+			GasUsed:           21000,
+			Logs:              []*types.Log{},
+		})
+	}
+	header := blockfactory.NewTestHeader().With().
+		Number(new(big.Int).SetUint64(number)).
+		ParentHash(parent).
+		Header()
+	return types.NewBlock(header, transactions, receipts, nil, nil, nil), receipts
+}
+
+// writeTestBlock writes the block as the head block of the canonical chain, as
+// the blockchain does on insertion
+func writeTestBlock(db ethdb.Database, block *types.Block, receipts types.Receipts) error {
+	batch := db.NewBatch()
+	if err := rawdb.WriteBlock(batch, block); err != nil {
+		return err
+	}
+	if err := rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
+		return err
+	}
+	if err := rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64()); err != nil {
+		return err
+	}
+	if err := rawdb.WriteBlockTxLookUpEntries(batch, block); err != nil {
+		return err
+	}
+	if err := rawdb.WriteHeadBlockHash(batch, block.Hash()); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// writeTestChain writes the number of blocks with the number of transactions
+// per block, and returns the blocks
+func writeTestChain(db ethdb.Database, blocks, txs int) ([]*types.Block, error) {
+	var (
+		chain  []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i != blocks; i++ {
+		block, receipts := newTestBlock(uint64(i), parent, txs)
+		if err := writeTestBlock(db, block, receipts); err != nil {
+			return nil, err
+		}
+		chain = append(chain, block)
+		parent = block.Hash()
+	}
+	return chain, nil
+}
+
+func checkTestChain(t *testing.T, db ethdb.Database, chain []*types.Block) {
+	t.Helper()
+	for _, block := range chain {
+		hash := rawdb.ReadCanonicalHash(db, block.NumberU64())
+		if b := rawdb.ReadBlock(db, hash, block.NumberU64()); b == nil || b.Hash() != block.Hash() {
+			t.Fatalf("block %v not found", block.NumberU64())
+		}
+		if receipts := rawdb.ReadReceipts(db, hash, block.NumberU64()); len(receipts) != len(block.Transactions()) {
+			t.Fatalf("unexpected receipts of block %v: %v", block.NumberU64(), len(receipts))
+		}
+		for _, tx := range block.Transactions() {
+			if found, _, number, _ := rawdb.ReadTransaction(db, tx.Hash()); found == nil || number != block.NumberU64() {
+				t.Fatalf("transaction %v not found", tx.Hash().Hex())
+			}
+		}
+	}
+}
+
+func TestDiskDBFactory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbfactory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, backend := range testBackends {
+		for _, threshold := range []uint64{0, 2} {
+			factory, err := NewDiskDBFactory(backend, path.Join(dir, fmt.Sprint(threshold)), threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db, err := factory.NewChainDB(1)
+			if err != nil {
+				t.Fatalf("%v: %v", backend, err)
+			}
+			chain, err := writeTestChain(db, 5, 2)
+			if err != nil {
+				t.Fatalf("%v: %v", backend, err)
+			}
+			if threshold != 0 {
+				if moved, err := rawdb.MigrateToFreezer(db); err != nil || moved != 2 {
+					t.Fatalf("%v: unexpected blocks frozen %v: %v", backend, moved, err)
+				}
+			}
+			checkTestChain(t, db, chain)
+			db.Close()
+
+			if _, err := os.Stat(factory.ShardDBDir(1)); err != nil {
+				t.Errorf("%v: database directory not found: %v", backend, err)
+			}
+		}
+	}
+	if _, err := NewDiskDBFactory("unknown", dir, 0); err == nil {
+		t.Errorf("unexpected factory of unknown backend")
+	}
+}
+
+func TestDiskDBFactoryFrozenWithoutFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbfactory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, backend := range testBackends {
+		factory, _ := NewDiskDBFactory(backend, dir, 2)
+		db, err := factory.NewChainDB(0)
+		if err != nil {
+			t.Fatalf("%v: %v", backend, err)
+		}
+		chain, err := writeTestChain(db, 5, 2)
+		if err != nil {
+			t.Fatalf("%v: %v", backend, err)
+		}
+		moved, err := rawdb.MigrateToFreezer(db)
+		if err != nil || moved == 0 {
+			t.Fatalf("%v: unexpected blocks frozen %v: %v", backend, moved, err)
+		}
+		db.Close()
+
+		// the blocks frozen are still read with the freezer disabled
+		factory, _ = NewDiskDBFactory(backend, dir, 0)
+		if db, err = factory.NewChainDB(0); err != nil {
+			t.Fatalf("%v: %v", backend, err)
+		}
+		checkTestChain(t, db, chain)
+		if more, err := rawdb.MigrateToFreezer(db); err != nil || more != 0 {
+			t.Errorf("%v: unexpected blocks frozen with the freezer disabled %v: %v", backend, more, err)
+		}
+		if frozen, _ := db.Ancients(); frozen != moved {
+			t.Errorf("%v: unexpected number of frozen blocks %v / %v", backend, frozen, moved)
+		}
+		db.Close()
+	}
+}
+
+func TestMigrateDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dbmigrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := &LDBFactory{RootDir: dir, FreezerThreshold: 2}
+	db, err := src.NewChainDB(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := writeTestChain(db, 6, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rawdb.MigrateToFreezer(db); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	dst := &BadgerDBFactory{RootDir: dir, FreezerThreshold: 2}
+	copied, err := MigrateDB(src, dst, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied == 0 {
+		t.Errorf("nothing copied")
+	}
+	if db, err = dst.NewChainDB(0); err != nil {
+		t.Fatal(err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 3 {
+		t.Errorf("unexpected frozen blocks %v", frozen)
+	}
+	checkTestChain(t, db, chain)
+	db.Close()
+
+	if _, err := MigrateDB(src, dst, 0); err == nil {
+		t.Errorf("unexpected migration into an existing database")
+	}
+	if _, err := MigrateDB(src, dst, 1); err == nil {
+		t.Errorf("unexpected migration of a missing database")
+	}
+}
+
+// benchmarkChainDB runs the benchmark on a new chain database of each backend
+func benchmarkChainDB(b *testing.B, run func(b *testing.B, db ethdb.Database)) {
+	for _, backend := range testBackends {
+		b.Run(backend, func(b *testing.B) {
+			dir, err := ioutil.TempDir("", "dbbench")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			factory, err := NewDiskDBFactory(backend, dir, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			db, err := factory.NewChainDB(0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+			run(b, db)
+		})
+	}
+}
+
+// BenchmarkInsertBlock measures the writes of the blockchain on the insertion
+// of a block of 100 transactions
+func BenchmarkInsertBlock(b *testing.B) {
+	benchmarkChainDB(b, func(b *testing.B, db ethdb.Database) {
+		var (
+			blocks   = make([]*types.Block, b.N)
+			receipts = make([]types.Receipts, b.N)
+			parent   common.Hash
+		)
+		for i := range blocks {
+			blocks[i], receipts[i] = newTestBlock(uint64(i), parent, 100)
+			parent = blocks[i].Hash()
+		}
+		b.ResetTimer()
+		for i := range blocks {
+			if err := writeTestBlock(db, blocks[i], receipts[i]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchmarkReads measures the reads of the rpc on a chain of 1000 blocks of 20
+// transactions
+func benchmarkReads(b *testing.B, read func(db ethdb.Database, block *types.Block) bool) {
+	benchmarkChainDB(b, func(b *testing.B, db ethdb.Database) {
+		chain, err := writeTestChain(db, 1000, 20)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if !read(db, chain[(i*7919)%len(chain)]) {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
+
+// BenchmarkReadBlockByNumber measures the read of a block by its number, as
+// hmy_getBlockByNumber
+func BenchmarkReadBlockByNumber(b *testing.B) {
+	benchmarkReads(b, func(db ethdb.Database, block *types.Block) bool {
+		hash := rawdb.ReadCanonicalHash(db, block.NumberU64())
+		return rawdb.ReadBlock(db, hash, block.NumberU64()) != nil
+	})
+}
+
+// BenchmarkReadTransaction measures the read of a transaction by its hash, as
+// hmy_getTransactionByHash
+func BenchmarkReadTransaction(b *testing.B) {
+	benchmarkReads(b, func(db ethdb.Database, block *types.Block) bool {
+		tx, _, _, _ := rawdb.ReadTransaction(db, block.Transactions()[3].Hash())
+		return tx != nil
+	})
+}
+
+// BenchmarkReadReceipt measures the read of a transaction receipt by the hash
+// of the transaction, as hmy_getTransactionReceipt
+func BenchmarkReadReceipt(b *testing.B) {
+	benchmarkReads(b, func(db ethdb.Database, block *types.Block) bool {
+		receipt, _, _, _ := rawdb.ReadReceipt(db, block.Transactions()[3].Hash())
+		return receipt != nil
+	})
+}
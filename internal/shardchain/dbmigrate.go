@@ -0,0 +1,134 @@
+package shardchain
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// migrateLogInterval is the interval of the progress logs of the migration
+const migrateLogInterval = 8 * time.Second
+
+// MigrateDB copies the database of the shard of the source factory into a new
+// database of the destination factory: all the entries of the key value store,
+// and the files of the freezer if any. The destination database shall not
+// exist. The number of entries copied is returned.
+func MigrateDB(src, dst DiskDBFactory, shardID uint32) (uint64, error) {
+	srcDir, dstDir := src.ShardDBDir(shardID), dst.ShardDBDir(shardID)
+	if _, err := os.Stat(srcDir); err != nil {
+		return 0, err
+	}
+	if _, err := os.Stat(dstDir); !os.IsNotExist(err) {
+		return 0, errors.Errorf("destination database %v already exists", dstDir)
+	}
+	copied, err := MigrateKeyValueStore(src.Backend(), srcDir, dst.Backend(), dstDir)
+	if err != nil {
+		return copied, err
+	}
+	return copied, copyFreezer(path.Join(srcDir, freezerDir), path.Join(dstDir, freezerDir))
+}
+
+// MigrateKeyValueStore copies all the entries of the key value store of the
+// backend in the source directory into the key value store of the other
+// backend in the destination directory. The number of entries copied is
+// returned.
+func MigrateKeyValueStore(srcBackend, srcDir, dstBackend, dstDir string) (uint64, error) {
+	srcDB, err := NewKeyValueStore(srcBackend, srcDir)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot open %v", srcDir)
+	}
+	defer srcDB.Close()
+	dstDB, err := NewKeyValueStore(dstBackend, dstDir)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot open %v", dstDir)
+	}
+	defer dstDB.Close()
+	return CopyKeyValues(dstDB, srcDB)
+}
+
+// CopyKeyValues copies all the entries of the source key value store into the
+// destination, and returns the number of entries copied
+func CopyKeyValues(dst ethdb.Batcher, src ethdb.Iteratee) (uint64, error) {
+	var (
+		copied  uint64
+		size    common.StorageSize
+		lastLog = time.Now()
+		batch   = dst.NewBatch()
+		it      = src.NewIterator()
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if err := batch.Put(key, value); err != nil {
+			return copied, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return copied, err
+			}
+			batch.Reset()
+		}
+		copied++
+		size += common.StorageSize(len(key) + len(value))
+		if time.Since(lastLog) > migrateLogInterval {
+			utils.Logger().Info().Uint64("entries", copied).Str("size", size.String()).
+				Msg("[shardchain] copying database")
+			lastLog = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return copied, err
+	}
+	return copied, batch.Write()
+}
+
+// copyFreezer copies the files of the freezer directory, if it exists
+func copyFreezer(srcDir, dstDir string) error {
+	entries, err := ioutil.ReadDir(srcDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(path.Join(srcDir, entry.Name()), path.Join(dstDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
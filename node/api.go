@@ -2,8 +2,10 @@ package node
 
 import (
 	"github.com/ethereum/go-ethereum/rpc"
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/hmy"
+	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/rosetta"
 	hmy_rpc "github.com/harmony-one/harmony/rpc"
 	rpc_common "github.com/harmony-one/harmony/rpc/common"
@@ -36,6 +38,11 @@ func (node *Node) ListBlockedPeer() []peer.ID {
 	return node.host.ListBlockedPeer()
 }
 
+// ListPeerScore return the scores of the known peers
+func (node *Node) ListPeerScore() []p2p.PeerScore {
+	return node.host.ListPeerScore()
+}
+
 // PendingCXReceipts returns node.pendingCXReceiptsProof
 func (node *Node) PendingCXReceipts() []*types.CXReceiptsProof {
 	cxReceipts := make([]*types.CXReceiptsProof, len(node.pendingCXReceipts))
@@ -64,7 +71,8 @@ func (node *Node) ReportPlainErrorSink() types.TransactionErrorReports {
 
 // StartRPC start RPC service
 func (node *Node) StartRPC() error {
-	harmony := hmy.New(node, node.TxPool, node.CxPool, node.Consensus.ShardID)
+	harmony := hmy.New(node, node.TxPool, node.CxPool, node.CxTracker, node.Consensus.ShardID)
+	node.rpcHarmony = harmony
 
 	// Gather all the possible APIs to surface
 	apis := node.APIs(harmony)
@@ -73,30 +81,49 @@ func (node *Node) StartRPC() error {
 		apis = append(apis, service.APIs()...)
 	}
 
-	return hmy_rpc.StartServers(harmony, apis, node.NodeConfig.RPCServer)
+	var handlers []prom.Handler
+	if node.NodeConfig.Health.RPCEnabled {
+		handlers = node.HealthHandlers()
+	}
+	return hmy_rpc.StartServers(harmony, apis, node.NodeConfig.RPCServer, handlers...)
 }
 
 // StopRPC stop RPC service
 func (node *Node) StopRPC() error {
-	return hmy_rpc.StopServers()
+	if err := hmy_rpc.StopServers(); err != nil {
+		return err
+	}
+	if node.rpcHarmony != nil {
+		node.rpcHarmony.Stop()
+		node.rpcHarmony = nil
+	}
+	return nil
 }
 
 // StartRosetta start rosetta service
 func (node *Node) StartRosetta() error {
-	harmony := hmy.New(node, node.TxPool, node.CxPool, node.Consensus.ShardID)
+	harmony := hmy.New(node, node.TxPool, node.CxPool, node.CxTracker, node.Consensus.ShardID)
+	node.rosettaHarmony = harmony
 	return rosetta.StartServers(harmony, node.NodeConfig.RosettaServer)
 }
 
 // StopRosetta stops rosetta service
 func (node *Node) StopRosetta() error {
-	return rosetta.StopServers()
+	if err := rosetta.StopServers(); err != nil {
+		return err
+	}
+	if node.rosettaHarmony != nil {
+		node.rosettaHarmony.Stop()
+		node.rosettaHarmony = nil
+	}
+	return nil
 }
 
 // APIs return the collection of local RPC services.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (node *Node) APIs(harmony *hmy.Harmony) []rpc.API {
 	// Append all the local APIs and return
-	return []rpc.API{
+	apis := []rpc.API{
 		hmy_rpc.NewPublicNetAPI(node.host, harmony.ChainID, hmy_rpc.V1),
 		hmy_rpc.NewPublicNetAPI(node.host, harmony.ChainID, hmy_rpc.V2),
 		hmy_rpc.NewPublicNetAPI(node.host, harmony.ChainID, hmy_rpc.Eth),
@@ -104,6 +131,13 @@ func (node *Node) APIs(harmony *hmy.Harmony) []rpc.API {
 		filters.NewPublicFilterAPI(harmony, false, "hmy"),
 		filters.NewPublicFilterAPI(harmony, false, "eth"),
 	}
+	if node.NodeConfig.RPCServer.DebugEnabled {
+		apis = append(apis,
+			hmy_rpc.NewPrivateNetAPI(node.host, hmy_rpc.V1),
+			hmy_rpc.NewPrivateNetAPI(node.host, hmy_rpc.V2),
+		)
+	}
+	return apis
 }
 
 // GetConsensusMode returns the current consensus mode
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"time"
+
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	"github.com/harmony-one/harmony/node/health"
+	hmy_rpc "github.com/harmony-one/harmony/rpc"
+)
+
+// healthBackend is the node status checked by the health endpoints
+type healthBackend struct {
+	node *Node
+}
+
+func (b healthBackend) ShardID() uint32 {
+	return b.node.Blockchain().ShardID()
+}
+
+// SyncStatus returns the status of the stream downloader, or of the legacy
+// sync when the downloader is off
+func (b healthBackend) SyncStatus(shardID uint32) (bool, uint64) {
+	if !b.node.NodeConfig.Downloader {
+		return b.node.legacySyncStatus(shardID)
+	}
+	return b.node.SyncStatus(shardID)
+}
+
+func (b healthBackend) PeerCount() int {
+	return b.node.host.GetPeerCount()
+}
+
+func (b healthBackend) HeadTime() time.Time {
+	return time.Unix(b.node.Blockchain().CurrentHeader().Time().Int64(), 0)
+}
+
+func (b healthBackend) BootTime() time.Time {
+	return time.Unix(b.node.unixTimeAtNodeStart, 0)
+}
+
+func (b healthBackend) RPCBacklog() int64 {
+	return hmy_rpc.InflightRequests()
+}
+
+// HealthHandlers returns the handlers of the liveness and readiness endpoints
+// of the node
+func (node *Node) HealthHandlers() []prom.Handler {
+	return health.NewChecker(healthBackend{node}, node.NodeConfig.Health).Handlers()
+}
@@ -0,0 +1,170 @@
+// Package health serves the liveness and readiness endpoints of a node, used by
+// load balancers to route the requests to the nodes ready to serve them.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/shard"
+)
+
+// Paths of the endpoints
+const (
+	LivePath  = "/health/live"
+	ReadyPath = "/health/ready"
+)
+
+// Names of the readiness checks
+const (
+	CheckShardSync  = "shard_sync"
+	CheckBeaconSync = "beacon_sync"
+	CheckPeers      = "peers"
+	CheckHeadAge    = "head_age"
+	CheckRPCBacklog = "rpc_backlog"
+)
+
+// Backend is the node status the checks are run against
+type Backend interface {
+	ShardID() uint32
+	// SyncStatus returns whether the chain of the shard is syncing, and the
+	// target block number
+	SyncStatus(shardID uint32) (bool, uint64)
+	PeerCount() int
+	HeadTime() time.Time
+	BootTime() time.Time
+	RPCBacklog() int64
+}
+
+// Check is the result of a readiness check
+type Check struct {
+	OK        bool   `json:"ok"`
+	Value     int64  `json:"value"`
+	Threshold int64  `json:"threshold,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Report is the result of all the readiness checks
+type Report struct {
+	Ready  bool             `json:"ready"`
+	Checks map[string]Check `json:"checks"`
+}
+
+// Liveness is the response of the liveness endpoint
+type Liveness struct {
+	Alive  bool  `json:"alive"`
+	Uptime int64 `json:"uptime"` // seconds since the node started
+}
+
+// Checker runs the readiness checks with the thresholds of the config
+type Checker struct {
+	backend Backend
+	config  nodeconfig.HealthConfig
+}
+
+// NewChecker creates a new checker of the backend
+func NewChecker(backend Backend, config nodeconfig.HealthConfig) *Checker {
+	return &Checker{
+		backend: backend,
+		config:  config,
+	}
+}
+
+// Handlers returns the handlers of the liveness and readiness endpoints
+func (c *Checker) Handlers() []prom.Handler {
+	return []prom.Handler{
+		{Path: LivePath, Handler: c.liveHandler},
+		{Path: ReadyPath, Handler: c.readyHandler},
+	}
+}
+
+// Live returns the liveness of the node. The node is alive as long as it
+// serves the request.
+func (c *Checker) Live() Liveness {
+	return Liveness{
+		Alive:  true,
+		Uptime: int64(time.Since(c.backend.BootTime()) / time.Second),
+	}
+}
+
+// Ready runs the readiness checks. The node is ready if all the checks pass.
+func (c *Checker) Ready() Report {
+	return c.ready(time.Now())
+}
+
+func (c *Checker) ready(now time.Time) Report {
+	checks := make(map[string]Check)
+
+	shardID := c.backend.ShardID()
+	checks[CheckShardSync] = c.checkSync(shardID)
+	if shardID != shard.BeaconChainShardID {
+		checks[CheckBeaconSync] = c.checkSync(shard.BeaconChainShardID)
+	}
+
+	peers := c.backend.PeerCount()
+	checks[CheckPeers] = Check{
+		OK:        peers >= c.config.MinPeers,
+		Value:     int64(peers),
+		Threshold: int64(c.config.MinPeers),
+	}
+
+	age := int64(now.Sub(c.backend.HeadTime()) / time.Second)
+	checks[CheckHeadAge] = Check{
+		OK:        c.config.MaxBlockAge <= 0 || age <= int64(c.config.MaxBlockAge),
+		Value:     age,
+		Threshold: int64(c.config.MaxBlockAge),
+	}
+
+	backlog := c.backend.RPCBacklog()
+	checks[CheckRPCBacklog] = Check{
+		OK:        c.config.MaxRPCBacklog <= 0 || backlog <= int64(c.config.MaxRPCBacklog),
+		Value:     backlog,
+		Threshold: int64(c.config.MaxRPCBacklog),
+	}
+
+	report := Report{Ready: true, Checks: checks}
+	for _, check := range checks {
+		if !check.OK {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (c *Checker) checkSync(shardID uint32) Check {
+	syncing, target := c.backend.SyncStatus(shardID)
+	if syncing {
+		return Check{
+			OK:      false,
+			Value:   int64(target),
+			Message: fmt.Sprintf("shard %v syncing to block %v", shardID, target),
+		}
+	}
+	return Check{OK: true, Value: int64(target)}
+}
+
+func (c *Checker) liveHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.Live())
+}
+
+func (c *Checker) readyHandler(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready()
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[health] failed to write response")
+	}
+}
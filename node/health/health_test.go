@@ -0,0 +1,172 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+)
+
+type testBackend struct {
+	shardID  uint32
+	syncing  map[uint32]bool
+	peers    int
+	headTime time.Time
+	bootTime time.Time
+	backlog  int64
+}
+
+func (b *testBackend) ShardID() uint32 { return b.shardID }
+
+func (b *testBackend) SyncStatus(shardID uint32) (bool, uint64) {
+	return b.syncing[shardID], 100
+}
+
+func (b *testBackend) PeerCount() int      { return b.peers }
+func (b *testBackend) HeadTime() time.Time { return b.headTime }
+func (b *testBackend) BootTime() time.Time { return b.bootTime }
+func (b *testBackend) RPCBacklog() int64   { return b.backlog }
+
+var testConfig = nodeconfig.HealthConfig{
+	MinPeers:      3,
+	MaxBlockAge:   60,
+	MaxRPCBacklog: 10,
+}
+
+func TestChecker_Ready(t *testing.T) {
+	now := time.Now()
+	healthy := func() *testBackend {
+		return &testBackend{
+			shardID:  1,
+			syncing:  make(map[uint32]bool),
+			peers:    5,
+			headTime: now.Add(-10 * time.Second),
+			bootTime: now.Add(-time.Hour),
+			backlog:  2,
+		}
+	}
+	tests := []struct {
+		edit      func(b *testBackend)
+		config    nodeconfig.HealthConfig
+		expReady  bool
+		expFailed string
+	}{
+		{
+			edit:     func(b *testBackend) {},
+			config:   testConfig,
+			expReady: true,
+		},
+		{
+			edit:      func(b *testBackend) { b.syncing[1] = true },
+			config:    testConfig,
+			expReady:  false,
+			expFailed: CheckShardSync,
+		},
+		{
+			edit:      func(b *testBackend) { b.syncing[0] = true },
+			config:    testConfig,
+			expReady:  false,
+			expFailed: CheckBeaconSync,
+		},
+		{
+			edit:      func(b *testBackend) { b.peers = 2 },
+			config:    testConfig,
+			expReady:  false,
+			expFailed: CheckPeers,
+		},
+		{
+			edit:      func(b *testBackend) { b.headTime = now.Add(-61 * time.Second) },
+			config:    testConfig,
+			expReady:  false,
+			expFailed: CheckHeadAge,
+		},
+		{
+			edit:      func(b *testBackend) { b.backlog = 11 },
+			config:    testConfig,
+			expReady:  false,
+			expFailed: CheckRPCBacklog,
+		},
+		{
+			// zero thresholds are not checked
+			edit: func(b *testBackend) {
+				b.peers, b.headTime, b.backlog = 0, now.Add(-time.Hour), 1000
+			},
+			config:   nodeconfig.HealthConfig{},
+			expReady: true,
+		},
+	}
+	for i, test := range tests {
+		b := healthy()
+		test.edit(b)
+		report := NewChecker(b, test.config).ready(now)
+		if report.Ready != test.expReady {
+			t.Errorf("Test %v: unexpected ready %v", i, report.Ready)
+		}
+		for name, check := range report.Checks {
+			if check.OK != (name != test.expFailed) {
+				t.Errorf("Test %v: unexpected check %v: %+v", i, name, check)
+			}
+		}
+	}
+}
+
+func TestChecker_BeaconShard(t *testing.T) {
+	b := &testBackend{syncing: map[uint32]bool{0: true}}
+	report := NewChecker(b, nodeconfig.HealthConfig{}).Ready()
+	if _, ok := report.Checks[CheckBeaconSync]; ok {
+		t.Errorf("unexpected beacon sync check on the beacon shard")
+	}
+	if report.Ready {
+		t.Errorf("unexpected ready while syncing")
+	}
+}
+
+func TestChecker_Handlers(t *testing.T) {
+	b := &testBackend{
+		shardID:  0,
+		syncing:  make(map[uint32]bool),
+		headTime: time.Now(),
+		bootTime: time.Now().Add(-time.Minute),
+	}
+	c := NewChecker(b, nodeconfig.HealthConfig{MinPeers: 1})
+	mux := http.NewServeMux()
+	for _, h := range c.Handlers() {
+		mux.HandleFunc(h.Path, h.Handler)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LivePath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected live status %v", rec.Code)
+	}
+	var live Liveness
+	if err := json.Unmarshal(rec.Body.Bytes(), &live); err != nil {
+		t.Fatal(err)
+	}
+	if !live.Alive || live.Uptime < 60 {
+		t.Errorf("unexpected liveness %+v", live)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected ready status %v", rec.Code)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Ready || report.Checks[CheckPeers].OK {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	b.peers = 1
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected ready status %v", rec.Code)
+	}
+}
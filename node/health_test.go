@@ -0,0 +1,71 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/harmony-one/harmony/api/service/legacysync"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/vm"
+	"github.com/harmony-one/harmony/internal/chain"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/params"
+	"github.com/pkg/errors"
+)
+
+type testChains map[uint32]*core.BlockChain
+
+func (c testChains) ShardChain(shardID uint32) (*core.BlockChain, error) {
+	bc, ok := c[shardID]
+	if !ok {
+		return nil, errors.Errorf("no chain of shard %v", shardID)
+	}
+	return bc, nil
+}
+
+func (c testChains) CloseShardChain(shardID uint32) error { return nil }
+func (c testChains) Close() error                         { return nil }
+
+func makeTestChain(t *testing.T, shardID uint32) *core.BlockChain {
+	db := rawdb.NewMemoryDatabase()
+	gspec := core.Genesis{
+		Config:  params.TestChainConfig,
+		Factory: blockfactory.ForTest,
+		ShardID: shardID,
+	}
+	gspec.MustCommit(db)
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, chain.Engine, vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bc
+}
+
+func TestHealthBackend_SyncStatusWithoutDownloader(t *testing.T) {
+	node := &Node{
+		NodeConfig: &nodeconfig.ConfigType{ShardID: 1, Downloader: false},
+		shardChains: testChains{
+			0: makeTestChain(t, 0),
+			1: makeTestChain(t, 1),
+		},
+	}
+	backend := healthBackend{node}
+
+	// legacy sync client not running
+	if syncing, target := backend.SyncStatus(1); syncing || target != 0 {
+		t.Errorf("unexpected shard sync status %v %v", syncing, target)
+	}
+	// legacy sync client running without any sync peer yet
+	node.stateSync = legacysync.CreateStateSync("127.0.0.1", "9000", [20]byte{}, false)
+	if syncing, _ := backend.SyncStatus(1); !syncing {
+		t.Errorf("shard sync without peers reported as synced")
+	}
+	if syncing, _ := backend.SyncStatus(0); syncing {
+		t.Errorf("beacon sync reported as syncing before started")
+	}
+	node.beaconSync = legacysync.CreateStateSync("127.0.0.1", "9000", [20]byte{}, false)
+	if syncing, _ := backend.SyncStatus(0); !syncing {
+		t.Errorf("beacon sync without peers reported as synced")
+	}
+}
@@ -0,0 +1,33 @@
+package msgrecord
+
+import (
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prom.PromRegistry().MustRegister(
+		recordCounter,
+		droppedRecordCounter,
+	)
+}
+
+var (
+	recordCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "msgrecord",
+			Name:      "records",
+			Help:      "number of p2p messages recorded",
+		},
+	)
+
+	droppedRecordCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "msgrecord",
+			Name:      "records_dropped",
+			Help:      "number of p2p messages dropped by the recorder as the queue is full",
+		},
+	)
+)
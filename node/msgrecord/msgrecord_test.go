@@ -0,0 +1,89 @@
+package msgrecord
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/crypto/bls"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msgrecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "msg.record")
+
+	var senderKey bls.SerializedPublicKey
+	senderKey[0] = 1
+	msgs := [][]byte{
+		makeTestConsensusMessage(t, msg_pb.MessageType_ANNOUNCE, 0, senderKey[:]),
+		makeTestConsensusMessage(t, msg_pb.MessageType_PREPARED, 1, senderKey[:]),
+		{byte(proto.Node), 0, 1, 2},
+		makeTestConsensusMessage(t, msg_pb.MessageType_COMMITTED, 0, nil),
+		{byte(proto.Consensus), 0xff},
+	}
+
+	r := NewRecorder(nodeconfig.MessageRecordConfig{File: file})
+	for _, msg := range msgs {
+		r.Record("topic", libp2p_peer.ID("from"), libp2p_peer.ID("peer"), msg)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	h := &testHandler{}
+	reader := NewReader(file)
+	defer reader.Close()
+	stats, err := Replay(context.Background(), h, 0, reader, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := ReplayStats{Records: 5, Replayed: 2, Skipped: 2, Failed: 1}
+	if stats != exp {
+		t.Errorf("unexpected stats %+v / %+v", stats, exp)
+	}
+	if len(h.types) != 2 || h.types[0] != msg_pb.MessageType_ANNOUNCE || h.types[1] != msg_pb.MessageType_COMMITTED {
+		t.Errorf("unexpected messages replayed %v", h.types)
+	}
+	if h.keys[0] != senderKey || h.keys[1] != (bls.SerializedPublicKey{}) {
+		t.Errorf("unexpected sender keys replayed")
+	}
+}
+
+func makeTestConsensusMessage(t *testing.T, typ msg_pb.MessageType, shardID uint32, senderKey []byte) []byte {
+	msg := &msg_pb.Message{
+		Type: typ,
+		Request: &msg_pb.Message_Consensus{
+			Consensus: &msg_pb.ConsensusRequest{
+				ShardId:      shardID,
+				SenderPubkey: senderKey,
+			},
+		},
+	}
+	b, err := protobuf.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proto.ConstructConsensusMessage(b)
+}
+
+type testHandler struct {
+	types []msg_pb.MessageType
+	keys  []bls.SerializedPublicKey
+}
+
+func (h *testHandler) HandleMessageUpdate(ctx context.Context, msg *msg_pb.Message, senderKey *bls.SerializedPublicKey) error {
+	h.types = append(h.types, msg.Type)
+	h.keys = append(h.keys, *senderKey)
+	return nil
+}
@@ -0,0 +1,115 @@
+// Package msgrecord records the consensus and node p2p messages received by a
+// node, and replays the recordings offline into a consensus instance.
+package msgrecord
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	"github.com/harmony-one/harmony/internal/utils"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/natefinch/lumberjack"
+)
+
+const (
+	// recordBuffer is the number of records queued for writing. Records are
+	// dropped when the queue is full, so that the pubsub handler is never blocked.
+	recordBuffer = 1024
+
+	defaultMaxSize = 100
+)
+
+// Record is a p2p message received by the node
+type Record struct {
+	Time  time.Time `json:"time"`
+	Topic string    `json:"topic"`
+	From  string    `json:"from"` // peer ID of the author of the message
+	Peer  string    `json:"peer"` // peer ID of the peer relaying the message
+	Data  []byte    `json:"data"` // message content, starting with the category
+}
+
+// Recorder writes the records as JSON lines to a file rotated by size
+type Recorder struct {
+	w       *lumberjack.Logger
+	recordC chan Record
+	closeC  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewRecorder creates a recorder writing to the file of the config, and starts
+// writing the records in the background
+func NewRecorder(config nodeconfig.MessageRecordConfig) *Recorder {
+	if config.MaxSize <= 0 {
+		config.MaxSize = defaultMaxSize
+	}
+	r := &Recorder{
+		w: &lumberjack.Logger{
+			Filename:   config.File,
+			MaxSize:    config.MaxSize,
+			MaxBackups: config.MaxBackups,
+		},
+		recordC: make(chan Record, recordBuffer),
+		closeC:  make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.loop()
+	return r
+}
+
+// Record queues the message for writing, or drops it if the queue is full
+func (r *Recorder) Record(topic string, from, peer libp2p_peer.ID, data []byte) {
+	rec := Record{
+		Time:  time.Now(),
+		Topic: topic,
+		From:  from.Pretty(),
+		Peer:  peer.Pretty(),
+		Data:  data,
+	}
+	select {
+	case r.recordC <- rec:
+	default:
+		droppedRecordCounter.Inc()
+	}
+}
+
+// Close writes the queued records and closes the file
+func (r *Recorder) Close() error {
+	close(r.closeC)
+	r.wg.Wait()
+	return r.w.Close()
+}
+
+func (r *Recorder) loop() {
+	defer r.wg.Done()
+	for {
+		select {
+		case rec := <-r.recordC:
+			r.write(rec)
+		case <-r.closeC:
+			for {
+				select {
+				case rec := <-r.recordC:
+					r.write(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) write(rec Record) {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		utils.Logger().Warn().Err(err).Msg("[msgrecord] failed to encode record")
+		return
+	}
+	// one write per record, so that a record is never split by the rotation
+	if _, err := r.w.Write(append(b, '\n')); err != nil {
+		utils.Logger().Warn().Err(err).Msg("[msgrecord] failed to write record")
+		return
+	}
+	recordCounter.Inc()
+}
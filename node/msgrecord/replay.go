@@ -0,0 +1,174 @@
+package msgrecord
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/harmony/api/proto"
+	msg_pb "github.com/harmony-one/harmony/api/proto/message"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/pkg/errors"
+)
+
+// Reader reads the records of the recording files in order
+type Reader struct {
+	files []string
+	f     *os.File
+	dec   *json.Decoder
+}
+
+// NewReader creates a reader of the recording files. The rotated files shall
+// be given in the order they were written.
+func NewReader(files ...string) *Reader {
+	return &Reader{files: files}
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (Record, error) {
+	for {
+		if r.dec == nil {
+			if len(r.files) == 0 {
+				return Record{}, io.EOF
+			}
+			f, err := os.Open(r.files[0])
+			if err != nil {
+				return Record{}, err
+			}
+			r.f, r.dec, r.files = f, json.NewDecoder(bufio.NewReader(f)), r.files[1:]
+		}
+		var rec Record
+		err := r.dec.Decode(&rec)
+		if err == io.EOF {
+			r.f.Close()
+			r.f, r.dec = nil, nil
+			continue
+		}
+		if err != nil {
+			return Record{}, errors.Wrapf(err, "cannot decode record of %v", r.f.Name())
+		}
+		return rec, nil
+	}
+}
+
+// Close closes the file being read
+func (r *Reader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
+
+// Handler handles the replayed consensus messages. It is implemented by
+// consensus.Consensus.
+type Handler interface {
+	HandleMessageUpdate(ctx context.Context, msg *msg_pb.Message, senderKey *bls.SerializedPublicKey) error
+}
+
+// ReplayStats is the number of records replayed, skipped and failed
+type ReplayStats struct {
+	Records  int // all the records read
+	Replayed int // consensus messages handled
+	Skipped  int // node messages and consensus messages of other shards
+	Failed   int // consensus messages failing to decode or be handled
+}
+
+// Replay feeds the consensus messages of the records in order into the handler
+// of the shard. Handling errors are logged and the replay goes on. With a
+// positive speed, the time between two records is kept, divided by the speed;
+// otherwise the records are replayed at once.
+func Replay(ctx context.Context, h Handler, shardID uint32, r *Reader, speed float64) (ReplayStats, error) {
+	var (
+		stats ReplayStats
+		last  time.Time
+	)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Records++
+
+		if speed > 0 && !last.IsZero() && rec.Time.After(last) {
+			select {
+			case <-time.After(time.Duration(float64(rec.Time.Sub(last)) / speed)):
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			}
+		}
+		last = rec.Time
+
+		msg, senderKey, err := parseConsensusRecord(rec, shardID)
+		if err != nil {
+			stats.Failed++
+			utils.Logger().Warn().Err(err).Time("time", rec.Time).Str("from", rec.From).
+				Msg("[msgrecord] invalid consensus message")
+			continue
+		}
+		if msg == nil {
+			stats.Skipped++
+			continue
+		}
+		if err := h.HandleMessageUpdate(ctx, msg, senderKey); err != nil {
+			stats.Failed++
+			utils.Logger().Warn().Err(err).Time("time", rec.Time).Str("from", rec.From).
+				Str("type", msg.Type.String()).Msg("[msgrecord] failed to handle consensus message")
+			continue
+		}
+		stats.Replayed++
+	}
+}
+
+// parseConsensusRecord returns the consensus message of the record and its
+// sender key, the same way as the node pubsub handler, or a nil message if
+// the record is not a consensus message of the shard. The sender key is empty
+// for the messages signed by multiple keys.
+func parseConsensusRecord(rec Record, shardID uint32) (*msg_pb.Message, *bls.SerializedPublicKey, error) {
+	category, err := proto.GetMessageCategory(rec.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if category != proto.Consensus {
+		return nil, nil, nil
+	}
+	payload, err := proto.GetConsensusMessagePayload(rec.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var msg msg_pb.Message
+	if err := protobuf.Unmarshal(payload, &msg); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	var senderKey []byte
+	if con := msg.GetConsensus(); con != nil {
+		if con.ShardId != shardID {
+			return nil, nil, nil
+		}
+		senderKey = con.SenderPubkey
+	} else if vc := msg.GetViewchange(); vc != nil {
+		if vc.ShardId != shardID {
+			return nil, nil, nil
+		}
+		senderKey = vc.SenderPubkey
+	} else {
+		return nil, nil, errors.New("no sender of consensus message")
+	}
+
+	serializedKey := bls.SerializedPublicKey{}
+	if len(senderKey) > 0 {
+		if len(senderKey) != bls.PublicKeySizeInBytes {
+			return nil, nil, errors.Errorf("invalid sender key size %v", len(senderKey))
+		}
+		copy(serializedKey[:], senderKey)
+	}
+	return &msg, &serializedKey, nil
+}
@@ -24,12 +24,14 @@ import (
 	"github.com/harmony-one/harmony/core/rawdb"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/hmy"
 	"github.com/harmony-one/harmony/internal/chain"
 	common2 "github.com/harmony-one/harmony/internal/common"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/params"
 	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/harmony-one/harmony/node/msgrecord"
 	"github.com/harmony-one/harmony/node/worker"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/shard"
@@ -85,9 +87,12 @@ type Node struct {
 	// BeaconNeighbors store only neighbor nodes in the beacon chain shard
 	BeaconNeighbors      sync.Map // All the neighbor nodes, key is the sha256 of Peer IP/Port, value is the p2p.Peer
 	TxPool               *core.TxPool
-	CxPool               *core.CxPool // pool for missing cross shard receipts resend
+	CxPool               *core.CxPool    // pool for missing cross shard receipts resend
+	CxTracker            *core.CxTracker // tracker of the delivery of the outgoing cross shard receipts
 	Worker, BeaconWorker *worker.Worker
 	downloaderServer     *downloader.Server
+	// The services backing the RPC and rosetta servers, stopped with their servers
+	rpcHarmony, rosettaHarmony *hmy.Harmony
 	// Syncing component.
 	syncID                 [SyncIDLength]byte // a unique ID for the node during the state syncing process with peers
 	stateSync, beaconSync  *legacysync.StateSync
@@ -128,6 +133,9 @@ type Node struct {
 	// context control for pub-sub handling
 	psCtx    context.Context
 	psCancel func()
+	// recorder of the received consensus and node messages, nil if disabled
+	recorder     *msgrecord.Recorder
+	recorderLock sync.RWMutex // guards recorder, read by the pubsub validators
 }
 
 // Blockchain returns the blockchain for the node's current shard.
@@ -369,6 +377,7 @@ var (
 	errIgnoreBeaconMsg   = errors.New("ignore beacon sync block")
 	errInvalidEpoch      = errors.New("invalid epoch for transaction")
 	errInvalidShard      = errors.New("invalid shard")
+	errUndecodableMsg    = errors.New("cannot decode message")
 )
 
 // validateNodeMessage validate node message
@@ -440,7 +449,7 @@ func (node *Node) validateShardBoundMessage(
 	)
 	if err := protobuf.Unmarshal(payload, &m); err != nil {
 		nodeConsensusMessageCounterVec.With(prometheus.Labels{"type": "invalid_unmarshal"}).Inc()
-		return nil, nil, true, errors.WithStack(err)
+		return nil, nil, true, errors.Wrap(errUndecodableMsg, err.Error())
 	}
 
 	// ignore messages not intended for explorer
@@ -551,9 +560,42 @@ var (
 	errConsensusMessageOnUnexpectedTopic = errors.New("received consensus on wrong topic")
 )
 
+// reportPeerError records the validation error of a message in the
+// reputation of the peer which relayed it
+func (node *Node) reportPeerError(peer libp2p_peer.ID, err error) {
+	if behavior, ok := peerErrorBehavior(err); ok {
+		node.host.ReportPeer(peer, behavior)
+	}
+}
+
+// peerErrorBehavior returns the behavior of a peer relaying a message failing
+// the validation with err, false if it is not held against the peer
+func peerErrorBehavior(err error) (p2p.PeerBehavior, bool) {
+	switch errors.Cause(err) {
+	case core.ErrOversizedData:
+		return p2p.OversizedMessage, true
+	case errWrongShardID:
+		return p2p.WrongShardMessage, true
+	case errUndecodableMsg:
+		return p2p.UndecodableMessage, true
+	case errWrongSizeOfBitmap:
+		// the bitmap of the previous committee, around a committee change
+		return p2p.StaleMessage, true
+	case shard.ErrValidNotInCommittee:
+		// the committee may just have changed, not held against the peer
+		return 0, false
+	}
+	return p2p.InvalidMessage, true
+}
+
 // StartPubSub kicks off the node message handling
 func (node *Node) StartPubSub() error {
 	node.psCtx, node.psCancel = context.WithCancel(context.Background())
+	if cfg := node.NodeConfig.MessageRecord; cfg.File != "" {
+		node.recorderLock.Lock()
+		node.recorder = msgrecord.NewRecorder(cfg)
+		node.recorderLock.Unlock()
+	}
 
 	// groupID and whether this topic is used for consensus
 	type t struct {
@@ -653,12 +695,13 @@ func (node *Node) StartPubSub() error {
 
 				// first to validate the size of the p2p message
 				if len(hmyMsg) < p2pMsgPrefixSize {
-					// TODO (lc): block peers sending empty messages
 					nodeP2PMessageCounterVec.With(prometheus.Labels{"type": "invalid_size"}).Inc()
+					node.host.ReportPeer(peer, p2p.InvalidMessage)
 					return libp2p_pubsub.ValidationReject
 				}
 
 				openBox := hmyMsg[p2pMsgPrefixSize:]
+				node.recordMessage(topicNamed, msg.GetFrom(), peer, openBox)
 
 				// validate message category
 				switch proto.MessageCategory(openBox[proto.MessageCategoryBytes-1]) {
@@ -666,6 +709,7 @@ func (node *Node) StartPubSub() error {
 					// received consensus message in non-consensus bound topic
 					if !isConsensusBound {
 						nodeP2PMessageCounterVec.With(prometheus.Labels{"type": "invalid_bound"}).Inc()
+						node.host.ReportPeer(peer, p2p.WrongTopicMessage)
 						errChan <- withError{
 							errors.WithStack(errConsensusMessageOnUnexpectedTopic), msg,
 						}
@@ -679,6 +723,7 @@ func (node *Node) StartPubSub() error {
 					)
 
 					if err != nil {
+						node.reportPeerError(peer, err)
 						errChan <- withError{err, msg.GetFrom()}
 						return libp2p_pubsub.ValidationReject
 					}
@@ -687,6 +732,11 @@ func (node *Node) StartPubSub() error {
 					if ignore {
 						return libp2p_pubsub.ValidationAccept
 					}
+					node.host.ReportPeer(peer, p2p.ValidMessage)
+					// keep the connection with the committee member sending it
+					if *senderPubKey != (bls.SerializedPublicKey{}) {
+						node.host.ProtectPeer(msg.GetFrom(), p2p.ConsensusPeerTag, p2p.ConsensusPeerTTL)
+					}
 
 					msg.ValidatorData = validated{
 						consensusBound: true,
@@ -700,6 +750,7 @@ func (node *Node) StartPubSub() error {
 					// node message is almost empty
 					if len(openBox) <= p2pNodeMsgPrefixSize {
 						nodeP2PMessageCounterVec.With(prometheus.Labels{"type": "invalid_size"}).Inc()
+						node.host.ReportPeer(peer, p2p.InvalidMessage)
 						return libp2p_pubsub.ValidationReject
 					}
 					nodeP2PMessageCounterVec.With(prometheus.Labels{"type": "node_total"}).Inc()
@@ -713,11 +764,12 @@ func (node *Node) StartPubSub() error {
 							// but propogate the messages to other nodes
 							return libp2p_pubsub.ValidationAccept
 						default:
-							// TODO (lc): block peers sending error messages
+							node.reportPeerError(peer, err)
 							errChan <- withError{err, msg.GetFrom()}
 							return libp2p_pubsub.ValidationReject
 						}
 					}
+					node.host.ReportPeer(peer, p2p.ValidMessage)
 					msg.ValidatorData = validated{
 						consensusBound: false,
 						handleE:        node.HandleNodeMessage,
@@ -728,6 +780,7 @@ func (node *Node) StartPubSub() error {
 				default:
 					// ignore garbled messages
 					nodeP2PMessageCounterVec.With(prometheus.Labels{"type": "ignored"}).Inc()
+					node.host.ReportPeer(peer, p2p.InvalidMessage)
 					return libp2p_pubsub.ValidationReject
 				}
 				select {
@@ -891,11 +944,38 @@ func (node *Node) StartPubSub() error {
 	return nil
 }
 
+// recordMessage records the consensus and node messages if the recorder is enabled
+func (node *Node) recordMessage(topic string, from, peer libp2p_peer.ID, openBox []byte) {
+	if len(openBox) < proto.MessageCategoryBytes {
+		return
+	}
+	switch proto.MessageCategory(openBox[proto.MessageCategoryBytes-1]) {
+	case proto.Consensus, proto.Node:
+	default:
+		return
+	}
+	node.recorderLock.RLock()
+	defer node.recorderLock.RUnlock()
+	if node.recorder != nil {
+		node.recorder.Record(topic, from, peer, openBox)
+	}
+}
+
 // StopPubSub stops the pubsub handling
 func (node *Node) StopPubSub() {
 	if node.psCancel != nil {
 		node.psCancel()
 	}
+	// the validators may still run, the recorder is detached from them first
+	node.recorderLock.Lock()
+	recorder := node.recorder
+	node.recorder = nil
+	node.recorderLock.Unlock()
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			utils.Logger().Warn().Err(err).Msg("failed to close message recorder")
+		}
+	}
 }
 
 // GetSyncID returns the syncID of this node
@@ -971,6 +1051,8 @@ func New(
 		txPoolConfig.Journal = fmt.Sprintf("%v/%v", node.NodeConfig.DBDir, txPoolConfig.Journal)
 		node.TxPool = core.NewTxPool(txPoolConfig, node.Blockchain().Config(), blockchain, node.TransactionErrorSink)
 		node.CxPool = core.NewCxPool(core.CxPoolSize)
+		node.CxTracker = core.NewCxTracker(blockchain, beaconChain, node.CxPool)
+		go node.trackCXReceipts()
 		node.Worker = worker.New(node.Blockchain().Config(), blockchain, chain.Engine)
 
 		node.deciderCache, _ = lru.New(16)
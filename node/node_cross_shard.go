@@ -116,6 +116,25 @@ func (node *Node) BroadcastMissingCXReceipts() {
 	}
 }
 
+// trackCXReceipts tracks the delivery of the outgoing cross shard receipts of
+// the new blocks of the chain, the undelivered receipts being resent by the
+// next BroadcastMissingCXReceipts
+func (node *Node) trackCXReceipts() {
+	chainCh := make(chan core.ChainEvent, 16)
+	sub := node.Blockchain().SubscribeChainEvent(chainCh)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			node.CxTracker.Track(ev.Block)
+			node.CxTracker.Update()
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
 var (
 	errDoubleSpent = errors.New("[verifyIncomingReceipts] Double Spent")
 )
@@ -166,7 +166,7 @@ func (node *Node) commitBlockForExplorer(block *types.Block) {
 func (node *Node) GetTransactionsHistory(address, txType, order string) ([]common.Hash, error) {
 	addressData := &explorer.Address{}
 	key := explorer.GetAddressKey(address)
-	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key), nil)
+	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key))
 	if err != nil {
 		utils.Logger().Debug().Err(err).
 			Msgf("[Explorer] Error retrieving transaction history for address %s", address)
@@ -199,7 +199,7 @@ func (node *Node) GetTransactionsHistory(address, txType, order string) ([]commo
 func (node *Node) GetStakingTransactionsHistory(address, txType, order string) ([]common.Hash, error) {
 	addressData := &explorer.Address{}
 	key := explorer.GetAddressKey(address)
-	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key), nil)
+	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key))
 	if err != nil {
 		utils.Logger().Debug().Err(err).
 			Msgf("[Explorer] Staking transaction history for address %s not found", address)
@@ -232,7 +232,7 @@ func (node *Node) GetStakingTransactionsHistory(address, txType, order string) (
 func (node *Node) GetTransactionsCount(address, txType string) (uint64, error) {
 	addressData := &explorer.Address{}
 	key := explorer.GetAddressKey(address)
-	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key), nil)
+	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key))
 	if err != nil {
 		utils.Logger().Error().Err(err).Str("addr", address).Msg("[Explorer] Address not found")
 		return 0, nil
@@ -255,7 +255,7 @@ func (node *Node) GetTransactionsCount(address, txType string) (uint64, error) {
 func (node *Node) GetStakingTransactionsCount(address, txType string) (uint64, error) {
 	addressData := &explorer.Address{}
 	key := explorer.GetAddressKey(address)
-	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key), nil)
+	bytes, err := explorer.GetStorageInstance(node.SelfPeer.IP, node.SelfPeer.Port).GetDB().Get([]byte(key))
 	if err != nil {
 		utils.Logger().Error().Err(err).Str("addr", address).Msg("[Explorer] Address not found")
 		return 0, nil
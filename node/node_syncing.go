@@ -298,9 +298,9 @@ func (node *Node) doSync(bc *core.BlockChain, worker *worker.Worker, willJoinCon
 }
 
 // SupportGRPCSyncServer do gRPC sync server
-func (node *Node) SupportGRPCSyncServer() {
+func (node *Node) SupportGRPCSyncServer() error {
 	node.InitSyncingServer()
-	node.StartSyncingServer()
+	return node.StartSyncingServer()
 }
 
 // StartGRPCSyncClient start the legacy gRPC sync process
@@ -340,16 +340,19 @@ func (node *Node) supportSyncing() {
 // InitSyncingServer starts downloader server.
 func (node *Node) InitSyncingServer() {
 	if node.downloaderServer == nil {
-		node.downloaderServer = legdownloader.NewServer(node)
+		node.downloaderServer = legdownloader.NewServer(node, node.NodeConfig.LegacySync, node.NodeConfig.P2PPriKey)
 	}
 }
 
 // StartSyncingServer starts syncing server.
-func (node *Node) StartSyncingServer() {
+func (node *Node) StartSyncingServer() error {
 	utils.Logger().Info().Msg("[SYNC] support_syncing: StartSyncingServer")
 	if node.downloaderServer.GrpcServer == nil {
-		node.downloaderServer.Start(node.SelfPeer.IP, legacysync.GetSyncingPort(node.SelfPeer.Port))
+		if _, err := node.downloaderServer.Start(node.SelfPeer.IP, legacysync.GetSyncingPort(node.SelfPeer.Port)); err != nil {
+			return errors.Wrap(err, "cannot start syncing server")
+		}
 	}
+	return nil
 }
 
 // SendNewBlockToUnsync send latest verified block to unsync, registered nodes
@@ -577,6 +580,28 @@ func (node *Node) SyncStatus(shardID uint32) (bool, uint64) {
 	return ds.SyncStatus(shardID)
 }
 
+// legacySyncStatus returns the syncing status of the legacy gRPC sync of the
+// given shard, for the nodes running without the stream downloader
+func (node *Node) legacySyncStatus(shardID uint32) (bool, uint64) {
+	var (
+		ss *legacysync.StateSync
+		bc *core.BlockChain
+	)
+	switch shardID {
+	case node.Blockchain().ShardID():
+		ss, bc = node.stateSync, node.Blockchain()
+	case shard.BeaconChainShardID:
+		ss, bc = node.beaconSync, node.Beaconchain()
+	default:
+		return false, 0
+	}
+	if ss == nil {
+		// legacy sync client is not running, nothing to sync to
+		return false, bc.CurrentBlock().NumberU64()
+	}
+	return ss.SyncStatus(bc)
+}
+
 // IsOutOfSync return whether the node is out of sync of the given hsardID
 func (node *Node) IsOutOfSync(shardID uint32) bool {
 	ds := node.getDownloaders()
@@ -2,15 +2,22 @@ package node
 
 import (
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
+	"github.com/harmony-one/harmony/api/proto"
 	"github.com/harmony-one/harmony/consensus"
 	"github.com/harmony-one/harmony/consensus/quorum"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/crypto/bls"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/shardchain"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/multibls"
+	"github.com/harmony-one/harmony/node/msgrecord"
 	"github.com/harmony-one/harmony/p2p"
 	"github.com/harmony-one/harmony/shard"
 	"github.com/stretchr/testify/assert"
@@ -230,3 +237,52 @@ func TestAddBeaconPeer(t *testing.T) {
 		}
 	}
 }
+
+func TestPeerErrorBehavior(t *testing.T) {
+	tests := []struct {
+		err      error
+		behavior p2p.PeerBehavior
+		reported bool
+	}{
+		{core.ErrOversizedData, p2p.OversizedMessage, true},
+		{errWrongShardID, p2p.WrongShardMessage, true},
+		{errUndecodableMsg, p2p.UndecodableMessage, true},
+		{errWrongSizeOfBitmap, p2p.StaleMessage, true},
+		{errNotRightKeySize, p2p.InvalidMessage, true},
+		{shard.ErrValidNotInCommittee, 0, false},
+	}
+	for i, test := range tests {
+		behavior, reported := peerErrorBehavior(test.err)
+		if reported != test.reported || (reported && behavior != test.behavior) {
+			t.Errorf("test %d: unexpected behavior %v for %v", i, behavior, test.err)
+		}
+	}
+}
+
+func TestStopPubSubRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	node := &Node{}
+	node.recorder = msgrecord.NewRecorder(nodeconfig.MessageRecordConfig{
+		File: filepath.Join(dir, "messages.log"),
+	})
+	msg := []byte{byte(proto.Consensus), 1, 2, 3}
+
+	// the validators keep recording while the pubsub stops
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			node.recordMessage("topic", "from", "peer", msg)
+		}
+	}()
+	node.StopPubSub()
+	wg.Wait()
+	if node.recorder != nil {
+		t.Error("recorder not detached")
+	}
+}
@@ -0,0 +1,92 @@
+package p2p
+
+import (
+	"net"
+	"strconv"
+
+	p2ptypes "github.com/harmony-one/harmony/p2p/types"
+	ma "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/pkg/errors"
+)
+
+// listenAddrs returns the multiaddrs the host listens on, the configured ones
+// or the TCP multiaddr of the IP and port of the peer
+func listenAddrs(self *Peer, addrs []string) ([]ma.Multiaddr, error) {
+	if len(addrs) != 0 {
+		maddrs, err := p2ptypes.StringsToMultiAddrs(addrs)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid listen multiaddr")
+		}
+		for _, maddr := range maddrs {
+			if isQUICAddr(maddr) && !quicSupported {
+				return nil, errors.Errorf("cannot listen on %v: QUIC transport is not built in, build with the quic tag", maddr)
+			}
+		}
+		return maddrs, nil
+	}
+	ip := net.ParseIP(self.IP)
+	if ip == nil {
+		return nil, errors.Errorf("invalid listen ip %#v", self.IP)
+	}
+	port, err := strconv.Atoi(self.Port)
+	if err != nil {
+		return nil, errors.Wrapf(err,
+			"cannot create listen multiaddr from port %#v", self.Port)
+	}
+	maddr, err := manet.FromNetAddr(&net.TCPAddr{IP: ip, Port: port})
+	if err != nil {
+		return nil, errors.Wrapf(err,
+			"cannot create listen multiaddr from port %#v", self.Port)
+	}
+	return []ma.Multiaddr{maddr}, nil
+}
+
+// announceAddrsFactory returns the addrs factory of the host announcing the
+// configured multiaddrs in place of the listen multiaddrs, nil if there are none
+func announceAddrsFactory(addrs []string) (func([]ma.Multiaddr) []ma.Multiaddr, error) {
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+	maddrs, err := p2ptypes.StringsToMultiAddrs(addrs)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid announce multiaddr")
+	}
+	for _, maddr := range maddrs {
+		if isQUICAddr(maddr) && !quicSupported {
+			return nil, errors.Errorf("cannot announce %v: QUIC transport is not built in, build with the quic tag", maddr)
+		}
+	}
+	return func([]ma.Multiaddr) []ma.Multiaddr {
+		return maddrs
+	}, nil
+}
+
+// tcpAddr returns the IP and port of the first TCP multiaddr, IPv4 first
+func tcpAddr(maddrs []ma.Multiaddr) (string, string, bool) {
+	var found *net.TCPAddr
+	for _, maddr := range maddrs {
+		addr, err := manet.ToNetAddr(maddr)
+		if err != nil {
+			continue
+		}
+		tcp, ok := addr.(*net.TCPAddr)
+		if !ok {
+			continue
+		}
+		if found == nil || (found.IP.To4() == nil && tcp.IP.To4() != nil) {
+			found = tcp
+		}
+	}
+	if found == nil {
+		return "", "", false
+	}
+	_, port, _ := net.SplitHostPort(found.String())
+	return found.IP.String(), port, true
+}
+
+// isQUICAddr returns whether the multiaddr is a QUIC multiaddr
+func isQUICAddr(maddr ma.Multiaddr) bool {
+	_, err := maddr.ValueForProtocol(ma.P_QUIC)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package p2p
+
+import (
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestListenAddrs(t *testing.T) {
+	tests := []struct {
+		self    Peer
+		addrs   []string
+		expAddr []string
+		expErr  bool
+	}{
+		{
+			self:    Peer{IP: "0.0.0.0", Port: "9000"},
+			expAddr: []string{"/ip4/0.0.0.0/tcp/9000"},
+		},
+		{
+			self:    Peer{IP: "::", Port: "9000"},
+			expAddr: []string{"/ip6/::/tcp/9000"},
+		},
+		{
+			self:    Peer{IP: "0.0.0.0", Port: "9000"},
+			addrs:   []string{"/ip6/::1/tcp/9100", "/ip4/127.0.0.1/tcp/9200"},
+			expAddr: []string{"/ip6/::1/tcp/9100", "/ip4/127.0.0.1/tcp/9200"},
+		},
+		{
+			self:   Peer{IP: "0.0.0.0", Port: "port"},
+			expErr: true,
+		},
+		{
+			self:   Peer{IP: "0.0.0.0", Port: "9000"},
+			addrs:  []string{"invalid"},
+			expErr: true,
+		},
+	}
+	for i, test := range tests {
+		addrs, err := listenAddrs(&test.self, test.addrs)
+		if (err != nil) != test.expErr {
+			t.Fatalf("Test %v: unexpected error %v", i, err)
+		}
+		if len(addrs) != len(test.expAddr) {
+			t.Fatalf("Test %v: unexpected addrs %v", i, addrs)
+		}
+		for j, addr := range addrs {
+			if addr.String() != test.expAddr[j] {
+				t.Errorf("Test %v: unexpected addr %v / %v", i, addr, test.expAddr[j])
+			}
+		}
+	}
+}
+
+func TestQUICAddrs(t *testing.T) {
+	quic := []string{"/ip4/0.0.0.0/udp/9000/quic"}
+	if _, err := listenAddrs(&Peer{}, quic); (err == nil) != quicSupported {
+		t.Errorf("unexpected error listening on QUIC with quic support %v: %v", quicSupported, err)
+	}
+	if _, err := announceAddrsFactory(quic); (err == nil) != quicSupported {
+		t.Errorf("unexpected error announcing QUIC with quic support %v: %v", quicSupported, err)
+	}
+}
+
+func TestTCPAddr(t *testing.T) {
+	addrs := []ma.Multiaddr{
+		ma.StringCast("/ip4/0.0.0.0/udp/9000/quic"),
+		ma.StringCast("/ip6/::/tcp/9100"),
+		ma.StringCast("/ip4/0.0.0.0/tcp/9000"),
+	}
+	ip, port, ok := tcpAddr(addrs)
+	if !ok || ip != "0.0.0.0" || port != "9000" {
+		t.Errorf("unexpected tcp addr %v %v %v", ip, port, ok)
+	}
+	ip, port, ok = tcpAddr(addrs[:2])
+	if !ok || ip != "::" || port != "9100" {
+		t.Errorf("unexpected tcp addr %v %v %v", ip, port, ok)
+	}
+	if _, _, ok = tcpAddr(addrs[:1]); ok {
+		t.Errorf("unexpected tcp addr of a QUIC multiaddr")
+	}
+}
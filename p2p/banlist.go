@@ -0,0 +1,124 @@
+package p2p
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+)
+
+// BannedPeer is a peer banned by the operator
+type BannedPeer struct {
+	PeerID libp2p_peer.ID `json:"peerid"`
+	Reason string         `json:"reason"`
+	Time   int64          `json:"time"` // Unix time of the ban
+}
+
+// BanList is the list of the banned peers, persisted to a file across
+// restarts. The peers are banned until they are unbanned.
+type BanList struct {
+	file string
+
+	lock  sync.RWMutex
+	peers map[libp2p_peer.ID]BannedPeer
+}
+
+// NewBanList loads the ban list persisted to file. The list is only kept in
+// memory if file is empty.
+func NewBanList(file string) (*BanList, error) {
+	bl := &BanList{
+		file:  file,
+		peers: make(map[libp2p_peer.ID]BannedPeer),
+	}
+	if file == "" {
+		return bl, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return bl, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read ban list %s", file)
+	}
+	var peers []BannedPeer
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return nil, errors.Wrapf(err, "cannot decode ban list %s", file)
+	}
+	for _, peer := range peers {
+		bl.peers[peer.PeerID] = peer
+	}
+	return bl, nil
+}
+
+// Ban bans the peer
+func (bl *BanList) Ban(id libp2p_peer.ID, reason string) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	bl.peers[id] = BannedPeer{PeerID: id, Reason: reason, Time: time.Now().Unix()}
+	return bl.save()
+}
+
+// Unban lifts the ban of the peer
+func (bl *BanList) Unban(id libp2p_peer.ID) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if _, ok := bl.peers[id]; !ok {
+		return errors.Errorf("peer %s is not banned", id.Pretty())
+	}
+	delete(bl.peers, id)
+	return bl.save()
+}
+
+// IsBanned returns whether the peer is banned
+func (bl *BanList) IsBanned(id libp2p_peer.ID) bool {
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	_, ok := bl.peers[id]
+	return ok
+}
+
+// List returns the banned peers, latest ban first
+func (bl *BanList) List() []BannedPeer {
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	return bl.list()
+}
+
+func (bl *BanList) list() []BannedPeer {
+	peers := make([]BannedPeer, 0, len(bl.peers))
+	for _, peer := range bl.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Time != peers[j].Time {
+			return peers[i].Time > peers[j].Time
+		}
+		return peers[i].PeerID < peers[j].PeerID
+	})
+	return peers
+}
+
+// save writes the ban list to its file, replacing it atomically
+func (bl *BanList) save() error {
+	if bl.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(bl.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := bl.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrapf(err, "cannot write ban list %s", tmp)
+	}
+	return os.Rename(tmp, bl.file)
+}
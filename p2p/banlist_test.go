@@ -0,0 +1,77 @@
+package p2p
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestBanListPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "harmony-banlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "banlist.json")
+
+	bl, err := NewBanList(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer1, err := libp2p_peer.Decode("QmbPVwrqWsTYXq1RxGWcxx9SWaTUCfoo1wA6wmdbduWe29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer2, err := libp2p_peer.Decode("Qmdfjtk6hPoyrH1zVD9PEH4zfWLo38dP2mDvvKXfh3tnEv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.Ban(peer1, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.Ban(peer2, "invalid blocks"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.Unban(peer2); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.Unban(peer2); err == nil {
+		t.Errorf("expect error unbanning a peer not banned")
+	}
+
+	loaded, err := NewBanList(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.IsBanned(peer1) {
+		t.Errorf("peer1 not banned after reload")
+	}
+	if loaded.IsBanned(peer2) {
+		t.Errorf("peer2 banned after reload")
+	}
+	peers := loaded.List()
+	if len(peers) != 1 || peers[0].Reason != "spam" {
+		t.Errorf("unexpected banned peers %+v", peers)
+	}
+}
+
+func TestPeerGater(t *testing.T) {
+	bl, err := NewBanList("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gater := &peerGater{bans: bl}
+	id := libp2p_peer.ID("peer")
+	if !gater.InterceptPeerDial(id) {
+		t.Errorf("peer not banned is gated")
+	}
+	if err := bl.Ban(id, ""); err != nil {
+		t.Fatal(err)
+	}
+	if gater.InterceptPeerDial(id) || gater.InterceptAddrDial(id, nil) || gater.InterceptSecured(0, id, nil) {
+		t.Errorf("banned peer is not gated")
+	}
+}
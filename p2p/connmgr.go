@@ -0,0 +1,386 @@
+package p2p
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	libp2p_connmgr "github.com/libp2p/go-libp2p-core/connmgr"
+	libp2p_network "github.com/libp2p/go-libp2p-core/network"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Default connection manager config, used for the fields not set
+const (
+	DefaultConnLowWater      = 160
+	DefaultConnHighWater     = 192
+	DefaultConnGracePeriod   = time.Minute
+	DefaultConnSilencePeriod = 10 * time.Second
+)
+
+const (
+	// connMetricsInterval is the interval the per-protocol stream counts are reported
+	connMetricsInterval = 10 * time.Second
+
+	// ConsensusPeerTag is the protection tag of the peers of the committee
+	ConsensusPeerTag = "consensus"
+	// ConsensusPeerTTL is the time a committee peer is protected after its
+	// last consensus message
+	ConsensusPeerTTL = 10 * time.Minute
+)
+
+// ConnManagerConfig is the config of the connection manager
+type ConnManagerConfig struct {
+	// LowWater is the number of connections the connections are trimmed down to
+	LowWater int
+	// HighWater is the number of connections above which they are trimmed
+	HighWater int
+	// GracePeriod is the time the new connections are not trimmed for
+	GracePeriod time.Duration
+	// SilencePeriod is the minimum time between two trims
+	SilencePeriod time.Duration
+}
+
+type connPeer struct {
+	firstSeen time.Time
+	value     int
+	tags      map[string]int
+	conns     map[libp2p_network.Conn]time.Time
+}
+
+// connManager is the connection manager of the host. It trims the
+// connections down to the low watermark once they exceed the high watermark,
+// closing the connections of the peers of lowest value first. The new
+// connections and the protected peers are never trimmed.
+type connManager struct {
+	cfg ConnManagerConfig
+
+	lock      sync.Mutex
+	peers     map[libp2p_peer.ID]*connPeer
+	protected map[libp2p_peer.ID]map[string]time.Time // Expiry of the protections, zero for never
+	numConns  int
+	lastTrim  time.Time
+
+	trimCh chan struct{}
+	ctx    context.Context
+	cancel func()
+}
+
+func newConnManager(cfg ConnManagerConfig) *connManager {
+	if cfg.HighWater <= 0 {
+		cfg.HighWater = DefaultConnHighWater
+	}
+	if cfg.LowWater <= 0 || cfg.LowWater > cfg.HighWater {
+		cfg.LowWater = cfg.HighWater * DefaultConnLowWater / DefaultConnHighWater
+	}
+	if cfg.GracePeriod <= 0 {
+		cfg.GracePeriod = DefaultConnGracePeriod
+	}
+	if cfg.SilencePeriod <= 0 {
+		cfg.SilencePeriod = DefaultConnSilencePeriod
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cm := &connManager{
+		cfg:       cfg,
+		peers:     make(map[libp2p_peer.ID]*connPeer),
+		protected: make(map[libp2p_peer.ID]map[string]time.Time),
+		trimCh:    make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	go cm.loop()
+	return cm
+}
+
+func (cm *connManager) loop() {
+	ticker := time.NewTicker(connMetricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cm.trimCh:
+			cm.trim(false)
+		case <-ticker.C:
+			cm.reportMetrics()
+		case <-cm.ctx.Done():
+			return
+		}
+	}
+}
+
+// TrimOpenConns trims the connections down to the low watermark
+func (cm *connManager) TrimOpenConns(ctx context.Context) {
+	cm.trim(true)
+}
+
+func (cm *connManager) trim(force bool) {
+	cm.lock.Lock()
+	now := time.Now()
+	if !force && now.Sub(cm.lastTrim) < cm.cfg.SilencePeriod {
+		cm.lock.Unlock()
+		return
+	}
+	cm.lastTrim = now
+	conns := cm.connsToClose(now)
+	cm.lock.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	trimmedConnCounter.Add(float64(len(conns)))
+}
+
+// connsToClose returns the connections to close to get down to the low
+// watermark, of the unprotected peers out of their grace period by value
+func (cm *connManager) connsToClose(now time.Time) []libp2p_network.Conn {
+	if cm.numConns <= cm.cfg.LowWater {
+		return nil
+	}
+	candidates := make([]*connPeer, 0, len(cm.peers))
+	for id, p := range cm.peers {
+		if len(p.conns) == 0 || cm.isProtected(id, now) || now.Sub(p.firstSeen) < cm.cfg.GracePeriod {
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].value != candidates[j].value {
+			return candidates[i].value < candidates[j].value
+		}
+		return candidates[i].firstSeen.After(candidates[j].firstSeen)
+	})
+
+	target := cm.numConns - cm.cfg.LowWater
+	var conns []libp2p_network.Conn
+	for _, p := range candidates {
+		if len(conns) >= target {
+			break
+		}
+		for conn := range p.conns {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+func (cm *connManager) isProtected(id libp2p_peer.ID, now time.Time) bool {
+	for tag, until := range cm.protected[id] {
+		if until.IsZero() || now.Before(until) {
+			return true
+		}
+		delete(cm.protected[id], tag)
+	}
+	delete(cm.protected, id)
+	return false
+}
+
+func (cm *connManager) getPeer(id libp2p_peer.ID) *connPeer {
+	p, ok := cm.peers[id]
+	if !ok {
+		p = &connPeer{
+			firstSeen: time.Now(),
+			tags:      make(map[string]int),
+			conns:     make(map[libp2p_network.Conn]time.Time),
+		}
+		cm.peers[id] = p
+	}
+	return p
+}
+
+// TagPeer tags the connected peer with a value. The tags of a peer are
+// dropped along with its last connection.
+func (cm *connManager) TagPeer(id libp2p_peer.ID, tag string, value int) {
+	cm.UpsertTag(id, tag, func(int) int { return value })
+}
+
+// UntagPeer removes the tag of the peer
+func (cm *connManager) UntagPeer(id libp2p_peer.ID, tag string) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	if p, ok := cm.peers[id]; ok {
+		p.value -= p.tags[tag]
+		delete(p.tags, tag)
+	}
+}
+
+// UpsertTag updates the value of the tag of the connected peer
+func (cm *connManager) UpsertTag(id libp2p_peer.ID, tag string, upsert func(int) int) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	p, ok := cm.peers[id]
+	if !ok {
+		return
+	}
+	old := p.tags[tag]
+	p.tags[tag] = upsert(old)
+	p.value += p.tags[tag] - old
+}
+
+// GetTagInfo returns the tags and the connections of the peer
+func (cm *connManager) GetTagInfo(id libp2p_peer.ID) *libp2p_connmgr.TagInfo {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	p, ok := cm.peers[id]
+	if !ok {
+		return nil
+	}
+	info := &libp2p_connmgr.TagInfo{
+		FirstSeen: p.firstSeen,
+		Value:     p.value,
+		Tags:      make(map[string]int, len(p.tags)),
+		Conns:     make(map[string]time.Time, len(p.conns)),
+	}
+	for tag, value := range p.tags {
+		info.Tags[tag] = value
+	}
+	for conn, t := range p.conns {
+		info.Conns[conn.RemoteMultiaddr().String()] = t
+	}
+	return info
+}
+
+// Protect protects the peer from the trims until it is unprotected
+func (cm *connManager) Protect(id libp2p_peer.ID, tag string) {
+	cm.protect(id, tag, time.Time{})
+}
+
+// ProtectFor protects the peer from the trims for the ttl
+func (cm *connManager) ProtectFor(id libp2p_peer.ID, tag string, ttl time.Duration) {
+	cm.protect(id, tag, time.Now().Add(ttl))
+}
+
+func (cm *connManager) protect(id libp2p_peer.ID, tag string, until time.Time) {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	tags, ok := cm.protected[id]
+	if !ok {
+		tags = make(map[string]time.Time)
+		cm.protected[id] = tags
+	}
+	tags[tag] = until
+}
+
+// Unprotect removes the protection of the tag, and returns whether the peer
+// is still protected by other tags
+func (cm *connManager) Unprotect(id libp2p_peer.ID, tag string) bool {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	if tags, ok := cm.protected[id]; ok {
+		delete(tags, tag)
+	}
+	return cm.isProtected(id, time.Now())
+}
+
+// IsProtected returns whether the peer is protected by the tag, or by any
+// tag if tag is empty
+func (cm *connManager) IsProtected(id libp2p_peer.ID, tag string) bool {
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	if tag == "" {
+		return cm.isProtected(id, time.Now())
+	}
+	until, ok := cm.protected[id][tag]
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// Notifee returns the notifee tracking the connections of the network
+func (cm *connManager) Notifee() libp2p_network.Notifiee {
+	return (*connNotifee)(cm)
+}
+
+// Close stops the connection manager
+func (cm *connManager) Close() error {
+	cm.cancel()
+	return nil
+}
+
+// reportMetrics reports the number of connections, of connected and protected peers and the
+// number of streams of each protocol
+func (cm *connManager) reportMetrics() {
+	cm.lock.Lock()
+	now := time.Now()
+	protected := 0
+	for id := range cm.protected {
+		if cm.isProtected(id, now) {
+			protected++
+		}
+	}
+	numConns, numPeers := cm.numConns, len(cm.peers)
+	var conns []libp2p_network.Conn
+	for _, p := range cm.peers {
+		for conn := range p.conns {
+			conns = append(conns, conn)
+		}
+	}
+	cm.lock.Unlock()
+
+	streams := make(map[string]int)
+	for _, conn := range conns {
+		for _, st := range conn.GetStreams() {
+			streams[string(st.Protocol())]++
+		}
+	}
+	numConnsGauge.Set(float64(numConns))
+	connectedPeersGauge.Set(float64(numPeers))
+	protectedPeersGauge.Set(float64(protected))
+	numStreamsGaugeVec.Reset()
+	for proto, n := range streams {
+		numStreamsGaugeVec.WithLabelValues(proto).Set(float64(n))
+	}
+}
+
+// connNotifee tracks the connections for the connection manager
+type connNotifee connManager
+
+func (nn *connNotifee) cm() *connManager {
+	return (*connManager)(nn)
+}
+
+func (nn *connNotifee) Connected(_ libp2p_network.Network, conn libp2p_network.Conn) {
+	cm := nn.cm()
+	cm.lock.Lock()
+	p := cm.getPeer(conn.RemotePeer())
+	if _, ok := p.conns[conn]; !ok {
+		p.conns[conn] = time.Now()
+		cm.numConns++
+	}
+	trim := cm.numConns > cm.cfg.HighWater
+	cm.lock.Unlock()
+
+	if trim {
+		select {
+		case cm.trimCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (nn *connNotifee) Disconnected(_ libp2p_network.Network, conn libp2p_network.Conn) {
+	cm := nn.cm()
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+
+	p, ok := cm.peers[conn.RemotePeer()]
+	if !ok {
+		return
+	}
+	if _, ok := p.conns[conn]; ok {
+		delete(p.conns, conn)
+		cm.numConns--
+	}
+	if len(p.conns) == 0 {
+		delete(cm.peers, conn.RemotePeer())
+	}
+}
+
+func (nn *connNotifee) Listen(libp2p_network.Network, ma.Multiaddr)                {}
+func (nn *connNotifee) ListenClose(libp2p_network.Network, ma.Multiaddr)           {}
+func (nn *connNotifee) OpenedStream(libp2p_network.Network, libp2p_network.Stream) {}
+func (nn *connNotifee) ClosedStream(libp2p_network.Network, libp2p_network.Stream) {}
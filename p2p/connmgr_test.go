@@ -0,0 +1,114 @@
+package p2p
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	libp2p_network "github.com/libp2p/go-libp2p-core/network"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type testConn struct {
+	libp2p_network.Conn
+	peer   libp2p_peer.ID
+	closed bool
+}
+
+func (c *testConn) RemotePeer() libp2p_peer.ID { return c.peer }
+
+func (c *testConn) RemoteMultiaddr() ma.Multiaddr {
+	return ma.StringCast("/ip4/127.0.0.1/tcp/9000")
+}
+
+func (c *testConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestConnManager_Trim(t *testing.T) {
+	cm := newConnManager(ConnManagerConfig{
+		LowWater:    4,
+		HighWater:   6,
+		GracePeriod: time.Minute,
+	})
+	defer cm.Close()
+
+	var conns []*testConn
+	for i := 0; i != 8; i++ {
+		conn := &testConn{peer: libp2p_peer.ID(fmt.Sprintf("peer%d", i))}
+		conns = append(conns, conn)
+		cm.Notifee().Connected(nil, conn)
+	}
+	// out of grace period but the last peer
+	cm.lock.Lock()
+	for _, p := range cm.peers {
+		p.firstSeen = time.Now().Add(-time.Hour)
+	}
+	cm.peers["peer7"].firstSeen = time.Now()
+	cm.lock.Unlock()
+	cm.Protect("peer0", "static")
+	cm.ProtectFor("peer1", ConsensusPeerTag, time.Minute)
+	cm.ProtectFor("peer2", ConsensusPeerTag, -time.Minute) // expired
+	cm.TagPeer("peer3", "useful", 10)
+
+	cm.TrimOpenConns(nil)
+
+	closed := 0
+	for i, conn := range conns {
+		if !conn.closed {
+			continue
+		}
+		closed++
+		switch i {
+		case 0, 1, 3, 7:
+			t.Errorf("peer%d connection trimmed", i)
+		}
+	}
+	if closed != 4 {
+		t.Errorf("unexpected number of trimmed connections %v / %v", closed, 4)
+	}
+}
+
+func TestConnManager_Protect(t *testing.T) {
+	cm := newConnManager(ConnManagerConfig{})
+	defer cm.Close()
+
+	id := libp2p_peer.ID("peer")
+	cm.Protect(id, "static")
+	cm.ProtectFor(id, ConsensusPeerTag, time.Minute)
+	if !cm.IsProtected(id, ConsensusPeerTag) {
+		t.Errorf("peer not protected by consensus tag")
+	}
+	if !cm.Unprotect(id, "static") {
+		t.Errorf("peer not protected after removing one tag")
+	}
+	if cm.Unprotect(id, ConsensusPeerTag) {
+		t.Errorf("peer protected after removing all tags")
+	}
+	cm.ProtectFor(id, ConsensusPeerTag, -time.Second)
+	if cm.IsProtected(id, "") {
+		t.Errorf("peer protected after the protection expired")
+	}
+}
+
+func TestConnManager_Peers(t *testing.T) {
+	cm := newConnManager(ConnManagerConfig{})
+	defer cm.Close()
+
+	cm.TagPeer("peer0", "useful", 10)
+	if info := cm.GetTagInfo("peer0"); info != nil {
+		t.Errorf("unconnected peer tagged")
+	}
+	conn := &testConn{peer: "peer0"}
+	cm.Notifee().Connected(nil, conn)
+	cm.TagPeer("peer0", "useful", 10)
+	if info := cm.GetTagInfo("peer0"); info == nil || info.Value != 10 {
+		t.Errorf("unexpected tag info %v", info)
+	}
+	cm.Notifee().Disconnected(nil, conn)
+	if len(cm.peers) != 0 || cm.numConns != 0 {
+		t.Errorf("disconnected peer kept: %v peers %v conns", len(cm.peers), cm.numConns)
+	}
+}
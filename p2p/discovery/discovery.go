@@ -13,8 +13,12 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// dhtMetricsInterval is the interval the dht metrics are reported at
+const dhtMetricsInterval = 10 * time.Second
+
 // Discovery is the interface for the underlying peer discovery protocol.
-// The interface is implemented by dhtDiscovery
+// The interface is implemented by dhtDiscovery, staticDiscovery, dnsDiscovery
+// and multiDiscovery
 type Discovery interface {
 	Start() error
 	Close() error
@@ -63,6 +67,7 @@ func NewDHTDiscovery(host libp2p_host.Host, opt DHTConfig) (Discovery, error) {
 
 // Start bootstrap the dht discovery service.
 func (d *dhtDiscovery) Start() error {
+	go d.reportMetrics()
 	return d.dht.Bootstrap(d.ctx)
 }
 
@@ -72,6 +77,20 @@ func (d *dhtDiscovery) Close() error {
 	return nil
 }
 
+// reportMetrics reports the size of the routing table until the discovery is closed
+func (d *dhtDiscovery) reportMetrics() {
+	ticker := time.NewTicker(dhtMetricsInterval)
+	defer ticker.Stop()
+	for {
+		dhtTableSizeGauge.Set(float64(d.dht.RoutingTable().Size()))
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
 // Advertise advertises a service
 func (d *dhtDiscovery) Advertise(ctx context.Context, ns string) (time.Duration, error) {
 	return d.disc.Advertise(ctx, ns)
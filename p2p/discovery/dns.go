@@ -0,0 +1,210 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/libp2p/go-libp2p-core/discovery"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// defaultDNSSyncInterval is the interval the DNS trees are synced at
+	defaultDNSSyncInterval = 30 * time.Minute
+	// dnsAdvertiseTTL is the advertise interval returned by the DNS discovery.
+	// Peers are published to the tree by its operator, so it is long.
+	dnsAdvertiseTTL = time.Hour
+)
+
+// ENRLibp2p is the ENR entry holding the libp2p multiaddrs of a node, including
+// the peer ID. The harmony peer keys are not the secp256k1 ENR identity keys,
+// so the peer ID cannot be derived from the node record itself.
+type ENRLibp2p []string
+
+// ENRKey implements enr.Entry
+func (e ENRLibp2p) ENRKey() string {
+	return "libp2p"
+}
+
+// DNSConfig is the config of the DNS discovery
+type DNSConfig struct {
+	// URLs are the enrtree:// URLs of the signed DNS trees (EIP-1459)
+	URLs []string
+	// SyncInterval is the interval the trees are synced at
+	SyncInterval time.Duration
+	// Resolver overrides the system DNS resolver
+	Resolver dnsdisc.Resolver
+}
+
+// dnsDiscovery is a Discovery of the peers published in signed DNS trees.
+// Every namespace is served by all peers.
+type dnsDiscovery struct {
+	client *dnsdisc.Client
+	config DNSConfig
+
+	lock  sync.RWMutex
+	peers []libp2p_peer.AddrInfo
+
+	logger zerolog.Logger
+	ctx    context.Context
+	cancel func()
+}
+
+// NewDNSDiscovery creates a Discovery finding the peers of the DNS trees
+func NewDNSDiscovery(config DNSConfig) (Discovery, error) {
+	for _, url := range config.URLs {
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			return nil, errors.Wrapf(err, "invalid DNS tree url %v", url)
+		}
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = defaultDNSSyncInterval
+	}
+	client, err := dnsdisc.NewClient(dnsdisc.Config{Resolver: config.Resolver})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &dnsDiscovery{
+		client: client,
+		config: config,
+		logger: utils.Logger().With().Str("module", "dns discovery").Logger(),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Start syncs the DNS trees in the background
+func (d *dnsDiscovery) Start() error {
+	go d.syncLoop()
+	return nil
+}
+
+// Close stops syncing the DNS trees
+func (d *dnsDiscovery) Close() error {
+	d.cancel()
+	return nil
+}
+
+func (d *dnsDiscovery) syncLoop() {
+	d.sync()
+
+	ticker := time.NewTicker(d.config.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.sync()
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// sync downloads all the DNS trees. The peers of a tree failing to sync are
+// kept from the previous round.
+func (d *dnsDiscovery) sync() {
+	d.lock.RLock()
+	prev := make(map[libp2p_peer.ID]libp2p_peer.AddrInfo)
+	for _, peer := range d.peers {
+		prev[peer.ID] = peer
+	}
+	d.lock.RUnlock()
+
+	var (
+		peers   []libp2p_peer.AddrInfo
+		failed  bool
+		visited = make(map[libp2p_peer.ID]struct{})
+	)
+	for _, url := range d.config.URLs {
+		tree, err := d.client.SyncTree(url)
+		if err != nil {
+			d.logger.Warn().Err(err).Str("url", url).Msg("failed to sync DNS tree")
+			failed = true
+			continue
+		}
+		for _, peer := range nodesToAddrInfos(tree.Nodes(), d.logger) {
+			if _, ok := visited[peer.ID]; ok {
+				continue
+			}
+			visited[peer.ID] = struct{}{}
+			peers = append(peers, peer)
+		}
+	}
+	if failed {
+		for id, peer := range prev {
+			if _, ok := visited[id]; !ok {
+				peers = append(peers, peer)
+			}
+		}
+	}
+	d.logger.Info().Int("peers", len(peers)).Msg("synced DNS trees")
+
+	d.lock.Lock()
+	d.peers = peers
+	d.lock.Unlock()
+}
+
+// nodesToAddrInfos returns the libp2p peers of the nodes. Nodes without or with
+// an invalid libp2p entry are skipped.
+func nodesToAddrInfos(nodes []*enode.Node, logger zerolog.Logger) []libp2p_peer.AddrInfo {
+	var addrs []ma.Multiaddr
+	for _, node := range nodes {
+		var entry ENRLibp2p
+		if err := node.Load(&entry); err != nil {
+			logger.Debug().Err(err).Str("node", node.ID().String()).Msg("no libp2p entry in node record")
+			continue
+		}
+		for _, s := range entry {
+			addr, err := ma.NewMultiaddr(s)
+			if err == nil {
+				if _, id := libp2p_peer.SplitAddr(addr); id == "" {
+					err = libp2p_peer.ErrInvalidAddr
+				}
+			}
+			if err != nil {
+				logger.Debug().Err(err).Str("node", node.ID().String()).Msg("invalid libp2p multiaddr in node record")
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	// all addrs have a peer ID, so it never fails
+	peers, _ := libp2p_peer.AddrInfosFromP2pAddrs(addrs...)
+	return peers
+}
+
+// Advertise does nothing for the DNS discovery
+func (d *dnsDiscovery) Advertise(ctx context.Context, ns string) (time.Duration, error) {
+	return dnsAdvertiseTTL, nil
+}
+
+// FindPeers returns up to peerLimit peers of the DNS trees, all of them if
+// peerLimit is 0
+func (d *dnsDiscovery) FindPeers(ctx context.Context, ns string, peerLimit int) (<-chan libp2p_peer.AddrInfo, error) {
+	d.lock.RLock()
+	peers := d.peers
+	d.lock.RUnlock()
+
+	if peerLimit > 0 && len(peers) > peerLimit {
+		peers = peers[:peerLimit]
+	}
+	ch := make(chan libp2p_peer.AddrInfo, len(peers))
+	for _, peer := range peers {
+		ch <- peer
+	}
+	close(ch)
+	return ch, nil
+}
+
+// GetRawDiscovery returns the DNS discovery as a libp2p discovery
+func (d *dnsDiscovery) GetRawDiscovery() discovery.Discovery {
+	return rawDiscovery{d}
+}
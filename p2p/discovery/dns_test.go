@@ -0,0 +1,99 @@
+package discovery
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	libp2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestDNSDiscovery_FindPeers(t *testing.T) {
+	var (
+		nodes []*enode.Node
+		ids   = make(map[libp2p_peer.ID]struct{})
+	)
+	for i := 0; i != 3; i++ {
+		_, pub, _ := libp2p_crypto.GenerateECDSAKeyPair(rand.Reader)
+		id, _ := libp2p_peer.IDFromPublicKey(pub)
+		ids[id] = struct{}{}
+		nodes = append(nodes, makeTestENRNode(t, ENRLibp2p{fmt.Sprintf("/ip4/1.2.3.%v/tcp/9000/p2p/%v", i, id.Pretty())}))
+	}
+	// nodes without a valid libp2p entry are skipped
+	nodes = append(nodes, makeTestENRNode(t, nil))
+	nodes = append(nodes, makeTestENRNode(t, ENRLibp2p{"/ip4/1.2.3.4/tcp/9000"}))
+
+	tree, err := dnsdisc.MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	treeKey, _ := crypto.GenerateKey()
+	url, err := tree.Sign(treeKey, "nodes.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disc, err := NewDNSDiscovery(DNSConfig{
+		URLs:     []string{url},
+		Resolver: testResolver(tree.ToTXT("nodes.example.org")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	disc.(*dnsDiscovery).sync()
+
+	ch, err := disc.FindPeers(context.Background(), "ns", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for peer := range ch {
+		if _, ok := ids[peer.ID]; !ok {
+			t.Errorf("unexpected peer %v", peer.ID)
+		}
+		if len(peer.Addrs) != 1 {
+			t.Errorf("unexpected addrs %v", peer.Addrs)
+		}
+		found++
+	}
+	if found != len(ids) {
+		t.Errorf("found %v peers, expect %v", found, len(ids))
+	}
+}
+
+func TestNewDNSDiscovery_InvalidURL(t *testing.T) {
+	if _, err := NewDNSDiscovery(DNSConfig{URLs: []string{"enrtree://nodes.example.org"}}); err == nil {
+		t.Errorf("invalid url accepted")
+	}
+}
+
+func makeTestENRNode(t *testing.T, entry ENRLibp2p) *enode.Node {
+	key, _ := crypto.GenerateKey()
+	var r enr.Record
+	if entry != nil {
+		r.Set(entry)
+	}
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatal(err)
+	}
+	node, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+type testResolver map[string]string
+
+func (r testResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := r[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, fmt.Errorf("no TXT record for %v", name)
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/harmony-one/harmony/internal/utils"
+	p2ptypes "github.com/harmony-one/harmony/p2p/types"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+)
+
+// peersFile is a JSON file holding an array of peer multiaddrs. The file is
+// reloaded whenever it is modified.
+type peersFile struct {
+	path string
+
+	lock    sync.Mutex
+	modTime time.Time
+	peers   []libp2p_peer.AddrInfo
+}
+
+// NewFileDiscovery creates a Discovery finding the peers listed in the JSON
+// file at path, e.g. ["/ip4/1.2.3.4/tcp/9000/p2p/Qm..."]. Changes to the file
+// are picked up without a restart.
+func NewFileDiscovery(path string) (Discovery, error) {
+	pf := &peersFile{path: path}
+	if _, err := pf.load(); err != nil {
+		return nil, err
+	}
+	return NewStaticDiscovery(pf.addrInfos), nil
+}
+
+// addrInfos returns the peers of the file, reloading it if it is modified. The
+// previous peers are kept if the file cannot be loaded.
+func (pf *peersFile) addrInfos() []libp2p_peer.AddrInfo {
+	peers, err := pf.load()
+	if err != nil {
+		utils.Logger().Warn().Err(err).Str("file", pf.path).Msg("failed to reload peers file")
+	}
+	return peers
+}
+
+func (pf *peersFile) load() ([]libp2p_peer.AddrInfo, error) {
+	pf.lock.Lock()
+	defer pf.lock.Unlock()
+
+	info, err := os.Stat(pf.path)
+	if err != nil {
+		return pf.peers, errors.Wrap(err, "cannot stat peers file")
+	}
+	if info.ModTime().Equal(pf.modTime) {
+		return pf.peers, nil
+	}
+	b, err := ioutil.ReadFile(pf.path)
+	if err != nil {
+		return pf.peers, errors.Wrap(err, "cannot read peers file")
+	}
+	var addrs []string
+	if err := json.Unmarshal(b, &addrs); err != nil {
+		return pf.peers, errors.Wrap(err, "cannot decode peers file")
+	}
+	peers, err := p2ptypes.ResolveAndParseMultiAddrs(addrs)
+	if err != nil {
+		return pf.peers, errors.Wrap(err, "cannot parse peers file")
+	}
+	pf.peers, pf.modTime = peers, info.ModTime()
+	return pf.peers, nil
+}
@@ -0,0 +1,86 @@
+package discovery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	libp2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestFileDiscovery_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peers.json")
+
+	addrs := makeTestPeerAddrs(2)
+	writeTestPeersFile(t, path, addrs, time.Now().Add(-time.Minute))
+	disc, err := NewFileDiscovery(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found := countTestPeers(t, disc); found != 2 {
+		t.Errorf("found %v peers, expect %v", found, 2)
+	}
+
+	writeTestPeersFile(t, path, append(addrs, makeTestPeerAddrs(1)...), time.Now())
+	if found := countTestPeers(t, disc); found != 3 {
+		t.Errorf("found %v peers after reload, expect %v", found, 3)
+	}
+
+	// an invalid file keeps the previous peers
+	if err := ioutil.WriteFile(path, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if found := countTestPeers(t, disc); found != 3 {
+		t.Errorf("found %v peers after invalid reload, expect %v", found, 3)
+	}
+}
+
+func TestNewFileDiscovery_Missing(t *testing.T) {
+	if _, err := NewFileDiscovery(filepath.Join(os.TempDir(), "no-such-peers.json")); err == nil {
+		t.Errorf("missing file accepted")
+	}
+}
+
+func makeTestPeerAddrs(num int) []string {
+	var addrs []string
+	for i := 0; i != num; i++ {
+		_, pub, _ := libp2p_crypto.GenerateECDSAKeyPair(rand.Reader)
+		id, _ := libp2p_peer.IDFromPublicKey(pub)
+		addrs = append(addrs, "/ip4/127.0.0.1/tcp/9000/p2p/"+id.Pretty())
+	}
+	return addrs
+}
+
+func writeTestPeersFile(t *testing.T, path string, addrs []string, modTime time.Time) {
+	b, _ := json.Marshal(addrs)
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countTestPeers(t *testing.T, disc Discovery) int {
+	ch, err := disc.FindPeers(context.Background(), "ns", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for range ch {
+		found++
+	}
+	return found
+}
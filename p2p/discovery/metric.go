@@ -0,0 +1,23 @@
+package discovery
+
+import (
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prom.PromRegistry().MustRegister(
+		dhtTableSizeGauge,
+	)
+}
+
+var (
+	dhtTableSizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "discovery",
+			Name:      "dht_table_size",
+			Help:      "number of peers in the dht routing table",
+		},
+	)
+)
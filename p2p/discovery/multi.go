@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/harmony-one/harmony/internal/utils"
+	"github.com/libp2p/go-libp2p-core/discovery"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// multiDiscovery is a Discovery combining several discovery sources. Peers are
+// found from all the sources at once, and advertised to all of them.
+type multiDiscovery struct {
+	discs  []Discovery
+	logger zerolog.Logger
+}
+
+// NewMultiDiscovery creates a Discovery finding peers from all the given sources
+func NewMultiDiscovery(discs ...Discovery) Discovery {
+	if len(discs) == 1 {
+		return discs[0]
+	}
+	return &multiDiscovery{
+		discs:  discs,
+		logger: utils.Logger().With().Str("module", "discovery").Logger(),
+	}
+}
+
+// Start starts all the discovery sources
+func (d *multiDiscovery) Start() error {
+	for _, disc := range d.discs {
+		if err := disc.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all the discovery sources, and returns the first error
+func (d *multiDiscovery) Close() error {
+	var firstErr error
+	for _, disc := range d.discs {
+		if err := disc.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Advertise advertises the service to all sources, and returns the shortest
+// TTL. It fails only if all the sources fail.
+func (d *multiDiscovery) Advertise(ctx context.Context, ns string) (time.Duration, error) {
+	var (
+		ttl     time.Duration
+		lastErr error
+		success bool
+	)
+	for _, disc := range d.discs {
+		discTTL, err := disc.Advertise(ctx, ns)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !success || discTTL < ttl {
+			ttl = discTTL
+		}
+		success = true
+	}
+	if !success {
+		return 0, lastErr
+	}
+	return ttl, nil
+}
+
+// FindPeers finds up to peerLimit distinct peers from all the sources, all of
+// them if peerLimit is 0. It fails only if all the sources fail.
+func (d *multiDiscovery) FindPeers(ctx context.Context, ns string, peerLimit int) (<-chan libp2p_peer.AddrInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		chs     []<-chan libp2p_peer.AddrInfo
+		lastErr error
+	)
+	for _, disc := range d.discs {
+		ch, err := disc.FindPeers(ctx, ns, peerLimit)
+		if err != nil {
+			d.logger.Warn().Err(err).Str("ns", ns).Msg("failed to find peers from discovery source")
+			lastErr = err
+			continue
+		}
+		chs = append(chs, ch)
+	}
+	if len(chs) == 0 {
+		cancel()
+		return nil, errors.Wrap(lastErr, "all discovery sources failed")
+	}
+
+	merged := make(chan libp2p_peer.AddrInfo)
+	var wg sync.WaitGroup
+	for _, ch := range chs {
+		wg.Add(1)
+		go func(ch <-chan libp2p_peer.AddrInfo) {
+			defer wg.Done()
+			for peer := range ch {
+				select {
+				case merged <- peer:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	out := make(chan libp2p_peer.AddrInfo)
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		found := make(map[libp2p_peer.ID]struct{})
+		for peer := range merged {
+			if _, ok := found[peer.ID]; ok {
+				continue
+			}
+			found[peer.ID] = struct{}{}
+			select {
+			case out <- peer:
+			case <-ctx.Done():
+				return
+			}
+			if peerLimit > 0 && len(found) >= peerLimit {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// GetRawDiscovery returns the multi discovery as a libp2p discovery
+func (d *multiDiscovery) GetRawDiscovery() discovery.Discovery {
+	return rawDiscovery{d}
+}
@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/discovery"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestMultiDiscovery_FindPeers(t *testing.T) {
+	disc := NewMultiDiscovery(
+		NewStaticDiscovery(func() []libp2p_peer.AddrInfo {
+			return []libp2p_peer.AddrInfo{{ID: "peer1"}, {ID: "peer2"}}
+		}),
+		NewStaticDiscovery(func() []libp2p_peer.AddrInfo {
+			return []libp2p_peer.AddrInfo{{ID: "peer2"}, {ID: "peer3"}}
+		}),
+		&errDiscovery{},
+	)
+
+	tests := []struct {
+		limit  int
+		expLen int
+	}{
+		{0, 3},
+		{2, 2},
+		{5, 3},
+	}
+	for i, test := range tests {
+		ch, err := disc.GetRawDiscovery().FindPeers(context.Background(), "ns", discovery.Limit(test.limit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[libp2p_peer.ID]struct{})
+		for peer := range ch {
+			if _, ok := found[peer.ID]; ok {
+				t.Errorf("Test %v: duplicate peer %v", i, peer.ID)
+			}
+			found[peer.ID] = struct{}{}
+		}
+		if len(found) != test.expLen {
+			t.Errorf("Test %v: found %v peers, expect %v", i, len(found), test.expLen)
+		}
+	}
+}
+
+func TestMultiDiscovery_Advertise(t *testing.T) {
+	disc := NewMultiDiscovery(NewStaticDiscovery(nil), &errDiscovery{})
+	ttl, err := disc.Advertise(context.Background(), "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != staticAdvertiseTTL {
+		t.Errorf("unexpected ttl %v / %v", ttl, staticAdvertiseTTL)
+	}
+
+	disc = NewMultiDiscovery(&errDiscovery{}, &errDiscovery{})
+	if _, err := disc.Advertise(context.Background(), "ns"); err == nil {
+		t.Errorf("expect error when all sources fail")
+	}
+	if _, err := disc.FindPeers(context.Background(), "ns", 0); err == nil {
+		t.Errorf("expect error when all sources fail")
+	}
+}
+
+type errDiscovery struct{}
+
+func (d *errDiscovery) Start() error { return nil }
+func (d *errDiscovery) Close() error { return nil }
+
+func (d *errDiscovery) Advertise(ctx context.Context, ns string) (time.Duration, error) {
+	return 0, errors.New("advertise error")
+}
+
+func (d *errDiscovery) FindPeers(ctx context.Context, ns string, peerLimit int) (<-chan libp2p_peer.AddrInfo, error) {
+	return nil, errors.New("find peers error")
+}
+
+func (d *errDiscovery) GetRawDiscovery() discovery.Discovery {
+	return rawDiscovery{d}
+}
@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/discovery"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+// staticAdvertiseTTL is the advertise interval returned by the static discovery.
+// There is nothing to advertise, so it is long.
+const staticAdvertiseTTL = time.Hour
+
+// staticDiscovery is a Discovery of a fixed set of peers, used in place of the
+// DHT discovery when it is disabled. Every namespace is served by all peers.
+type staticDiscovery struct {
+	peers func() []libp2p_peer.AddrInfo
+}
+
+// NewStaticDiscovery creates a Discovery finding the peers returned by peers
+func NewStaticDiscovery(peers func() []libp2p_peer.AddrInfo) Discovery {
+	return &staticDiscovery{peers: peers}
+}
+
+// Start does nothing for the static discovery
+func (d *staticDiscovery) Start() error {
+	return nil
+}
+
+// Close does nothing for the static discovery
+func (d *staticDiscovery) Close() error {
+	return nil
+}
+
+// Advertise does nothing for the static discovery
+func (d *staticDiscovery) Advertise(ctx context.Context, ns string) (time.Duration, error) {
+	return staticAdvertiseTTL, nil
+}
+
+// FindPeers returns up to peerLimit static peers, all of them if peerLimit is 0
+func (d *staticDiscovery) FindPeers(ctx context.Context, ns string, peerLimit int) (<-chan libp2p_peer.AddrInfo, error) {
+	peers := d.peers()
+	if peerLimit > 0 && len(peers) > peerLimit {
+		peers = peers[:peerLimit]
+	}
+	ch := make(chan libp2p_peer.AddrInfo, len(peers))
+	for _, peer := range peers {
+		ch <- peer
+	}
+	close(ch)
+	return ch, nil
+}
+
+// GetRawDiscovery returns the static discovery as a libp2p discovery
+func (d *staticDiscovery) GetRawDiscovery() discovery.Discovery {
+	return rawDiscovery{d}
+}
+
+// rawDiscovery adapts a Discovery to the libp2p discovery interface
+type rawDiscovery struct {
+	d Discovery
+}
+
+func (r rawDiscovery) Advertise(ctx context.Context, ns string, opts ...discovery.Option) (time.Duration, error) {
+	return r.d.Advertise(ctx, ns)
+}
+
+func (r rawDiscovery) FindPeers(ctx context.Context, ns string, opts ...discovery.Option) (<-chan libp2p_peer.AddrInfo, error) {
+	var options discovery.Options
+	if err := options.Apply(opts...); err != nil {
+		return nil, err
+	}
+	return r.d.FindPeers(ctx, ns, options.Limit)
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/discovery"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestStaticDiscovery_FindPeers(t *testing.T) {
+	peers := []libp2p_peer.AddrInfo{{ID: "peer1"}, {ID: "peer2"}, {ID: "peer3"}}
+	disc := NewStaticDiscovery(func() []libp2p_peer.AddrInfo { return peers })
+
+	tests := []struct {
+		limit  int
+		expLen int
+	}{
+		{0, 3},
+		{2, 2},
+		{5, 3},
+	}
+	for i, test := range tests {
+		ch, err := disc.GetRawDiscovery().FindPeers(context.Background(), "ns", discovery.Limit(test.limit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := 0
+		for range ch {
+			found++
+		}
+		if found != test.expLen {
+			t.Errorf("Test %v: found %v peers, expect %v", i, found, test.expLen)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/protocol"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p/discovery"
 	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	p2ptypes "github.com/harmony-one/harmony/p2p/types"
 	"github.com/libp2p/go-libp2p"
 	libp2p_crypto "github.com/libp2p/go-libp2p-core/crypto"
 	libp2p_host "github.com/libp2p/go-libp2p-core/host"
@@ -50,6 +52,19 @@ type Host interface {
 	ListPeer(topic string) []libp2p_peer.ID
 	ListTopic() []string
 	ListBlockedPeer() []libp2p_peer.ID
+	ListPeerScore() []PeerScore
+	// ReportPeer records a behavior of the peer in its reputation
+	ReportPeer(id libp2p_peer.ID, behavior PeerBehavior)
+	// AddStaticPeer adds a peer kept connected, trusted peers are never blocked
+	AddStaticPeer(addr string, trusted bool) error
+	RemoveStaticPeer(id libp2p_peer.ID) error
+	ListStaticPeer() []StaticPeer
+	// BanPeer bans the peer across restarts until it is unbanned
+	BanPeer(id libp2p_peer.ID, reason string) error
+	UnbanPeer(id libp2p_peer.ID) error
+	ListBannedPeer() []BannedPeer
+	// ProtectPeer protects the connections of the peer from the trims for the ttl
+	ProtectPeer(id libp2p_peer.ID, tag string, ttl time.Duration)
 }
 
 // Peer is the object for a p2p peer (node)
@@ -78,6 +93,15 @@ type HostConfig struct {
 	BLSKey        libp2p_crypto.PrivKey
 	BootNodes     []string
 	DataStoreFile *string
+	ListenAddrs   []string // Multiaddrs to listen on, the IP and port of Self if empty
+	AnnounceAddrs []string // Multiaddrs announced to the peers, the listen ones if empty
+	StaticPeers   []string // Peers kept connected
+	TrustedPeers  []string // Peers kept connected and never blocked
+	NoDiscovery   bool     // Only connect to the static and trusted peers, and the DNS trees and peers file ones
+	DNSTrees      []string // enrtree:// URLs of the signed DNS trees to find peers from
+	PeersFile     string   // JSON file of peer multiaddrs to find peers from
+	BanListFile   string   // File the banned peers are persisted to
+	ConnManager   ConnManagerConfig
 }
 
 // NewHost ..
@@ -86,30 +110,83 @@ func NewHost(cfg HostConfig) (Host, error) {
 		self = cfg.Self
 		key  = cfg.BLSKey
 	)
-	listenAddr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/%s/tcp/%s", self.IP, self.Port))
+	listenAddrs, err := listenAddrs(self, cfg.ListenAddrs)
 	if err != nil {
-		return nil, errors.Wrapf(err,
-			"cannot create listen multiaddr from port %#v", self.Port)
+		return nil, err
+	}
+	announce, err := announceAddrsFactory(cfg.AnnounceAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	static := newStaticPeers()
+	staticPeers, err := p2ptypes.ResolveAndParseMultiAddrs(cfg.StaticPeers)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse static peers")
+	}
+	static.add(staticPeers, false)
+	trustedPeers, err := p2ptypes.ResolveAndParseMultiAddrs(cfg.TrustedPeers)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse trusted peers")
+	}
+	static.add(trustedPeers, true)
+
+	bans, err := NewBanList(cfg.BanListFile)
+	if err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	p2pHost, err := libp2p.New(ctx,
-		libp2p.ListenAddrs(listenAddr),
+	connMgr := newConnManager(cfg.ConnManager)
+	opts := []libp2p.Option{
+		libp2p.ListenAddrs(listenAddrs...),
+		transportOption(),
 		libp2p.Identity(key),
 		libp2p.EnableNATService(),
 		libp2p.ForceReachabilityPublic(),
-	)
+		libp2p.ConnectionGater(&peerGater{bans: bans}),
+		libp2p.ConnectionManager(connMgr),
+		libp2p.BandwidthReporter(bandwidthCounter),
+	}
+	if announce != nil {
+		opts = append(opts, libp2p.AddrsFactory(announce))
+	}
+	p2pHost, err := libp2p.New(ctx, opts...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot initialize libp2p host")
 	}
+	for _, info := range static.addrInfos() {
+		p2pHost.Peerstore().AddAddrs(info.ID, info.Addrs, libp2p_peerstore.PermanentAddrTTL)
+	}
 
-	disc, err := discovery.NewDHTDiscovery(p2pHost, discovery.DHTConfig{
-		BootNodes:     cfg.BootNodes,
-		DataStoreFile: cfg.DataStoreFile,
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot create DHT discovery")
+	var disc discovery.Discovery
+	if cfg.NoDiscovery {
+		disc = discovery.NewStaticDiscovery(static.addrInfos)
+	} else {
+		disc, err = discovery.NewDHTDiscovery(p2pHost, discovery.DHTConfig{
+			BootNodes:     cfg.BootNodes,
+			DataStoreFile: cfg.DataStoreFile,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create DHT discovery")
+		}
+	}
+	discs := []discovery.Discovery{disc}
+	if len(cfg.DNSTrees) != 0 {
+		dnsDisc, err := discovery.NewDNSDiscovery(discovery.DNSConfig{URLs: cfg.DNSTrees})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create DNS discovery")
+		}
+		discs = append(discs, dnsDisc)
+	}
+	if cfg.PeersFile != "" {
+		fileDisc, err := discovery.NewFileDiscovery(cfg.PeersFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot create peers file discovery")
+		}
+		discs = append(discs, fileDisc)
 	}
+	disc = discovery.NewMultiDiscovery(discs...)
 
 	options := []libp2p_pubsub.Option{
 		// WithValidateQueueSize sets the buffer of validate queue. Defaults to 32. When queue is full, validation is throttled and new messages are dropped.
@@ -124,6 +201,12 @@ func NewHost(cfg HostConfig) (Host, error) {
 		libp2p_pubsub.WithDiscovery(disc.GetRawDiscovery()),
 	}
 
+	// the peers blocked before the pubsub is created are only refused by its
+	// blocklist, they are disconnected once the pubsub is set below
+	reputation := NewReputationTracker(nil)
+	reputation.SetTrusted(static.isTrusted)
+	options = append(options, peerScoreOptions(reputation)...)
+
 	traceFile := os.Getenv("P2P_TRACEFILE")
 	if len(traceFile) > 0 {
 		var tracer libp2p_pubsub.EventTracer
@@ -149,8 +232,21 @@ func NewHost(cfg HostConfig) (Host, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "cannot initialize libp2p pub-sub")
 	}
+	reputation.SetOnBlock(func(id libp2p_peer.ID) {
+		utils.Logger().Warn().Str("peer", id.Pretty()).Msg("blocking peer for its low reputation")
+		pubsub.BlacklistPeer(id)
+		p2pHost.Network().ClosePeer(id)
+	})
 
 	self.PeerID = p2pHost.ID()
+	if len(cfg.ListenAddrs) != 0 || len(cfg.AnnounceAddrs) != 0 {
+		// the multiaddrs of the peer, only known from IP and port otherwise
+		self.Addrs = p2pHost.Addrs()
+		// IP and port of the legacy services, bound to the TCP listen address
+		if ip, port, ok := tcpAddr(listenAddrs); ok {
+			self.IP, self.Port = ip, port
+		}
+	}
 	subLogger := utils.Logger().With().Str("hostID", p2pHost.ID().Pretty()).Logger()
 
 	// has to save the private key for host
@@ -162,12 +258,17 @@ func NewHost(cfg HostConfig) (Host, error) {
 		priKey:    key,
 		discovery: disc,
 		logger:    &subLogger,
+		blocklist: reputation,
+		connMgr:   connMgr,
+		static:    static,
+		bans:      bans,
 		ctx:       ctx,
 		cancel:    cancel,
 	}
 
 	utils.Logger().Info().
 		Str("self", net.JoinHostPort(self.IP, self.Port)).
+		Interface("Addrs", self.Addrs).
 		Interface("PeerID", self.PeerID).
 		Str("PubKey", self.ConsensusPubKey.SerializeToHexStr()).
 		Msg("libp2p host ready")
@@ -185,7 +286,10 @@ type HostV2 struct {
 	lock         sync.Mutex
 	discovery    discovery.Discovery
 	logger       *zerolog.Logger
-	blocklist    libp2p_pubsub.Blacklist
+	blocklist    *ReputationTracker
+	connMgr      *connManager
+	static       *staticPeers
+	bans         *BanList
 	ctx          context.Context
 	cancel       func()
 }
@@ -201,6 +305,8 @@ func (host *HostV2) Start() error {
 	for _, proto := range host.streamProtos {
 		proto.Start()
 	}
+	host.protectStaticPeers()
+	go host.keepStaticPeers()
 	return host.discovery.Start()
 }
 
@@ -247,6 +353,9 @@ func (host *HostV2) GetOrJoin(topic string) (*libp2p_pubsub.Topic, error) {
 	} else if t, err := host.pubsub.Join(topic); err != nil {
 		return nil, errors.Wrapf(err, "cannot join pubsub topic %x", topic)
 	} else {
+		if err := t.SetScoreParams(topicScoreParams()); err != nil {
+			host.logger.Warn().Err(err).Str("topic", topic).Msg("cannot set topic score params")
+		}
 		host.joined[topic] = t
 		return t, nil
 	}
@@ -350,9 +459,23 @@ func (host *HostV2) ListPeer(topic string) []libp2p_peer.ID {
 
 // ListBlockedPeer returns list of blocked peer
 func (host *HostV2) ListBlockedPeer() []libp2p_peer.ID {
-	// TODO: this is a place holder for now
-	peers := make([]libp2p_peer.ID, 0)
-	return peers
+	return host.blocklist.Blocked()
+}
+
+// ListPeerScore returns the scores of the known peers, lowest first
+func (host *HostV2) ListPeerScore() []PeerScore {
+	return host.blocklist.Scores()
+}
+
+// ReportPeer records a behavior of the peer in its reputation
+func (host *HostV2) ReportPeer(id libp2p_peer.ID, behavior PeerBehavior) {
+	host.blocklist.Report(id, behavior)
+}
+
+// ProtectPeer protects the connections of the peer from the trims of the
+// connection manager for the ttl
+func (host *HostV2) ProtectPeer(id libp2p_peer.ID, tag string, ttl time.Duration) {
+	host.connMgr.ProtectFor(id, tag, ttl)
 }
 
 // GetPeerCount ...
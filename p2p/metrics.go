@@ -0,0 +1,129 @@
+package p2p
+
+import (
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	libp2p_metrics "github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prom.PromRegistry().MustRegister(
+		peerBehaviorCounterVec,
+		blockedPeerCounter,
+		graylistedPeerGauge,
+		numConnsGauge,
+		connectedPeersGauge,
+		protectedPeersGauge,
+		trimmedConnCounter,
+		numStreamsGaugeVec,
+		bandwidthCollector{},
+	)
+}
+
+var (
+	peerBehaviorCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "peer_behavior",
+			Help:      "number of peer behaviors reported to the reputation tracker",
+		},
+		[]string{"behavior"},
+	)
+
+	blockedPeerCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "peers_blocked",
+			Help:      "number of peers blocked for their reputation",
+		},
+	)
+
+	graylistedPeerGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "peers_graylisted",
+			Help:      "number of peers with a gossipsub score below the graylist threshold",
+		},
+	)
+
+	numConnsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "connections",
+			Help:      "number of open connections",
+		},
+	)
+
+	connectedPeersGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "peers_connected",
+			Help:      "number of connected peers",
+		},
+	)
+
+	protectedPeersGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "peers_protected",
+			Help:      "number of peers protected from the connection trims",
+		},
+	)
+
+	trimmedConnCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "connections_trimmed",
+			Help:      "number of connections closed by the connection manager",
+		},
+	)
+
+	numStreamsGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "p2p",
+			Name:      "streams",
+			Help:      "number of open streams by protocol",
+		},
+		[]string{"protocol"},
+	)
+)
+
+// bandwidthCounter counts the traffic of the p2p host
+var bandwidthCounter = libp2p_metrics.NewBandwidthCounter()
+
+var (
+	bandwidthTotalDesc = prometheus.NewDesc(
+		"hmy_p2p_bandwidth_bytes_total",
+		"number of bytes sent and received by the p2p host",
+		[]string{"direction"}, nil,
+	)
+	bandwidthRateDesc = prometheus.NewDesc(
+		"hmy_p2p_bandwidth_rate",
+		"bytes per second sent and received by the p2p host",
+		[]string{"direction"}, nil,
+	)
+)
+
+// bandwidthCollector exports the bandwidth counter to prometheus
+type bandwidthCollector struct{}
+
+func (bandwidthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bandwidthTotalDesc
+	ch <- bandwidthRateDesc
+}
+
+func (bandwidthCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := bandwidthCounter.GetBandwidthTotals()
+	ch <- prometheus.MustNewConstMetric(bandwidthTotalDesc, prometheus.CounterValue, float64(stats.TotalIn), "in")
+	ch <- prometheus.MustNewConstMetric(bandwidthTotalDesc, prometheus.CounterValue, float64(stats.TotalOut), "out")
+	ch <- prometheus.MustNewConstMetric(bandwidthRateDesc, prometheus.GaugeValue, stats.RateIn, "in")
+	ch <- prometheus.MustNewConstMetric(bandwidthRateDesc, prometheus.GaugeValue, stats.RateOut, "out")
+}
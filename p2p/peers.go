@@ -0,0 +1,261 @@
+package p2p
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	p2ptypes "github.com/harmony-one/harmony/p2p/types"
+	"github.com/libp2p/go-libp2p-core/control"
+	libp2p_network "github.com/libp2p/go-libp2p-core/network"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	libp2p_peerstore "github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/pkg/errors"
+)
+
+const (
+	// staticPeerRedialInterval is the interval the disconnected static peers are redialed
+	staticPeerRedialInterval = 30 * time.Second
+	// staticPeerDialTimeout is the timeout of a dial of a static peer
+	staticPeerDialTimeout = 10 * time.Second
+
+	// Connection manager tags of the protected peers
+	staticPeerTag  = "static"
+	trustedPeerTag = "trusted"
+)
+
+var (
+	// ErrStaticPeer is returned when banning a static or trusted peer
+	ErrStaticPeer = errors.New("peer is a static or trusted peer")
+	// ErrNotStaticPeer is returned when removing a peer which is not static
+	ErrNotStaticPeer = errors.New("peer is not a static or trusted peer")
+)
+
+// StaticPeer is a peer the host keeps connected to
+type StaticPeer struct {
+	PeerID    libp2p_peer.ID `json:"peerid"`
+	Addrs     []string       `json:"addrs"`
+	Trusted   bool           `json:"trusted"`
+	Connected bool           `json:"connected"`
+}
+
+type staticPeer struct {
+	info    libp2p_peer.AddrInfo
+	trusted bool
+}
+
+// staticPeers are the static and trusted peers of the host. Static peers are
+// kept connected, trusted peers are also never blocked for their reputation.
+type staticPeers struct {
+	lock  sync.RWMutex
+	peers map[libp2p_peer.ID]*staticPeer
+}
+
+func newStaticPeers() *staticPeers {
+	return &staticPeers{peers: make(map[libp2p_peer.ID]*staticPeer)}
+}
+
+// add adds the peers, a peer both static and trusted is trusted
+func (sp *staticPeers) add(infos []libp2p_peer.AddrInfo, trusted bool) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	for _, info := range infos {
+		if p, ok := sp.peers[info.ID]; ok {
+			p.info.Addrs = append(p.info.Addrs, info.Addrs...)
+			p.trusted = p.trusted || trusted
+			continue
+		}
+		sp.peers[info.ID] = &staticPeer{info: info, trusted: trusted}
+	}
+}
+
+func (sp *staticPeers) remove(id libp2p_peer.ID) bool {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+
+	_, ok := sp.peers[id]
+	delete(sp.peers, id)
+	return ok
+}
+
+func (sp *staticPeers) contains(id libp2p_peer.ID) bool {
+	sp.lock.RLock()
+	defer sp.lock.RUnlock()
+
+	_, ok := sp.peers[id]
+	return ok
+}
+
+func (sp *staticPeers) isTrusted(id libp2p_peer.ID) bool {
+	sp.lock.RLock()
+	defer sp.lock.RUnlock()
+
+	p, ok := sp.peers[id]
+	return ok && p.trusted
+}
+
+// addrInfos returns the addresses of the static and trusted peers
+func (sp *staticPeers) addrInfos() []libp2p_peer.AddrInfo {
+	sp.lock.RLock()
+	defer sp.lock.RUnlock()
+
+	infos := make([]libp2p_peer.AddrInfo, 0, len(sp.peers))
+	for _, p := range sp.peers {
+		infos = append(infos, p.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// peerGater is the connection gater of the host, refusing the connections of
+// the banned peers
+type peerGater struct {
+	bans *BanList
+}
+
+func (g *peerGater) InterceptPeerDial(p libp2p_peer.ID) bool {
+	return !g.bans.IsBanned(p)
+}
+
+func (g *peerGater) InterceptAddrDial(p libp2p_peer.ID, _ ma.Multiaddr) bool {
+	return !g.bans.IsBanned(p)
+}
+
+func (g *peerGater) InterceptAccept(libp2p_network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *peerGater) InterceptSecured(_ libp2p_network.Direction, p libp2p_peer.ID, _ libp2p_network.ConnMultiaddrs) bool {
+	return !g.bans.IsBanned(p)
+}
+
+func (g *peerGater) InterceptUpgraded(libp2p_network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// protectStaticPeers keeps the connection manager from dropping the static
+// and trusted peers
+func (host *HostV2) protectStaticPeers() {
+	for _, info := range host.static.addrInfos() {
+		host.protectStaticPeer(info.ID)
+	}
+}
+
+func (host *HostV2) protectStaticPeer(id libp2p_peer.ID) {
+	host.h.ConnManager().Protect(id, staticPeerTag)
+	if host.static.isTrusted(id) {
+		host.h.ConnManager().Protect(id, trustedPeerTag)
+	}
+}
+
+// keepStaticPeers redials the disconnected static and trusted peers until
+// the host is closed
+func (host *HostV2) keepStaticPeers() {
+	ticker := time.NewTicker(staticPeerRedialInterval)
+	defer ticker.Stop()
+	for {
+		host.dialStaticPeers()
+		select {
+		case <-ticker.C:
+		case <-host.ctx.Done():
+			return
+		}
+	}
+}
+
+func (host *HostV2) dialStaticPeers() {
+	for _, info := range host.static.addrInfos() {
+		if host.h.Network().Connectedness(info.ID) == libp2p_network.Connected {
+			continue
+		}
+		go host.dialStaticPeer(info)
+	}
+}
+
+func (host *HostV2) dialStaticPeer(info libp2p_peer.AddrInfo) {
+	ctx, cancel := context.WithTimeout(host.ctx, staticPeerDialTimeout)
+	defer cancel()
+	if err := host.h.Connect(ctx, info); err != nil {
+		host.logger.Debug().Err(err).Str("peer", info.ID.Pretty()).Msg("cannot connect to static peer")
+	}
+}
+
+// AddStaticPeer adds a static peer, or a trusted peer, from its multiaddr
+// including its peer id, and connects to it
+func (host *HostV2) AddStaticPeer(addr string, trusted bool) error {
+	infos, err := p2ptypes.ResolveAndParseMultiAddrs([]string{addr})
+	if err != nil {
+		return errors.Wrapf(err, "invalid peer address %s", addr)
+	}
+	for _, info := range infos {
+		if host.bans.IsBanned(info.ID) {
+			return errors.Errorf("peer %s is banned", info.ID.Pretty())
+		}
+	}
+	host.static.add(infos, trusted)
+	for _, info := range infos {
+		host.h.Peerstore().AddAddrs(info.ID, info.Addrs, libp2p_peerstore.PermanentAddrTTL)
+		host.protectStaticPeer(info.ID)
+		go host.dialStaticPeer(info)
+	}
+	return nil
+}
+
+// RemoveStaticPeer removes a static or trusted peer and disconnects from it
+func (host *HostV2) RemoveStaticPeer(id libp2p_peer.ID) error {
+	if !host.static.remove(id) {
+		return ErrNotStaticPeer
+	}
+	host.h.ConnManager().Unprotect(id, staticPeerTag)
+	host.h.ConnManager().Unprotect(id, trustedPeerTag)
+	return host.h.Network().ClosePeer(id)
+}
+
+// ListStaticPeer returns the static and trusted peers
+func (host *HostV2) ListStaticPeer() []StaticPeer {
+	infos := host.static.addrInfos()
+	peers := make([]StaticPeer, 0, len(infos))
+	for _, info := range infos {
+		addrs := make([]string, 0, len(info.Addrs))
+		for _, addr := range info.Addrs {
+			addrs = append(addrs, addr.String())
+		}
+		peers = append(peers, StaticPeer{
+			PeerID:    info.ID,
+			Addrs:     addrs,
+			Trusted:   host.static.isTrusted(info.ID),
+			Connected: host.h.Network().Connectedness(info.ID) == libp2p_network.Connected,
+		})
+	}
+	return peers
+}
+
+// BanPeer bans the peer until it is unbanned, across restarts, and
+// disconnects from it
+func (host *HostV2) BanPeer(id libp2p_peer.ID, reason string) error {
+	if host.static.contains(id) {
+		return ErrStaticPeer
+	}
+	if err := host.bans.Ban(id, reason); err != nil {
+		return err
+	}
+	host.logger.Info().Str("peer", id.Pretty()).Str("reason", reason).Msg("peer banned")
+	return host.h.Network().ClosePeer(id)
+}
+
+// UnbanPeer lifts the ban of the peer
+func (host *HostV2) UnbanPeer(id libp2p_peer.ID) error {
+	if err := host.bans.Unban(id); err != nil {
+		return err
+	}
+	host.logger.Info().Str("peer", id.Pretty()).Msg("peer unbanned")
+	return nil
+}
+
+// ListBannedPeer returns the banned peers
+func (host *HostV2) ListBannedPeer() []BannedPeer {
+	return host.bans.List()
+}
@@ -0,0 +1,383 @@
+package p2p
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+	libp2p_pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// PeerBehavior is a behavior of a peer observed while validating its
+// messages, changing the reputation of the peer
+type PeerBehavior int
+
+// Behaviors of the peers
+const (
+	// ValidMessage is a message accepted by the validators
+	ValidMessage PeerBehavior = iota
+	// InvalidMessage is a message which cannot be decoded or is malformed
+	InvalidMessage
+	// OversizedMessage is a message larger than its type allows
+	OversizedMessage
+	// WrongShardMessage is a message of another shard
+	WrongShardMessage
+	// WrongTopicMessage is a message published on a topic it does not belong to
+	WrongTopicMessage
+	// UndecodableMessage is a message whose payload cannot be decoded at all
+	UndecodableMessage
+	// StaleMessage is a message of another committee, which honest peers may
+	// still relay around a committee change
+	StaleMessage
+)
+
+func (b PeerBehavior) String() string {
+	switch b {
+	case ValidMessage:
+		return "valid"
+	case InvalidMessage:
+		return "invalid"
+	case OversizedMessage:
+		return "oversized"
+	case WrongShardMessage:
+		return "wrong_shard"
+	case WrongTopicMessage:
+		return "wrong_topic"
+	case UndecodableMessage:
+		return "undecodable"
+	case StaleMessage:
+		return "stale"
+	}
+	return "unknown"
+}
+
+// behaviorScores are the changes of the reputation of a peer for each behavior
+var behaviorScores = map[PeerBehavior]float64{
+	ValidMessage:       0.1,
+	InvalidMessage:     -10,
+	OversizedMessage:   -20,
+	WrongShardMessage:  -5,
+	WrongTopicMessage:  -10,
+	UndecodableMessage: -15,
+	StaleMessage:       -2,
+}
+
+const (
+	// maxReputation caps the reputation earned by the valid messages of a peer
+	maxReputation = 10
+	// reputationHalfLife is the time for a reputation to decay to half of it
+	reputationHalfLife = 10 * time.Minute
+	// blockThreshold is the reputation below which a peer is blocked
+	blockThreshold = -100
+	// blockDuration is the time a peer is blocked for
+	blockDuration = time.Hour
+	// maxTrackedPeers is the number of peers above which the decayed
+	// reputations are dropped
+	maxTrackedPeers = 10000
+
+	// Thresholds of the gossipsub score of the peers, including the
+	// reputation as the application specific score
+	gossipThreshold             = -10
+	publishThreshold            = -50
+	graylistThreshold           = -80
+	acceptPXThreshold           = 5
+	opportunisticGraftThreshold = 3
+
+	// peerScoreInspectPeriod is the period of the gossipsub score snapshots
+	peerScoreInspectPeriod = 10 * time.Second
+)
+
+// PeerScore is the score of a peer
+type PeerScore struct {
+	PeerID     libp2p_peer.ID `json:"peerid"`
+	Score      float64        `json:"score"`      // GossipSub score, including the reputation
+	Reputation float64        `json:"reputation"` // Reputation from the validated messages
+	Graylisted bool           `json:"graylisted"`
+	Blocked    bool           `json:"blocked"`
+}
+
+type peerReputation struct {
+	score   float64
+	updated time.Time
+}
+
+// decay decays the reputation to now
+func (r *peerReputation) decay(now time.Time) {
+	elapsed := now.Sub(r.updated)
+	if elapsed <= 0 {
+		return
+	}
+	r.score *= math.Pow(0.5, float64(elapsed)/float64(reputationHalfLife))
+	r.updated = now
+}
+
+// ReputationTracker tracks the reputation of the peers from the outcome of
+// the validation of their messages, and blocks the peers whose reputation
+// drops below the block threshold. It is the blocklist of the pubsub.
+type ReputationTracker struct {
+	lock    sync.Mutex
+	peers   map[libp2p_peer.ID]*peerReputation
+	blocked map[libp2p_peer.ID]time.Time // Time the block of a peer expires
+	scores  map[libp2p_peer.ID]float64   // Last gossipsub scores
+
+	onBlock func(libp2p_peer.ID)
+	trusted func(libp2p_peer.ID) bool // Trusted peers are never blocked
+}
+
+// NewReputationTracker returns a new tracker calling onBlock on the peers
+// blocked for their reputation
+func NewReputationTracker(onBlock func(libp2p_peer.ID)) *ReputationTracker {
+	return &ReputationTracker{
+		peers:   make(map[libp2p_peer.ID]*peerReputation),
+		blocked: make(map[libp2p_peer.ID]time.Time),
+		scores:  make(map[libp2p_peer.ID]float64),
+		onBlock: onBlock,
+	}
+}
+
+// SetOnBlock sets the function called on the peers blocked for their
+// reputation
+func (t *ReputationTracker) SetOnBlock(onBlock func(libp2p_peer.ID)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.onBlock = onBlock
+}
+
+// SetTrusted sets the function telling the trusted peers, which are never
+// blocked whatever their reputation
+func (t *ReputationTracker) SetTrusted(trusted func(libp2p_peer.ID) bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.trusted = trusted
+}
+
+func (t *ReputationTracker) isTrusted(id libp2p_peer.ID) bool {
+	return t.trusted != nil && t.trusted(id)
+}
+
+// Report records a behavior of the peer
+func (t *ReputationTracker) Report(id libp2p_peer.ID, behavior PeerBehavior) {
+	peerBehaviorCounterVec.WithLabelValues(behavior.String()).Inc()
+	if id == "" {
+		return
+	}
+	now := time.Now()
+
+	t.lock.Lock()
+	r, ok := t.peers[id]
+	if !ok {
+		if len(t.peers) >= maxTrackedPeers {
+			t.prune(now)
+		}
+		r = &peerReputation{updated: now}
+		t.peers[id] = r
+	}
+	r.decay(now)
+	r.score = math.Min(r.score+behaviorScores[behavior], maxReputation)
+	until, blocked := t.blocked[id]
+	blocked = blocked && now.Before(until)
+	block := !blocked && r.score < blockThreshold && !t.isTrusted(id)
+	if block {
+		t.blocked[id] = now.Add(blockDuration)
+	}
+	onBlock := t.onBlock
+	t.lock.Unlock()
+
+	if block {
+		blockedPeerCounter.Inc()
+		if onBlock != nil {
+			// reports come from the pubsub validators, not to be blocked on pubsub
+			go onBlock(id)
+		}
+	}
+}
+
+// prune drops the decayed reputations and the expired blocks
+func (t *ReputationTracker) prune(now time.Time) {
+	for id, r := range t.peers {
+		r.decay(now)
+		if math.Abs(r.score) < 1 {
+			delete(t.peers, id)
+		}
+	}
+	for id, until := range t.blocked {
+		if now.After(until) {
+			delete(t.blocked, id)
+		}
+	}
+}
+
+// Reputation returns the reputation of the peer, the application specific
+// score of the gossipsub peer scoring
+func (t *ReputationTracker) Reputation(id libp2p_peer.ID) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	r, ok := t.peers[id]
+	if !ok {
+		return 0
+	}
+	r.decay(time.Now())
+	return r.score
+}
+
+// Add blocks the peer, implementing libp2p_pubsub.Blacklist
+func (t *ReputationTracker) Add(id libp2p_peer.ID) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.isTrusted(id) {
+		return false
+	}
+	if until, ok := t.blocked[id]; ok && time.Now().Before(until) {
+		return false
+	}
+	t.blocked[id] = time.Now().Add(blockDuration)
+	return true
+}
+
+// Contains returns whether the peer is blocked, implementing
+// libp2p_pubsub.Blacklist
+func (t *ReputationTracker) Contains(id libp2p_peer.ID) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.isTrusted(id) {
+		return false
+	}
+	until, ok := t.blocked[id]
+	if ok && time.Now().After(until) {
+		delete(t.blocked, id)
+		return false
+	}
+	return ok
+}
+
+// Blocked returns the peers blocked
+func (t *ReputationTracker) Blocked() []libp2p_peer.ID {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	peers := make([]libp2p_peer.ID, 0, len(t.blocked))
+	for id, until := range t.blocked {
+		if now.Before(until) {
+			peers = append(peers, id)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+	return peers
+}
+
+// inspectScores records a snapshot of the gossipsub scores of the peers
+func (t *ReputationTracker) inspectScores(scores map[libp2p_peer.ID]float64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.scores = scores
+	graylisted := 0
+	for _, score := range scores {
+		if score < graylistThreshold {
+			graylisted++
+		}
+	}
+	graylistedPeerGauge.Set(float64(graylisted))
+}
+
+// Scores returns the scores of the peers with a gossipsub score or a
+// reputation, ordered by score then reputation
+func (t *ReputationTracker) Scores() []PeerScore {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	byPeer := make(map[libp2p_peer.ID]*PeerScore)
+	get := func(id libp2p_peer.ID) *PeerScore {
+		s, ok := byPeer[id]
+		if !ok {
+			until, blocked := t.blocked[id]
+			s = &PeerScore{PeerID: id, Blocked: blocked && now.Before(until)}
+			byPeer[id] = s
+		}
+		return s
+	}
+	for id, score := range t.scores {
+		s := get(id)
+		s.Score = score
+		s.Graylisted = score < graylistThreshold
+	}
+	for id, r := range t.peers {
+		r.decay(now)
+		get(id).Reputation = r.score
+	}
+	scores := make([]PeerScore, 0, len(byPeer))
+	for _, s := range byPeer {
+		scores = append(scores, *s)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score < scores[j].Score
+		}
+		return scores[i].Reputation < scores[j].Reputation
+	})
+	return scores
+}
+
+// peerScoreOptions returns the pubsub options of the gossipsub peer scoring,
+// with the reputation of the tracker as the application specific score
+func peerScoreOptions(tracker *ReputationTracker) []libp2p_pubsub.Option {
+	params := &libp2p_pubsub.PeerScoreParams{
+		Topics:            make(map[string]*libp2p_pubsub.TopicScoreParams),
+		AppSpecificScore:  tracker.Reputation,
+		AppSpecificWeight: 1,
+
+		IPColocationFactorWeight:    -10,
+		IPColocationFactorThreshold: 10,
+
+		BehaviourPenaltyWeight:    -1,
+		BehaviourPenaltyThreshold: 6,
+		BehaviourPenaltyDecay:     libp2p_pubsub.ScoreParameterDecay(10 * time.Minute),
+
+		DecayInterval: libp2p_pubsub.DefaultDecayInterval,
+		DecayToZero:   libp2p_pubsub.DefaultDecayToZero,
+		RetainScore:   time.Hour,
+	}
+	thresholds := &libp2p_pubsub.PeerScoreThresholds{
+		GossipThreshold:             gossipThreshold,
+		PublishThreshold:            publishThreshold,
+		GraylistThreshold:           graylistThreshold,
+		AcceptPXThreshold:           acceptPXThreshold,
+		OpportunisticGraftThreshold: opportunisticGraftThreshold,
+	}
+	return []libp2p_pubsub.Option{
+		libp2p_pubsub.WithPeerScore(params, thresholds),
+		libp2p_pubsub.WithPeerScoreInspect(
+			libp2p_pubsub.PeerScoreInspectFn(tracker.inspectScores), peerScoreInspectPeriod,
+		),
+		libp2p_pubsub.WithBlacklist(tracker),
+	}
+}
+
+// topicScoreParams returns the score parameters of the topics, rewarding the
+// time in mesh and the first deliveries of the messages, and penalizing the
+// invalid messages
+func topicScoreParams() *libp2p_pubsub.TopicScoreParams {
+	return &libp2p_pubsub.TopicScoreParams{
+		TopicWeight: 1,
+
+		TimeInMeshWeight:  0.01,
+		TimeInMeshQuantum: time.Second,
+		TimeInMeshCap:     300,
+
+		FirstMessageDeliveriesWeight: 1,
+		FirstMessageDeliveriesDecay:  libp2p_pubsub.ScoreParameterDecay(time.Hour),
+		FirstMessageDeliveriesCap:    10,
+
+		InvalidMessageDeliveriesWeight: -10,
+		InvalidMessageDeliveriesDecay:  libp2p_pubsub.ScoreParameterDecay(time.Hour),
+	}
+}
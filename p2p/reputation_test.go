@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestReputationTracker(t *testing.T) {
+	blockedCh := make(chan libp2p_peer.ID, 1)
+	tracker := NewReputationTracker(func(id libp2p_peer.ID) { blockedCh <- id })
+	good, bad := libp2p_peer.ID("good"), libp2p_peer.ID("bad")
+
+	for i := 0; i < 200; i++ {
+		tracker.Report(good, ValidMessage)
+	}
+	if rep := tracker.Reputation(good); rep > maxReputation || rep < maxReputation-0.1 {
+		t.Errorf("unexpected reputation of good peer: %v", rep)
+	}
+
+	for i := 0; i < 4; i++ {
+		tracker.Report(bad, OversizedMessage)
+	}
+	if tracker.Contains(bad) {
+		t.Fatal("peer blocked above the block threshold")
+	}
+	for i := 0; i < 3; i++ {
+		tracker.Report(bad, InvalidMessage)
+	}
+	select {
+	case id := <-blockedCh:
+		if id != bad {
+			t.Errorf("unexpected peer blocked: %v", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("peer not blocked below the block threshold")
+	}
+	if !tracker.Contains(bad) || tracker.Contains(good) {
+		t.Error("unexpected blocklist content")
+	}
+	if blocked := tracker.Blocked(); len(blocked) != 1 || blocked[0] != bad {
+		t.Errorf("unexpected blocked peers: %v", blocked)
+	}
+
+	scores := tracker.Scores()
+	if len(scores) != 2 || scores[0].PeerID != bad {
+		t.Fatalf("unexpected scores: %+v", scores)
+	}
+	for _, score := range scores {
+		if score.Blocked != (score.PeerID == bad) {
+			t.Errorf("unexpected blocked flag of %v", score.PeerID)
+		}
+	}
+}
+
+func TestPeerReputationDecay(t *testing.T) {
+	now := time.Now()
+	r := &peerReputation{score: -80, updated: now}
+	r.decay(now.Add(2 * reputationHalfLife))
+	if r.score < -20.001 || r.score > -19.999 {
+		t.Errorf("unexpected decayed reputation: have %v, want -20", r.score)
+	}
+}
+
+func TestReputationTrackerBlockExpiry(t *testing.T) {
+	blockedCh := make(chan libp2p_peer.ID, 2)
+	tracker := NewReputationTracker(func(id libp2p_peer.ID) { blockedCh <- id })
+	bad := libp2p_peer.ID("bad")
+
+	for i := 0; i < 7; i++ {
+		tracker.Report(bad, UndecodableMessage)
+	}
+	select {
+	case <-blockedCh:
+	case <-time.After(time.Second):
+		t.Fatal("peer not blocked below the block threshold")
+	}
+
+	// the peer is blocked again once its block expires
+	tracker.lock.Lock()
+	tracker.blocked[bad] = time.Now().Add(-time.Second)
+	tracker.lock.Unlock()
+	tracker.Report(bad, StaleMessage)
+	select {
+	case <-blockedCh:
+	case <-time.After(time.Second):
+		t.Fatal("peer not blocked again after its block expired")
+	}
+	if !tracker.Contains(bad) {
+		t.Error("peer not in the blocklist")
+	}
+}
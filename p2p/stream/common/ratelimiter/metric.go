@@ -9,6 +9,10 @@ func init() {
 	prom.PromRegistry().MustRegister(
 		serverRequestCounter,
 		serverRequestDelayDuration,
+		serverRequestCostCounter,
+		serverResponseBytesCounter,
+		exceededResponseBudgetCounter,
+		clientRequestCostCounter,
 	)
 }
 
@@ -31,4 +35,40 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 5),
 		},
 	)
+
+	serverRequestCostCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "stream",
+			Name:      "server_request_cost",
+			Help:      "total cost of incoming requests as server",
+		},
+	)
+
+	serverResponseBytesCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "stream",
+			Name:      "server_response_bytes",
+			Help:      "number of response bytes as server",
+		},
+	)
+
+	exceededResponseBudgetCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "stream",
+			Name:      "num_exceeded_response_budget",
+			Help:      "number of responses refused for exceeding the response byte budget",
+		},
+	)
+
+	clientRequestCostCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "stream",
+			Name:      "client_request_cost",
+			Help:      "total cost of outgoing requests as client",
+		},
+	)
 )
@@ -2,7 +2,9 @@ package ratelimiter
 
 import (
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/ethereum/go-ethereum/event"
@@ -13,25 +15,42 @@ import (
 	"go.uber.org/ratelimit"
 )
 
+// ErrResponseBudgetExceeded is the error returned when a stream has used up its
+// response byte budget
+var ErrResponseBudgetExceeded = errors.New("response byte budget exceeded")
+
 // RateLimiter is the interface to limit the incoming request.
 // The purpose of rate limiter is to prevent the node from running out of resource
 // for consensus on DDoS attacks.
 type RateLimiter interface {
-	LimitRequest(stid sttypes.StreamID)
+	// LimitRequest blocks until the request of the given cost is allowed
+	LimitRequest(stid sttypes.StreamID, cost int)
+	// LimitResponse charges the response bytes to the budget of the stream, and
+	// returns ErrResponseBudgetExceeded if the budget is already used up
+	LimitResponse(stid sttypes.StreamID, size int) error
 
 	p2ptypes.LifeCycle
 }
 
+// Config is the config of the rate limiter
+type Config struct {
+	GlobalRate        int // Request cost per second for all streams
+	StreamRate        int // Request cost per second for a single stream
+	ResponseBytesRate int // Response bytes per second for a single stream
+}
+
 // rateLimiter is the implementation of RateLimiter.
-// The rateLimiter limit request rate:
+// The rateLimiter limit request rate, weighted by the request cost:
 //   1. For global stream requests
 //   2. For requests from a stream
-// TODO: make request weighted in rate limiter
+// It also limits the response bytes served to a stream.
 type rateLimiter struct {
 	globalLimiter ratelimit.Limiter
 
 	streamRate int
+	bytesRate  int
 	limiters   map[sttypes.StreamID]ratelimit.Limiter
+	budgets    map[sttypes.StreamID]*byteBudget
 	sm         streammanager.Subscriber
 
 	lock   sync.RWMutex
@@ -39,12 +58,14 @@ type rateLimiter struct {
 }
 
 // NewRateLimiter creates a new rate limiter
-func NewRateLimiter(sm streammanager.Subscriber, global, single int) RateLimiter {
+func NewRateLimiter(sm streammanager.Subscriber, config Config) RateLimiter {
 	return &rateLimiter{
-		globalLimiter: ratelimit.New(global),
+		globalLimiter: ratelimit.New(config.GlobalRate),
 
-		streamRate: single,
+		streamRate: config.StreamRate,
+		bytesRate:  config.ResponseBytesRate,
 		limiters:   make(map[sttypes.StreamID]ratelimit.Limiter),
+		budgets:    make(map[sttypes.StreamID]*byteBudget),
 		sm:         sm,
 
 		closeC: make(chan struct{}),
@@ -79,8 +100,9 @@ func (rl *rateLimiter) rmStreamLoop(rmStC chan streammanager.EvtStreamRemoved, s
 	}
 }
 
-func (rl *rateLimiter) LimitRequest(stid sttypes.StreamID) {
+func (rl *rateLimiter) LimitRequest(stid sttypes.StreamID, cost int) {
 	serverRequestCounter.Inc()
+	serverRequestCostCounter.Add(float64(cost))
 	timer := prometheus.NewTimer(serverRequestDelayDuration)
 	defer timer.ObserveDuration()
 
@@ -100,12 +122,93 @@ func (rl *rateLimiter) LimitRequest(stid sttypes.StreamID) {
 		rl.lock.Unlock()
 	}
 
-	rl.globalLimiter.Take()
-	limiter.Take()
+	take(rl.globalLimiter, cost)
+	take(limiter, cost)
+}
+
+func (rl *rateLimiter) LimitResponse(stid sttypes.StreamID, size int) error {
+	serverResponseBytesCounter.Add(float64(size))
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	budget, ok := rl.budgets[stid]
+	if !ok {
+		budget = newByteBudget(rl.bytesRate, time.Now())
+		rl.budgets[stid] = budget
+	}
+	if !budget.consume(size, time.Now()) {
+		exceededResponseBudgetCounter.Inc()
+		return ErrResponseBudgetExceeded
+	}
+	return nil
 }
 
 func (rl *rateLimiter) unRegStream(stid sttypes.StreamID) {
 	rl.lock.Lock()
 	delete(rl.limiters, stid)
+	delete(rl.budgets, stid)
 	defer rl.lock.Unlock()
 }
+
+// take takes cost tokens from the limiter
+func take(limiter ratelimit.Limiter, cost int) {
+	for i := 0; i < cost; i++ {
+		limiter.Take()
+	}
+}
+
+// byteBudget is a byte budget refilled at rate bytes per second, up to one
+// second worth of bytes. A response is allowed as long as the budget is not
+// used up, and may take it into debt to be paid back by the refill.
+type byteBudget struct {
+	rate  float64
+	avail float64
+	last  time.Time
+}
+
+func newByteBudget(rate int, now time.Time) *byteBudget {
+	return &byteBudget{
+		rate:  float64(rate),
+		avail: float64(rate),
+		last:  now,
+	}
+}
+
+// consume charges the bytes to the budget if it is not used up
+func (b *byteBudget) consume(size int, now time.Time) bool {
+	b.avail += now.Sub(b.last).Seconds() * b.rate
+	if b.avail > b.rate {
+		b.avail = b.rate
+	}
+	b.last = now
+	if b.avail <= 0 {
+		return false
+	}
+	b.avail -= float64(size)
+	return true
+}
+
+// ClientRateLimiter limits the outgoing requests weighted by their cost, so that
+// the node keeps within the rate limits of the remote servers.
+type ClientRateLimiter interface {
+	// LimitRequest blocks until the request of the given cost is allowed
+	LimitRequest(cost int)
+}
+
+type clientRateLimiter struct {
+	limiter ratelimit.Limiter
+}
+
+// NewClientRateLimiter creates a new client rate limiter with the rate in request
+// cost per second
+func NewClientRateLimiter(rate int) ClientRateLimiter {
+	return &clientRateLimiter{
+		limiter: ratelimit.New(rate),
+	}
+}
+
+func (rl *clientRateLimiter) LimitRequest(cost int) {
+	clientRequestCostCounter.Add(float64(cost))
+	take(rl.limiter, cost)
+}
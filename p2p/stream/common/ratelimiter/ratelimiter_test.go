@@ -12,12 +12,15 @@ import (
 
 func TestRateLimiter(t *testing.T) {
 	sm := &testStreamManager{}
-	rl := NewRateLimiter(sm, 10, 10)
+	rl := NewRateLimiter(sm, Config{GlobalRate: 10, StreamRate: 10, ResponseBytesRate: 100})
 	rl.Start()
 	defer rl.Close()
 
 	stid := makeTestStreamID(1)
-	rl.LimitRequest(stid)
+	rl.LimitRequest(stid, 1)
+	if err := rl.LimitResponse(stid, 10); err != nil {
+		t.Fatal(err)
+	}
 	sm.removeStream(stid)
 
 	time.Sleep(100 * time.Millisecond)
@@ -26,9 +29,47 @@ func TestRateLimiter(t *testing.T) {
 	if _, ok := rlImpl.limiters[stid]; ok {
 		t.Errorf("after remove, the limiter still in rate limiter")
 	}
+	if _, ok := rlImpl.budgets[stid]; ok {
+		t.Errorf("after remove, the budget still in rate limiter")
+	}
 	rlImpl.lock.Unlock()
 }
 
+func TestRateLimiter_LimitResponse(t *testing.T) {
+	sm := &testStreamManager{}
+	rl := NewRateLimiter(sm, Config{GlobalRate: 10, StreamRate: 10, ResponseBytesRate: 100})
+
+	stid := makeTestStreamID(1)
+	if err := rl.LimitResponse(stid, 150); err != nil {
+		t.Fatalf("first response: %v", err)
+	}
+	if err := rl.LimitResponse(stid, 10); err != ErrResponseBudgetExceeded {
+		t.Errorf("unexpected error %v / %v", err, ErrResponseBudgetExceeded)
+	}
+	if err := rl.LimitResponse(makeTestStreamID(2), 10); err != nil {
+		t.Errorf("budget of another stream: %v", err)
+	}
+}
+
+func TestByteBudget(t *testing.T) {
+	now := time.Now()
+	b := newByteBudget(100, now)
+
+	if !b.consume(300, now) {
+		t.Fatalf("budget not available")
+	}
+	// 1s refill pays back half of the debt
+	if b.consume(1, now.Add(time.Second)) {
+		t.Errorf("budget available while in debt")
+	}
+	// capped at one second worth of bytes
+	b = newByteBudget(100, now)
+	b.consume(1, now.Add(time.Hour))
+	if b.avail != 99 {
+		t.Errorf("unexpected available bytes %v / %v", b.avail, 99)
+	}
+}
+
 type testStreamManager struct {
 	removeFeed event.Feed
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
 	p2ptypes "github.com/harmony-one/harmony/p2p/types"
+	"github.com/libp2p/go-libp2p-core/connmgr"
 	"github.com/libp2p/go-libp2p-core/network"
 	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
@@ -48,6 +49,13 @@ type Reader interface {
 type host interface {
 	ID() libp2p_peer.ID
 	NewStream(ctx context.Context, p libp2p_peer.ID, pids ...protocol.ID) (network.Stream, error)
+	ConnManager() connmgr.ConnManager
+}
+
+// peerStream is a stream knowing its remote peer, protected from the
+// connection trims while it is in use
+type peerStream interface {
+	RemotePeer() libp2p_peer.ID
 }
 
 // peerFinder is the adapter interface of discovery.Discovery
@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 
 	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	"github.com/libp2p/go-libp2p-core/connmgr"
 	"github.com/libp2p/go-libp2p-core/network"
 	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
@@ -110,6 +111,10 @@ func (h *testHost) ID() libp2p_peer.ID {
 	return myPeerID
 }
 
+func (h *testHost) ConnManager() connmgr.ConnManager {
+	return &connmgr.NullConnMgr{}
+}
+
 // NewStream mock the upper function logic. When stream setup and running protocol, the
 // upper code logic will call StreamManager to add new stream
 func (h *testHost) NewStream(ctx context.Context, p libp2p_peer.ID, pids ...protocol.ID) (network.Stream, error) {
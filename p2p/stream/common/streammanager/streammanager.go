@@ -229,6 +229,9 @@ func (sm *streamManager) handleAddStream(st sttypes.Stream) error {
 	}
 
 	sm.streams.addStream(st)
+	if ps, ok := st.(peerStream); ok {
+		sm.host.ConnManager().Protect(ps.RemotePeer(), string(sm.myProtoID))
+	}
 
 	sm.addStreamFeed.Send(EvtStreamAdded{st})
 	addedStreamsCounterVec.With(prometheus.Labels{"topic": string(sm.myProtoID)}).Inc()
@@ -243,6 +246,9 @@ func (sm *streamManager) handleRemoveStream(id sttypes.StreamID) error {
 	}
 
 	sm.streams.deleteStream(st)
+	if ps, ok := st.(peerStream); ok {
+		sm.host.ConnManager().Unprotect(ps.RemotePeer(), string(sm.myProtoID))
+	}
 	// if stream number is smaller than HardLoCap, spin up the discover
 	if !sm.hardHaveEnoughStream() {
 		select {
@@ -0,0 +1,166 @@
+package light
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/pkg/errors"
+)
+
+// Chain is the chain served by the light protocol
+type Chain interface {
+	CurrentHeader() *block.Header
+	GetHeaderByNumber(number uint64) *block.Header
+	ReadCommitSig(blockNum uint64) ([]byte, error)
+	StateAt(root common.Hash) (*state.DB, error)
+}
+
+// chainHelper is the adapter for blockchain which is friendly to unit test.
+type chainHelper interface {
+	getCurrentHeader() (*block.Header, []byte, error)
+	getHeadersByNumber(bns []uint64) ([]*block.Header, [][]byte, error)
+	getEpochHeaders(epochs []uint64) ([]*block.Header, [][]byte, error)
+	getAccountProof(bn uint64, addr common.Address, keys []common.Hash) ([][]byte, [][][]byte, error)
+}
+
+type chainHelperImpl struct {
+	chain Chain
+}
+
+func newChainHelper(chain Chain) *chainHelperImpl {
+	return &chainHelperImpl{
+		chain: chain,
+	}
+}
+
+var errHeaderNotFound = errors.New("header not found")
+
+func (ch *chainHelperImpl) getCurrentHeader() (*block.Header, []byte, error) {
+	header := ch.chain.CurrentHeader()
+	sig, err := ch.getHeaderSigAndBitmap(header)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "header %v", header.Number())
+	}
+	return header, sig, nil
+}
+
+func (ch *chainHelperImpl) getHeadersByNumber(bns []uint64) ([]*block.Header, [][]byte, error) {
+	var (
+		headers = make([]*block.Header, 0, len(bns))
+		sigs    = make([][]byte, 0, len(bns))
+	)
+	for _, bn := range bns {
+		var sig []byte
+		header := ch.chain.GetHeaderByNumber(bn)
+		if header != nil {
+			var err error
+			if sig, err = ch.getHeaderSigAndBitmap(header); err != nil {
+				return nil, nil, errors.Wrapf(err, "header %v", bn)
+			}
+		}
+		headers = append(headers, header)
+		sigs = append(sigs, sig)
+	}
+	return headers, sigs, nil
+}
+
+// getEpochHeaders returns the last header of each epoch, carrying the shard
+// state of the next epoch. The header is the last one of an earlier epoch if
+// the shard has no block in the epoch, and nil if the epoch is not over.
+func (ch *chainHelperImpl) getEpochHeaders(epochs []uint64) ([]*block.Header, [][]byte, error) {
+	var (
+		headers = make([]*block.Header, 0, len(epochs))
+		sigs    = make([][]byte, 0, len(epochs))
+	)
+	for _, epoch := range epochs {
+		var sig []byte
+		header := ch.getEpochLastHeader(epoch)
+		if header != nil {
+			var err error
+			if sig, err = ch.getHeaderSigAndBitmap(header); err != nil {
+				return nil, nil, errors.Wrapf(err, "header %v", header.Number())
+			}
+		}
+		headers = append(headers, header)
+		sigs = append(sigs, sig)
+	}
+	return headers, sigs, nil
+}
+
+// getEpochLastHeader returns the last header with an epoch not after the
+// epoch, found with a binary search on the block numbers, the epochs of the
+// headers being increasing
+func (ch *chainHelperImpl) getEpochLastHeader(epoch uint64) *block.Header {
+	current := ch.chain.CurrentHeader()
+	if current.Epoch().Uint64() <= epoch {
+		// the epoch is not over, unless the current header is its last one
+		if current.IsLastBlockInEpoch() {
+			return current
+		}
+		return nil
+	}
+	// lo ends at the first block number of an epoch after the epoch
+	lo, hi := uint64(0), current.Number().Uint64()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header := ch.chain.GetHeaderByNumber(mid)
+		if header == nil {
+			return nil
+		}
+		if header.Epoch().Uint64() <= epoch {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == 0 {
+		return nil
+	}
+	last := ch.chain.GetHeaderByNumber(lo - 1)
+	if last == nil || !last.IsLastBlockInEpoch() {
+		return nil
+	}
+	return last
+}
+
+func (ch *chainHelperImpl) getAccountProof(bn uint64, addr common.Address, keys []common.Hash) ([][]byte, [][][]byte, error) {
+	header := ch.chain.GetHeaderByNumber(bn)
+	if header == nil {
+		return nil, nil, errHeaderNotFound
+	}
+	db, err := ch.chain.StateAt(header.Root())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "state of block %v", bn)
+	}
+	proof, err := db.GetProof(addr)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "account proof")
+	}
+	storageProofs := make([][][]byte, 0, len(keys))
+	if !db.Exist(addr) {
+		return proof, storageProofs, nil
+	}
+	for _, key := range keys {
+		sp, err := db.GetStorageProof(addr, key)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "storage proof of %v", key.Hex())
+		}
+		storageProofs = append(storageProofs, sp)
+	}
+	return proof, storageProofs, nil
+}
+
+// getHeaderSigAndBitmap returns the commit signature and bitmap of the header,
+// from the next header or stored separately for the current header
+func (ch *chainHelperImpl) getHeaderSigAndBitmap(header *block.Header) ([]byte, error) {
+	nextHeader := ch.chain.GetHeaderByNumber(header.Number().Uint64() + 1)
+	if nextHeader != nil && nextHeader.ParentHash() == header.Hash() {
+		sigBytes := nextHeader.LastCommitSignature()
+		bitMap := nextHeader.LastCommitBitmap()
+		sb := make([]byte, len(sigBytes)+len(bitMap))
+		copy(sb[:], sigBytes[:])
+		copy(sb[len(sigBytes):], bitMap[:])
+		return sb, nil
+	}
+	return ch.chain.ReadCommitSig(header.Number().Uint64())
+}
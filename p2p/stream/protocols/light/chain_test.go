@@ -0,0 +1,222 @@
+package light
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/harmony-one/harmony/block"
+	blockfactory "github.com/harmony-one/harmony/block/factory"
+	"github.com/harmony-one/harmony/core/state"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/shard"
+)
+
+var (
+	// epochs of the blocks of the test chain, the shard having no block in
+	// epoch 2
+	testEpochs = []uint64{0, 0, 0, 1, 1, 1, 3, 3, 3, 4}
+
+	testAddr    = common.Address{1}
+	testKey     = common.Hash{2}
+	testValue   = common.Hash{3}
+	testBalance = big.NewInt(100)
+)
+
+type testChain struct {
+	headers []*block.Header
+	sdb     state.Database
+	root    common.Hash
+}
+
+// makeTestChain makes the chain of the blocks of the epochs, the last block of
+// an epoch carrying the shard state of the epoch of the next block, and the
+// state of all the blocks having an account with a storage slot
+func makeTestChain(epochs []uint64) *testChain {
+	sdb := state.NewDatabase(rawdb.NewMemoryDatabase())
+	db, _ := state.New(common.Hash{}, sdb)
+	db.AddBalance(testAddr, testBalance)
+	db.SetState(testAddr, testKey, testValue)
+	root, _ := db.Commit(false)
+	sdb.TrieDB().Commit(root, false)
+
+	chain := &testChain{sdb: sdb, root: root}
+	parentHash := common.Hash{}
+	for i, epoch := range epochs {
+		hb := blockfactory.NewTestHeader().With().
+			Number(big.NewInt(int64(i))).
+			Epoch(new(big.Int).SetUint64(epoch)).
+			ParentHash(parentHash).
+			Root(root)
+		if i > 0 {
+			hb = hb.LastCommitSignature(makeTestSig(uint64(i - 1))).
+				LastCommitBitmap([]byte{byte(i - 1)})
+		}
+		if i+1 < len(epochs) && epochs[i+1] != epoch {
+			ss, _ := shard.EncodeWrapper(shard.State{
+				Epoch:  new(big.Int).SetUint64(epochs[i+1]),
+				Shards: []shard.Committee{{ShardID: 0}},
+			}, true)
+			hb = hb.ShardState(ss)
+		}
+		header := hb.Header()
+		chain.headers = append(chain.headers, header)
+		parentHash = header.Hash()
+	}
+	return chain
+}
+
+func makeTestSig(bn uint64) bls.SerializedSignature {
+	var sig bls.SerializedSignature
+	sig[0] = byte(bn)
+	return sig
+}
+
+func makeTestSigAndBitmap(bn uint64) []byte {
+	sig := makeTestSig(bn)
+	return append(sig[:], byte(bn))
+}
+
+func (tc *testChain) CurrentHeader() *block.Header {
+	return tc.headers[len(tc.headers)-1]
+}
+
+func (tc *testChain) GetHeaderByNumber(number uint64) *block.Header {
+	if number >= uint64(len(tc.headers)) {
+		return nil
+	}
+	return tc.headers[number]
+}
+
+func (tc *testChain) ReadCommitSig(blockNum uint64) ([]byte, error) {
+	if blockNum != uint64(len(tc.headers)-1) {
+		return nil, errors.New("not found")
+	}
+	return makeTestSigAndBitmap(blockNum), nil
+}
+
+func (tc *testChain) StateAt(root common.Hash) (*state.DB, error) {
+	return state.New(root, tc.sdb)
+}
+
+func TestChainHelper_getEpochHeaders(t *testing.T) {
+	ch := newChainHelper(makeTestChain(testEpochs))
+
+	epochs := []uint64{0, 1, 2, 3, 4, 5}
+	headers, sigs, err := ch.getEpochHeaders(epochs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// epoch 2 has the last header of epoch 1, the epoch 4 is not over
+	expected := []int64{2, 5, 5, 8, -1, -1}
+	for i, bn := range expected {
+		if bn < 0 {
+			if headers[i] != nil || sigs[i] != nil {
+				t.Errorf("epoch %v: unexpected header", epochs[i])
+			}
+			continue
+		}
+		if headers[i] == nil || headers[i].Number().Int64() != bn {
+			t.Errorf("epoch %v: unexpected header %v, expected %v", epochs[i], headers[i], bn)
+			continue
+		}
+		if !headers[i].IsLastBlockInEpoch() {
+			t.Errorf("epoch %v: no shard state", epochs[i])
+		}
+		if !bytes.Equal(sigs[i], makeTestSigAndBitmap(uint64(bn))) {
+			t.Errorf("epoch %v: unexpected commit sig", epochs[i])
+		}
+	}
+}
+
+func TestChainHelper_getEpochHeadersAtEpochEnd(t *testing.T) {
+	ch := newChainHelper(makeTestChain(append(testEpochs, 5)))
+
+	headers, sigs, err := ch.getEpochHeaders([]uint64{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers[0] == nil || headers[0].Number().Uint64() != 9 {
+		t.Fatalf("unexpected header %v", headers[0])
+	}
+	if !bytes.Equal(sigs[0], makeTestSigAndBitmap(9)) {
+		t.Errorf("unexpected commit sig")
+	}
+}
+
+func TestChainHelper_getHeadersByNumber(t *testing.T) {
+	ch := newChainHelper(makeTestChain(testEpochs))
+
+	bns := []uint64{0, 5, 9, 10}
+	headers, sigs, err := ch.getHeadersByNumber(bns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, bn := range bns[:3] {
+		if headers[i].Number().Uint64() != bn {
+			t.Errorf("unexpected header %v, expected %v", headers[i].Number(), bn)
+		}
+		if !bytes.Equal(sigs[i], makeTestSigAndBitmap(bn)) {
+			t.Errorf("header %v: unexpected commit sig", bn)
+		}
+	}
+	if headers[3] != nil || sigs[3] != nil {
+		t.Errorf("unexpected unknown header")
+	}
+}
+
+func TestChainHelper_getAccountProof(t *testing.T) {
+	chain := makeTestChain(testEpochs)
+	ch := newChainHelper(chain)
+
+	proof, storageProofs, err := ch.getAccountProof(3, testAddr, []common.Hash{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := verifyTestProof(t, chain.root, testAddr.Bytes(), proof); len(value) == 0 {
+		t.Errorf("account not proved")
+	}
+	if len(storageProofs) != 1 {
+		t.Fatalf("unexpected storage proofs %v", len(storageProofs))
+	}
+	db, _ := chain.StateAt(chain.root)
+	storageRoot := db.StorageTrie(testAddr).Hash()
+	if value := verifyTestProof(t, storageRoot, testKey.Bytes(), storageProofs[0]); len(value) == 0 {
+		t.Errorf("storage slot not proved")
+	}
+
+	// missing account
+	proof, storageProofs, err = ch.getAccountProof(3, common.Address{9}, []common.Hash{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := verifyTestProof(t, chain.root, common.Address{9}.Bytes(), proof); value != nil {
+		t.Errorf("unexpected proof of missing account")
+	}
+	if len(storageProofs) != 0 {
+		t.Errorf("unexpected storage proofs of missing account")
+	}
+
+	if _, _, err := ch.getAccountProof(10, testAddr, nil); err != errHeaderNotFound {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func verifyTestProof(t *testing.T, root common.Hash, key []byte, proof [][]byte) []byte {
+	t.Helper()
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	value, _, err := trie.VerifyProof(root, crypto.Keccak256(key), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
@@ -0,0 +1,218 @@
+package light
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/crypto/bls"
+	lightmsg "github.com/harmony-one/harmony/p2p/stream/protocols/light/message"
+	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	"github.com/pkg/errors"
+)
+
+// HeaderWithSig is a block header with its commit signature and bitmap
+type HeaderWithSig struct {
+	Header       *block.Header
+	CommitSig    []byte
+	CommitBitmap []byte
+}
+
+// AccountProof is the proof of an account and of its storage slots in the
+// state of a block, as the trie nodes from the state root
+type AccountProof struct {
+	BlockNum      uint64
+	Address       common.Address
+	Proof         [][]byte
+	StorageKeys   []common.Hash
+	StorageProofs [][][]byte
+}
+
+// GetCurrentHeader do getCurrentHeaderRequest through light stream protocol.
+// Return the current header of the remote node with its commit signature.
+func (p *Protocol) GetCurrentHeader(ctx context.Context, opts ...Option) (header *HeaderWithSig, stid sttypes.StreamID, err error) {
+	timer := p.doMetricClientRequest("getCurrentHeader")
+	defer p.doMetricPostClientRequest("getCurrentHeader", err, timer)
+
+	req := newRequest(lightmsg.MakeGetCurrentHeaderRequest(), "GetCurrentHeader")
+	p.crl.LimitRequest(getCurrentHeaderCost)
+	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
+	if err != nil {
+		return
+	}
+	headers, err := getHeadersFromResponse(resp, lightmsg.GetCurrentHeaderCode)
+	if err != nil {
+		return
+	}
+	if len(headers) != 1 || headers[0] == nil {
+		err = errors.New("current header not in response")
+		return
+	}
+	header = headers[0]
+	return
+}
+
+// GetHeadersByNumber do getHeadersByNumberRequest through light stream protocol.
+// Return the headers with their commit signatures, nil for an unknown header.
+func (p *Protocol) GetHeadersByNumber(ctx context.Context, bns []uint64, opts ...Option) (headers []*HeaderWithSig, stid sttypes.StreamID, err error) {
+	timer := p.doMetricClientRequest("getHeadersByNumber")
+	defer p.doMetricPostClientRequest("getHeadersByNumber", err, timer)
+
+	if len(bns) == 0 {
+		err = fmt.Errorf("zero block numbers requested")
+		return
+	}
+	if len(bns) > GetHeadersByNumAmountCap {
+		err = fmt.Errorf("number of headers exceed cap of %v", GetHeadersByNumAmountCap)
+		return
+	}
+	req := newRequest(lightmsg.MakeGetHeadersByNumRequest(bns), fmt.Sprintf("GetHeadersByNumber: %v", bns))
+	p.crl.LimitRequest(getHeadersByNumCost * len(bns))
+	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
+	if err != nil {
+		return
+	}
+	headers, err = getHeadersFromResponse(resp, lightmsg.GetHeadersByNumCode)
+	if err == nil && len(headers) != len(bns) {
+		err = fmt.Errorf("headers size not expected: %v / %v", len(headers), len(bns))
+	}
+	return
+}
+
+// GetEpochHeaders do getEpochHeadersRequest through light stream protocol.
+// Return the last header of each epoch, carrying the shard state of the next
+// epoch, with its commit signature. The header is the last one of an earlier
+// epoch if the shard has no block in the epoch, and nil if the epoch is not over.
+func (p *Protocol) GetEpochHeaders(ctx context.Context, epochs []uint64, opts ...Option) (headers []*HeaderWithSig, stid sttypes.StreamID, err error) {
+	timer := p.doMetricClientRequest("getEpochHeaders")
+	defer p.doMetricPostClientRequest("getEpochHeaders", err, timer)
+
+	if len(epochs) == 0 {
+		err = fmt.Errorf("zero epochs requested")
+		return
+	}
+	if len(epochs) > GetEpochHeadersAmountCap {
+		err = fmt.Errorf("number of epochs exceed cap of %v", GetEpochHeadersAmountCap)
+		return
+	}
+	req := newRequest(lightmsg.MakeGetEpochHeadersRequest(epochs), fmt.Sprintf("GetEpochHeaders: %v", epochs))
+	p.crl.LimitRequest(getEpochHeadersCost * len(epochs))
+	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
+	if err != nil {
+		return
+	}
+	headers, err = getHeadersFromResponse(resp, lightmsg.GetEpochHeadersCode)
+	if err == nil && len(headers) != len(epochs) {
+		err = fmt.Errorf("headers size not expected: %v / %v", len(headers), len(epochs))
+	}
+	return
+}
+
+// GetAccountProof do getAccountProofRequest through light stream protocol.
+// Return the proof of the account and of its storage slots of the keys in the
+// state of the block number. The proof is not verified.
+func (p *Protocol) GetAccountProof(ctx context.Context, bn uint64, addr common.Address, keys []common.Hash, opts ...Option) (proof *AccountProof, stid sttypes.StreamID, err error) {
+	timer := p.doMetricClientRequest("getAccountProof")
+	defer p.doMetricPostClientRequest("getAccountProof", err, timer)
+
+	if len(keys) > GetAccountProofStorageKeysCap {
+		err = fmt.Errorf("number of storage keys exceed cap of %v", GetAccountProofStorageKeysCap)
+		return
+	}
+	req := newRequest(lightmsg.MakeGetAccountProofRequest(bn, addr, keys),
+		fmt.Sprintf("GetAccountProof: %v at %v", addr.Hex(), bn))
+	p.crl.LimitRequest(getAccountProofCost * (1 + len(keys)))
+	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
+	if err != nil {
+		return
+	}
+	lResp, ok := resp.(*lightResponse)
+	if !ok || lResp == nil {
+		err = errors.New("not light response")
+		return
+	}
+	apResp, err := lResp.msg.GetAccountProofResponse()
+	if err != nil {
+		return
+	}
+	if len(apResp.StorageProofs) != 0 && len(apResp.StorageProofs) != len(keys) {
+		err = fmt.Errorf("storage proofs size not expected: %v / %v", len(apResp.StorageProofs), len(keys))
+		return
+	}
+	proof = &AccountProof{
+		BlockNum:      bn,
+		Address:       addr,
+		Proof:         apResp.AccountProof,
+		StorageKeys:   keys,
+		StorageProofs: apResp.StorageProofs,
+	}
+	return
+}
+
+// request is the light protocol request which implements sttypes.Request
+type request struct {
+	msg  *lightmsg.Request
+	desc string
+}
+
+func newRequest(msg *lightmsg.Request, desc string) *request {
+	return &request{
+		msg:  msg,
+		desc: desc,
+	}
+}
+
+func (req *request) ReqID() uint64 {
+	return req.msg.ReqID
+}
+
+func (req *request) SetReqID(val uint64) {
+	req.msg.ReqID = val
+}
+
+func (req *request) String() string {
+	return fmt.Sprintf("REQUEST [%v]", req.desc)
+}
+
+func (req *request) IsSupportedByProto(target sttypes.ProtoSpec) bool {
+	return target.Version.GreaterThanOrEqual(MinVersion)
+}
+
+func (req *request) Encode() ([]byte, error) {
+	msg := lightmsg.MakeMessageFromRequest(req.msg)
+	return rlp.EncodeToBytes(msg)
+}
+
+func getHeadersFromResponse(resp sttypes.Response, code uint64) ([]*HeaderWithSig, error) {
+	lResp, ok := resp.(*lightResponse)
+	if !ok || lResp == nil {
+		return nil, errors.New("not light response")
+	}
+	hResp, err := lResp.msg.GetHeadersResponse(code)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]*HeaderWithSig, 0, len(hResp.HeadersBytes))
+	for i, hb := range hResp.HeadersBytes {
+		if len(hb) == 0 {
+			headers = append(headers, nil)
+			continue
+		}
+		header := new(block.Header)
+		if err := rlp.DecodeBytes(hb, header); err != nil {
+			return nil, errors.Wrap(err, "[HeadersResponse]")
+		}
+		sig := hResp.CommitSigs[i]
+		if len(sig) < bls.BLSSignatureSizeInBytes {
+			return nil, fmt.Errorf("invalid commit sig of header %v", header.Number())
+		}
+		headers = append(headers, &HeaderWithSig{
+			Header:       header,
+			CommitSig:    sig[:bls.BLSSignatureSizeInBytes],
+			CommitBitmap: sig[bls.BLSSignatureSizeInBytes:],
+		})
+	}
+	return headers, nil
+}
@@ -0,0 +1,221 @@
+package light
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/p2p/stream/common/ratelimiter"
+	lightmsg "github.com/harmony-one/harmony/p2p/stream/protocols/light/message"
+	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+)
+
+var (
+	_ sttypes.Protocol = &Protocol{}
+	_ sttypes.Request  = &request{}
+	_ sttypes.Response = &lightResponse{}
+)
+
+var testStreamID = sttypes.StreamID("[test stream]")
+
+func TestProtocol_GetEpochHeaders(t *testing.T) {
+	p := makeTestProtocol(makeTestChain(testEpochs))
+
+	headers, stid, err := p.GetEpochHeaders(context.Background(), []uint64{1, 2, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stid != testStreamID {
+		t.Errorf("unexpected stream ID %v", stid)
+	}
+	if len(headers) != 3 || headers[2] != nil {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+	for _, h := range headers[:2] {
+		if err := checkHeaderWithSig(h, 5); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestProtocol_GetHeadersByNumber(t *testing.T) {
+	p := makeTestProtocol(makeTestChain(testEpochs))
+
+	headers, _, err := p.GetHeadersByNumber(context.Background(), []uint64{3, 9, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 3 || headers[2] != nil {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+	for i, bn := range []uint64{3, 9} {
+		if err := checkHeaderWithSig(headers[i], bn); err != nil {
+			t.Error(err)
+		}
+	}
+
+	bns := make([]uint64, GetHeadersByNumAmountCap+1)
+	if _, _, err := p.GetHeadersByNumber(context.Background(), bns); err == nil {
+		t.Errorf("unexpected request above the cap")
+	}
+}
+
+func TestProtocol_GetCurrentHeader(t *testing.T) {
+	p := makeTestProtocol(makeTestChain(testEpochs))
+
+	header, _, err := p.GetCurrentHeader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkHeaderWithSig(header, 9); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProtocol_GetAccountProof(t *testing.T) {
+	chain := makeTestChain(testEpochs)
+	p := makeTestProtocol(chain)
+
+	proof, _, err := p.GetAccountProof(context.Background(), 4, testAddr, []common.Hash{testKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.BlockNum != 4 || proof.Address != testAddr || len(proof.StorageProofs) != 1 {
+		t.Fatalf("unexpected proof %+v", proof)
+	}
+	if value := verifyTestProof(t, chain.root, testAddr.Bytes(), proof.Proof); len(value) == 0 {
+		t.Errorf("account not proved")
+	}
+
+	// error response of the server
+	_, _, err = p.GetAccountProof(context.Background(), 10, testAddr, nil)
+	if err := assertError(err, errHeaderNotFound); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRequestCost(t *testing.T) {
+	tests := []struct {
+		req  *lightmsg.Request
+		cost int
+	}{
+		{lightmsg.MakeGetCurrentHeaderRequest(), getCurrentHeaderCost},
+		{lightmsg.MakeGetHeadersByNumRequest([]uint64{1, 2, 3}), 3 * getHeadersByNumCost},
+		{lightmsg.MakeGetHeadersByNumRequest(make([]uint64, 1000)), GetHeadersByNumAmountCap * getHeadersByNumCost},
+		{lightmsg.MakeGetEpochHeadersRequest([]uint64{1, 2}), 2 * getEpochHeadersCost},
+		{lightmsg.MakeGetAccountProofRequest(1, testAddr, nil), 2 * getAccountProofCost},
+		{lightmsg.MakeGetAccountProofRequest(1, testAddr, []common.Hash{{1}, {2}}), 3 * getAccountProofCost},
+		{&lightmsg.Request{Code: 100}, getCurrentHeaderCost},
+	}
+	for i, test := range tests {
+		if cost := requestCost(test.req); cost != test.cost {
+			t.Errorf("Test %v: unexpected cost %v / %v", i, cost, test.cost)
+		}
+	}
+}
+
+func checkHeaderWithSig(h *HeaderWithSig, bn uint64) error {
+	if h == nil || h.Header.Number().Uint64() != bn {
+		return fmt.Errorf("unexpected header %v, expected %v", h, bn)
+	}
+	sig := makeTestSig(bn)
+	if !bytes.Equal(h.CommitSig, sig[:]) || !bytes.Equal(h.CommitBitmap, []byte{byte(bn)}) {
+		return fmt.Errorf("unexpected commit sig of header %v", bn)
+	}
+	if len(h.CommitSig) != bls.BLSSignatureSizeInBytes {
+		return fmt.Errorf("unexpected commit sig size %v", len(h.CommitSig))
+	}
+	return nil
+}
+
+// makeTestProtocol makes the protocol with the requests served by a stream of
+// the chain
+func makeTestProtocol(chain Chain) *Protocol {
+	return &Protocol{
+		rm:  &testRequestManager{chain: newChainHelper(chain)},
+		crl: ratelimiter.NewClientRateLimiter(100),
+	}
+}
+
+type testRequestManager struct {
+	chain chainHelper
+}
+
+func (rm *testRequestManager) Start()                                             {}
+func (rm *testRequestManager) Close()                                             {}
+func (rm *testRequestManager) DeliverResponse(sttypes.StreamID, sttypes.Response) {}
+
+// DoRequest serves the request with the stream compute functions, through the
+// message encoding
+func (rm *testRequestManager) DoRequest(ctx context.Context, request sttypes.Request, opts ...Option) (sttypes.Response, sttypes.StreamID, error) {
+	b, err := request.Encode()
+	if err != nil {
+		return nil, "", err
+	}
+	msg := &lightmsg.Message{}
+	if err := rlp.DecodeBytes(b, msg); err != nil {
+		return nil, "", err
+	}
+	st := &lightStream{chain: rm.chain}
+	resp, err := rm.serve(st, msg.Req)
+	if err != nil {
+		resp = lightmsg.MakeErrorResponseMessage(msg.Req.ReqID, err)
+	}
+	if b, err = rlp.EncodeToBytes(resp); err != nil {
+		return nil, "", err
+	}
+	msg = &lightmsg.Message{}
+	if err := rlp.DecodeBytes(b, msg); err != nil {
+		return nil, "", err
+	}
+	return &lightResponse{msg.Resp}, testStreamID, nil
+}
+
+func (rm *testRequestManager) serve(st *lightStream, req *lightmsg.Request) (*lightmsg.Message, error) {
+	switch req.Code {
+	case lightmsg.GetCurrentHeaderCode:
+		header, sig, err := st.chain.getCurrentHeader()
+		if err != nil {
+			return nil, err
+		}
+		return st.computeHeadersResp(req.ReqID, req.Code, []*block.Header{header}, [][]byte{sig})
+	case lightmsg.GetHeadersByNumCode:
+		hReq, err := req.GetHeadersByNumRequest()
+		if err != nil {
+			return nil, err
+		}
+		return st.computeRespFromBlockNumber(req.ReqID, hReq.Nums)
+	case lightmsg.GetEpochHeadersCode:
+		eReq, err := req.GetEpochHeadersRequest()
+		if err != nil {
+			return nil, err
+		}
+		return st.computeRespFromEpochs(req.ReqID, eReq.Epochs)
+	case lightmsg.GetAccountProofCode:
+		aReq, err := req.GetAccountProofRequest()
+		if err != nil {
+			return nil, err
+		}
+		return st.computeAccountProofResp(req.ReqID, aReq)
+	}
+	return nil, errUnknownReqType
+}
+
+func assertError(got, expect error) error {
+	if (got == nil) != (expect == nil) {
+		return fmt.Errorf("unexpected error: %v / %v", got, expect)
+	}
+	if got == nil {
+		return nil
+	}
+	if !strings.Contains(got.Error(), expect.Error()) {
+		return fmt.Errorf("unexpected error: %v / %v", got, expect)
+	}
+	return nil
+}
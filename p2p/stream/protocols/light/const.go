@@ -0,0 +1,41 @@
+package light
+
+import "time"
+
+const (
+	// GetHeadersByNumAmountCap is the cap of a single GetHeadersByNum request
+	GetHeadersByNumAmountCap = 50
+
+	// GetEpochHeadersAmountCap is the cap of a single GetEpochHeaders request.
+	// An epoch header carries the shard state of the next epoch, up to a few
+	// hundred KB on mainnet, so the cap is kept small.
+	GetEpochHeadersAmountCap = 10
+
+	// GetAccountProofStorageKeysCap is the cap of the storage keys of a single
+	// GetAccountProof request
+	GetAccountProofStorageKeysCap = 20
+
+	// minAdvertiseInterval is the minimum advertise interval
+	minAdvertiseInterval = 1 * time.Minute
+
+	// rateLimiterGlobalRequestPerSecond is the request cost per second limit for all streams in the light protocol.
+	rateLimiterGlobalRequestPerSecond = 500
+
+	// rateLimiterSingleRequestsPerSecond is the request cost per second limit for a single stream in the light protocol.
+	rateLimiterSingleRequestsPerSecond = 100
+
+	// rateLimiterClientRequestsPerSecond is the request cost per second limit for the outgoing requests.
+	rateLimiterClientRequestsPerSecond = 500
+
+	// rateLimiterSingleResponseBytesPerSecond is the response bytes per second limit for a single stream
+	// in the light protocol. A stream exceeding it gets an error response and is closed.
+	rateLimiterSingleResponseBytesPerSecond = 4 * 1024 * 1024
+
+	// Request costs. A current header request has a fixed cost, the header requests cost per
+	// header, and an account proof request costs per proof. An epoch header is looked up with
+	// a binary search on the chain, hence the higher cost.
+	getCurrentHeaderCost = 1
+	getHeadersByNumCost  = 1
+	getEpochHeadersCost  = 10
+	getAccountProofCost  = 5
+)
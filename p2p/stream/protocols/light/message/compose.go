@@ -0,0 +1,111 @@
+package message
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MakeGetCurrentHeaderRequest makes the GetCurrentHeader request
+func MakeGetCurrentHeaderRequest() *Request {
+	return makeRequest(GetCurrentHeaderCode, &GetCurrentHeaderRequest{})
+}
+
+// MakeGetHeadersByNumRequest makes the GetHeadersByNum request
+func MakeGetHeadersByNumRequest(bns []uint64) *Request {
+	return makeRequest(GetHeadersByNumCode, &GetHeadersByNumRequest{
+		Nums: bns,
+	})
+}
+
+// MakeGetEpochHeadersRequest makes the GetEpochHeaders request
+func MakeGetEpochHeadersRequest(epochs []uint64) *Request {
+	return makeRequest(GetEpochHeadersCode, &GetEpochHeadersRequest{
+		Epochs: epochs,
+	})
+}
+
+// MakeGetAccountProofRequest makes the GetAccountProof request
+func MakeGetAccountProofRequest(bn uint64, addr common.Address, keys []common.Hash) *Request {
+	return makeRequest(GetAccountProofCode, &GetAccountProofRequest{
+		BlockNum:    bn,
+		Address:     addr,
+		StorageKeys: keys,
+	})
+}
+
+// MakeErrorResponseMessage makes the error response message
+func MakeErrorResponseMessage(rid uint64, err error) *Message {
+	resp := MakeErrorResponse(rid, err)
+	return makeMessageFromResponse(resp)
+}
+
+// MakeErrorResponse makes the error response as a response
+func MakeErrorResponse(rid uint64, err error) *Response {
+	return makeResponse(rid, ErrorCode, &ErrorResponse{
+		Error: err.Error(),
+	})
+}
+
+// MakeHeadersResponseMessage makes the response message of the header request
+// of the code
+func MakeHeadersResponseMessage(rid uint64, code uint64, headersBytes, sigs [][]byte) *Message {
+	resp := MakeHeadersResponse(rid, code, headersBytes, sigs)
+	return makeMessageFromResponse(resp)
+}
+
+// MakeHeadersResponse makes the response of the header request of the code
+func MakeHeadersResponse(rid uint64, code uint64, headersBytes, sigs [][]byte) *Response {
+	return makeResponse(rid, code, &HeadersResponse{
+		HeadersBytes: headersBytes,
+		CommitSigs:   sigs,
+	})
+}
+
+// MakeAccountProofResponseMessage makes the GetAccountProof response message
+func MakeAccountProofResponseMessage(rid uint64, proof [][]byte, storageProofs [][][]byte) *Message {
+	resp := MakeAccountProofResponse(rid, proof, storageProofs)
+	return makeMessageFromResponse(resp)
+}
+
+// MakeAccountProofResponse makes the GetAccountProof response
+func MakeAccountProofResponse(rid uint64, proof [][]byte, storageProofs [][][]byte) *Response {
+	return makeResponse(rid, GetAccountProofCode, &AccountProofResponse{
+		AccountProof:  proof,
+		StorageProofs: storageProofs,
+	})
+}
+
+// MakeMessageFromRequest makes a message from the request
+func MakeMessageFromRequest(req *Request) *Message {
+	return &Message{
+		Req: req,
+	}
+}
+
+func makeMessageFromResponse(resp *Response) *Message {
+	return &Message{
+		Resp: resp,
+	}
+}
+
+func makeRequest(code uint64, payload interface{}) *Request {
+	return &Request{
+		Code: code,
+		Data: encodePayload(payload),
+	}
+}
+
+func makeResponse(rid uint64, code uint64, payload interface{}) *Response {
+	return &Response{
+		ReqID: rid,
+		Code:  code,
+		Data:  encodePayload(payload),
+	}
+}
+
+// encodePayload encodes the payload of a request or response. The payloads
+// are structs of byte slices and integers, never failing to encode.
+func encodePayload(payload interface{}) rlp.RawValue {
+	b, _ := rlp.EncodeToBytes(payload)
+	return b
+}
@@ -0,0 +1,84 @@
+// Package message defines the messages of the light stream protocol. A message
+// is RLP encoded, and carries either a request or a response, whose payload is
+// the RLP encoding of the type identified by its code.
+package message
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Codes of the requests and their responses
+const (
+	GetCurrentHeaderCode uint64 = iota
+	GetHeadersByNumCode
+	GetEpochHeadersCode
+	GetAccountProofCode
+
+	// ErrorCode is the code of the error response of any request
+	ErrorCode uint64 = 0xff
+)
+
+// Message is the light protocol message, with either a request or a response
+type Message struct {
+	Req  *Request  `rlp:"nil"`
+	Resp *Response `rlp:"nil"`
+}
+
+// Request is a light protocol request
+type Request struct {
+	ReqID uint64
+	Code  uint64
+	Data  rlp.RawValue
+}
+
+// Response is a light protocol response, of the code of its request or the
+// error code
+type Response struct {
+	ReqID uint64
+	Code  uint64
+	Data  rlp.RawValue
+}
+
+// GetCurrentHeaderRequest is the request of the current header of the chain
+type GetCurrentHeaderRequest struct{}
+
+// GetHeadersByNumRequest is the request of the headers of the block numbers
+type GetHeadersByNumRequest struct {
+	Nums []uint64
+}
+
+// GetEpochHeadersRequest is the request of the last headers of the epochs,
+// carrying the shard state of the next epoch
+type GetEpochHeadersRequest struct {
+	Epochs []uint64
+}
+
+// GetAccountProofRequest is the request of the proof of an account, and of
+// its storage slots, in the state of the block number
+type GetAccountProofRequest struct {
+	BlockNum    uint64
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// ErrorResponse is the response of a failed request
+type ErrorResponse struct {
+	Error string
+}
+
+// HeadersResponse is the response of the header requests. The header bytes are
+// empty for an unknown header, and each commit signature is the aggregated
+// signature followed by the bitmap.
+type HeadersResponse struct {
+	HeadersBytes [][]byte
+	CommitSigs   [][]byte
+}
+
+// AccountProofResponse is the response of the GetAccountProof request, with
+// the trie nodes proving the account and each of the storage slots. The
+// storage proofs are empty for an account not in the state.
+type AccountProofResponse struct {
+	AccountProof  [][]byte
+	StorageProofs [][][]byte
+}
@@ -0,0 +1,107 @@
+package message
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/pkg/errors"
+)
+
+// ResponseError is the error from an error response
+type ResponseError struct {
+	msg string
+}
+
+// Error is the error string of ResponseError
+func (err *ResponseError) Error() string {
+	return fmt.Sprintf("[RESPONSE] %v", err.msg)
+}
+
+// GetHeadersByNumRequest parses the request to GetHeadersByNumRequest
+func (req *Request) GetHeadersByNumRequest() (*GetHeadersByNumRequest, error) {
+	r := &GetHeadersByNumRequest{}
+	if err := req.decode(GetHeadersByNumCode, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetEpochHeadersRequest parses the request to GetEpochHeadersRequest
+func (req *Request) GetEpochHeadersRequest() (*GetEpochHeadersRequest, error) {
+	r := &GetEpochHeadersRequest{}
+	if err := req.decode(GetEpochHeadersCode, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetAccountProofRequest parses the request to GetAccountProofRequest
+func (req *Request) GetAccountProofRequest() (*GetAccountProofRequest, error) {
+	r := &GetAccountProofRequest{}
+	if err := req.decode(GetAccountProofCode, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (req *Request) decode(code uint64, payload interface{}) error {
+	if req.Code != code {
+		return fmt.Errorf("unexpected request code %v", req.Code)
+	}
+	if err := rlp.DecodeBytes(req.Data, payload); err != nil {
+		return errors.Wrapf(err, "cannot decode request of code %v", code)
+	}
+	return nil
+}
+
+// GetHeadersResponse parses the response to the HeadersResponse of the header
+// request of the code
+func (resp *Response) GetHeadersResponse(code uint64) (*HeadersResponse, error) {
+	r := &HeadersResponse{}
+	if err := resp.decode(code, r); err != nil {
+		return nil, err
+	}
+	if len(r.HeadersBytes) != len(r.CommitSigs) {
+		return nil, fmt.Errorf("commit sigs size not expected: %v / %v",
+			len(r.CommitSigs), len(r.HeadersBytes))
+	}
+	return r, nil
+}
+
+// GetAccountProofResponse parses the response to AccountProofResponse
+func (resp *Response) GetAccountProofResponse() (*AccountProofResponse, error) {
+	r := &AccountProofResponse{}
+	if err := resp.decode(GetAccountProofCode, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// decode decodes the payload of the response of the code, or returns the error
+// of an error response
+func (resp *Response) decode(code uint64, payload interface{}) error {
+	if resp.Code == ErrorCode {
+		errResp := &ErrorResponse{}
+		if err := rlp.DecodeBytes(resp.Data, errResp); err != nil {
+			return errors.Wrap(err, "cannot decode error response")
+		}
+		return &ResponseError{errResp.Error}
+	}
+	if resp.Code != code {
+		return fmt.Errorf("unexpected response code %v", resp.Code)
+	}
+	if err := rlp.DecodeBytes(resp.Data, payload); err != nil {
+		return errors.Wrapf(err, "cannot decode response of code %v", code)
+	}
+	return nil
+}
+
+// String returns the string of the request
+func (req *Request) String() string {
+	return fmt.Sprintf("[Request %v: code %v]", req.ReqID, req.Code)
+}
+
+// String returns the string of the response
+func (resp *Response) String() string {
+	return fmt.Sprintf("[Response %v: code %v]", resp.ReqID, resp.Code)
+}
@@ -0,0 +1,350 @@
+package light
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/block"
+	lightmsg "github.com/harmony-one/harmony/p2p/stream/protocols/light/message"
+	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
+	libp2p_network "github.com/libp2p/go-libp2p-core/network"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// lightStream is the structure for a stream running light protocol.
+type lightStream struct {
+	// Basic stream
+	*sttypes.BaseStream
+
+	protocol *Protocol
+	chain    chainHelper // nil if not serving the protocol
+
+	// pipeline channels
+	reqC  chan *lightmsg.Request
+	respC chan *lightmsg.Response
+
+	// close related fields. Concurrent call of close is possible.
+	closeC    chan struct{}
+	closeStat uint32
+
+	logger zerolog.Logger
+}
+
+// wrapStream wraps the raw libp2p stream to lightStream
+func (p *Protocol) wrapStream(raw libp2p_network.Stream) *lightStream {
+	bs := sttypes.NewBaseStream(raw)
+	logger := p.logger.With().
+		Str("ID", string(bs.ID())).
+		Str("Remote Protocol", string(bs.ProtoID())).
+		Logger()
+
+	var chain chainHelper
+	if p.chain != nil {
+		chain = newChainHelper(p.chain)
+	}
+	return &lightStream{
+		BaseStream: bs,
+		protocol:   p,
+		chain:      chain,
+		reqC:       make(chan *lightmsg.Request, 100),
+		respC:      make(chan *lightmsg.Response, 100),
+		closeC:     make(chan struct{}),
+		closeStat:  0,
+		logger:     logger,
+	}
+}
+
+func (st *lightStream) run() {
+	st.logger.Info().Str("StreamID", string(st.ID())).Msg("running light protocol on stream")
+	defer st.logger.Info().Str("StreamID", string(st.ID())).Msg("end running light protocol on stream")
+
+	go st.handleReqLoop()
+	go st.handleRespLoop()
+	st.readMsgLoop()
+}
+
+// readMsgLoop is the loop
+func (st *lightStream) readMsgLoop() {
+	for {
+		msg, err := st.readMsg()
+		if err != nil {
+			if err := st.Close(); err != nil {
+				st.logger.Err(err).Msg("failed to close light stream")
+			}
+			return
+		}
+		st.deliverMsg(msg)
+	}
+}
+
+// deliverMsg process the delivered message and forward to the corresponding channel
+func (st *lightStream) deliverMsg(msg *lightmsg.Message) {
+	if req := msg.Req; req != nil {
+		go func() {
+			select {
+			case st.reqC <- req:
+			case <-time.After(1 * time.Minute):
+				st.logger.Warn().Str("request", req.String()).
+					Msg("request handler severely jammed, message dropped")
+			}
+		}()
+	}
+	if resp := msg.Resp; resp != nil {
+		go func() {
+			select {
+			case st.respC <- resp:
+			case <-time.After(1 * time.Minute):
+				st.logger.Warn().Str("response", resp.String()).
+					Msg("response handler severely jammed, message dropped")
+			}
+		}()
+	}
+}
+
+func (st *lightStream) handleReqLoop() {
+	for {
+		select {
+		case req := <-st.reqC:
+			st.protocol.rl.LimitRequest(st.ID(), requestCost(req))
+			err := st.handleReq(req)
+
+			if err != nil {
+				st.logger.Info().Err(err).Str("request", req.String()).
+					Msg("handle request error. Closing stream")
+				if err := st.Close(); err != nil {
+					st.logger.Err(err).Msg("failed to close light stream")
+				}
+				return
+			}
+
+		case <-st.closeC:
+			return
+		}
+	}
+}
+
+func (st *lightStream) handleRespLoop() {
+	for {
+		select {
+		case resp := <-st.respC:
+			st.handleResp(resp)
+
+		case <-st.closeC:
+			return
+		}
+	}
+}
+
+// Close stops the stream handling and closes the underlying stream
+func (st *lightStream) Close() error {
+	notClosed := atomic.CompareAndSwapUint32(&st.closeStat, 0, 1)
+	if !notClosed {
+		// Already closed by another goroutine. Directly return
+		return nil
+	}
+	if err := st.protocol.sm.RemoveStream(st.ID()); err != nil {
+		st.logger.Err(err).Str("stream ID", string(st.ID())).
+			Msg("failed to remove light stream on close")
+	}
+	close(st.closeC)
+	return st.BaseStream.Close()
+}
+
+// ResetOnClose reset the stream on close
+func (st *lightStream) ResetOnClose() error {
+	notClosed := atomic.CompareAndSwapUint32(&st.closeStat, 0, 1)
+	if !notClosed {
+		// Already closed by another goroutine. Directly return
+		return nil
+	}
+	close(st.closeC)
+	return st.BaseStream.ResetOnClose()
+}
+
+func (st *lightStream) handleReq(req *lightmsg.Request) error {
+	if st.chain == nil {
+		return st.handleRequestWithError(req.ReqID, "notServing", errNotServing)
+	}
+	switch req.Code {
+	case lightmsg.GetCurrentHeaderCode:
+		return st.handleGetCurrentHeaderRequest(req.ReqID)
+	case lightmsg.GetHeadersByNumCode:
+		return st.handleGetHeadersByNumRequest(req)
+	case lightmsg.GetEpochHeadersCode:
+		return st.handleGetEpochHeadersRequest(req)
+	case lightmsg.GetAccountProofCode:
+		return st.handleGetAccountProofRequest(req)
+	}
+	// unsupported request type
+	return st.handleRequestWithError(req.ReqID, "unknown", errUnknownReqType)
+}
+
+func (st *lightStream) handleGetCurrentHeaderRequest(rid uint64) error {
+	st.doMetricServerRequest("getCurrentHeader")
+
+	header, sig, err := st.chain.getCurrentHeader()
+	var resp *lightmsg.Message
+	if err == nil {
+		resp, err = st.computeHeadersResp(rid, lightmsg.GetCurrentHeaderCode,
+			[]*block.Header{header}, [][]byte{sig})
+	}
+	return errors.Wrap(st.writeRespOrError(rid, resp, err), "[GetCurrentHeader]")
+}
+
+func (st *lightStream) handleGetHeadersByNumRequest(req *lightmsg.Request) error {
+	st.doMetricServerRequest("getHeadersByNumber")
+
+	var resp *lightmsg.Message
+	hReq, err := req.GetHeadersByNumRequest()
+	if err == nil {
+		resp, err = st.computeRespFromBlockNumber(req.ReqID, hReq.Nums)
+	}
+	return errors.Wrap(st.writeRespOrError(req.ReqID, resp, err), "[GetHeadersByNumber]")
+}
+
+func (st *lightStream) handleGetEpochHeadersRequest(req *lightmsg.Request) error {
+	st.doMetricServerRequest("getEpochHeaders")
+
+	var resp *lightmsg.Message
+	eReq, err := req.GetEpochHeadersRequest()
+	if err == nil {
+		resp, err = st.computeRespFromEpochs(req.ReqID, eReq.Epochs)
+	}
+	return errors.Wrap(st.writeRespOrError(req.ReqID, resp, err), "[GetEpochHeaders]")
+}
+
+func (st *lightStream) handleGetAccountProofRequest(req *lightmsg.Request) error {
+	st.doMetricServerRequest("getAccountProof")
+
+	var resp *lightmsg.Message
+	aReq, err := req.GetAccountProofRequest()
+	if err == nil {
+		resp, err = st.computeAccountProofResp(req.ReqID, aReq)
+	}
+	return errors.Wrap(st.writeRespOrError(req.ReqID, resp, err), "[GetAccountProof]")
+}
+
+func (st *lightStream) handleRequestWithError(rid uint64, reqType string, err error) error {
+	st.doMetricServerRequest(reqType)
+	resp := lightmsg.MakeErrorResponseMessage(rid, err)
+	return st.writeMsg(resp)
+}
+
+func (st *lightStream) handleResp(resp *lightmsg.Response) {
+	st.protocol.rm.DeliverResponse(st.ID(), &lightResponse{resp})
+}
+
+func (st *lightStream) readMsg() (*lightmsg.Message, error) {
+	b, err := st.ReadBytes()
+	if err != nil {
+		return nil, err
+	}
+	var msg = &lightmsg.Message{}
+	if err := rlp.DecodeBytes(b, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (st *lightStream) writeMsg(msg *lightmsg.Message) error {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	return st.WriteBytes(b)
+}
+
+// writeRespOrError writes the response, or an error response if the request
+// failed. The error of the request is returned along with any write error.
+func (st *lightStream) writeRespOrError(rid uint64, resp *lightmsg.Message, err error) error {
+	if err != nil {
+		resp = lightmsg.MakeErrorResponseMessage(rid, err)
+	}
+	if writeErr := st.writeResp(rid, resp); writeErr != nil {
+		if err == nil {
+			err = writeErr
+		} else {
+			err = fmt.Errorf("%v; [writeMsg] %v", err.Error(), writeErr)
+		}
+	}
+	return err
+}
+
+// writeResp writes the response if the stream is within its response byte budget.
+// Otherwise an error response is written, and the error returned closes the stream.
+func (st *lightStream) writeResp(rid uint64, msg *lightmsg.Message) error {
+	b, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	if err := st.protocol.rl.LimitResponse(st.ID(), len(b)); err != nil {
+		if writeErr := st.writeMsg(lightmsg.MakeErrorResponseMessage(rid, err)); writeErr != nil {
+			return fmt.Errorf("%v; [writeMsg] %v", err.Error(), writeErr)
+		}
+		return err
+	}
+	return st.WriteBytes(b)
+}
+
+func (st *lightStream) computeRespFromBlockNumber(rid uint64, bns []uint64) (*lightmsg.Message, error) {
+	if len(bns) > GetHeadersByNumAmountCap {
+		err := fmt.Errorf("GetHeadersByNum amount exceed cap: %v>%v", len(bns), GetHeadersByNumAmountCap)
+		return nil, err
+	}
+	headers, sigs, err := st.chain.getHeadersByNumber(bns)
+	if err != nil {
+		return nil, err
+	}
+	return st.computeHeadersResp(rid, lightmsg.GetHeadersByNumCode, headers, sigs)
+}
+
+func (st *lightStream) computeRespFromEpochs(rid uint64, epochs []uint64) (*lightmsg.Message, error) {
+	if len(epochs) > GetEpochHeadersAmountCap {
+		err := fmt.Errorf("GetEpochHeaders amount exceed cap: %v>%v", len(epochs), GetEpochHeadersAmountCap)
+		return nil, err
+	}
+	headers, sigs, err := st.chain.getEpochHeaders(epochs)
+	if err != nil {
+		return nil, err
+	}
+	return st.computeHeadersResp(rid, lightmsg.GetEpochHeadersCode, headers, sigs)
+}
+
+func (st *lightStream) computeHeadersResp(rid uint64, code uint64, headers []*block.Header, sigs [][]byte) (*lightmsg.Message, error) {
+	headersBytes := make([][]byte, 0, len(headers))
+	for _, header := range headers {
+		var hb []byte
+		if header != nil {
+			var err error
+			if hb, err = rlp.EncodeToBytes(header); err != nil {
+				return nil, err
+			}
+		}
+		headersBytes = append(headersBytes, hb)
+	}
+	return lightmsg.MakeHeadersResponseMessage(rid, code, headersBytes, sigs), nil
+}
+
+func (st *lightStream) computeAccountProofResp(rid uint64, req *lightmsg.GetAccountProofRequest) (*lightmsg.Message, error) {
+	if len(req.StorageKeys) > GetAccountProofStorageKeysCap {
+		err := fmt.Errorf("GetAccountProof storage keys exceed cap: %v>%v",
+			len(req.StorageKeys), GetAccountProofStorageKeysCap)
+		return nil, err
+	}
+	proof, storageProofs, err := st.chain.getAccountProof(req.BlockNum, req.Address, req.StorageKeys)
+	if err != nil {
+		return nil, err
+	}
+	return lightmsg.MakeAccountProofResponseMessage(rid, proof, storageProofs), nil
+}
+
+func (st *lightStream) doMetricServerRequest(reqType string) {
+	serverRequestCounterVec.With(prometheus.Labels{
+		"topic":        string(st.ProtoID()),
+		"request_type": reqType,
+	}).Inc()
+}
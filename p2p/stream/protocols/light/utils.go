@@ -0,0 +1,95 @@
+package light
+
+import (
+	"fmt"
+
+	"github.com/harmony-one/harmony/p2p/stream/common/requestmanager"
+	lightmsg "github.com/harmony-one/harmony/p2p/stream/protocols/light/message"
+	"github.com/pkg/errors"
+)
+
+var (
+	errUnknownReqType = errors.New("unknown request")
+	errNotServing     = errors.New("light protocol not served")
+)
+
+func (c *RateLimitConfig) fixValues() {
+	if c.GlobalRate <= 0 {
+		c.GlobalRate = rateLimiterGlobalRequestPerSecond
+	}
+	if c.StreamRate <= 0 {
+		c.StreamRate = rateLimiterSingleRequestsPerSecond
+	}
+	if c.ClientRate <= 0 {
+		c.ClientRate = rateLimiterClientRequestsPerSecond
+	}
+	if c.ResponseBytesRate <= 0 {
+		c.ResponseBytesRate = rateLimiterSingleResponseBytesPerSecond
+	}
+}
+
+// requestCost returns the cost of the incoming request. The number of items
+// charged is capped at the request amount cap, above which the request is
+// refused anyway.
+func requestCost(req *lightmsg.Request) int {
+	switch req.Code {
+	case lightmsg.GetHeadersByNumCode:
+		if hReq, err := req.GetHeadersByNumRequest(); err == nil {
+			return getHeadersByNumCost * capItems(len(hReq.Nums), GetHeadersByNumAmountCap)
+		}
+	case lightmsg.GetEpochHeadersCode:
+		if eReq, err := req.GetEpochHeadersRequest(); err == nil {
+			return getEpochHeadersCost * capItems(len(eReq.Epochs), GetEpochHeadersAmountCap)
+		}
+	case lightmsg.GetAccountProofCode:
+		if aReq, err := req.GetAccountProofRequest(); err == nil {
+			// the account proof and the proof of each storage slot
+			return getAccountProofCost * (1 + capItems(len(aReq.StorageKeys), GetAccountProofStorageKeysCap))
+		}
+	}
+	// current header request, unknown request and invalid request
+	return getCurrentHeaderCost
+}
+
+func capItems(n, limit int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > limit {
+		return limit
+	}
+	return n
+}
+
+// lightResponse is the light protocol response which implements sttypes.Response
+type lightResponse struct {
+	msg *lightmsg.Response
+}
+
+// ReqID return the request ID of the response
+func (resp *lightResponse) ReqID() uint64 {
+	return resp.msg.ReqID
+}
+
+func (resp *lightResponse) String() string {
+	return fmt.Sprintf("[LightResponse %v]", resp.msg.String())
+}
+
+// Option is the additional option to do requests.
+// Currently, three options are supported:
+//  1. WithHighPriority - do the request in high priority.
+//  2. WithBlacklist - do the request without the given stream ids as blacklist
+//  3. WithWhitelist - do the request only with the given stream ids
+type Option = requestmanager.RequestOption
+
+var (
+	// WithHighPriority instruct the request manager to do the request with high
+	// priority
+	WithHighPriority = requestmanager.WithHighPriority
+	// WithBlacklist instruct the request manager not to assign the request to the
+	// given streamID
+	WithBlacklist = requestmanager.WithBlacklist
+	// WithWhitelist instruct the request manager only to assign the request to the
+	// given streamID
+	WithWhitelist = requestmanager.WithWhitelist
+)
@@ -31,6 +31,7 @@ func (p *Protocol) GetBlocksByNumber(ctx context.Context, bns []uint64, opts ...
 	}
 
 	req := newGetBlocksByNumberRequest(bns)
+	p.crl.LimitRequest(p.config.RateLimit.Costs.GetBlocksByNumber * len(bns))
 	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
 	if err != nil {
 		// At this point, error can be context canceled, context timed out, or waiting queue
@@ -50,6 +51,7 @@ func (p *Protocol) GetCurrentBlockNumber(ctx context.Context, opts ...Option) (b
 
 	req := newGetBlockNumberRequest()
 
+	p.crl.LimitRequest(p.config.RateLimit.Costs.GetBlockNumber)
 	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
 	if err != nil {
 		return 0, stid, err
@@ -75,6 +77,7 @@ func (p *Protocol) GetBlockHashes(ctx context.Context, bns []uint64, opts ...Opt
 	}
 
 	req := newGetBlockHashesRequest(bns)
+	p.crl.LimitRequest(p.config.RateLimit.Costs.GetBlockHashes * len(bns))
 	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
 	if err != nil {
 		return
@@ -97,6 +100,7 @@ func (p *Protocol) GetBlocksByHashes(ctx context.Context, hs []common.Hash, opts
 		return
 	}
 	req := newGetBlocksByHashesRequest(hs)
+	p.crl.LimitRequest(p.config.RateLimit.Costs.GetBlocksByHashes * len(hs))
 	resp, stid, err := p.rm.DoRequest(ctx, req, opts...)
 	if err != nil {
 		return
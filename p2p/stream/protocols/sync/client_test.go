@@ -302,12 +302,18 @@ func makeTestProtocol(f getResponseFn) *Protocol {
 	copy(streamIDs, initStreamIDs)
 	sm := &testStreamManager{streamIDs}
 
-	rl := ratelimiter.NewRateLimiter(sm, 10, 10)
+	rl := ratelimiter.NewRateLimiter(sm, ratelimiter.Config{
+		GlobalRate:        10,
+		StreamRate:        10,
+		ResponseBytesRate: 10 * 1024 * 1024,
+	})
+	crl := ratelimiter.NewClientRateLimiter(10)
 
 	return &Protocol{
-		rm: rm,
-		rl: rl,
-		sm: sm,
+		rm:  rm,
+		rl:  rl,
+		crl: crl,
+		sm:  sm,
 	}
 }
 
@@ -20,11 +20,25 @@ const (
 	// minAdvertiseInterval is the minimum advertise interval
 	minAdvertiseInterval = 1 * time.Minute
 
-	// rateLimiterGlobalRequestPerSecond is the request per second limit for all streams in the sync protocol.
+	// rateLimiterGlobalRequestPerSecond is the request cost per second limit for all streams in the sync protocol.
 	// This constant helps prevent the node resource from exhausting for being the stream sync host.
-	rateLimiterGlobalRequestPerSecond = 50
+	rateLimiterGlobalRequestPerSecond = 1000
 
-	// rateLimiterSingleRequestsPerSecond is the request per second limit for a single stream in the sync protocol.
+	// rateLimiterSingleRequestsPerSecond is the request cost per second limit for a single stream in the sync protocol.
 	// This constant helps prevent the node resource from exhausting from a single remote node.
-	rateLimiterSingleRequestsPerSecond = 10
+	rateLimiterSingleRequestsPerSecond = 200
+
+	// rateLimiterClientRequestsPerSecond is the request cost per second limit for the outgoing requests.
+	rateLimiterClientRequestsPerSecond = 1000
+
+	// rateLimiterSingleResponseBytesPerSecond is the response bytes per second limit for a single stream
+	// in the sync protocol. A stream exceeding it gets an error response and is closed.
+	rateLimiterSingleResponseBytesPerSecond = 8 * 1024 * 1024
+
+	// Default request costs. A block number request has a fixed cost, while the other requests cost
+	// per item requested.
+	defaultGetBlockNumberCost    = 1
+	defaultGetBlockHashesCost    = 1
+	defaultGetBlocksByNumberCost = 5
+	defaultGetBlocksByHashesCost = 5
 )
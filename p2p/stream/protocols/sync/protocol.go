@@ -42,6 +42,7 @@ type (
 		chain    engine.ChainReader            // provide SYNC data
 		schedule shardingconfig.Schedule       // provide schedule information
 		rl       ratelimiter.RateLimiter       // limit the incoming request rate
+		crl      ratelimiter.ClientRateLimiter // limit the outgoing request rate
 		sm       streammanager.StreamManager   // stream management
 		rm       requestmanager.RequestManager // deliver the response from stream
 		disc     discovery.Discovery
@@ -67,11 +68,35 @@ type (
 		SmHardLowCap int
 		SmHiCap      int
 		DiscBatch    int
+
+		// rate limiter config
+		RateLimit RateLimitConfig
+	}
+
+	// RateLimitConfig is the config of the rate limiting of the sync requests,
+	// weighted by the request costs. Zero values are replaced by the defaults.
+	RateLimitConfig struct {
+		GlobalRate        int // Request cost per second served to all streams
+		StreamRate        int // Request cost per second served to a single stream
+		ClientRate        int // Request cost per second sent to the remote streams
+		ResponseBytesRate int // Response bytes per second served to a single stream
+
+		Costs RequestCosts
+	}
+
+	// RequestCosts is the cost of each sync request type. The block number request
+	// has a fixed cost, the other requests cost per item requested.
+	RequestCosts struct {
+		GetBlockNumber    int
+		GetBlockHashes    int
+		GetBlocksByNumber int
+		GetBlocksByHashes int
 	}
 )
 
 // NewProtocol creates a new sync protocol
 func NewProtocol(config Config) *Protocol {
+	config.RateLimit.fixValues()
 	ctx, cancel := context.WithCancel(context.Background())
 
 	sp := &Protocol{
@@ -91,7 +116,12 @@ func NewProtocol(config Config) *Protocol {
 	sp.sm = streammanager.NewStreamManager(sp.ProtoID(), config.Host, config.Discovery,
 		sp.HandleStream, smConfig)
 
-	sp.rl = ratelimiter.NewRateLimiter(sp.sm, rateLimiterGlobalRequestPerSecond, rateLimiterSingleRequestsPerSecond)
+	sp.rl = ratelimiter.NewRateLimiter(sp.sm, ratelimiter.Config{
+		GlobalRate:        config.RateLimit.GlobalRate,
+		StreamRate:        config.RateLimit.StreamRate,
+		ResponseBytesRate: config.RateLimit.ResponseBytesRate,
+	})
+	sp.crl = ratelimiter.NewClientRateLimiter(config.RateLimit.ClientRate)
 
 	sp.rm = requestmanager.NewRequestManager(sp.sm)
 
@@ -113,7 +113,7 @@ func (st *syncStream) handleReqLoop() {
 	for {
 		select {
 		case req := <-st.reqC:
-			st.protocol.rl.LimitRequest(st.ID())
+			st.protocol.rl.LimitRequest(st.ID(), st.protocol.config.RateLimit.Costs.requestCost(req))
 			err := st.handleReq(req)
 
 			if err != nil {
@@ -193,7 +193,7 @@ func (st *syncStream) handleGetBlockNumberRequest(rid uint64) error {
 	}).Inc()
 
 	resp := st.computeBlockNumberResp(rid)
-	if err := st.writeMsg(resp); err != nil {
+	if err := st.writeResp(rid, resp); err != nil {
 		return errors.Wrap(err, "[GetBlockNumber]: writeMsg")
 	}
 	return nil
@@ -209,7 +209,7 @@ func (st *syncStream) handleGetBlockHashesRequest(rid uint64, req *syncpb.GetBlo
 	if err != nil {
 		resp = syncpb.MakeErrorResponseMessage(rid, err)
 	}
-	if writeErr := st.writeMsg(resp); writeErr != nil {
+	if writeErr := st.writeResp(rid, resp); writeErr != nil {
 		if err == nil {
 			err = writeErr
 		} else {
@@ -229,7 +229,7 @@ func (st *syncStream) handleGetBlocksByNumRequest(rid uint64, req *syncpb.GetBlo
 	if resp == nil && err != nil {
 		resp = syncpb.MakeErrorResponseMessage(rid, err)
 	}
-	if writeErr := st.writeMsg(resp); writeErr != nil {
+	if writeErr := st.writeResp(rid, resp); writeErr != nil {
 		if err == nil {
 			err = writeErr
 		} else {
@@ -250,7 +250,7 @@ func (st *syncStream) handleGetBlocksByHashesRequest(rid uint64, req *syncpb.Get
 	if resp == nil && err != nil {
 		resp = syncpb.MakeErrorResponseMessage(rid, err)
 	}
-	if writeErr := st.writeMsg(resp); writeErr != nil {
+	if writeErr := st.writeResp(rid, resp); writeErr != nil {
 		if err == nil {
 			err = writeErr
 		} else {
@@ -293,6 +293,22 @@ func (st *syncStream) writeMsg(msg *syncpb.Message) error {
 	return st.WriteBytes(b)
 }
 
+// writeResp writes the response if the stream is within its response byte budget.
+// Otherwise an error response is written, and the error returned closes the stream.
+func (st *syncStream) writeResp(rid uint64, msg *syncpb.Message) error {
+	b, err := protobuf.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := st.protocol.rl.LimitResponse(st.ID(), len(b)); err != nil {
+		if writeErr := st.writeMsg(syncpb.MakeErrorResponseMessage(rid, err)); writeErr != nil {
+			return fmt.Errorf("%v; [writeMsg] %v", err.Error(), writeErr)
+		}
+		return err
+	}
+	return st.WriteBytes(b)
+}
+
 func (st *syncStream) computeBlockNumberResp(rid uint64) *syncpb.Message {
 	bn := st.chain.getCurrentBlockNumber()
 	return syncpb.MakeGetBlockNumberResponseMessage(rid, bn)
@@ -3,11 +3,13 @@ package sync
 import (
 	"bytes"
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	protobuf "github.com/golang/protobuf/proto"
+	"github.com/harmony-one/harmony/p2p/stream/common/ratelimiter"
 	syncpb "github.com/harmony-one/harmony/p2p/stream/protocols/sync/message"
 	sttypes "github.com/harmony-one/harmony/p2p/stream/types"
 	ic "github.com/libp2p/go-libp2p-core/crypto"
@@ -126,6 +128,39 @@ func TestSyncStream_HandleGetBlocksByHashes(t *testing.T) {
 	}
 }
 
+func TestSyncStream_ResponseBudgetExceeded(t *testing.T) {
+	st, remoteSt := makeTestSyncStream()
+	st.protocol.rl = ratelimiter.NewRateLimiter(st.protocol.sm, ratelimiter.Config{
+		GlobalRate:        10,
+		StreamRate:        10,
+		ResponseBytesRate: 1,
+	})
+
+	go st.run()
+
+	b, _ := protobuf.Marshal(testGetBlockRequestMsg)
+	for i := 0; i != 2; i++ {
+		if err := remoteSt.WriteBytes(b); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if receivedBytes, _ := remoteSt.ReadBytes(); checkBlocksResult(testGetBlockNumbers, receivedBytes) != nil {
+		t.Fatalf("first response not served")
+	}
+	receivedBytes, _ := remoteSt.ReadBytes()
+	var msg = &syncpb.Message{}
+	if err := protobuf.Unmarshal(receivedBytes, msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.GetResp().GetErrorResponse() == nil {
+		t.Errorf("expect error response, got %v", msg.String())
+	}
+	if atomic.LoadUint32(&st.closeStat) != 1 {
+		t.Errorf("stream not closed after exceeding the response budget")
+	}
+}
+
 func makeTestSyncStream() (*syncStream, *testRemoteBaseStream) {
 	localRaw, remoteRaw := makePairP2PStreams()
 	remote := newTestRemoteBaseStream(remoteRaw)
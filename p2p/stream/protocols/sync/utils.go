@@ -13,6 +13,60 @@ var (
 	errUnknownReqType = errors.New("unknown request")
 )
 
+func (c *RateLimitConfig) fixValues() {
+	if c.GlobalRate <= 0 {
+		c.GlobalRate = rateLimiterGlobalRequestPerSecond
+	}
+	if c.StreamRate <= 0 {
+		c.StreamRate = rateLimiterSingleRequestsPerSecond
+	}
+	if c.ClientRate <= 0 {
+		c.ClientRate = rateLimiterClientRequestsPerSecond
+	}
+	if c.ResponseBytesRate <= 0 {
+		c.ResponseBytesRate = rateLimiterSingleResponseBytesPerSecond
+	}
+	if c.Costs.GetBlockNumber <= 0 {
+		c.Costs.GetBlockNumber = defaultGetBlockNumberCost
+	}
+	if c.Costs.GetBlockHashes <= 0 {
+		c.Costs.GetBlockHashes = defaultGetBlockHashesCost
+	}
+	if c.Costs.GetBlocksByNumber <= 0 {
+		c.Costs.GetBlocksByNumber = defaultGetBlocksByNumberCost
+	}
+	if c.Costs.GetBlocksByHashes <= 0 {
+		c.Costs.GetBlocksByHashes = defaultGetBlocksByHashesCost
+	}
+}
+
+// requestCost returns the cost of the incoming request. The number of items
+// charged is capped at the request amount cap, above which the request is
+// refused anyway.
+func (c RequestCosts) requestCost(req *syncpb.Request) int {
+	if ghReq := req.GetGetBlockHashesRequest(); ghReq != nil {
+		return c.GetBlockHashes * capItems(len(ghReq.Nums), GetBlockHashesAmountCap)
+	}
+	if bnReq := req.GetGetBlocksByNumRequest(); bnReq != nil {
+		return c.GetBlocksByNumber * capItems(len(bnReq.Nums), GetBlocksByNumAmountCap)
+	}
+	if bhReq := req.GetGetBlocksByHashesRequest(); bhReq != nil {
+		return c.GetBlocksByHashes * capItems(len(bhReq.BlockHashes), GetBlocksByHashesAmountCap)
+	}
+	// block number request and unknown request
+	return c.GetBlockNumber
+}
+
+func capItems(n, limit int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > limit {
+		return limit
+	}
+	return n
+}
+
 // syncResponse is the sync protocol response which implements sttypes.Response
 type syncResponse struct {
 	pb *syncpb.Response
@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	libp2p_network "github.com/libp2p/go-libp2p-core/network"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -52,6 +53,11 @@ func (st *BaseStream) ID() StreamID {
 	return StreamID(st.raw.Conn().ID())
 }
 
+// RemotePeer returns the peer ID of the remote end of the stream
+func (st *BaseStream) RemotePeer() libp2p_peer.ID {
+	return st.raw.Conn().RemotePeer()
+}
+
 // ProtoID return the remote protocol ID of the stream
 func (st *BaseStream) ProtoID() ProtoID {
 	return ProtoID(st.raw.Protocol())
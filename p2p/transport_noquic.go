@@ -0,0 +1,18 @@
+// +build !quic
+
+package p2p
+
+import (
+	"github.com/libp2p/go-libp2p"
+)
+
+// quicSupported tells whether the host can listen on QUIC multiaddrs
+const quicSupported = false
+
+// transportOption returns the transports of the host, TCP and websocket
+// only for the builds without the quic tag. The release build sets the tag,
+// it is left out of the other builds as quic-go v0.19 panics at init on the
+// Go releases it does not know about
+func transportOption() libp2p.Option {
+	return libp2p.DefaultTransports
+}
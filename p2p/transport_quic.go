@@ -0,0 +1,20 @@
+// +build quic
+
+package p2p
+
+import (
+	"github.com/libp2p/go-libp2p"
+	libp2p_quic "github.com/libp2p/go-libp2p-quic-transport"
+)
+
+// quicSupported tells whether the host can listen on QUIC multiaddrs
+const quicSupported = true
+
+// transportOption returns the transports of the host, TCP and websocket
+// along with QUIC
+func transportOption() libp2p.Option {
+	return libp2p.ChainOptions(
+		libp2p.DefaultTransports,
+		libp2p.Transport(libp2p_quic.NewTransport),
+	)
+}
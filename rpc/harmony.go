@@ -5,8 +5,10 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/harmony-one/harmony/hmy"
+	"github.com/harmony-one/harmony/p2p"
 )
 
 // PublicHarmonyService provides an API to access Harmony related information.
@@ -59,13 +61,63 @@ func (s *PublicHarmonyService) Syncing(
 // GasPrice returns a suggestion for a gas price.
 // Note that the return type is an interface to account for the different versions
 func (s *PublicHarmonyService) GasPrice(ctx context.Context) (interface{}, error) {
-	// TODO(dm): add SuggestPrice API
+	price, err := s.hmy.SuggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
 	// Format response according to version
 	switch s.version {
 	case V1, Eth:
-		return (*hexutil.Big)(big.NewInt(1)), nil
+		return (*hexutil.Big)(price), nil
 	case V2:
-		return 1, nil
+		return price, nil
+	default:
+		return nil, ErrUnknownRPCVersion
+	}
+}
+
+// FeeHistory returns the fee market history of up to blockCount blocks ending
+// with lastBlock: the gas used ratio of every block and, if requested, the
+// given percentiles of the gas prices paid in every block, weighted by gas
+// used. Harmony has no base fee, so baseFeePerGas is always zero.
+// Note that the return type is an interface to account for the different versions
+func (s *PublicHarmonyService) FeeHistory(
+	ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, rewardPercentiles []float64,
+) (interface{}, error) {
+	history, err := s.hmy.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	// Format response according to version
+	switch s.version {
+	case V1, Eth:
+		result := &FeeHistoryResult{
+			OldestBlock:  (*hexutil.Big)(new(big.Int).SetUint64(history.OldestBlock)),
+			GasUsedRatio: history.GasUsedRatio,
+		}
+		if history.Reward != nil {
+			result.Reward = make([][]*hexutil.Big, len(history.Reward))
+			for i, rewards := range history.Reward {
+				result.Reward[i] = make([]*hexutil.Big, len(rewards))
+				for j, reward := range rewards {
+					result.Reward[i][j] = (*hexutil.Big)(reward)
+				}
+			}
+		}
+		if history.BaseFee != nil {
+			result.BaseFee = make([]*hexutil.Big, len(history.BaseFee))
+			for i, fee := range history.BaseFee {
+				result.BaseFee[i] = (*hexutil.Big)(fee)
+			}
+		}
+		return result, nil
+	case V2:
+		return &FeeHistoryResultV2{
+			OldestBlock:  history.OldestBlock,
+			Reward:       history.Reward,
+			BaseFee:      history.BaseFee,
+			GasUsedRatio: history.GasUsedRatio,
+		}, nil
 	default:
 		return nil, ErrUnknownRPCVersion
 	}
@@ -86,3 +138,12 @@ func (s *PublicHarmonyService) GetPeerInfo(
 	// Response output is the same for all versions
 	return NewStructuredResponse(s.hmy.GetPeerInfo())
 }
+
+// GetPeerScores returns the gossipsub scores and the reputation of the peers
+// known to the node, lowest score first
+func (s *PublicHarmonyService) GetPeerScores(
+	ctx context.Context,
+) ([]p2p.PeerScore, error) {
+	// Response output is the same for all versions
+	return s.hmy.GetPeerScores(), nil
+}
@@ -0,0 +1,666 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// APIKeyHeader is the http header of the api key of a request
+	APIKeyHeader = "X-API-Key"
+
+	// JSON-RPC error codes of the rejected requests, see EIP-1474
+	limitExceededErrorCode = -32005
+	unauthorizedErrorCode  = -32001
+
+	// maxRequestSize matches the request size limit of the rpc server
+	maxRequestSize = 5 * 1024 * 1024
+	// websocket buffer sizes, as in the rpc server
+	wsReadBuffer  = 1024
+	wsWriteBuffer = 1024
+	// maxRateBuckets is the number of clients whose request rate is tracked
+	maxRateBuckets = 65536
+
+	// Reasons of the rejected requests, used as metric labels
+	rejectAuth     = "auth"
+	rejectBatch    = "batch"
+	rejectRate     = "rate"
+	rejectResponse = "response"
+
+	// clientRule is the rule label of the rate limit of a client
+	clientRule = "client"
+)
+
+// Error messages of the rejected requests
+const (
+	errUnknownAPIKey    = "unknown api key"
+	errAPIKeyRequired   = "api key required"
+	errBatchTooLarge    = "batch too large, max %d requests"
+	errRateLimited      = "rate limit of %s exceeded"
+	errResponseTooLarge = "response too large, max %d bytes"
+	errRequestTooLarge  = "request too large, max %d bytes"
+)
+
+// rateBucket is a token bucket refilled at rate tokens per second
+type rateBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateBucket(rate float64, burst int, now time.Time) *rateBucket {
+	b := float64(burst)
+	if b < 1 {
+		b = 1
+	}
+	return &rateBucket{rate: rate, burst: b, tokens: b, last: now}
+}
+
+// take takes a token from the bucket if there is one
+func (b *rateBucket) take(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// requestLimiter enforces the request limits of the rpc servers: api keys,
+// batch sizes, request rates and response sizes.
+type requestLimiter struct {
+	config  nodeconfig.RPCServerConfig
+	apiKeys map[string]nodeconfig.RPCAPIKey
+
+	lock    sync.Mutex
+	buckets *lru.Cache // Rate buckets of the clients by client and rule
+}
+
+// newRequestLimiter returns the limiter of the given config, nil if it sets
+// no limit at all.
+func newRequestLimiter(config nodeconfig.RPCServerConfig) *requestLimiter {
+	if config.BatchLimit <= 0 && config.ResponseLimit <= 0 && config.RateLimit <= 0 &&
+		len(config.MethodRateLimits) == 0 && len(config.APIKeys) == 0 && !config.APIKeyRequired {
+		return nil
+	}
+	apiKeys := make(map[string]nodeconfig.RPCAPIKey, len(config.APIKeys))
+	for _, key := range config.APIKeys {
+		apiKeys[key.Key] = key
+	}
+	buckets, _ := lru.New(maxRateBuckets)
+	return &requestLimiter{
+		config:  config,
+		apiKeys: apiKeys,
+		buckets: buckets,
+	}
+}
+
+// rpcClient is the sender of a request, identified by its api key if it has
+// one, by its ip otherwise.
+type rpcClient struct {
+	id     string
+	apiKey *nodeconfig.RPCAPIKey
+}
+
+// client authenticates the sender of the request, stripping the api key off
+// the url path. It returns the error message of the rejected requests.
+func (l *requestLimiter) client(r *http.Request) (*rpcClient, string) {
+	key := r.Header.Get(APIKeyHeader)
+	if len(l.apiKeys) > 0 {
+		if path := strings.Trim(r.URL.Path, "/"); path != "" && !strings.Contains(path, "/") {
+			if key == "" {
+				key = path
+			}
+			r.URL.Path = "/"
+		}
+	}
+	if key != "" {
+		apiKey, ok := l.apiKeys[key]
+		if !ok {
+			return nil, errUnknownAPIKey
+		}
+		return &rpcClient{id: "key:" + key, apiKey: &apiKey}, ""
+	}
+	if l.config.APIKeyRequired {
+		return nil, errAPIKeyRequired
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return &rpcClient{id: "ip:" + ip}, ""
+}
+
+// allow takes a token from the rate buckets of the client for the method, and
+// returns the rule exceeded if it has none left.
+func (l *requestLimiter) allow(client *rpcClient, method string) (string, bool) {
+	now := time.Now()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	rate, burst := l.config.RateLimit, l.config.RateBurst
+	if client.apiKey != nil {
+		rate, burst = client.apiKey.Rate, client.apiKey.Burst
+	}
+	if rate > 0 && !l.bucket(client.id, clientRule, rate, burst, now).take(now) {
+		return clientRule, false
+	}
+	if rule := l.methodRule(method); rule != nil {
+		if !l.bucket(client.id, rule.Method, rule.Rate, rule.Burst, now).take(now) {
+			return rule.Method, false
+		}
+	}
+	return "", true
+}
+
+func (l *requestLimiter) bucket(client, rule string, rate float64, burst int, now time.Time) *rateBucket {
+	key := client + "/" + rule
+	if b, ok := l.buckets.Get(key); ok {
+		return b.(*rateBucket)
+	}
+	b := newRateBucket(rate, burst, now)
+	l.buckets.Add(key, b)
+	return b
+}
+
+// methodRule returns the rate limit of the method, the method's own rather
+// than its namespace's.
+func (l *requestLimiter) methodRule(method string) *nodeconfig.RPCRateLimit {
+	namespace := method
+	if i := strings.Index(method, "_"); i >= 0 {
+		namespace = method[:i]
+	}
+	var match *nodeconfig.RPCRateLimit
+	for i, rule := range l.config.MethodRateLimits {
+		if rule.Rate <= 0 {
+			continue
+		}
+		if rule.Method == method {
+			return &l.config.MethodRateLimits[i]
+		}
+		if rule.Method == namespace && match == nil {
+			match = &l.config.MethodRateLimits[i]
+		}
+	}
+	return match
+}
+
+// jsonrpcRequest is the part of a JSON-RPC request the limiter looks at
+type jsonrpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcErrorResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   jsonrpcError    `json:"error"`
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *jsonrpcErrorResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcErrorResponse{
+		Version: "2.0",
+		ID:      id,
+		Error:   jsonrpcError{Code: code, Message: message},
+	}
+}
+
+// limitedBody is a request body split by the limits of its client
+type limitedBody struct {
+	batch    bool
+	reqs     []jsonrpcRequest        // Requests of the body, nil if it is malformed
+	allowed  []byte                  // Body of the allowed requests, nil if none is
+	rejected []*jsonrpcErrorResponse // Error responses of the rejected requests
+	batchErr *jsonrpcErrorResponse   // Error response of a batch rejected as a whole
+}
+
+// response returns the response to a body whose requests are all rejected, nil
+// if none is expected.
+func (b *limitedBody) response() interface{} {
+	switch {
+	case b.batchErr != nil:
+		return b.batchErr
+	case len(b.rejected) == 0:
+		return nil
+	case !b.batch:
+		return b.rejected[0]
+	}
+	return b.rejected
+}
+
+// limit enforces the batch size and the rate limits of the client on the
+// requests of body. The malformed bodies are left to the rpc server.
+func (l *requestLimiter) limit(client *rpcClient, body []byte) *limitedBody {
+	reqs, rawReqs, batch, err := parseMessages(body)
+	res := &limitedBody{batch: batch, allowed: body}
+	if err != nil {
+		return res
+	}
+	res.reqs = reqs
+	if res.batch {
+		batchSizeHistogram.Observe(float64(len(reqs)))
+		if l.config.BatchLimit > 0 && len(reqs) > l.config.BatchLimit {
+			rejectedRequestCounterVec.WithLabelValues(rejectBatch, "").Inc()
+			res.allowed = nil
+			res.batchErr = errorResponse(nil, limitExceededErrorCode, fmt.Sprintf(errBatchTooLarge, l.config.BatchLimit))
+			return res
+		}
+	}
+
+	var allowed []json.RawMessage
+	for i, req := range reqs {
+		if rule, ok := l.allow(client, req.Method); !ok {
+			rejectedRequestCounterVec.WithLabelValues(rejectRate, rule).Inc()
+			if len(req.ID) > 0 {
+				res.rejected = append(res.rejected, errorResponse(req.ID, limitExceededErrorCode, fmt.Sprintf(errRateLimited, rule)))
+			}
+			continue
+		}
+		allowed = append(allowed, rawReqs[i])
+	}
+	switch {
+	case len(allowed) == 0:
+		res.allowed = nil
+	case len(allowed) < len(reqs):
+		res.allowed, _ = json.Marshal(allowed)
+	}
+	return res
+}
+
+// parseMessages parses the JSON-RPC messages of a body, a batch or a single one
+func parseMessages(body []byte) ([]jsonrpcRequest, []json.RawMessage, bool, error) {
+	var (
+		rawMsgs []json.RawMessage
+		trimmed = bytes.TrimSpace(body)
+		batch   = len(trimmed) > 0 && trimmed[0] == '['
+	)
+	if batch {
+		if err := json.Unmarshal(body, &rawMsgs); err != nil {
+			return nil, nil, batch, err
+		}
+	} else {
+		rawMsgs = []json.RawMessage{body}
+	}
+	msgs := make([]jsonrpcRequest, len(rawMsgs))
+	for i, raw := range rawMsgs {
+		if err := json.Unmarshal(raw, &msgs[i]); err != nil {
+			return nil, nil, batch, err
+		}
+	}
+	return msgs, rawMsgs, batch, nil
+}
+
+// httpHandler returns the handler enforcing the limits on the http requests
+// before they are passed to next.
+func (l *requestLimiter) httpHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Permit the empty requests of the health checks
+		if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, errMsg := l.client(r)
+		if client == nil {
+			rejectedRequestCounterVec.WithLabelValues(rejectAuth, "").Inc()
+			writeJSON(w, errorResponse(nil, unauthorizedErrorCode, errMsg))
+			return
+		}
+		if r.Method == http.MethodOptions || r.ContentLength > maxRequestSize {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Read one more byte than the limit to tell the oversized bodies of
+		// unknown length apart, rather than passing them on truncated
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxRequestSize {
+			http.Error(w, fmt.Sprintf(errRequestTooLarge, maxRequestSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+
+		limited := l.limit(client, body)
+		if limited.allowed == nil {
+			if resp := limited.response(); resp != nil {
+				writeJSON(w, resp)
+			}
+			return
+		}
+		if len(limited.rejected) == 0 {
+			l.serve(w, r, next, limited.reqs)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(limited.allowed))
+		r.ContentLength = int64(len(limited.allowed))
+
+		rec := newResponseRecorder(l.config.ResponseLimit)
+		next.ServeHTTP(rec, r)
+		if rec.overflow {
+			l.writeResponseTooLarge(w, limited.reqs)
+			return
+		}
+		var responses []json.RawMessage
+		if err := json.Unmarshal(rec.body.Bytes(), &responses); err != nil {
+			rec.flush(w)
+			return
+		}
+		for _, resp := range limited.rejected {
+			raw, _ := json.Marshal(resp)
+			responses = append(responses, raw)
+		}
+		writeJSON(w, responses)
+	})
+}
+
+// serve passes the unmodified request to next, enforcing the response size
+// limit.
+func (l *requestLimiter) serve(w http.ResponseWriter, r *http.Request, next http.Handler, reqs []jsonrpcRequest) {
+	if l.config.ResponseLimit <= 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+	rec := newResponseRecorder(l.config.ResponseLimit)
+	next.ServeHTTP(rec, r)
+	if rec.overflow {
+		l.writeResponseTooLarge(w, reqs)
+		return
+	}
+	rec.flush(w)
+}
+
+func (l *requestLimiter) writeResponseTooLarge(w http.ResponseWriter, reqs []jsonrpcRequest) {
+	if resp := l.responseTooLarge(reqs); resp != nil {
+		writeJSON(w, resp)
+	}
+}
+
+// responseTooLarge returns the error responses of the requests whose response
+// exceeds the size limit.
+func (l *requestLimiter) responseTooLarge(reqs []jsonrpcRequest) interface{} {
+	rejectedRequestCounterVec.WithLabelValues(rejectResponse, "").Inc()
+	msg := fmt.Sprintf(errResponseTooLarge, l.config.ResponseLimit)
+	if len(reqs) == 1 {
+		return errorResponse(reqs[0].ID, limitExceededErrorCode, msg)
+	}
+	resps := make([]*jsonrpcErrorResponse, 0, len(reqs))
+	for _, req := range reqs {
+		if len(req.ID) > 0 {
+			resps = append(resps, errorResponse(req.ID, limitExceededErrorCode, msg))
+		}
+	}
+	return resps
+}
+
+// wsHandler returns the handler authenticating the websocket connections and
+// limiting the rate of the new connections of each client. The requests sent
+// over an open connection are then limited like the http requests.
+func (l *requestLimiter) wsHandler(server *rpc.Server, origins []string) http.Handler {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  wsReadBuffer,
+		WriteBufferSize: wsWriteBuffer,
+		CheckOrigin:     wsOriginValidator(origins),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, errMsg := l.client(r)
+		if client == nil {
+			rejectedRequestCounterVec.WithLabelValues(rejectAuth, "").Inc()
+			http.Error(w, errMsg, http.StatusUnauthorized)
+			return
+		}
+		if rule, ok := l.allow(client, ""); !ok {
+			rejectedRequestCounterVec.WithLabelValues(rejectRate, rule).Inc()
+			http.Error(w, fmt.Sprintf(errRateLimited, rule), http.StatusTooManyRequests)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		server.ServeCodec(l.newWSCodec(client, conn), 0)
+	})
+}
+
+// wsOriginValidator accepts the websocket connections from the given origins,
+// any origin for "*", and the connections without origin of the non-browser
+// clients.
+func wsOriginValidator(origins []string) func(*http.Request) bool {
+	allowed := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		allowed[strings.ToLower(origin)] = struct{}{}
+	}
+	return func(r *http.Request) bool {
+		origin, ok := r.Header["Origin"]
+		if !ok || len(origin) == 0 {
+			return true
+		}
+		if _, ok := allowed["*"]; ok {
+			return true
+		}
+		_, ok = allowed[strings.ToLower(origin[0])]
+		return ok
+	}
+}
+
+// wsLimitedConn enforces the limits of the client on the messages of a
+// websocket connection served by the rpc server. The rejected requests of a
+// batch are answered along with the response of the allowed ones.
+type wsLimitedConn struct {
+	limiter *requestLimiter
+	client  *rpcClient
+	conn    *websocket.Conn
+
+	writeLock sync.Mutex // Guards the writes to conn
+
+	lock    sync.Mutex
+	pending map[string][][]*jsonrpcErrorResponse // Rejections of a batch by the first id of its allowed requests
+}
+
+func (l *requestLimiter) newWSCodec(client *rpcClient, conn *websocket.Conn) rpc.ServerCodec {
+	conn.SetReadLimit(maxRequestSize)
+	c := &wsLimitedConn{
+		limiter: l,
+		client:  client,
+		conn:    conn,
+		pending: make(map[string][][]*jsonrpcErrorResponse),
+	}
+	return rpc.NewFuncCodec(conn, c.write, c.read)
+}
+
+// read decodes into v the next message with an allowed request, answering the
+// messages whose requests are all rejected on its own.
+func (c *wsLimitedConn) read(v interface{}) error {
+	for {
+		_, body, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		limited := c.limiter.limit(c.client, body)
+		if limited.allowed != nil {
+			if len(limited.rejected) != 0 && !c.addPending(limited) {
+				// no response to join the rejections to
+				if err := c.writeJSON(limited.rejected); err != nil {
+					return err
+				}
+			}
+			return json.Unmarshal(limited.allowed, v)
+		}
+		if resp := limited.response(); resp != nil {
+			if err := c.writeJSON(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addPending keeps the rejections of a batch until the response of its allowed
+// requests is written, false if no response is expected.
+func (c *wsLimitedConn) addPending(limited *limitedBody) bool {
+	var allowed []jsonrpcRequest
+	json.Unmarshal(limited.allowed, &allowed)
+	for _, req := range allowed {
+		if len(req.ID) > 0 {
+			key := idKey(req.ID)
+			c.lock.Lock()
+			c.pending[key] = append(c.pending[key], limited.rejected)
+			c.lock.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// takePending returns the rejections of the batch answered by resps
+func (c *wsLimitedConn) takePending(resps []jsonrpcRequest) []*jsonrpcErrorResponse {
+	if len(resps) == 0 {
+		return nil
+	}
+	key := idKey(resps[0].ID)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	queue := c.pending[key]
+	if len(queue) == 0 {
+		return nil
+	}
+	if len(queue) == 1 {
+		delete(c.pending, key)
+	} else {
+		c.pending[key] = queue[1:]
+	}
+	return queue[0]
+}
+
+func (c *wsLimitedConn) hasPending() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.pending) != 0
+}
+
+// write encodes a message of the rpc server, joining the rejections of a batch
+// to its response, which is replaced by errors if it exceeds the size limit.
+func (c *wsLimitedConn) write(v interface{}) error {
+	limit := c.limiter.config.ResponseLimit
+	if limit <= 0 && !c.hasPending() {
+		return c.writeJSON(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resps, rawResps, batch, err := parseMessages(data)
+	if err != nil {
+		return c.writeMessage(data)
+	}
+	if batch {
+		if rejected := c.takePending(resps); len(rejected) != 0 {
+			for _, resp := range rejected {
+				raw, _ := json.Marshal(resp)
+				rawResps = append(rawResps, raw)
+				resps = append(resps, jsonrpcRequest{ID: resp.ID})
+			}
+			data, _ = json.Marshal(rawResps)
+		}
+	}
+	// the subscription notifications are not limited
+	if limit > 0 && len(data) > limit && (batch || len(resps[0].ID) > 0) {
+		return c.writeJSON(c.limiter.responseTooLarge(resps))
+	}
+	return c.writeMessage(data)
+}
+
+func (c *wsLimitedConn) writeJSON(v interface{}) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsLimitedConn) writeMessage(data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// idKey is the compact form of a request id
+func idKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, id); err != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// responseRecorder buffers a response up to a size limit
+type responseRecorder struct {
+	header   http.Header
+	code     int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func newResponseRecorder(limit int) *responseRecorder {
+	return &responseRecorder{header: make(http.Header), code: http.StatusOK, limit: limit}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	rec.code = code
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.overflow {
+		return len(b), nil
+	}
+	if rec.limit > 0 && rec.body.Len()+len(b) > rec.limit {
+		rec.overflow = true
+		rec.body.Reset()
+		return len(b), nil
+	}
+	return rec.body.Write(b)
+}
+
+// flush writes the recorded response to w
+func (rec *responseRecorder) flush(w http.ResponseWriter) {
+	for k, v := range rec.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.code)
+	w.Write(rec.body.Bytes())
+}
@@ -0,0 +1,280 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
+)
+
+// testResponse is a JSON-RPC response, with either a result or an error
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  *jsonrpcError   `json:"error"`
+}
+
+// echoHandler answers each request with its method, recording the bodies served
+type echoHandler struct {
+	bodies [][]byte
+}
+
+func (h *echoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	h.bodies = append(h.bodies, body)
+	reqs, _, batch, err := parseMessages(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resps := []testResponse{}
+	for _, req := range reqs {
+		resps = append(resps, testResponse{ID: req.ID, Result: req.Method})
+	}
+	if batch {
+		writeJSON(w, resps)
+		return
+	}
+	writeJSON(w, resps[0])
+}
+
+func testRequests(methods ...string) string {
+	reqs := make([]string, 0, len(methods))
+	for i, method := range methods {
+		reqs = append(reqs, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"%s"}`, i+1, method))
+	}
+	return "[" + strings.Join(reqs, ",") + "]"
+}
+
+func postRequest(handler http.Handler, body io.Reader, length int64) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.ContentLength = length
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// checkResponses checks the results and the rate limited requests of a batch response
+func checkResponses(t *testing.T, data []byte, results map[string]string, limited []string) {
+	t.Helper()
+	var resps []testResponse
+	if err := json.Unmarshal(data, &resps); err != nil {
+		t.Fatalf("bad batch response %s: %v", data, err)
+	}
+	if len(resps) != len(results)+len(limited) {
+		t.Fatalf("unexpected number of responses %s", data)
+	}
+	for _, resp := range resps {
+		id := string(resp.ID)
+		if resp.Error != nil {
+			if resp.Error.Code != limitExceededErrorCode {
+				t.Errorf("unexpected error of request %v: %v", id, resp.Error.Message)
+			}
+			continue
+		}
+		if results[id] != resp.Result {
+			t.Errorf("unexpected result of request %v: %v / %v", id, resp.Result, results[id])
+		}
+	}
+	for _, id := range limited {
+		found := false
+		for _, resp := range resps {
+			found = found || (string(resp.ID) == id && resp.Error != nil)
+		}
+		if !found {
+			t.Errorf("request %v not rate limited", id)
+		}
+	}
+}
+
+func TestLimiterBatchLimit(t *testing.T) {
+	limiter := newRequestLimiter(nodeconfig.RPCServerConfig{BatchLimit: 2})
+	next := &echoHandler{}
+	handler := limiter.httpHandler(next)
+
+	body := testRequests("hmy_blockNumber", "hmy_gasPrice")
+	w := postRequest(handler, strings.NewReader(body), int64(len(body)))
+	checkResponses(t, w.Body.Bytes(), map[string]string{"1": "hmy_blockNumber", "2": "hmy_gasPrice"}, nil)
+
+	body = testRequests("hmy_blockNumber", "hmy_gasPrice", "hmy_syncing")
+	w = postRequest(handler, strings.NewReader(body), int64(len(body)))
+	var resp testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code != limitExceededErrorCode {
+		t.Errorf("batch over the limit not rejected: %s", w.Body.Bytes())
+	}
+	if len(next.bodies) != 1 {
+		t.Errorf("batch over the limit served")
+	}
+}
+
+func TestLimiterMethodRules(t *testing.T) {
+	limiter := newRequestLimiter(nodeconfig.RPCServerConfig{
+		MethodRateLimits: []nodeconfig.RPCRateLimit{
+			{Method: "debug", Rate: 0.001, Burst: 1},
+			{Method: "debug_traceBlockByNumber", Rate: 0.001, Burst: 2},
+		},
+	})
+	next := &echoHandler{}
+	handler := limiter.httpHandler(next)
+
+	body := testRequests("debug_traceTransaction", "debug_getBadBlocks", "hmy_blockNumber",
+		"debug_traceBlockByNumber", "debug_traceBlockByNumber", "debug_traceBlockByNumber")
+	w := postRequest(handler, strings.NewReader(body), int64(len(body)))
+	checkResponses(t, w.Body.Bytes(), map[string]string{
+		"1": "debug_traceTransaction",
+		"3": "hmy_blockNumber",
+		"4": "debug_traceBlockByNumber",
+		"5": "debug_traceBlockByNumber",
+	}, []string{"2", "6"})
+
+	// only the allowed requests are served
+	if len(next.bodies) != 1 {
+		t.Fatalf("unexpected number of bodies served %v", len(next.bodies))
+	}
+	reqs, _, _, err := parseMessages(next.bodies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 4 {
+		t.Errorf("rate limited requests served: %s", next.bodies[0])
+	}
+
+	// a single request rejected is not served
+	body = `{"jsonrpc":"2.0","id":7,"method":"debug_getBadBlocks"}`
+	w = postRequest(handler, strings.NewReader(body), int64(len(body)))
+	var resp testResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || string(resp.ID) != "7" {
+		t.Errorf("request not rate limited: %s", w.Body.Bytes())
+	}
+	if len(next.bodies) != 1 {
+		t.Errorf("rate limited request served")
+	}
+}
+
+func TestLimiterOversizedBody(t *testing.T) {
+	limiter := newRequestLimiter(nodeconfig.RPCServerConfig{BatchLimit: 2})
+	body := `{"jsonrpc":"2.0","id":1,"method":"hmy_call","params":["` +
+		strings.Repeat("x", maxRequestSize) + `"]}`
+
+	// the rpc server rejects the bodies of known length itself
+	next := &echoHandler{}
+	postRequest(limiter.httpHandler(next), strings.NewReader(body), int64(len(body)))
+	if len(next.bodies) != 1 || len(next.bodies[0]) != len(body) {
+		t.Errorf("oversized body of known length not passed on untouched")
+	}
+
+	// the bodies of unknown length are not passed on truncated
+	next = &echoHandler{}
+	w := postRequest(limiter.httpHandler(next), strings.NewReader(body), -1)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("unexpected status of oversized chunked body %v", w.Code)
+	}
+	if len(next.bodies) != 0 {
+		t.Errorf("oversized chunked body served truncated")
+	}
+
+	// the bodies of unknown length within the limit are served
+	body = testRequests("hmy_blockNumber")
+	w = postRequest(limiter.httpHandler(next), bytes.NewBufferString(body), -1)
+	checkResponses(t, w.Body.Bytes(), map[string]string{"1": "hmy_blockNumber"}, nil)
+}
+
+type testService struct{}
+
+func (testService) BlockNumber() uint64  { return 1 }
+func (testService) GetBadBlocks() string { return "none" }
+func (testService) Large() string        { return strings.Repeat("x", 1024) }
+
+func TestLimiterWebsocket(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("test", testService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	limiter := newRequestLimiter(nodeconfig.RPCServerConfig{
+		BatchLimit:       3,
+		ResponseLimit:    512,
+		MethodRateLimits: []nodeconfig.RPCRateLimit{{Method: "test_getBadBlocks", Rate: 0.001, Burst: 1}},
+	})
+	httpServer := httptest.NewServer(limiter.wsHandler(server, []string{"*"}))
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	exchange := func(body string) []byte {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	errorCode := func(data []byte) int {
+		t.Helper()
+		var resp testResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			t.Fatalf("bad response %s: %v", data, err)
+		}
+		if resp.Error == nil {
+			return 0
+		}
+		return resp.Error.Code
+	}
+
+	// the rejections of a batch are joined to its response
+	var resps []json.RawMessage
+	data := exchange(testRequests("test_getBadBlocks", "test_getBadBlocks", "test_blockNumber"))
+	if err := json.Unmarshal(data, &resps); err != nil || len(resps) != 3 {
+		t.Fatalf("unexpected batch response %s", data)
+	}
+	limited := 0
+	for _, resp := range resps {
+		if errorCode(resp) == limitExceededErrorCode {
+			limited++
+		}
+	}
+	if limited != 1 {
+		t.Errorf("unexpected number of rate limited requests %v: %s", limited, data)
+	}
+
+	// a single request rejected
+	if code := errorCode(exchange(`{"jsonrpc":"2.0","id":9,"method":"test_getBadBlocks"}`)); code != limitExceededErrorCode {
+		t.Errorf("request not rate limited over websocket")
+	}
+	// a batch over the limit
+	if code := errorCode(exchange(testRequests("test_blockNumber", "test_blockNumber", "test_blockNumber", "test_blockNumber"))); code != limitExceededErrorCode {
+		t.Errorf("batch over the limit not rejected over websocket")
+	}
+	// a response over the limit
+	if code := errorCode(exchange(`{"jsonrpc":"2.0","id":10,"method":"test_large"}`)); code != limitExceededErrorCode {
+		t.Errorf("response over the limit not rejected over websocket")
+	}
+	// the connection is still served
+	if code := errorCode(exchange(`{"jsonrpc":"2.0","id":11,"method":"test_blockNumber"}`)); code != 0 {
+		t.Errorf("request within the limits rejected over websocket")
+	}
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prom.PromRegistry().MustRegister(
+		rejectedRequestCounterVec,
+		batchSizeHistogram,
+		inflightRequestsGauge,
+	)
+}
+
+var (
+	rejectedRequestCounterVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "hmy",
+			Subsystem: "rpc",
+			Name:      "rejected_requests",
+			Help:      "number of rpc requests rejected by the request limits",
+		},
+		[]string{"reason", "rule"},
+	)
+
+	batchSizeHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "hmy",
+			Subsystem: "rpc",
+			Name:      "batch_size",
+			Help:      "number of requests in the rpc batches",
+			// Buckets: 1, 4, 16, 64, 256, +INF
+			Buckets: prometheus.ExponentialBuckets(1, 4, 5),
+		},
+	)
+
+	inflightRequestsGauge = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: "hmy",
+			Subsystem: "rpc",
+			Name:      "inflight_requests",
+			Help:      "number of http rpc requests being served",
+		},
+		func() float64 { return float64(InflightRequests()) },
+	)
+)
@@ -9,6 +9,7 @@ import (
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/p2p"
+	libp2p_peer "github.com/libp2p/go-libp2p-core/peer"
 )
 
 // PublicNetService offers network related RPC methods
@@ -65,3 +66,74 @@ func (s *PublicNetService) Version(ctx context.Context) interface{} {
 		return fmt.Sprintf("%d", s.chainID)
 	}
 }
+
+// PrivateNetService offers the RPC methods managing the peers of the node
+type PrivateNetService struct {
+	net     p2p.Host
+	version Version
+}
+
+// NewPrivateNetAPI creates a new API managing the peers of the node
+func NewPrivateNetAPI(net p2p.Host, version Version) rpc.API {
+	return rpc.API{
+		Namespace: version.Namespace(),
+		Version:   APIVersion,
+		Service:   &PrivateNetService{net, version},
+		Public:    false,
+	}
+}
+
+// AddPeer adds a static peer, or a trusted peer never blocked, from its
+// multiaddr including its peer id. The node keeps connected to it.
+func (s *PrivateNetService) AddPeer(ctx context.Context, addr string, trusted bool) (bool, error) {
+	if err := s.net.AddStaticPeer(addr, trusted); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemovePeer removes a static or trusted peer and disconnects from it
+func (s *PrivateNetService) RemovePeer(ctx context.Context, id string) (bool, error) {
+	peerID, err := libp2p_peer.Decode(id)
+	if err != nil {
+		return false, err
+	}
+	if err := s.net.RemoveStaticPeer(peerID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BanPeer bans the peer, across restarts, until it is unbanned
+func (s *PrivateNetService) BanPeer(ctx context.Context, id string, reason string) (bool, error) {
+	peerID, err := libp2p_peer.Decode(id)
+	if err != nil {
+		return false, err
+	}
+	if err := s.net.BanPeer(peerID, reason); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of the peer
+func (s *PrivateNetService) UnbanPeer(ctx context.Context, id string) (bool, error) {
+	peerID, err := libp2p_peer.Decode(id)
+	if err != nil {
+		return false, err
+	}
+	if err := s.net.UnbanPeer(peerID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListStaticPeers returns the static and trusted peers
+func (s *PrivateNetService) ListStaticPeers(ctx context.Context) []p2p.StaticPeer {
+	return s.net.ListStaticPeer()
+}
+
+// ListBannedPeers returns the banned peers, latest ban first
+func (s *PrivateNetService) ListBannedPeers(ctx context.Context) []p2p.BannedPeer {
+	return s.net.ListBannedPeer()
+}
@@ -3,10 +3,13 @@ package rpc
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
+	prom "github.com/harmony-one/harmony/api/service/prometheus"
 	"github.com/harmony-one/harmony/hmy"
 	nodeconfig "github.com/harmony-one/harmony/internal/configs/node"
 	"github.com/harmony-one/harmony/internal/utils"
@@ -21,6 +24,7 @@ const (
 	V2
 	Eth
 	Debug
+	Trace
 )
 
 const (
@@ -43,9 +47,9 @@ const (
 
 var (
 	// HTTPModules ..
-	HTTPModules = []string{"hmy", "hmyv2", "eth", "debug", netNamespace, netV1Namespace, netV2Namespace, web3Namespace, "explorer"}
+	HTTPModules = []string{"hmy", "hmyv2", "eth", "debug", "trace", netNamespace, netV1Namespace, netV2Namespace, web3Namespace, "explorer"}
 	// WSModules ..
-	WSModules = []string{"hmy", "hmyv2", "eth", "debug", netNamespace, netV1Namespace, netV2Namespace, web3Namespace, "web3"}
+	WSModules = []string{"hmy", "hmyv2", "eth", "debug", "trace", netNamespace, netV1Namespace, netV2Namespace, web3Namespace, "web3"}
 
 	httpListener     net.Listener
 	httpHandler      *rpc.Server
@@ -57,6 +61,10 @@ var (
 	httpTimeouts     = rpc.DefaultHTTPTimeouts
 	httpOrigins      = []string{"*"}
 	wsOrigins        = []string{"*"}
+	limiter          *requestLimiter
+	inflightRequests int64
+
+	flatTraceIndexer *hmy.FlatTraceIndexer
 )
 
 // Version of the RPC
@@ -67,13 +75,19 @@ func (n Version) Namespace() string {
 	return HTTPModules[n]
 }
 
-// StartServers starts the http & ws servers
-func StartServers(hmy *hmy.Harmony, apis []rpc.API, config nodeconfig.RPCServerConfig) error {
+// StartServers starts the http & ws servers. The additional handlers are served
+// on the http port aside from the rpc handler.
+func StartServers(hmy *hmy.Harmony, apis []rpc.API, config nodeconfig.RPCServerConfig, handlers ...prom.Handler) error {
 	apis = append(apis, getAPIs(hmy, config.DebugEnabled)...)
+	limiter = newRequestLimiter(config)
+
+	if config.TraceIndexEnabled {
+		startFlatTraceIndexer(hmy)
+	}
 
 	if config.HTTPEnabled {
 		httpEndpoint = fmt.Sprintf("%v:%v", config.HTTPIp, config.HTTPPort)
-		if err := startHTTP(apis); err != nil {
+		if err := startHTTP(apis, handlers); err != nil {
 			return err
 		}
 	}
@@ -116,9 +130,20 @@ func StopServers() error {
 		wsHandler.Stop()
 		wsHandler = nil
 	}
+	if flatTraceIndexer != nil {
+		flatTraceIndexer.Stop()
+		flatTraceIndexer = nil
+	}
 	return nil
 }
 
+// startFlatTraceIndexer starts indexing the flat traces served by the trace namespace
+func startFlatTraceIndexer(harmony *hmy.Harmony) {
+	flatTraceIndexer = hmy.NewFlatTraceIndexer(harmony)
+	flatTraceIndexer.Start()
+	utils.Logger().Info().Msg("Flat trace indexer started")
+}
+
 // getAPIs returns all the API methods for the RPC interface
 func getAPIs(hmy *hmy.Harmony, debugEnable bool) []rpc.API {
 	publicAPIs := []rpc.API{
@@ -141,6 +166,7 @@ func getAPIs(hmy *hmy.Harmony, debugEnable bool) []rpc.API {
 		NewPublicStakingAPI(hmy, V1),
 		NewPublicStakingAPI(hmy, V2),
 		NewPublicTracerAPI(hmy, Debug),
+		NewPublicTraceAPI(hmy, Trace),
 		// Legacy methods (subject to removal)
 		v1.NewPublicLegacyAPI(hmy, "hmy"),
 		eth.NewPublicEthService(hmy, "eth"),
@@ -150,6 +176,7 @@ func getAPIs(hmy *hmy.Harmony, debugEnable bool) []rpc.API {
 	privateAPIs := []rpc.API{
 		NewPrivateDebugAPI(hmy, V1),
 		NewPrivateDebugAPI(hmy, V2),
+		NewPrivateTracerAPI(hmy, Debug),
 	}
 
 	if debugEnable {
@@ -158,28 +185,81 @@ func getAPIs(hmy *hmy.Harmony, debugEnable bool) []rpc.API {
 	return publicAPIs
 }
 
-func startHTTP(apis []rpc.API) (err error) {
-	httpListener, httpHandler, err = rpc.StartHTTPEndpoint(
-		httpEndpoint, apis, HTTPModules, httpOrigins, httpVirtualHosts, httpTimeouts,
-	)
-	if err != nil {
+// newServer returns an rpc server registering the apis of the modules, or
+// all of them if exposeAll
+func newServer(apis []rpc.API, modules []string, exposeAll bool) (*rpc.Server, error) {
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	handler := rpc.NewServer()
+	for _, api := range apis {
+		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return handler, nil
+}
+
+// InflightRequests returns the number of http rpc requests being served
+func InflightRequests() int64 {
+	return atomic.LoadInt64(&inflightRequests)
+}
+
+func startHTTP(apis []rpc.API, handlers []prom.Handler) (err error) {
+	if httpHandler, err = newServer(apis, HTTPModules, false); err != nil {
+		return err
+	}
+	if httpListener, err = net.Listen("tcp", httpEndpoint); err != nil {
 		return err
 	}
+	var handler http.Handler = countInflight(httpHandler)
+	if limiter != nil {
+		handler = limiter.httpHandler(handler)
+	}
+	if len(handlers) > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/", handler)
+		for _, h := range handlers {
+			mux.HandleFunc(h.Path, h.Handler)
+		}
+		handler = mux
+	}
+	go rpc.NewHTTPServer(httpOrigins, httpVirtualHosts, httpTimeouts, handler).Serve(httpListener)
 
 	utils.Logger().Info().
 		Str("url", fmt.Sprintf("http://%s", httpEndpoint)).
 		Str("cors", strings.Join(httpOrigins, ",")).
 		Str("vhosts", strings.Join(httpVirtualHosts, ",")).
+		Bool("limited", limiter != nil).
 		Msg("HTTP endpoint opened")
 	fmt.Printf("Started RPC server at: %v\n", httpEndpoint)
 	return nil
 }
 
+// countInflight keeps the number of requests being served by the handler
+func countInflight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&inflightRequests, 1)
+		defer atomic.AddInt64(&inflightRequests, -1)
+		next.ServeHTTP(w, r)
+	})
+}
+
 func startWS(apis []rpc.API) (err error) {
-	wsListener, wsHandler, err = rpc.StartWSEndpoint(wsEndpoint, apis, WSModules, wsOrigins, true)
-	if err != nil {
+	if wsHandler, err = newServer(apis, WSModules, true); err != nil {
 		return err
 	}
+	if wsListener, err = net.Listen("tcp", wsEndpoint); err != nil {
+		return err
+	}
+	handler := wsHandler.WebsocketHandler(wsOrigins)
+	if limiter != nil {
+		handler = limiter.wsHandler(wsHandler, wsOrigins)
+	}
+	go (&http.Server{Handler: handler}).Serve(wsListener)
 
 	utils.Logger().Info().
 		Str("url", fmt.Sprintf("ws://%s", wsListener.Addr())).
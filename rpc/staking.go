@@ -7,15 +7,23 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/core"
+	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/hmy"
 	internal_common "github.com/harmony-one/harmony/internal/common"
+	v2 "github.com/harmony-one/harmony/rpc/v2"
 	"github.com/harmony-one/harmony/shard"
+	staking "github.com/harmony-one/harmony/staking/types"
 )
 
 const (
 	validatorsPageSize = 100
+	// validatorHistoryMaxEpochs is the max number of epochs of a validator history query
+	validatorHistoryMaxEpochs = 1000
 )
 
 // PublicStakingService provides an API to access Harmony's staking services.
@@ -676,6 +684,132 @@ func (s *PublicStakingService) GetAvailableRedelegationBalance(
 	return redelegationTotal, nil
 }
 
+// parseValidatorHistoryArgs checks the validator address and the epoch range of a
+// validator history query
+func parseValidatorHistoryArgs(
+	address string, fromEpoch, toEpoch int64,
+) (common.Address, error) {
+	if fromEpoch < 0 || toEpoch < fromEpoch {
+		return common.Address{}, errors.Errorf("invalid epoch range [%d, %d]", fromEpoch, toEpoch)
+	}
+	if toEpoch-fromEpoch >= validatorHistoryMaxEpochs {
+		return common.Address{}, errors.Errorf(
+			"epoch range [%d, %d] cannot be over %d epochs", fromEpoch, toEpoch, validatorHistoryMaxEpochs,
+		)
+	}
+	return internal_common.ParseAddr(address)
+}
+
+// GetValidatorUptime returns the signing outcome of a validator from fromEpoch to
+// toEpoch, in total, by epoch, by BLS key and by shard.
+func (s *PublicStakingService) GetValidatorUptime(
+	ctx context.Context, address string, fromEpoch, toEpoch int64,
+) (StructuredResponse, error) {
+	if !isBeaconShard(s.hmy) {
+		return nil, ErrNotBeaconShard
+	}
+	addr, err := parseValidatorHistoryArgs(address, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	history := s.hmy.GetValidatorSigningHistory(addr, uint64(fromEpoch), uint64(toEpoch))
+	oneAddr, _ := internal_common.AddressToBech32(addr)
+
+	// Response output is the same for all versions
+	return NewStructuredResponse(
+		NewValidatorUptime(oneAddr, uint64(fromEpoch), uint64(toEpoch), history),
+	)
+}
+
+// GetValidatorMissedBlocks returns the blocks missed by the BLS keys of a validator
+// from fromEpoch to toEpoch.
+func (s *PublicStakingService) GetValidatorMissedBlocks(
+	ctx context.Context, address string, fromEpoch, toEpoch int64,
+) ([]KeyMissedBlocks, error) {
+	if !isBeaconShard(s.hmy) {
+		return nil, ErrNotBeaconShard
+	}
+	addr, err := parseValidatorHistoryArgs(address, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+	history := s.hmy.GetValidatorSigningHistory(addr, uint64(fromEpoch), uint64(toEpoch))
+
+	// Response output is the same for all versions
+	return NewKeyMissedBlocks(history), nil
+}
+
+// GetValidatorAPRHistory returns the aprs of a validator from fromEpoch to toEpoch.
+func (s *PublicStakingService) GetValidatorAPRHistory(
+	ctx context.Context, address string, fromEpoch, toEpoch int64,
+) ([]staking.APREntry, error) {
+	if !isBeaconShard(s.hmy) {
+		return nil, ErrNotBeaconShard
+	}
+	addr, err := parseValidatorHistoryArgs(address, fromEpoch, toEpoch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response output is the same for all versions
+	return s.hmy.GetValidatorAPRHistory(addr, uint64(fromEpoch), uint64(toEpoch)), nil
+}
+
+// SimulateStakingTransaction runs the checks of a staking transaction against the state
+// of the latest block, or of the given block on archival nodes, and returns the validator
+// changes, the gas and the failed checks without sending the transaction.
+func (s *PublicStakingService) SimulateStakingTransaction(
+	ctx context.Context, args StakingSimulationArgs, blockNumber *BlockNumber,
+) (StructuredResponse, error) {
+	if !isBeaconShard(s.hmy) {
+		return nil, ErrNotBeaconShard
+	}
+	blockNum := rpc.LatestBlockNumber
+	if blockNumber != nil {
+		blockNum = blockNumber.EthBlockNumber()
+	}
+
+	var (
+		tx  *staking.StakingTransaction
+		msg staking.StakeMsg
+	)
+	if len(args.RawTransaction) != 0 {
+		if len(args.RawTransaction) >= types.MaxEncodedPoolTransactionSize {
+			return nil, errors.Wrapf(core.ErrOversizedData, "encoded tx size: %d", len(args.RawTransaction))
+		}
+		tx = new(staking.StakingTransaction)
+		if err := rlp.DecodeBytes(args.RawTransaction, tx); err != nil {
+			return nil, err
+		}
+		c := s.hmy.ChainConfig().ChainID
+		if id := tx.ChainID(); id.Cmp(c) != 0 {
+			return nil, errors.Wrapf(
+				ErrInvalidChainID, "blockchain chain id:%s, given %s", c.String(), id.String(),
+			)
+		}
+	} else {
+		var err error
+		if msg, err = args.ToStakeMsg(); err != nil {
+			return nil, err
+		}
+	}
+
+	sim, header, err := s.hmy.SimulateStakingTransaction(ctx, blockNum, tx, msg)
+	if err != nil {
+		return nil, err
+	}
+	switch s.version {
+	case V2:
+		result, err := v2.NewStakingSimulation(sim, header.Number().Uint64())
+		if err != nil {
+			return nil, err
+		}
+		return NewStructuredResponse(result)
+	default:
+		return nil, ErrUnknownRPCVersion
+	}
+}
+
 func isBeaconShard(hmy *hmy.Harmony) bool {
 	return hmy.ShardID == shard.BeaconChainShardID
 }
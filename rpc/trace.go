@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/harmony-one/harmony/hmy"
+)
+
+const (
+	// maxTraceFilterReexecBlocks is the largest number of blocks trace_filter
+	// re-executes, the blocks of the range missing from the flat trace index.
+	maxTraceFilterReexecBlocks = 100
+	// maxTraceFilterBlocks is the largest block range of trace_filter,
+	// including the blocks covered by the flat trace index.
+	maxTraceFilterBlocks = 10000
+	// maxTraceFilterTraces is the largest number of traces trace_filter returns.
+	maxTraceFilterTraces = 10000
+)
+
+// PublicTraceService provides the Parity style flat trace API of the trace
+// namespace. Blocks indexed by the flat trace indexer are served from the
+// database, all other blocks are re-executed.
+type PublicTraceService struct {
+	hmy     *hmy.Harmony
+	version Version
+}
+
+// NewPublicTraceAPI creates a new API for the RPC interface
+func NewPublicTraceAPI(hmy *hmy.Harmony, version Version) rpc.API {
+	return rpc.API{
+		Namespace: version.Namespace(),
+		Version:   APIVersion,
+		Service:   &PublicTraceService{hmy, version},
+		Public:    true,
+	}
+}
+
+// TraceFilterArgs are the arguments of trace_filter.
+type TraceFilterArgs struct {
+	FromBlock   *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock     *rpc.BlockNumber `json:"toBlock"`
+	FromAddress []common.Address `json:"fromAddress"`
+	ToAddress   []common.Address `json:"toAddress"`
+	After       *uint64          `json:"after"`
+	Count       *uint64          `json:"count"`
+}
+
+// Block returns the flat traces of all transactions in the given block.
+func (s *PublicTraceService) Block(ctx context.Context, number rpc.BlockNumber) ([]*hmy.FlatTrace, error) {
+	if isBlockGreaterThanLatest(s.hmy, number) {
+		return nil, ErrRequestedBlockTooHigh
+	}
+	block, err := s.hmy.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return s.hmy.TraceBlockFlat(ctx, block)
+}
+
+// Transaction returns the flat traces of the given transaction.
+func (s *PublicTraceService) Transaction(ctx context.Context, hash common.Hash) ([]*hmy.FlatTrace, error) {
+	return s.hmy.TraceTransactionFlat(ctx, hash)
+}
+
+// Filter returns the flat traces of the given block range sent from any of
+// the from addresses and received by any of the to addresses.
+func (s *PublicTraceService) Filter(ctx context.Context, args TraceFilterArgs) ([]*hmy.FlatTrace, error) {
+	current := s.hmy.CurrentBlock().NumberU64()
+	filter := &hmy.FlatTraceFilter{
+		FromBlock:   current,
+		ToBlock:     current,
+		FromAddress: args.FromAddress,
+		ToAddress:   args.ToAddress,
+	}
+	if args.FromBlock != nil && *args.FromBlock != rpc.LatestBlockNumber {
+		if isBlockGreaterThanLatest(s.hmy, *args.FromBlock) {
+			return nil, ErrRequestedBlockTooHigh
+		}
+		filter.FromBlock = uint64(*args.FromBlock)
+	}
+	if args.ToBlock != nil && *args.ToBlock != rpc.LatestBlockNumber {
+		if isBlockGreaterThanLatest(s.hmy, *args.ToBlock) {
+			return nil, ErrRequestedBlockTooHigh
+		}
+		filter.ToBlock = uint64(*args.ToBlock)
+	}
+	if filter.FromBlock > filter.ToBlock {
+		return nil, fmt.Errorf("from block can not be greater than the to block")
+	}
+	if filter.ToBlock-filter.FromBlock >= maxTraceFilterBlocks {
+		return nil, fmt.Errorf("block range can not be greater than %d", maxTraceFilterBlocks)
+	}
+	if args.After != nil {
+		filter.After = *args.After
+	}
+	if args.Count != nil {
+		if *args.Count > maxTraceFilterTraces {
+			return nil, fmt.Errorf("count can not be greater than %d", maxTraceFilterTraces)
+		}
+		filter.Count = *args.Count
+	}
+	filter.MaxTraces = maxTraceFilterTraces
+	filter.MaxReexec = maxTraceFilterReexecBlocks
+
+	// Reject early the ranges above the index head too large to re-execute,
+	// the other blocks not indexed are counted while filtering
+	reexecFrom := filter.FromBlock
+	if head, ok := s.hmy.FlatTraceIndexHead(); ok && head >= reexecFrom {
+		reexecFrom = head + 1
+	}
+	if reexecFrom <= filter.ToBlock && filter.ToBlock-reexecFrom >= maxTraceFilterReexecBlocks {
+		return nil, fmt.Errorf(
+			"block range of unindexed traces can not be greater than %d", maxTraceFilterReexecBlocks,
+		)
+	}
+	return s.hmy.FilterFlatTraces(ctx, filter)
+}
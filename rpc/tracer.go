@@ -175,3 +175,70 @@ func (s *PublicTracerService) TraceCall(ctx context.Context, args CallArgs, bloc
 	// Trace the transaction and return
 	return s.hmy.TraceTx(ctx, msg, vmctx, statedb, config)
 }
+
+// PrivateTracerService provides the chain tracing APIs writing to the local
+// disk of the node. It is only exposed along with the other private debug APIs.
+type PrivateTracerService struct {
+	hmy     *hmy.Harmony
+	version Version
+}
+
+// NewPrivateTracerAPI creates a new API for the RPC interface
+func NewPrivateTracerAPI(hmy *hmy.Harmony, version Version) rpc.API {
+	return rpc.API{
+		Namespace: version.Namespace(),
+		Version:   APIVersion,
+		Service:   &PrivateTracerService{hmy, version},
+		Public:    false,
+	}
+}
+
+// TraceChainToFile starts tracing the blocks from start to end, both included,
+// in the background. The traces are written to chunked JSONL files on the node
+// and the progress is checkpointed after every chunk, so the job can be paused
+// and resumed, even across node restarts.
+func (s *PrivateTracerService) TraceChainToFile(ctx context.Context, start, end rpc.BlockNumber, config *hmy.ChainTraceConfig) (*hmy.ChainTraceProgress, error) {
+	if isBlockGreaterThanLatest(s.hmy, start) || isBlockGreaterThanLatest(s.hmy, end) {
+		return nil, ErrRequestedBlockTooHigh
+	}
+	from, err := s.hmy.BlockByNumber(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, fmt.Errorf("start block #%d not found", start)
+	}
+	to, err := s.hmy.BlockByNumber(ctx, end)
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, fmt.Errorf("end block #%d not found", end)
+	}
+	return s.hmy.StartChainTrace(from, to, config)
+}
+
+// PauseTraceChain pauses a running chain tracing job at its last checkpoint.
+func (s *PrivateTracerService) PauseTraceChain(ctx context.Context, id string) (*hmy.ChainTraceProgress, error) {
+	return s.hmy.PauseChainTrace(id)
+}
+
+// ResumeTraceChain resumes a paused or failed chain tracing job from its last checkpoint.
+func (s *PrivateTracerService) ResumeTraceChain(ctx context.Context, id string) (*hmy.ChainTraceProgress, error) {
+	return s.hmy.ResumeChainTrace(id)
+}
+
+// CancelTraceChain stops a chain tracing job for good.
+func (s *PrivateTracerService) CancelTraceChain(ctx context.Context, id string) (*hmy.ChainTraceProgress, error) {
+	return s.hmy.CancelChainTrace(id)
+}
+
+// TraceChainStatus returns the progress of a chain tracing job.
+func (s *PrivateTracerService) TraceChainStatus(ctx context.Context, id string) (*hmy.ChainTraceProgress, error) {
+	return s.hmy.ChainTrace(id)
+}
+
+// TraceChainJobs returns the progress of all chain tracing jobs since the node started.
+func (s *PrivateTracerService) TraceChainJobs(ctx context.Context) []*hmy.ChainTraceProgress {
+	return s.hmy.ChainTraces()
+}
@@ -721,6 +721,50 @@ func (s *PublicTransactionService) ResendCx(ctx context.Context, txID common.Has
 	return success, nil
 }
 
+// GetCXReceiptsProof returns the proof of the cross shard receipts of the block of
+// the cross shard transaction of the given hash to its destination shard, with the
+// commit signature and bitmap of the block, for a verification against the committee
+// of the source shard with core/cxproof
+func (s *PublicTransactionService) GetCXReceiptsProof(
+	ctx context.Context, hash common.Hash,
+) (StructuredResponse, error) {
+	cxp, err := s.hmy.GetCXReceiptsProof(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	switch s.version {
+	case V2:
+		proof, err := v2.NewCxReceiptsProof(hash, cxp)
+		if err != nil {
+			return nil, err
+		}
+		return NewStructuredResponse(proof)
+	default:
+		return nil, ErrUnknownRPCVersion
+	}
+}
+
+// GetCrossShardTxStatus returns the delivery status of the cross shard transaction
+// of the given hash: pending, delivered or stuck, with the numbers of the blocks of the
+// source and destination shards. The delivery of an outgoing transaction to a shard
+// other than the beacon shard is only known from a node of the destination shard, so
+// such a receipt is resent on each timeout and reported pending, or stuck after all
+// the resends, with the height of the destination shard known from the beacon crosslinks.
+func (s *PublicTransactionService) GetCrossShardTxStatus(
+	ctx context.Context, hash common.Hash,
+) (StructuredResponse, error) {
+	status, err := s.hmy.GetCrossShardTxStatus(hash)
+	if err != nil {
+		return nil, err
+	}
+	switch s.version {
+	case V2:
+		return NewStructuredResponse(v2.NewCxTxStatus(hash, status))
+	default:
+		return nil, ErrUnknownRPCVersion
+	}
+}
+
 // returnHashesWithPagination returns result with pagination (offset, page in TxHistoryArgs).
 func returnHashesWithPagination(hashes []common.Hash, pageIndex uint32, pageSize uint32) []common.Hash {
 	size := defaultPageSize
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
@@ -16,9 +17,13 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/harmony-one/harmony/block"
 	"github.com/harmony-one/harmony/core/types"
+	"github.com/harmony-one/harmony/crypto/bls"
+	internal_common "github.com/harmony-one/harmony/internal/common"
 	"github.com/harmony-one/harmony/internal/utils"
 	"github.com/harmony-one/harmony/numeric"
 	"github.com/harmony-one/harmony/shard"
+	staking "github.com/harmony-one/harmony/staking/types"
+	"github.com/pkg/errors"
 )
 
 // CallArgs represents the arguments for a call.
@@ -95,6 +100,22 @@ type Undelegation struct {
 	Epoch  *big.Int
 }
 
+// FeeHistoryResult is the fee history of a range of blocks, hex encoded
+type FeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistoryResultV2 is the fee history of a range of blocks
+type FeeHistoryResultV2 struct {
+	OldestBlock  uint64       `json:"oldestBlock"`
+	Reward       [][]*big.Int `json:"reward,omitempty"`
+	BaseFee      []*big.Int   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64    `json:"gasUsedRatio"`
+}
+
 // StructuredResponse type of RPCs
 type StructuredResponse = map[string]interface{}
 
@@ -196,6 +217,300 @@ func (ta *TxHistoryArgs) UnmarshalFromInterface(blockArgs interface{}) error {
 	return nil
 }
 
+// StakingSimulationArgs represents the arguments to simulate a staking transaction,
+// either a signed raw transaction or the fields of an unsigned staking message.
+type StakingSimulationArgs struct {
+	RawTransaction hexutil.Bytes `json:"rawTransaction"`
+
+	Type               string          `json:"type"`
+	ValidatorAddress   string          `json:"validatorAddress"`
+	DelegatorAddress   string          `json:"delegatorAddress"`
+	Amount             *big.Int        `json:"amount"`
+	MinSelfDelegation  *big.Int        `json:"minSelfDelegation"`
+	MaxTotalDelegation *big.Int        `json:"maxTotalDelegation"`
+	CommissionRate     *numeric.Dec    `json:"commissionRate"`
+	MaxCommissionRate  *numeric.Dec    `json:"maxCommissionRate"`
+	MaxChangeRate      *numeric.Dec    `json:"maxChangeRate"`
+	Name               string          `json:"name"`
+	Identity           string          `json:"identity"`
+	Website            string          `json:"website"`
+	SecurityContact    string          `json:"securityContact"`
+	Details            string          `json:"details"`
+	SlotPubKeys        []hexutil.Bytes `json:"slotPubKeys"`
+	SlotKeySigs        []hexutil.Bytes `json:"slotKeySigs"`
+	SlotPubKeyToAdd    hexutil.Bytes   `json:"slotPubKeyToAdd"`
+	SlotKeyToAddSig    hexutil.Bytes   `json:"slotKeyToAddSig"`
+	SlotPubKeyToRemove hexutil.Bytes   `json:"slotPubKeyToRemove"`
+}
+
+// ToStakeMsg returns the unsigned staking message given by the arguments
+func (args *StakingSimulationArgs) ToStakeMsg() (staking.StakeMsg, error) {
+	description := staking.Description{
+		Name:            args.Name,
+		Identity:        args.Identity,
+		Website:         args.Website,
+		SecurityContact: args.SecurityContact,
+		Details:         args.Details,
+	}
+	switch strings.ToLower(args.Type) {
+	case "createvalidator":
+		validator, err := parseOptionalAddr(args.ValidatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		msg := &staking.CreateValidator{
+			ValidatorAddress:   validator,
+			Description:        description,
+			MinSelfDelegation:  args.MinSelfDelegation,
+			MaxTotalDelegation: args.MaxTotalDelegation,
+			Amount:             args.Amount,
+		}
+		msg.CommissionRates = staking.CommissionRates{
+			Rate:          decOrZero(args.CommissionRate),
+			MaxRate:       decOrZero(args.MaxCommissionRate),
+			MaxChangeRate: decOrZero(args.MaxChangeRate),
+		}
+		for _, b := range args.SlotPubKeys {
+			key, err := toSerializedPublicKey(b)
+			if err != nil {
+				return nil, err
+			}
+			msg.SlotPubKeys = append(msg.SlotPubKeys, *key)
+		}
+		for _, b := range args.SlotKeySigs {
+			sig, err := toSerializedSignature(b)
+			if err != nil {
+				return nil, err
+			}
+			msg.SlotKeySigs = append(msg.SlotKeySigs, *sig)
+		}
+		return msg, nil
+
+	case "editvalidator":
+		validator, err := parseOptionalAddr(args.ValidatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		msg := &staking.EditValidator{
+			ValidatorAddress:   validator,
+			Description:        description,
+			CommissionRate:     args.CommissionRate,
+			MinSelfDelegation:  args.MinSelfDelegation,
+			MaxTotalDelegation: args.MaxTotalDelegation,
+		}
+		if len(args.SlotPubKeyToAdd) != 0 {
+			if msg.SlotKeyToAdd, err = toSerializedPublicKey(args.SlotPubKeyToAdd); err != nil {
+				return nil, err
+			}
+			if msg.SlotKeyToAddSig, err = toSerializedSignature(args.SlotKeyToAddSig); err != nil {
+				return nil, err
+			}
+		}
+		if len(args.SlotPubKeyToRemove) != 0 {
+			if msg.SlotKeyToRemove, err = toSerializedPublicKey(args.SlotPubKeyToRemove); err != nil {
+				return nil, err
+			}
+		}
+		return msg, nil
+
+	case "delegate", "undelegate":
+		delegator, err := parseOptionalAddr(args.DelegatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := parseOptionalAddr(args.ValidatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		if strings.ToLower(args.Type) == "delegate" {
+			return &staking.Delegate{
+				DelegatorAddress: delegator,
+				ValidatorAddress: validator,
+				Amount:           args.Amount,
+			}, nil
+		}
+		return &staking.Undelegate{
+			DelegatorAddress: delegator,
+			ValidatorAddress: validator,
+			Amount:           args.Amount,
+		}, nil
+
+	case "collectrewards":
+		delegator, err := parseOptionalAddr(args.DelegatorAddress)
+		if err != nil {
+			return nil, err
+		}
+		return &staking.CollectRewards{DelegatorAddress: delegator}, nil
+	}
+	return nil, errors.Errorf("unknown staking type %q", args.Type)
+}
+
+func parseOptionalAddr(address string) (common.Address, error) {
+	if address == "" {
+		return common.Address{}, nil
+	}
+	return internal_common.ParseAddr(address)
+}
+
+func decOrZero(d *numeric.Dec) numeric.Dec {
+	if d == nil {
+		return numeric.ZeroDec()
+	}
+	return *d
+}
+
+func toSerializedPublicKey(b hexutil.Bytes) (*bls.SerializedPublicKey, error) {
+	if len(b) != bls.PublicKeySizeInBytes {
+		return nil, errors.Errorf("invalid BLS public key length %v", len(b))
+	}
+	var key bls.SerializedPublicKey
+	copy(key[:], b)
+	return &key, nil
+}
+
+func toSerializedSignature(b hexutil.Bytes) (*bls.SerializedSignature, error) {
+	if len(b) != bls.BLSSignatureSizeInBytes {
+		return nil, errors.Errorf("invalid BLS signature length %v", len(b))
+	}
+	var sig bls.SerializedSignature
+	copy(sig[:], b)
+	return &sig, nil
+}
+
+// SigningUptime represents the signing outcome of blocks
+type SigningUptime struct {
+	ToSign uint64      `json:"toSign"`
+	Signed uint64      `json:"signed"`
+	Missed uint64      `json:"missed"`
+	Uptime numeric.Dec `json:"uptime"`
+}
+
+func (u *SigningUptime) add(e *staking.KeySigningEntry) {
+	u.ToSign += e.NumBlocksToSign
+	u.Signed += e.NumBlocksSigned
+	u.Missed += e.NumBlocksMissed()
+	u.Uptime = numeric.ZeroDec()
+	if u.ToSign != 0 {
+		u.Uptime = numeric.NewDec(int64(u.Signed)).QuoInt64(int64(u.ToSign))
+	}
+}
+
+// KeyUptime represents the signing outcome of a BLS key in a shard
+type KeyUptime struct {
+	Key     string `json:"blsKey"`
+	ShardID uint32 `json:"shardID"`
+	SigningUptime
+}
+
+// ShardUptime represents the signing outcome of the BLS keys in a shard
+type ShardUptime struct {
+	ShardID uint32 `json:"shardID"`
+	SigningUptime
+}
+
+// EpochUptime represents the signing outcome of the BLS keys in an epoch
+type EpochUptime struct {
+	Epoch uint64 `json:"epoch"`
+	SigningUptime
+	Keys []KeyUptime `json:"keys"`
+}
+
+// ValidatorUptime represents the signing outcome of a validator over a range of
+// epochs, in total, by epoch, by BLS key and by shard
+type ValidatorUptime struct {
+	Address   string `json:"address"`
+	FromEpoch uint64 `json:"fromEpoch"`
+	ToEpoch   uint64 `json:"toEpoch"`
+	SigningUptime
+	Epochs []EpochUptime `json:"epochs"`
+	Keys   []KeyUptime   `json:"keys"`
+	Shards []ShardUptime `json:"shards"`
+}
+
+// NewValidatorUptime returns the ValidatorUptime of the signing history that will
+// serialize to the RPC representation
+func NewValidatorUptime(
+	address string, fromEpoch, toEpoch uint64, history []*staking.ValidatorEpochSigning,
+) *ValidatorUptime {
+	result := &ValidatorUptime{
+		Address:       address,
+		FromEpoch:     fromEpoch,
+		ToEpoch:       toEpoch,
+		SigningUptime: SigningUptime{Uptime: numeric.ZeroDec()},
+		Epochs:        []EpochUptime{},
+		Keys:          []KeyUptime{},
+		Shards:        []ShardUptime{},
+	}
+	keyIndex, shardIndex := map[string]int{}, map[uint32]int{}
+	for _, signing := range history {
+		epoch := EpochUptime{
+			Epoch:         signing.Epoch.Uint64(),
+			SigningUptime: SigningUptime{Uptime: numeric.ZeroDec()},
+			Keys:          make([]KeyUptime, 0, len(signing.Keys)),
+		}
+		for i := range signing.Keys {
+			e := &signing.Keys[i]
+			key := KeyUptime{Key: e.Key.Hex(), ShardID: e.ShardID}
+			key.add(e)
+			epoch.Keys = append(epoch.Keys, key)
+			epoch.add(e)
+			result.add(e)
+
+			id := fmt.Sprintf("%v-%v", key.Key, key.ShardID)
+			if _, ok := keyIndex[id]; !ok {
+				keyIndex[id] = len(result.Keys)
+				result.Keys = append(result.Keys, KeyUptime{Key: key.Key, ShardID: key.ShardID})
+			}
+			result.Keys[keyIndex[id]].add(e)
+			if _, ok := shardIndex[e.ShardID]; !ok {
+				shardIndex[e.ShardID] = len(result.Shards)
+				result.Shards = append(result.Shards, ShardUptime{ShardID: e.ShardID})
+			}
+			result.Shards[shardIndex[e.ShardID]].add(e)
+		}
+		result.Epochs = append(result.Epochs, epoch)
+	}
+	return result
+}
+
+// KeyMissedBlocks represents the blocks missed by a BLS key in an epoch. Truncated is
+// true if more blocks were missed than the ranges recorded.
+type KeyMissedBlocks struct {
+	Epoch     uint64               `json:"epoch"`
+	Key       string               `json:"blsKey"`
+	ShardID   uint32               `json:"shardID"`
+	Missed    uint64               `json:"missed"`
+	Blocks    []staking.BlockRange `json:"blocks"`
+	Truncated bool                 `json:"truncated"`
+}
+
+// NewKeyMissedBlocks returns the KeyMissedBlocks of the BLS keys which missed blocks
+// in the signing history that will serialize to the RPC representation
+func NewKeyMissedBlocks(history []*staking.ValidatorEpochSigning) []KeyMissedBlocks {
+	result := []KeyMissedBlocks{}
+	for _, signing := range history {
+		for i := range signing.Keys {
+			e := &signing.Keys[i]
+			if e.NumBlocksMissed() == 0 {
+				continue
+			}
+			recorded := uint64(0)
+			for _, r := range e.MissedBlocks {
+				recorded += r.To - r.From + 1
+			}
+			result = append(result, KeyMissedBlocks{
+				Epoch:     signing.Epoch.Uint64(),
+				Key:       e.Key.Hex(),
+				ShardID:   e.ShardID,
+				Missed:    e.NumBlocksMissed(),
+				Blocks:    e.MissedBlocks,
+				Truncated: recorded < e.NumBlocksMissed(),
+			})
+		}
+	}
+	return result
+}
+
 // HeaderInformation represents the latest consensus information
 type HeaderInformation struct {
 	BlockHash        common.Hash       `json:"blockHash"`
@@ -8,6 +8,8 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/harmony-one/harmony/core"
 	"github.com/harmony-one/harmony/core/types"
 	"github.com/harmony-one/harmony/crypto/bls"
 	internal_common "github.com/harmony-one/harmony/internal/common"
@@ -211,6 +213,133 @@ type CxReceipt struct {
 	Amount      *big.Int    `json:"value"`
 }
 
+// CxReceiptsProof represents the proof of the cross shard receipts of a block to a
+// destination shard that will serialize to the RPC representation
+type CxReceiptsProof struct {
+	TxHash               common.Hash   `json:"hash"`
+	BlockHash            common.Hash   `json:"blockHash"`
+	BlockNumber          *big.Int      `json:"blockNumber"`
+	Epoch                *big.Int      `json:"epoch"`
+	ViewID               *big.Int      `json:"viewID"`
+	ShardID              uint32        `json:"shardID"`
+	ToShardID            uint32        `json:"toShardID"`
+	Receipts             []*CxReceipt  `json:"receipts"`
+	OutgoingReceiptsRoot common.Hash   `json:"outgoingReceiptsRoot"`
+	ShardIDs             []uint32      `json:"shardIDs"`
+	ShardReceiptsRoots   []common.Hash `json:"shardReceiptsRoots"`
+	CommitSig            hexutil.Bytes `json:"commitSig"`
+	CommitBitmap         hexutil.Bytes `json:"commitBitmap"`
+	// RLP encoding of the proof, to be decoded and verified with core/cxproof
+	Proof hexutil.Bytes `json:"proof"`
+}
+
+// NewCxReceiptsProof returns a CxReceiptsProof that will serialize to the RPC representation
+func NewCxReceiptsProof(txHash common.Hash, cxp *types.CXReceiptsProof) (*CxReceiptsProof, error) {
+	proof, err := rlp.EncodeToBytes(cxp)
+	if err != nil {
+		return nil, err
+	}
+	header := cxp.Header
+	result := &CxReceiptsProof{
+		TxHash:               txHash,
+		BlockHash:            header.Hash(),
+		BlockNumber:          header.Number(),
+		Epoch:                header.Epoch(),
+		ViewID:               header.ViewID(),
+		ShardID:              header.ShardID(),
+		OutgoingReceiptsRoot: cxp.MerkleProof.CXReceiptHash,
+		ShardIDs:             cxp.MerkleProof.ShardIDs,
+		ShardReceiptsRoots:   cxp.MerkleProof.CXShardHashes,
+		CommitSig:            cxp.CommitSig,
+		CommitBitmap:         cxp.CommitBitmap,
+		Proof:                proof,
+	}
+	for _, cx := range cxp.Receipts {
+		receipt, err := NewCxReceipt(cx, result.BlockHash, header.Number().Uint64())
+		if err != nil {
+			return nil, err
+		}
+		result.ToShardID = cx.ToShardID
+		result.Receipts = append(result.Receipts, receipt)
+	}
+	return result, nil
+}
+
+// CxTxStatus represents the delivery status of a cross shard transaction that will
+// serialize to the RPC representation
+type CxTxStatus struct {
+	TxHash                 common.Hash `json:"hash"`
+	Status                 string      `json:"status"`
+	ShardID                uint32      `json:"shardID"`
+	ToShardID              uint32      `json:"toShardID"`
+	SourceBlockNumber      *big.Int    `json:"sourceBlockNumber"`
+	DestinationBlockNumber *big.Int    `json:"destinationBlockNumber"`
+	DestinationHeight      *big.Int    `json:"destinationHeight"`
+	Resends                int         `json:"resends"`
+}
+
+// NewCxTxStatus returns a CxTxStatus that will serialize to the RPC representation
+func NewCxTxStatus(txHash common.Hash, status *core.CxTxStatus) *CxTxStatus {
+	result := &CxTxStatus{
+		TxHash:            txHash,
+		Status:            status.Status.String(),
+		ShardID:           status.FromShardID,
+		ToShardID:         status.ToShardID,
+		SourceBlockNumber: new(big.Int).SetUint64(status.SourceBlockNum),
+		Resends:           status.Resends,
+	}
+	if status.DestinationBlockNum != 0 {
+		result.DestinationBlockNumber = new(big.Int).SetUint64(status.DestinationBlockNum)
+	}
+	if status.DestinationHeight != 0 {
+		result.DestinationHeight = new(big.Int).SetUint64(status.DestinationHeight)
+	}
+	return result
+}
+
+// StakingSimulation represents the simulated result of a staking transaction that will
+// serialize to the RPC representation
+type StakingSimulation struct {
+	Type          string                      `json:"type"`
+	BlockNumber   *big.Int                    `json:"blockNumber"`
+	Valid         bool                        `json:"valid"`
+	Gas           uint64                      `json:"gas"`
+	BalanceChange *big.Int                    `json:"balanceChange"`
+	Validators    []*staking.ValidatorWrapper `json:"validators"`
+	Redelegated   map[string]*big.Int         `json:"redelegated"`
+	Failures      []core.StakingFailure       `json:"failures"`
+}
+
+// NewStakingSimulation returns a StakingSimulation that will serialize to the RPC representation
+func NewStakingSimulation(sim *core.StakingSimulation, blockNumber uint64) (*StakingSimulation, error) {
+	result := &StakingSimulation{
+		Type:          sim.Directive.String(),
+		BlockNumber:   new(big.Int).SetUint64(blockNumber),
+		Valid:         sim.Valid(),
+		Gas:           sim.Gas,
+		BalanceChange: sim.BalanceChange,
+		Validators:    sim.Validators,
+		Failures:      sim.Failures,
+	}
+	if result.Validators == nil {
+		result.Validators = []*staking.ValidatorWrapper{}
+	}
+	if result.Failures == nil {
+		result.Failures = []core.StakingFailure{}
+	}
+	if len(sim.Redelegated) != 0 {
+		result.Redelegated = make(map[string]*big.Int, len(sim.Redelegated))
+		for addr, amount := range sim.Redelegated {
+			bech32, err := internal_common.AddressToBech32(addr)
+			if err != nil {
+				return nil, err
+			}
+			result.Redelegated[bech32] = amount
+		}
+	}
+	return result, nil
+}
+
 // NewCxReceipt returns a CxReceipt that will serialize to the RPC representation
 func NewCxReceipt(cx *types.CXReceipt, blockHash common.Hash, blockNumber uint64) (*CxReceipt, error) {
 	result := &CxReceipt{
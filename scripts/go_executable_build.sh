@@ -19,6 +19,7 @@ RACE=
 TRACEPTR=
 VERBOSE=
 GO_GCFLAGS="all=-c 2"
+GO_TAGS=quic
 DEBUG=false
 STATIC=true
 
@@ -65,6 +66,7 @@ OPTIONS:
    -v             verbose build process (default: $VERBOSE)
    -s             build static linux executable (default: $STATIC)
    -S             build non-static linux executable
+   -Q             build without the QUIC transport (default tags: $GO_TAGS)
 
 
 ACTION:
@@ -111,12 +113,12 @@ function build_only
          rm -f $BINDIR/$bin
          echo "building ${SRC[$bin]}"
          if [ "$DEBUG" == "true" ]; then
-            env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
+            env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -tags="${GO_TAGS}" -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
          else
             if [ "$STATIC" == "true" ]; then
-               env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}  -w -extldflags \"-static -lm\"" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
+               env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -tags="${GO_TAGS}" -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}  -w -extldflags \"-static -lm\"" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
             else
-               env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
+               env GOOS=$GOOS GOARCH=$GOARCH go build $VERBOSE -tags="${GO_TAGS}" -gcflags="${GO_GCFLAGS}" -ldflags="-X main.version=v${VERSION} -X main.commit=${COMMIT} -X main.builtAt=${BUILTAT} -X main.builtBy=${BUILTBY}" -o $BINDIR/$bin $RACE $TRACEPTR ${SRC[$bin]}
             fi
          fi
          if [ "$(uname -s)" == "Linux" ]; then
@@ -255,7 +257,7 @@ function release
 
 
 ################################ MAIN FUNCTION ##############################
-while getopts "hp:a:o:b:f:rtvsdS" option; do
+while getopts "hp:a:o:b:f:rtvsdSQ" option; do
    case $option in
       h) usage ;;
       p) PROFILE=$OPTARG ;;
@@ -269,6 +271,7 @@ while getopts "hp:a:o:b:f:rtvsdS" option; do
       d) DEBUG=true ;;
       s) STATIC=true ;;
       S) STATIC=false ;;
+      Q) GO_TAGS= ;;
    esac
 done
 
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/harmony-one/harmony/crypto/bls"
+	"github.com/harmony-one/harmony/numeric"
+)
+
+// MaxMissedRangesPerEpoch is the max number of missed block ranges recorded for a
+// BLS key in an epoch. Further missed blocks are still counted but not recorded.
+const MaxMissedRangesPerEpoch = 1024
+
+// BlockRange is the inclusive range of block numbers [From, To]
+type BlockRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// KeySigningEntry is the signing outcome of a BLS key of a validator in an epoch
+type KeySigningEntry struct {
+	Key             bls.SerializedPublicKey `json:"bls-key"`
+	ShardID         uint32                  `json:"shard-id"`
+	NumBlocksToSign uint64                  `json:"to-sign"`
+	NumBlocksSigned uint64                  `json:"signed"`
+	MissedBlocks    []BlockRange            `json:"missed-blocks"`
+}
+
+// Record counts the block of the given number as signed or missed by the key
+func (e *KeySigningEntry) Record(blockNum uint64, signed bool) {
+	e.NumBlocksToSign++
+	if signed {
+		e.NumBlocksSigned++
+		return
+	}
+	if l := len(e.MissedBlocks); l != 0 && e.MissedBlocks[l-1].To+1 == blockNum {
+		e.MissedBlocks[l-1].To = blockNum
+		return
+	}
+	if len(e.MissedBlocks) < MaxMissedRangesPerEpoch {
+		e.MissedBlocks = append(e.MissedBlocks, BlockRange{From: blockNum, To: blockNum})
+	}
+}
+
+// NumBlocksMissed is the number of blocks the key did not sign
+func (e *KeySigningEntry) NumBlocksMissed() uint64 {
+	return e.NumBlocksToSign - e.NumBlocksSigned
+}
+
+// Uptime is the ratio of the blocks signed to the blocks to sign
+func (e *KeySigningEntry) Uptime() numeric.Dec {
+	if e.NumBlocksToSign == 0 {
+		return numeric.ZeroDec()
+	}
+	return numeric.NewDec(int64(e.NumBlocksSigned)).QuoInt64(int64(e.NumBlocksToSign))
+}
+
+// ValidatorEpochSigning is the signing outcomes of the BLS keys of a validator
+// in an epoch, for the blocks of every shard
+type ValidatorEpochSigning struct {
+	Epoch *big.Int          `json:"epoch"`
+	Keys  []KeySigningEntry `json:"keys"`
+}
+
+// NewValidatorEpochSigning returns the empty signing outcomes of the epoch
+func NewValidatorEpochSigning(epoch *big.Int) *ValidatorEpochSigning {
+	return &ValidatorEpochSigning{
+		Epoch: new(big.Int).Set(epoch),
+		Keys:  []KeySigningEntry{},
+	}
+}
+
+// KeyEntry returns the entry of the BLS key in the shard, added if not found
+func (s *ValidatorEpochSigning) KeyEntry(
+	key bls.SerializedPublicKey, shardID uint32,
+) *KeySigningEntry {
+	for i := range s.Keys {
+		if s.Keys[i].Key == key && s.Keys[i].ShardID == shardID {
+			return &s.Keys[i]
+		}
+	}
+	s.Keys = append(s.Keys, KeySigningEntry{Key: key, ShardID: shardID})
+	return &s.Keys[len(s.Keys)-1]
+}
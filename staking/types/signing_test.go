@@ -0,0 +1,76 @@
+package types
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/harmony-one/harmony/numeric"
+)
+
+func TestKeySigningEntry_Record(t *testing.T) {
+	tests := []struct {
+		signed    []bool
+		expMissed []BlockRange
+		expUptime numeric.Dec
+	}{
+		{
+			signed:    []bool{true, true, true, true},
+			expMissed: nil,
+			expUptime: numeric.OneDec(),
+		},
+		{
+			signed:    []bool{true, false, false, true, false},
+			expMissed: []BlockRange{{From: 101, To: 102}, {From: 104, To: 104}},
+			expUptime: numeric.NewDecWithPrec(4, 1),
+		},
+		{
+			signed:    []bool{false, false, false, false},
+			expMissed: []BlockRange{{From: 100, To: 103}},
+			expUptime: numeric.ZeroDec(),
+		},
+	}
+	for i, test := range tests {
+		var e KeySigningEntry
+		for j, signed := range test.signed {
+			e.Record(uint64(100+j), signed)
+		}
+		if e.NumBlocksToSign != uint64(len(test.signed)) {
+			t.Errorf("Test %v: unexpected to sign %v", i, e.NumBlocksToSign)
+		}
+		if !reflect.DeepEqual(e.MissedBlocks, test.expMissed) {
+			t.Errorf("Test %v: unexpected missed blocks %v / %v", i, e.MissedBlocks, test.expMissed)
+		}
+		if !e.Uptime().Equal(test.expUptime) {
+			t.Errorf("Test %v: unexpected uptime %v / %v", i, e.Uptime(), test.expUptime)
+		}
+	}
+}
+
+func TestKeySigningEntry_RecordCapped(t *testing.T) {
+	var e KeySigningEntry
+	for i := 0; i != 2*MaxMissedRangesPerEpoch; i++ {
+		e.Record(uint64(2*i), false)
+		e.Record(uint64(2*i+1), true)
+	}
+	if len(e.MissedBlocks) != MaxMissedRangesPerEpoch {
+		t.Errorf("unexpected missed ranges %v", len(e.MissedBlocks))
+	}
+	if e.NumBlocksMissed() != 2*MaxMissedRangesPerEpoch {
+		t.Errorf("unexpected missed %v", e.NumBlocksMissed())
+	}
+}
+
+func TestValidatorEpochSigning_KeyEntry(t *testing.T) {
+	s := NewValidatorEpochSigning(big.NewInt(5))
+	s.KeyEntry(blsPubSigPairs[0].pub, 1).Record(10, true)
+	s.KeyEntry(blsPubSigPairs[0].pub, 1).Record(11, false)
+	s.KeyEntry(blsPubSigPairs[0].pub, 2).Record(10, true)
+
+	if len(s.Keys) != 2 {
+		t.Fatalf("unexpected keys %v", len(s.Keys))
+	}
+	if e := s.Keys[0]; e.NumBlocksToSign != 2 || e.NumBlocksSigned != 1 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}